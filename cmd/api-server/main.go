@@ -1,53 +1,449 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
 	"syscall"
 	"time"
 
+	"github.com/TommyLearning/go-rest-api-project/internal/audit"
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/cache"
+	"github.com/TommyLearning/go-rest-api-project/internal/compress"
+	"github.com/TommyLearning/go-rest-api-project/internal/cors"
+	"github.com/TommyLearning/go-rest-api-project/internal/gql"
+	"github.com/TommyLearning/go-rest-api-project/internal/httpcache"
+	"github.com/TommyLearning/go-rest-api-project/internal/idempotency"
 	"github.com/TommyLearning/go-rest-api-project/internal/logger"
 	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/openapi"
+	"github.com/TommyLearning/go-rest-api-project/internal/outbox"
 	"github.com/TommyLearning/go-rest-api-project/internal/postgres"
+	"github.com/TommyLearning/go-rest-api-project/internal/ratelimit"
 	"github.com/TommyLearning/go-rest-api-project/internal/router"
+	"github.com/TommyLearning/go-rest-api-project/internal/rpc"
+	"github.com/TommyLearning/go-rest-api-project/internal/server"
+	"github.com/TommyLearning/go-rest-api-project/internal/stream"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/TommyLearning/go-rest-api-project/internal/webhook"
+	"github.com/TommyLearning/go-rest-api-project/internal/worker"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
+// defaultCachePolicies lets the clients and the CDNs cache the public news for
+// a minute, and has the editors revalidate their copies.
+const defaultCachePolicies = "GET /news=public, max-age=60;" +
+	"GET /news/{news_id}=public, max-age=60;" +
+	"GET /news/{news_id}/similar=public, max-age=300;" +
+	"GET /tags/{slug}/news=public, max-age=60;" +
+	"GET /authors/{slug}/news=public, max-age=60;" +
+	"GET /editor/news=private, no-cache;" +
+	"GET /editor/news/{news_id}=private, no-cache"
+
 func main() {
 	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{AddSource: true}))
-	db, err := postgres.NewDB(&postgres.Config{
-		Host:     os.Getenv("DATABASE_HOST"),
-		DBName:   os.Getenv("DATABASE_NAME"),
-		Password: os.Getenv("DATABASE_PASSWORD"),
-		User:     os.Getenv("DATABASE_USER"),
-		Port:     os.Getenv("DATABASE_PORT"),
-		SSLMode:  "disable",
-	})
+	dbConfig := &postgres.Config{
+		Host:        os.Getenv("DATABASE_HOST"),
+		DBName:      os.Getenv("DATABASE_NAME"),
+		Password:    os.Getenv("DATABASE_PASSWORD"),
+		User:        os.Getenv("DATABASE_USER"),
+		Port:        os.Getenv("DATABASE_PORT"),
+		SSLMode:     cmp.Or(os.Getenv("DATABASE_SSLMODE"), "disable"),
+		SSLRootCert: os.Getenv("DATABASE_SSLROOTCERT"),
+		SSLCert:     os.Getenv("DATABASE_SSLCERT"),
+		SSLKey:      os.Getenv("DATABASE_SSLKEY"),
+	}
+	db, err := postgres.NewDB(dbConfig)
 	if err != nil {
 		log.Error("failed to connect to db", "error", err)
 		os.Exit(1)
 	}
+	keys, err := auth.ParseKeys(os.Getenv("API_KEYS"))
+	if err != nil {
+		log.Error("failed to parse api keys", "error", err)
+		os.Exit(1)
+	}
+	subjects, err := auth.ParseSubjects(os.Getenv("TLS_CLIENT_SUBJECTS"))
+	if err != nil {
+		log.Error("failed to parse client certificate subjects", "error", err)
+		os.Exit(1)
+	}
+	tenants, err := tenant.Load(os.Getenv("TENANTS_FILE"))
+	if err != nil {
+		log.Error("failed to load tenants", "error", err)
+		os.Exit(1)
+	}
+
+	schedulerInterval := 10 * time.Second
+	if v := os.Getenv("SCHEDULER_INTERVAL"); v != "" {
+		if schedulerInterval, err = time.ParseDuration(v); err != nil {
+			log.Error("failed to parse scheduler interval", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	sweepInterval := time.Minute
+	if v := os.Getenv("SWEEPER_INTERVAL"); v != "" {
+		if sweepInterval, err = time.ParseDuration(v); err != nil {
+			log.Error("failed to parse sweeper interval", "error", err)
+			os.Exit(1)
+		}
+	}
+	sweepAction := news.SweepArchive
+	if v := os.Getenv("SWEEPER_ACTION"); v != "" {
+		if sweepAction, err = news.ParseSweepAction(v); err != nil {
+			log.Error("failed to parse sweeper action", "error", err)
+			os.Exit(1)
+		}
+	}
+	grpcPort := "9090"
+	if v := os.Getenv("GRPC_PORT"); v != "" {
+		grpcPort = v
+	}
+
+	sweepBatchSize := 100
+	if v := os.Getenv("SWEEPER_BATCH_SIZE"); v != "" {
+		if sweepBatchSize, err = strconv.Atoi(v); err != nil || sweepBatchSize <= 0 {
+			log.Error("failed to parse sweeper batch size", "error", err, "value", v)
+			os.Exit(1)
+		}
+	}
+
+	// Delivered outbox events are kept as long for the streams to replay them.
+	outboxRetention := 7 * 24 * time.Hour
+	if v := os.Getenv("OUTBOX_RETENTION"); v != "" {
+		if outboxRetention, err = time.ParseDuration(v); err != nil || outboxRetention <= 0 {
+			log.Error("failed to parse outbox retention", "error", err, "value", v)
+			os.Exit(1)
+		}
+	}
+
 	newsStore := news.NewStore(db)
-	r := router.New(newsStore)
+	tagStore := news.NewTagStore(db)
+	authorStore := news.NewAuthorStore(db)
+	webhookStore := news.NewWebhookStore(db)
+	outboxStore := news.NewOutboxStore(db)
+	auditStore := news.NewAuditStore(db)
+	hub := stream.NewHub(outboxStore)
+	schema, err := gql.NewSchema(newsStore, tagStore, authorStore)
+	if err != nil {
+		log.Error("failed to build graphql schema", "error", err)
+		os.Exit(1)
+	}
+	doc, err := router.OpenAPI()
+	if err != nil {
+		log.Error("failed to build openapi document", "error", err)
+		os.Exit(1)
+	}
+	cacheTTL := 30 * time.Second
+	if v := os.Getenv("CACHE_TTL"); v != "" {
+		if cacheTTL, err = time.ParseDuration(v); err != nil {
+			log.Error("failed to parse cache ttl", "error", err)
+			os.Exit(1)
+		}
+	}
+	cacheSize, cacheBytes := 10000, 64<<20
+	if v := os.Getenv("CACHE_SIZE"); v != "" {
+		if cacheSize, err = strconv.Atoi(v); err != nil || cacheSize < 0 {
+			log.Error("failed to parse cache size", "error", err, "value", v)
+			os.Exit(1)
+		}
+	}
+	if v := os.Getenv("CACHE_BYTES"); v != "" {
+		if cacheBytes, err = strconv.Atoi(v); err != nil || cacheBytes < 0 {
+			log.Error("failed to parse cache bytes", "error", err, "value", v)
+			os.Exit(1)
+		}
+	}
+	cachedNewsStore := cache.NewNewsStore(newsStore, cache.NewLRU(cacheSize, cacheBytes, cacheTTL, nil))
 
-	wrappedRouter := logger.AddLoggerMid(log, logger.LoggerMid(r))
+	r := router.New(cachedNewsStore, os.Getenv("STRICT_JSON") != "false",
+		router.WithTags(tagStore),
+		router.WithAuthors(authorStore),
+		router.WithSweeper(newsStore),
+		router.WithCache(cachedNewsStore),
+		router.WithAudit(auditStore),
+		router.WithWebhooks(webhookStore),
+		router.WithStream(hub),
+		router.WithGraphQL(schema),
+		router.WithOpenAPI(doc),
+	)
+	var h http.Handler = r
+	if os.Getenv("OPENAPI_VALIDATE") == "true" {
+		h = openapi.Validate(doc, r)
+	}
 
-	log.Info("server starting on port 8080")
+	cachePolicies, err := httpcache.ParsePolicies(cmp.Or(os.Getenv("CACHE_CONTROL"), defaultCachePolicies))
+	if err != nil {
+		log.Error("failed to parse cache policies", "error", err)
+		os.Exit(1)
+	}
+	for pattern := range cachePolicies {
+		if !slices.Contains(doc.Patterns(), pattern) {
+			log.Error("failed to parse cache policies", "error", "unknown route pattern", "pattern", pattern)
+			os.Exit(1)
+		}
+	}
+	h = httpcache.Mid(cachePolicies, r, h)
+
+	idempotencyConfig := idempotency.Config{TTL: 24 * time.Hour, Lease: time.Minute}
+	for name, d := range map[string]*time.Duration{
+		"IDEMPOTENCY_TTL":   &idempotencyConfig.TTL,
+		"IDEMPOTENCY_WAIT":  &idempotencyConfig.Wait,
+		"IDEMPOTENCY_LEASE": &idempotencyConfig.Lease,
+	} {
+		if v := os.Getenv(name); v != "" {
+			if *d, err = time.ParseDuration(v); err != nil {
+				log.Error("failed to parse idempotency config", "error", err, "name", name)
+				os.Exit(1)
+			}
+		}
+	}
+	var idempotencyStore idempotency.Store = idempotency.NewMemoryStore()
+	if os.Getenv("IDEMPOTENCY_STORE") == "postgres" {
+		idempotencyStore = news.NewIdempotencyStore(db)
+	}
+	h = idempotency.Mid(idempotencyStore, idempotencyConfig, h)
 
-	server := &http.Server{
-		Addr:              ":8080",
-		ReadHeaderTimeout: 3 * time.Second,
-		Handler:           wrappedRouter,
+	rateLimit, err := ratelimit.ParseRate(cmp.Or(os.Getenv("RATE_LIMIT"), "0"))
+	if err != nil {
+		log.Error("failed to parse rate limit", "error", err)
+		os.Exit(1)
+	}
+	rateLimitRoutes, err := ratelimit.ParseRoutes(os.Getenv("RATE_LIMIT_ROUTES"))
+	if err != nil {
+		log.Error("failed to parse route rate limits", "error", err)
+		os.Exit(1)
 	}
+	for pattern := range rateLimitRoutes {
+		if !slices.Contains(doc.Patterns(), pattern) {
+			log.Error("failed to parse route rate limits", "error", "unknown route pattern", "pattern", pattern)
+			os.Exit(1)
+		}
+	}
+	trustedProxies, err := ratelimit.ParseProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		log.Error("failed to parse trusted proxies", "error", err)
+		os.Exit(1)
+	}
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore(nil)
+	if os.Getenv("RATE_LIMIT_STORE") == "postgres" {
+		rateLimitStore = news.NewRateLimitStore(db)
+	}
+	limiter := ratelimit.New(rateLimitStore, ratelimit.Config{
+		Default:        rateLimit,
+		Routes:         rateLimitRoutes,
+		TrustedProxies: trustedProxies,
+		Keys:           keys,
+	})
+
+	serverConfig := server.DefaultConfig
+	for name, d := range map[string]*time.Duration{
+		"SERVER_READ_HEADER_TIMEOUT": &serverConfig.ReadHeaderTimeout,
+		"SERVER_READ_TIMEOUT":        &serverConfig.ReadTimeout,
+		"SERVER_WRITE_TIMEOUT":       &serverConfig.WriteTimeout,
+		"SERVER_IDLE_TIMEOUT":        &serverConfig.IdleTimeout,
+		"HANDLER_TIMEOUT":            &serverConfig.HandlerTimeout,
+	} {
+		if v := os.Getenv(name); v != "" {
+			if *d, err = time.ParseDuration(v); err != nil {
+				log.Error("failed to parse server config", "error", err, "name", name)
+				os.Exit(1)
+			}
+		}
+	}
+	if v := os.Getenv("MAX_BODY_SIZE"); v != "" {
+		if serverConfig.MaxBodyBytes, err = server.ParseSize(v); err != nil {
+			log.Error("failed to parse server config", "error", err, "name", "MAX_BODY_SIZE")
+			os.Exit(1)
+		}
+	}
+	routeTimeouts, err := server.ParseRouteTimeouts(os.Getenv("HANDLER_ROUTE_TIMEOUTS"))
+	if err != nil {
+		log.Error("failed to parse route handler timeouts", "error", err)
+		os.Exit(1)
+	}
+	routeMaxBodyBytes, err := server.ParseRouteSizes(os.Getenv("MAX_BODY_ROUTE_SIZES"))
+	if err != nil {
+		log.Error("failed to parse route max body sizes", "error", err)
+		os.Exit(1)
+	}
+	for pattern := range routeTimeouts {
+		if !slices.Contains(doc.Patterns(), pattern) {
+			log.Error("failed to parse route handler timeouts", "error", "unknown route pattern", "pattern", pattern)
+			os.Exit(1)
+		}
+	}
+	for pattern := range routeMaxBodyBytes {
+		if !slices.Contains(doc.Patterns(), pattern) {
+			log.Error("failed to parse route max body sizes", "error", "unknown route pattern", "pattern", pattern)
+			os.Exit(1)
+		}
+	}
+	serverConfig.RouteTimeouts = maps.Clone(serverConfig.RouteTimeouts)
+	maps.Copy(serverConfig.RouteTimeouts, routeTimeouts)
+	serverConfig.RouteMaxBodyBytes = routeMaxBodyBytes
+	h = server.Limits(serverConfig, r, h)
+
+	compression := compress.Config{MinSize: compress.DefaultMinSize}
+	if compression.Encodings, err = compress.ParseEncodings(cmp.Or(os.Getenv("COMPRESSION_ENCODINGS"), "zstd,br,gzip")); err != nil {
+		log.Error("failed to parse compression encodings", "error", err)
+		os.Exit(1)
+	}
+	if v := os.Getenv("COMPRESSION_MIN_SIZE"); v != "" {
+		if compression.MinSize, err = strconv.Atoi(v); err != nil || compression.MinSize < 0 {
+			log.Error("failed to parse compression min size", "error", err, "value", v)
+			os.Exit(1)
+		}
+	}
+
+	var certs *server.Certificates
+	var grpcOpts []grpc.ServerOption
+	if certFile := os.Getenv("TLS_CERT_FILE"); certFile != "" {
+		if certs, err = server.LoadCertificates(certFile, os.Getenv("TLS_KEY_FILE"), os.Getenv("TLS_CLIENT_CA_FILE")); err != nil {
+			log.Error("failed to load certificates", "error", err)
+			os.Exit(1)
+		}
+		clientAuth, err := server.ParseClientAuth(cmp.Or(os.Getenv("TLS_CLIENT_AUTH"), "none"))
+		if err != nil {
+			log.Error("failed to parse client auth", "error", err)
+			os.Exit(1)
+		}
+		if serverConfig.TLS, err = server.NewTLSConfig(certs, clientAuth); err != nil {
+			log.Error("failed to configure tls", "error", err)
+			os.Exit(1)
+		}
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(serverConfig.TLS)))
+	}
+	certReloadInterval := 10 * time.Second
+	if v := os.Getenv("TLS_RELOAD_INTERVAL"); v != "" {
+		if certReloadInterval, err = time.ParseDuration(v); err != nil {
+			log.Error("failed to parse tls reload interval", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	h = compress.Mid(compression, audit.Mid(auditStore, r, trustedProxies, identify(r, limiter, subjects, keys, tenants, h)))
+
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		corsConfig := cors.Config{
+			Methods:        cors.DefaultMethods,
+			Headers:        cors.DefaultHeaders,
+			ExposedHeaders: cors.DefaultExposedHeaders,
+			Credentials:    os.Getenv("CORS_ALLOW_CREDENTIALS") == "true",
+			MaxAge:         10 * time.Minute,
+		}
+		if corsConfig.Origins, err = cors.ParseOrigins(origins); err != nil {
+			log.Error("failed to parse cors origins", "error", err)
+			os.Exit(1)
+		}
+		for name, list := range map[string]*[]string{
+			"CORS_ALLOWED_METHODS": &corsConfig.Methods,
+			"CORS_ALLOWED_HEADERS": &corsConfig.Headers,
+			"CORS_EXPOSED_HEADERS": &corsConfig.ExposedHeaders,
+		} {
+			if v := os.Getenv(name); v != "" {
+				*list = cors.ParseList(v)
+			}
+		}
+		if v := os.Getenv("CORS_MAX_AGE"); v != "" {
+			if corsConfig.MaxAge, err = time.ParseDuration(v); err != nil {
+				log.Error("failed to parse cors max age", "error", err)
+				os.Exit(1)
+			}
+		}
+		if err := corsConfig.Validate(); err != nil {
+			log.Error("failed to configure cors", "error", err)
+			os.Exit(1)
+		}
+		h = cors.Mid(corsConfig, r, h)
+	}
+
+	wrappedRouter := logger.AddLoggerMid(log, logger.RequestIDMid(logger.LoggerMid(server.Recover(h))))
+
+	log.Info("server starting on port 8080")
+
+	httpServer := server.New(":8080", serverConfig, wrappedRouter)
+	httpServer.RegisterOnShutdown(hub.Close)
+	grpcServer := rpc.NewServer(log, keys, subjects, tenants, newsStore, hub, grpcOpts...)
 
 	errGrp, errGrpCtx := errgroup.WithContext(context.Background())
+	workerCtx, stopWorkers := context.WithCancel(logger.CtxWithLogger(errGrpCtx, log))
+	defer stopWorkers()
 
 	errGrp.Go(func() error {
-		if err := server.ListenAndServe(); err != nil {
+		return worker.Run(workerCtx, "publish_due", schedulerInterval, worker.PublishDue(newsStore))
+	})
+	sinks := []outbox.Sink{outbox.SinkFunc(webhookStore.Enqueue)}
+	if path := os.Getenv("OUTBOX_FILE"); path != "" {
+		sinks = append(sinks, outbox.NewFileSink(path))
+	}
+	if url := os.Getenv("OUTBOX_HTTP_URL"); url != "" {
+		sinks = append(sinks, outbox.NewHTTPSink(url, &http.Client{Timeout: 10 * time.Second}))
+	}
+	relay := outbox.NewRelay(outboxStore, sinks...)
+	errGrp.Go(func() error {
+		return worker.Run(workerCtx, "relay_outbox", time.Second, relay.Dispatch)
+	})
+	errGrp.Go(func() error {
+		return worker.Run(workerCtx, "sweep_outbox", time.Hour, func(ctx context.Context) error {
+			_, err := outboxStore.SweepDelivered(ctx, time.Now().Add(-outboxRetention))
+			return err
+		})
+	})
+	dispatcher := webhook.NewDispatcher(webhookStore, &http.Client{Timeout: 10 * time.Second})
+	errGrp.Go(func() error {
+		return worker.Run(workerCtx, "deliver_webhooks", 5*time.Second, dispatcher.Dispatch)
+	})
+	errGrp.Go(func() error {
+		return postgres.Listen(workerCtx, dbConfig, news.NotifyChannel, func(ctx context.Context, payload string) {
+			cachedNewsStore.Notify(ctx, payload)
+			hub.Notify(ctx, payload)
+		})
+	})
+	errGrp.Go(func() error {
+		return worker.Run(workerCtx, "sweep_expired", sweepInterval, worker.SweepExpired(newsStore, sweepAction, sweepBatchSize))
+	})
+	// News stored before fingerprints were computed are backfilled at start,
+	// the hourly runs only pick up the rows skipped while locked by a replica.
+	errGrp.Go(func() error {
+		return worker.Run(workerCtx, "backfill_fingerprints", time.Hour, worker.BackfillFingerprints(newsStore, sweepBatchSize))
+	})
+	if s, ok := rateLimitStore.(*news.RateLimitStore); ok {
+		errGrp.Go(func() error {
+			return worker.Run(workerCtx, "sweep_rate_limits", time.Minute, func(ctx context.Context) error {
+				_, err := s.Sweep(ctx)
+				return err
+			})
+		})
+	}
+
+	errGrp.Go(func() error {
+		return worker.Run(workerCtx, "sweep_idempotency_keys", time.Minute, func(ctx context.Context) error {
+			_, err := idempotencyStore.Sweep(ctx, time.Now())
+			return err
+		})
+	})
+
+	if certs != nil {
+		errGrp.Go(func() error {
+			return worker.Run(workerCtx, "reload_certificates", certReloadInterval, certs.Reload)
+		})
+	}
+
+	errGrp.Go(func() error {
+		if err := server.ListenAndServe(httpServer); err != nil {
 			log.Error("faild to start server", "error", err)
 			return fmt.Errorf("failed to start server: %w", err)
 
@@ -55,6 +451,19 @@ func main() {
 		return nil
 	})
 
+	errGrp.Go(func() error {
+		lis, err := net.Listen("tcp", ":"+grpcPort)
+		if err != nil {
+			return fmt.Errorf("failed to listen for grpc: %w", err)
+		}
+		log.Info("grpc server starting on port " + grpcPort)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Error("failed to start grpc server", "error", err)
+			return fmt.Errorf("failed to start grpc server: %w", err)
+		}
+		return nil
+	})
+
 	errGrp.Go(func() error {
 		sigch := make(chan os.Signal, 1)
 		signal.Notify(sigch, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
@@ -68,9 +477,23 @@ func main() {
 		defer cancelFn()
 
 		log.Info("initiating graceful shutdown")
+		stopWorkers()
 
-		if err := server.Shutdown(ctxWithTimeout); err != nil {
-			return fmt.Errorf("error graceful shutdown: %w", err)
+		// The HTTP shutdown closes the hub, ending the Watch streams that the
+		// gRPC graceful stop waits for.
+		shutdownErr := httpServer.Shutdown(ctxWithTimeout)
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctxWithTimeout.Done():
+			grpcServer.Stop()
+		}
+		if shutdownErr != nil {
+			return fmt.Errorf("error graceful shutdown: %w", shutdownErr)
 		}
 
 		return nil
@@ -81,3 +504,11 @@ func main() {
 	}
 
 }
+
+// identify authenticates the requests to h and resolves their tenant, which
+// it records for audit.Mid as it goes. They are rate limited in between, once
+// their client certificate is known but before their API key is checked, for
+// the attempts with unknown keys to be limited.
+func identify(mux *http.ServeMux, l *ratelimit.Limiter, subjects auth.Subjects, keys auth.Keys, tenants tenant.Tenants, h http.Handler) http.Handler {
+	return auth.CertMid(subjects, ratelimit.Mid(l, mux, auth.Mid(keys, audit.Identify(tenant.Mid(tenants, audit.Identify(h))))))
+}
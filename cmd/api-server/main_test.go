@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/audit"
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/ratelimit"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_identify(t *testing.T) {
+	type request struct {
+		apiKey     string
+		remoteAddr string
+	}
+
+	testCases := []struct {
+		name             string
+		requests         []request
+		expectedStatuses []int
+	}{
+		{
+			name: "unknown keys are rate limited",
+			requests: []request{
+				{apiKey: "guess-1", remoteAddr: "203.0.113.7:5000"},
+				{apiKey: "guess-2", remoteAddr: "203.0.113.7:5000"},
+				{apiKey: "guess-3", remoteAddr: "203.0.113.7:5000"},
+				{apiKey: "guess-4", remoteAddr: "198.51.100.1:5000"},
+			},
+			expectedStatuses: []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusUnauthorized},
+		},
+		{
+			name: "known keys are rate limited as their principal",
+			requests: []request{
+				{apiKey: "guess", remoteAddr: "203.0.113.7:5000"},
+				{apiKey: "guess", remoteAddr: "203.0.113.7:5000"},
+				{apiKey: "key", remoteAddr: "203.0.113.7:5000"},
+				{apiKey: "key", remoteAddr: "198.51.100.1:5000"},
+				{apiKey: "key", remoteAddr: "198.51.100.2:5000"},
+			},
+			expectedStatuses: []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			keys, err := auth.ParseKeys("key=alice:editor")
+			require.NoError(t, err)
+			tenants, err := tenant.Load("")
+			require.NoError(t, err)
+			l := ratelimit.New(ratelimit.NewMemoryStore(nil), ratelimit.Config{
+				Default: ratelimit.Rate{Limit: 2, Period: time.Hour, Burst: 2},
+				Keys:    keys,
+			})
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /news", func(w http.ResponseWriter, r *http.Request) {})
+			h := identify(mux, l, auth.Subjects{}, keys, tenants, mux)
+
+			for i, req := range tc.requests {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(http.MethodGet, "/news", http.NoBody)
+				r.RemoteAddr = req.remoteAddr
+				r.Header.Set("X-API-Key", req.apiKey)
+
+				// Act
+				h.ServeHTTP(w, r)
+
+				// Assert
+				assert.Equal(t, tc.expectedStatuses[i], w.Code, "request %d", i)
+			}
+		})
+	}
+}
+
+type auditStore struct {
+	events []*audit.Event
+}
+
+func (s *auditStore) Append(ctx context.Context, e *audit.Event) error {
+	s.events = append(s.events, e)
+	return nil
+}
+
+func (s *auditStore) Find(ctx context.Context, f audit.Filter) ([]*audit.Event, error) {
+	return s.events, nil
+}
+
+func Test_identify_Audit(t *testing.T) {
+	testCases := []struct {
+		name             string
+		apiKey           string
+		tenant           string
+		expectedStatus   int
+		expectedActor    string
+		expectedTenantId string
+	}{
+		{name: "unknown key", apiKey: "guess", expectedStatus: http.StatusUnauthorized},
+		{name: "unknown tenant", apiKey: "key", tenant: "acme", expectedStatus: http.StatusNotFound, expectedActor: "alice"},
+		{name: "identified", apiKey: "key", expectedStatus: http.StatusCreated, expectedActor: "alice", expectedTenantId: tenant.Default},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			keys, err := auth.ParseKeys("key=alice:editor")
+			require.NoError(t, err)
+			tenants, err := tenant.Load("")
+			require.NoError(t, err)
+			l := ratelimit.New(ratelimit.NewMemoryStore(nil), ratelimit.Config{Keys: keys})
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /news", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+			})
+			s := &auditStore{}
+			h := audit.Mid(s, mux, nil, identify(mux, l, auth.Subjects{}, keys, tenants, mux))
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/news", http.NoBody)
+			r.Header.Set("X-API-Key", tc.apiKey)
+			if tc.tenant != "" {
+				r.Header.Set(tenant.Header, tc.tenant)
+			}
+
+			// Act
+			h(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			require.Len(t, s.events, 1)
+			assert.Equal(t, tc.expectedStatus, s.events[0].Status)
+			assert.Equal(t, tc.expectedActor, s.events[0].Actor)
+			assert.Equal(t, tc.expectedTenantId, s.events[0].TenantId)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package migrate
 
 import (
+	"cmp"
 	"fmt"
 	"log"
 	"log/slog"
@@ -16,12 +17,15 @@ import (
 
 func main() {
 	db, err := postgres.NewDB(&postgres.Config{
-		Host:     os.Getenv("DATABASE_HOST"),
-		DBName:   os.Getenv("DATABASE_NAME"),
-		Password: os.Getenv("DATABASE_PASSWORD"),
-		User:     os.Getenv("DATABASE_USER"),
-		Port:     os.Getenv("DATABASE_PORT"),
-		SSLMode:  "disable",
+		Host:        os.Getenv("DATABASE_HOST"),
+		DBName:      os.Getenv("DATABASE_NAME"),
+		Password:    os.Getenv("DATABASE_PASSWORD"),
+		User:        os.Getenv("DATABASE_USER"),
+		Port:        os.Getenv("DATABASE_PORT"),
+		SSLMode:     cmp.Or(os.Getenv("DATABASE_SSLMODE"), "disable"),
+		SSLRootCert: os.Getenv("DATABASE_SSLROOTCERT"),
+		SSLCert:     os.Getenv("DATABASE_SSLCERT"),
+		SSLKey:      os.Getenv("DATABASE_SSLKEY"),
 	})
 	if err != nil {
 		log.Fatal(err)
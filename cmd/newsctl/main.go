@@ -0,0 +1,105 @@
+// Command newsctl is the command-line client of the news API for operators.
+//
+// The API is reached through the profile of the environment in the
+// configuration file, which the --url and --api-key flags override, e.g.
+//
+//	newsctl --profile staging list --status draft
+//	newsctl -o yaml get 5f0c...
+//	newsctl edit 5f0c...
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/TommyLearning/go-rest-api-project/pkg/newsclient"
+	"github.com/urfave/cli/v2"
+)
+
+// defaultURL is the API of the local environment, used without profiles.
+const defaultURL = "http://localhost:8080"
+
+func main() {
+	if err := newApp(os.Stdout, os.Stderr).Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, "newsctl:", err)
+		os.Exit(1)
+	}
+}
+
+func newApp(stdout, stderr io.Writer) *cli.App {
+	return &cli.App{
+		Name:                 "newsctl",
+		Usage:                "manage the news of the news API",
+		Writer:               stdout,
+		ErrWriter:            stderr,
+		EnableBashCompletion: true,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Usage:   "configuration file with the profiles",
+				EnvVars: []string{"NEWSCTL_CONFIG"},
+				Value:   defaultConfigPath(),
+			},
+			&cli.StringFlag{
+				Name:    "profile",
+				Aliases: []string{"p"},
+				Usage:   "profile of the environment, the current one by default",
+				EnvVars: []string{"NEWSCTL_PROFILE"},
+			},
+			&cli.StringFlag{
+				Name:    "url",
+				Usage:   "base url of the API, overriding the profile",
+				EnvVars: []string{"NEWSCTL_URL"},
+			},
+			&cli.StringFlag{
+				Name:    "api-key",
+				Usage:   "API key, overriding the profile",
+				EnvVars: []string{"NEWSCTL_API_KEY"},
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "output format: table, json or yaml",
+				EnvVars: []string{"NEWSCTL_OUTPUT"},
+				Value:   formatTable,
+			},
+		},
+		Commands: []*cli.Command{
+			listCmd(),
+			getCmd(),
+			createCmd(),
+			editCmd(),
+			deleteCmd(),
+			restoreCmd(),
+			searchCmd(),
+			profileCmd(),
+			completionCmd(),
+		},
+	}
+}
+
+// clientOf returns the client of the API of the profile, with the flags
+// overriding it.
+func clientOf(c *cli.Context) (*newsclient.Client, error) {
+	config, err := loadConfig(c.String("config"))
+	if err != nil {
+		return nil, err
+	}
+	p, err := config.profile(c.String("profile"))
+	if err != nil {
+		return nil, err
+	}
+	url, apiKey := p.URL, p.APIKey
+	if c.IsSet("url") {
+		url = c.String("url")
+	}
+	if c.IsSet("api-key") {
+		apiKey = c.String("api-key")
+	}
+	return newsclient.New(url, newsclient.WithAPIKey(apiKey), newsclient.WithUserAgent("newsctl"))
+}
+
+func printerOf(c *cli.Context) (*printer, error) {
+	return newPrinter(c.App.Writer, c.String("output"))
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	"github.com/TommyLearning/go-rest-api-project/internal/router"
+	"github.com/TommyLearning/go-rest-api-project/internal/store"
+	"github.com/TommyLearning/go-rest-api-project/pkg/newsclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const newsJSON = `{
+	"authors": [{"name": "Alice Smith"}],
+	"title": "Election results",
+	"summary": "summary",
+	"content": "The results of the election",
+	"source": "https://example.com",
+	"tags": ["politics"],
+	"created_at": "2026-10-19T12:00:00Z"
+}`
+
+// run runs newsctl with the arguments and returns its output.
+func run(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var stdout bytes.Buffer
+	err := newApp(&stdout, &stdout).Run(append([]string{"newsctl"}, args...))
+	return stdout.String(), err
+}
+
+// newServer points newsctl at an API backed by the in-memory store, with the
+// editor key, and a configuration file in a temporary directory. It returns
+// the url of the API.
+func newServer(t *testing.T) string {
+	t.Helper()
+	keys, err := auth.ParseKeys("editor-key=alice:editor")
+	require.NoError(t, err)
+	srv := httptest.NewServer(auth.Mid(keys, router.New(store.NewNewsStore(), handler.DefaultStrict)))
+	t.Cleanup(srv.Close)
+	t.Setenv("NEWSCTL_CONFIG", filepath.Join(t.TempDir(), "config.yaml"))
+	t.Setenv("NEWSCTL_URL", srv.URL)
+	t.Setenv("NEWSCTL_API_KEY", "editor-key")
+	return srv.URL
+}
+
+func listJSON(t *testing.T, args ...string) []*newsclient.News {
+	t.Helper()
+	out, err := run(t, append([]string{"-o", "json"}, args...)...)
+	require.NoError(t, err)
+	var news []*newsclient.News
+	require.NoError(t, json.Unmarshal([]byte(out), &news))
+	return news
+}
+
+func TestNewsctl(t *testing.T) {
+	// Arrange
+	newServer(t)
+	file := filepath.Join(t.TempDir(), "news.json")
+	require.NoError(t, os.WriteFile(file, []byte(newsJSON), 0o600))
+
+	// Act & Assert
+	out, err := run(t, "create", "--from-file", file)
+	require.NoError(t, err)
+	assert.Equal(t, "news created\n", out)
+
+	news := listJSON(t, "list")
+	require.Len(t, news, 1)
+	id := news[0].Id.String()
+	assert.Equal(t, newsclient.StatusDraft, news[0].Status)
+	assert.Empty(t, listJSON(t, "list", "--public"))
+	assert.Len(t, listJSON(t, "list", "--status", "draft,in_review"), 1)
+	assert.Empty(t, listJSON(t, "list", "--status", "published"))
+	assert.Len(t, listJSON(t, "search", "ELECTION"), 1)
+	assert.Empty(t, listJSON(t, "search", "weather"))
+
+	out, err = run(t, "list")
+	require.NoError(t, err)
+	assert.Contains(t, out, "ID")
+	assert.Contains(t, out, id+"  draft")
+
+	out, err = run(t, "-o", "yaml", "get", id)
+	require.NoError(t, err)
+	var n map[string]any
+	require.NoError(t, yaml.Unmarshal([]byte(out), &n))
+	assert.Equal(t, "Election results", n["Title"])
+	assert.True(t, strings.HasPrefix(out, "Id: "+id+"\n"), "keys are in the JSON order")
+
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "sed -i s/Election/Referendum/")
+	out, err = run(t, "edit", id)
+	require.NoError(t, err)
+	assert.Equal(t, "news "+id+" updated\n", out)
+	out, err = run(t, "get", id)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Referendum results")
+
+	t.Setenv("EDITOR", "true")
+	out, err = run(t, "edit", id)
+	require.NoError(t, err)
+	assert.Equal(t, "no changes\n", out)
+
+	out, err = run(t, "delete", id)
+	require.NoError(t, err)
+	assert.Equal(t, "news "+id+" deleted\n", out)
+	_, err = run(t, "get", id)
+	assert.ErrorIs(t, err, newsclient.ErrNotFound)
+
+	out, err = run(t, "restore", id)
+	require.NoError(t, err)
+	assert.Equal(t, "news "+id+" restored\n", out)
+	assert.Len(t, listJSON(t, "list"), 1)
+}
+
+func TestNewsctl_Errors(t *testing.T) {
+	newServer(t)
+
+	testCases := []struct {
+		name        string
+		args        []string
+		expectedErr string
+	}{
+		{name: "unknown output", args: []string{"-o", "xml", "list"}, expectedErr: `unknown output format "xml"`},
+		{name: "invalid id", args: []string{"get", "1"}, expectedErr: "invalid news id: 1"},
+		{name: "missing id", args: []string{"delete"}, expectedErr: "delete: expected news ids"},
+		{name: "missing text", args: []string{"search"}, expectedErr: "search: missing text"},
+		{name: "public status", args: []string{"list", "--public", "--status", "draft"}, expectedErr: "--status does not apply"},
+		{name: "invalid status", args: []string{"list", "--status", "live"}, expectedErr: "400 Bad Request"},
+		{name: "missing file", args: []string{"create"}, expectedErr: `"from-file" not set`},
+		{name: "unknown profile", args: []string{"-p", "prod", "list"}, expectedErr: "unknown profile: prod"},
+		{name: "unknown shell", args: []string{"completion", "tcsh"}, expectedErr: `unsupported shell "tcsh"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			_, err := run(t, tc.args...)
+
+			// Assert
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectedErr)
+		})
+	}
+}
+
+func TestNewsctl_Profiles(t *testing.T) {
+	// Arrange
+	url := newServer(t)
+	// The profiles are not overridden; newServer restores the environment.
+	os.Unsetenv("NEWSCTL_URL")
+	os.Unsetenv("NEWSCTL_API_KEY")
+
+	// Act & Assert
+	_, err := run(t, "profile", "set", "--url", url, "local")
+	require.NoError(t, err)
+	_, err = run(t, "profile", "set", "--url", url, "--api-key", "editor-key", "editor")
+	require.NoError(t, err)
+
+	out, err := run(t, "profile", "list")
+	require.NoError(t, err)
+	assert.Contains(t, out, "*        local")
+	assert.Contains(t, out, "         editor")
+
+	_, err = run(t, "list")
+	assert.ErrorIs(t, err, newsclient.ErrUnauthorized, "the current profile has no key")
+	_, err = run(t, "-p", "editor", "list")
+	require.NoError(t, err)
+
+	_, err = run(t, "profile", "use", "editor")
+	require.NoError(t, err)
+	_, err = run(t, "list")
+	require.NoError(t, err)
+
+	_, err = run(t, "profile", "delete", "editor")
+	require.NoError(t, err)
+	config, err := loadConfig(os.Getenv("NEWSCTL_CONFIG"))
+	require.NoError(t, err)
+	assert.Empty(t, config.Current)
+	assert.Len(t, config.Profiles, 1)
+	info, err := os.Stat(os.Getenv("NEWSCTL_CONFIG"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
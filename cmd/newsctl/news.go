@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/TommyLearning/go-rest-api-project/pkg/newsclient"
+	"github.com/google/uuid"
+	"github.com/urfave/cli/v2"
+)
+
+// maxPageLimit is the largest page the API returns.
+const maxPageLimit = 100
+
+// listFlags filter the news of the list and search commands.
+func listFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: "tag", Usage: "only the news with the tag"},
+		&cli.StringFlag{Name: "status", Usage: "only the news in one of the comma separated states"},
+		&cli.IntFlag{Name: "limit", Usage: "at most limit news, all of them with 0", Value: newsclient.DefaultPageLimit},
+		&cli.BoolFlag{Name: "public", Usage: "only the news visible to the public"},
+	}
+}
+
+func listCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "list the news, newest first",
+		Flags: listFlags(),
+		Action: func(c *cli.Context) error {
+			return listNews(c, "")
+		},
+	}
+}
+
+func searchCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "search",
+		Usage:     "search the news by title, summary or content",
+		ArgsUsage: "<text>",
+		Flags:     listFlags(),
+		Action: func(c *cli.Context) error {
+			query := strings.TrimSpace(strings.Join(c.Args().Slice(), " "))
+			if query == "" {
+				return errors.New("search: missing text")
+			}
+			return listNews(c, query)
+		},
+	}
+}
+
+// listNews prints the news of the list flags matching the query, in any
+// editorial state unless only the public ones are asked for.
+func listNews(c *cli.Context, query string) error {
+	client, err := clientOf(c)
+	if err != nil {
+		return err
+	}
+	p, err := printerOf(c)
+	if err != nil {
+		return err
+	}
+	limit := c.Int("limit")
+	if limit < 0 {
+		return fmt.Errorf("invalid limit: %d", limit)
+	}
+	opts := newsclient.ListOptions{Tag: c.String("tag"), Query: query, Limit: min(limit, maxPageLimit)}
+	if statuses := c.String("status"); statuses != "" {
+		if c.Bool("public") {
+			return errors.New("--status does not apply to the public news")
+		}
+		for _, s := range strings.Split(statuses, ",") {
+			opts.Statuses = append(opts.Statuses, newsclient.Status(strings.TrimSpace(s)))
+		}
+	}
+
+	var all iter.Seq2[*newsclient.News, error]
+	if c.Bool("public") {
+		all = client.AllNews(c.Context, opts)
+	} else {
+		all = client.AllEditorNews(c.Context, opts)
+	}
+	var news []*newsclient.News
+	for n, err := range all {
+		if err != nil {
+			return err
+		}
+		news = append(news, n)
+		if len(news) == limit {
+			break
+		}
+	}
+	return p.list(news)
+}
+
+func getCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "get",
+		Usage:     "print a news",
+		ArgsUsage: "<id>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "public", Usage: "only if the news is visible to the public"},
+		},
+		Action: func(c *cli.Context) error {
+			id, err := idArg(c)
+			if err != nil {
+				return err
+			}
+			client, err := clientOf(c)
+			if err != nil {
+				return err
+			}
+			p, err := printerOf(c)
+			if err != nil {
+				return err
+			}
+			var n *newsclient.News
+			if c.Bool("public") {
+				n, err = client.GetNews(c.Context, id)
+			} else {
+				n, err = client.GetEditorNews(c.Context, id)
+			}
+			if err != nil {
+				return err
+			}
+			return p.news(n)
+		},
+	}
+}
+
+func createCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "create",
+		Usage: "create a draft news from a JSON file",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:      "from-file",
+				Aliases:   []string{"f"},
+				Usage:     "JSON file of the news, - for the standard input",
+				Required:  true,
+				TakesFile: true,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			client, err := clientOf(c)
+			if err != nil {
+				return err
+			}
+			var b []byte
+			if path := c.String("from-file"); path == "-" {
+				b, err = io.ReadAll(os.Stdin)
+			} else {
+				b, err = os.ReadFile(path)
+			}
+			if err != nil {
+				return fmt.Errorf("read news: %w", err)
+			}
+			var in newsclient.NewsInput
+			if err := json.Unmarshal(b, &in); err != nil {
+				return fmt.Errorf("parse news: %w", err)
+			}
+			if err := client.CreateNews(c.Context, in); err != nil {
+				return err
+			}
+			fmt.Fprintln(c.App.Writer, "news created")
+			return nil
+		},
+	}
+}
+
+func editCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "edit",
+		Usage:     "edit a news as JSON in $VISUAL or $EDITOR",
+		ArgsUsage: "<id>",
+		Action: func(c *cli.Context) error {
+			id, err := idArg(c)
+			if err != nil {
+				return err
+			}
+			client, err := clientOf(c)
+			if err != nil {
+				return err
+			}
+			n, err := client.GetEditorNews(c.Context, id)
+			if err != nil {
+				return err
+			}
+			before, err := json.MarshalIndent(n.Input(), "", "  ")
+			if err != nil {
+				return err
+			}
+
+			f, err := os.CreateTemp("", "newsctl-*.json")
+			if err != nil {
+				return err
+			}
+			path := f.Name()
+			_, err = f.Write(append(before, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(path)
+				return err
+			}
+			if err := runEditor(path); err != nil {
+				os.Remove(path)
+				return err
+			}
+			after, err := os.ReadFile(path)
+			if err != nil {
+				os.Remove(path)
+				return err
+			}
+			if bytes.Equal(bytes.TrimSpace(before), bytes.TrimSpace(after)) {
+				os.Remove(path)
+				fmt.Fprintln(c.App.Writer, "no changes")
+				return nil
+			}
+
+			// The edited file is kept when the news cannot be updated, so
+			// that the changes are not lost.
+			var in newsclient.NewsInput
+			if err := json.Unmarshal(after, &in); err != nil {
+				return fmt.Errorf("parse news, edits kept in %s: %w", path, err)
+			}
+			if err := client.UpdateNews(c.Context, id, in); err != nil {
+				return fmt.Errorf("update news, edits kept in %s: %w", path, err)
+			}
+			os.Remove(path)
+			fmt.Fprintf(c.App.Writer, "news %s updated\n", id)
+			return nil
+		},
+	}
+}
+
+// runEditor opens the file in the editor of the user, vi by default. The
+// editor may have arguments, e.g. "code --wait".
+func runEditor(path string) error {
+	editor := strings.Fields(os.Getenv("VISUAL"))
+	if len(editor) == 0 {
+		editor = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], path)...) //nolint:gosec // the editor of the user
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run editor %s: %w", editor[0], err)
+	}
+	return nil
+}
+
+func deleteCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "delete",
+		Usage:     "delete news, which can be restored",
+		ArgsUsage: "<id>...",
+		Action: func(c *cli.Context) error {
+			return eachId(c, func(client *newsclient.Client, id uuid.UUID) error {
+				if err := client.DeleteNews(c.Context, id); err != nil {
+					return err
+				}
+				fmt.Fprintf(c.App.Writer, "news %s deleted\n", id)
+				return nil
+			})
+		},
+	}
+}
+
+func restoreCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "restore",
+		Usage:     "restore deleted news",
+		ArgsUsage: "<id>...",
+		Action: func(c *cli.Context) error {
+			return eachId(c, func(client *newsclient.Client, id uuid.UUID) error {
+				if _, err := client.RestoreNews(c.Context, id); err != nil {
+					return err
+				}
+				fmt.Fprintf(c.App.Writer, "news %s restored\n", id)
+				return nil
+			})
+		},
+	}
+}
+
+// idArg returns the news ID, the only argument of the command.
+func idArg(c *cli.Context) (uuid.UUID, error) {
+	if c.NArg() != 1 {
+		return uuid.Nil, fmt.Errorf("%s: expected a news id", c.Command.Name)
+	}
+	id, err := uuid.Parse(c.Args().First())
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid news id: %s", c.Args().First())
+	}
+	return id, nil
+}
+
+// eachId calls f with the news IDs of the arguments, all of which are parsed
+// first, and stops at the first error.
+func eachId(c *cli.Context, f func(*newsclient.Client, uuid.UUID) error) error {
+	if c.NArg() == 0 {
+		return fmt.Errorf("%s: expected news ids", c.Command.Name)
+	}
+	ids := make([]uuid.UUID, 0, c.NArg())
+	for _, arg := range c.Args().Slice() {
+		id, err := uuid.Parse(arg)
+		if err != nil {
+			return fmt.Errorf("invalid news id: %s", arg)
+		}
+		ids = append(ids, id)
+	}
+	client, err := clientOf(c)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := f(client, id); err != nil {
+			return fmt.Errorf("news %s: %w", id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/pkg/newsclient"
+	"gopkg.in/yaml.v3"
+)
+
+// The output formats of the news.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+var formats = []string{formatTable, formatJSON, formatYAML}
+
+// maxTitleWidth truncates the titles of the news tables.
+const maxTitleWidth = 60
+
+// printer writes news to w in the format.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case formatTable, formatJSON, formatYAML:
+		return &printer{w: w, format: format}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q, want one of %s", format, strings.Join(formats, ", "))
+	}
+}
+
+// list writes the news, one row per news in the table format.
+func (p *printer) list(news []*newsclient.News) error {
+	if news == nil {
+		news = []*newsclient.News{}
+	}
+	if p.format != formatTable {
+		return p.encode(news)
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tCREATED\tAUTHOR\tTITLE")
+	for _, n := range news {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", n.Id, n.Status, n.CreatedAt.Format(time.DateTime), n.Author, truncate(n.Title, maxTitleWidth))
+	}
+	return tw.Flush()
+}
+
+// news writes the news, one row per field in the table format.
+func (p *printer) news(n *newsclient.News) error {
+	if p.format != formatTable {
+		return p.encode(n)
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	rows := [][2]string{
+		{"ID", n.Id.String()},
+		{"Title", n.Title},
+		{"Author", n.Author},
+		{"Status", string(n.Status)},
+		{"Tags", strings.Join(n.Tags, ", ")},
+		{"Source", n.Source},
+		{"Created", timeOf(n.CreatedAt)},
+		{"Updated", timeOf(n.UpdatedAt)},
+		{"Published", timeOf(n.PublishedAt)},
+		{"Publish at", timeOf(n.PublishAt)},
+		{"Embargo until", timeOf(n.EmbargoUntil)},
+		{"Expires at", timeOf(n.ExpiresAt)},
+		{"Summary", n.Summary},
+	}
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s:\t%s\n", row[0], row[1])
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(p.w, "\n%s\n", n.Content)
+	return err
+}
+
+// encode writes v as JSON, or as YAML with the keys of its JSON encoding in
+// the same order.
+func (p *printer) encode(v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if p.format == formatJSON {
+		_, err = fmt.Fprintf(p.w, "%s\n", b)
+		return err
+	}
+	// JSON is YAML: decoding it into nodes keeps the order of the keys, and
+	// the nodes only have to be turned from the flow style into the block one.
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	enc := yaml.NewEncoder(p.w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func blockStyle(n *yaml.Node) {
+	n.Style &^= yaml.FlowStyle | yaml.DoubleQuotedStyle
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+func timeOf(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:width-1]) + "…"
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+)
+
+func profileCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "profile",
+		Usage: "manage the profiles of the environments",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "list the profiles, the current one marked with *",
+				Action: func(c *cli.Context) error {
+					config, err := loadConfig(c.String("config"))
+					if err != nil {
+						return err
+					}
+					tw := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+					fmt.Fprintln(tw, "CURRENT\tNAME\tURL")
+					for _, name := range slices.Sorted(maps.Keys(config.Profiles)) {
+						current := ""
+						if name == config.Current {
+							current = "*"
+						}
+						fmt.Fprintf(tw, "%s\t%s\t%s\n", current, name, config.Profiles[name].URL)
+					}
+					return tw.Flush()
+				},
+			},
+			{
+				Name:      "set",
+				Usage:     "create or update a profile",
+				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "url", Usage: "base url of the API"},
+					&cli.StringFlag{Name: "api-key", Usage: "API key"},
+				},
+				Action: func(c *cli.Context) error {
+					name := c.Args().First()
+					if c.NArg() != 1 || name == "" {
+						return errors.New("profile set: expected a profile name")
+					}
+					config, err := loadConfig(c.String("config"))
+					if err != nil {
+						return err
+					}
+					p, ok := config.Profiles[name]
+					if !ok {
+						p = &Profile{URL: defaultURL}
+						config.Profiles[name] = p
+					}
+					if c.IsSet("url") {
+						p.URL = c.String("url")
+					}
+					if c.IsSet("api-key") {
+						p.APIKey = c.String("api-key")
+					}
+					if config.Current == "" {
+						config.Current = name
+					}
+					return config.save(c.String("config"))
+				},
+			},
+			{
+				Name:      "use",
+				Usage:     "make a profile the current one",
+				ArgsUsage: "<name>",
+				Action: func(c *cli.Context) error {
+					name := c.Args().First()
+					config, err := loadConfig(c.String("config"))
+					if err != nil {
+						return err
+					}
+					if _, ok := config.Profiles[name]; !ok {
+						return fmt.Errorf("unknown profile: %s", name)
+					}
+					config.Current = name
+					return config.save(c.String("config"))
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "delete a profile",
+				ArgsUsage: "<name>",
+				Action: func(c *cli.Context) error {
+					name := c.Args().First()
+					config, err := loadConfig(c.String("config"))
+					if err != nil {
+						return err
+					}
+					if _, ok := config.Profiles[name]; !ok {
+						return fmt.Errorf("unknown profile: %s", name)
+					}
+					delete(config.Profiles, name)
+					if config.Current == name {
+						config.Current = ""
+					}
+					return config.save(c.String("config"))
+				},
+			},
+		},
+	}
+}
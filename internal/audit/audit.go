@@ -0,0 +1,224 @@
+// Package audit keeps an append-only log of the writes: who sent each POST,
+// PUT, PATCH and DELETE request, from where, to which route and news, how it
+// ended, and the hashes of the news before and after it.
+//
+// The middleware records the request and its outcome, while the stores track
+// the news they write in the context of the request, in the transaction of
+// the write.
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/ratelimit"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/google/uuid"
+)
+
+// Event is the audit record of a write request.
+type Event struct {
+	Id int64 `json:"id"`
+	// TenantId is the tenant of the request, empty for the requests
+	// rejected before it is resolved.
+	TenantId   string    `json:"tenant_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	// Actor is the name of the principal of the request, empty for the
+	// anonymous requests.
+	Actor      string `json:"actor"`
+	AuthMethod string `json:"auth_method"`
+	IP         string `json:"ip"`
+	RequestId  string `json:"request_id"`
+	Method     string `json:"method"`
+	// Route is the pattern of the route of the request, empty when no route
+	// matched.
+	Route  string     `json:"route"`
+	Path   string     `json:"path"`
+	NewsId *uuid.UUID `json:"news_id,omitempty"`
+	Status int        `json:"status"`
+	// BeforeHash and AfterHash are the SHA-256 of the row of the news before
+	// and after the write, empty when there was none.
+	BeforeHash string `json:"before_hash,omitempty"`
+	AfterHash  string `json:"after_hash,omitempty"`
+}
+
+// Filter narrows down the events found, oldest first.
+type Filter struct {
+	Actor  string
+	NewsId uuid.UUID
+	// From and To bound the time of the events, From included and To
+	// excluded.
+	From time.Time
+	To   time.Time
+	// After is the ID of the last event of the previous page.
+	After int64
+	Limit int
+}
+
+// Store keeps the events. Events are only ever appended.
+type Store interface {
+	Append(ctx context.Context, e *Event) error
+	Find(ctx context.Context, f Filter) ([]*Event, error)
+}
+
+// Mid records an event for the POST, PUT, PATCH and DELETE requests once they
+// are served, whatever their outcome, including the requests of no route and
+// the ones rejected by the authentication or the tenant resolution, which it
+// runs before. Their principal and tenant are the ones Identify records, if
+// any. The IP of the client is resolved behind the trusted proxies. The event
+// is logged if it cannot be stored, since the response is already sent.
+func Mid(s Store, mux *http.ServeMux, trusted []netip.Prefix, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodPatch && r.Method != http.MethodDelete {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+		_, pattern := mux.Handler(r)
+		e := &Event{
+			OccurredAt: time.Now().UTC(),
+			IP:         ratelimit.ClientIP(r, trusted).String(),
+			RequestId:  logger.RequestID(ctx),
+			Method:     r.Method,
+			Route:      pattern,
+			Path:       r.URL.Path,
+		}
+		if id, err := uuid.Parse(pathValue(pattern, r.URL.Path, "news_id")); err == nil {
+			e.NewsId = &id
+		}
+		t := &tracker{}
+		rec := &recorder{ResponseWriter: w}
+		// Panics are recorded as the 500 Internal Server Error they end
+		// up in.
+		defer func() {
+			v := recover()
+			if v != nil {
+				rec.status = http.StatusInternalServerError
+			}
+			appendEvent(context.WithoutCancel(ctx), s, e, t, rec.status)
+			if v != nil {
+				panic(v)
+			}
+		}()
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(ctx, trackerKey{}, t)))
+	}
+}
+
+func appendEvent(ctx context.Context, s Store, e *Event, t *tracker, status int) {
+	e.Status = status
+	if e.Status == 0 {
+		e.Status = http.StatusOK
+	}
+	t.l.Lock()
+	e.TenantId, e.Actor, e.AuthMethod = t.tenantId, t.actor, t.authMethod
+	if t.tracked {
+		e.NewsId = &t.newsId
+		e.BeforeHash, e.AfterHash = t.before, t.after
+	}
+	t.l.Unlock()
+	if err := s.Append(ctx, e); err != nil {
+		logger.FromContext(ctx).Error("failed to append audit event", "error", err,
+			"actor", e.Actor, "route", e.Route, "path", e.Path, "status", e.Status)
+	}
+}
+
+// pathValue returns the value of the wildcard of the pattern in the path, or
+// "" if the pattern has no such wildcard.
+func pathValue(pattern, path, name string) string {
+	_, pattern, _ = strings.Cut(pattern, " ")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, s := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if s == "{"+name+"}" && i < len(segments) {
+			return segments[i]
+		}
+	}
+	return ""
+}
+
+type trackerKey struct{}
+
+// tracker collects the principal and the tenant of a request, and the news
+// written during it.
+type tracker struct {
+	l          sync.Mutex
+	actor      string
+	authMethod string
+	tenantId   string
+	tracked    bool
+	newsId     uuid.UUID
+	before     string
+	after      string
+}
+
+// Identify records the principal and the tenant of the requests resolved so
+// far, for the event of Mid. It runs once they are authenticated, and again
+// once their tenant is resolved.
+func Identify(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if t, ok := ctx.Value(trackerKey{}).(*tracker); ok {
+			t.l.Lock()
+			if p, ok := auth.FromContext(ctx); ok {
+				t.actor, t.authMethod = p.Name, p.Method
+			}
+			if tt, ok := tenant.Lookup(ctx); ok {
+				t.tenantId = tt.Id
+			}
+			t.l.Unlock()
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// Audited reports whether the request of the context is audited, for the
+// stores to compute the hashes of Track only then.
+func Audited(ctx context.Context) bool {
+	_, ok := ctx.Value(trackerKey{}).(*tracker)
+	return ok
+}
+
+// Track records that the request of the context wrote the news, from the
+// before hash to the after hash. When the request writes the news several
+// times, its event keeps the first before hash and the last after hash.
+func Track(ctx context.Context, newsId uuid.UUID, before, after string) {
+	t, ok := ctx.Value(trackerKey{}).(*tracker)
+	if !ok {
+		return
+	}
+	t.l.Lock()
+	defer t.l.Unlock()
+	if !t.tracked || t.newsId != newsId {
+		t.tracked, t.newsId, t.before = true, newsId, before
+	}
+	t.after = after
+}
+
+// recorder records the status of the response.
+type recorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *recorder) WriteHeader(status int) {
+	if w.status == 0 && status >= 200 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
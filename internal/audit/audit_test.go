@@ -0,0 +1,181 @@
+package audit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/audit"
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store keeps the events appended in memory.
+type store struct {
+	events []*audit.Event
+}
+
+func (s *store) Append(_ context.Context, e *audit.Event) error {
+	s.events = append(s.events, e)
+	return nil
+}
+
+func (s *store) Find(context.Context, audit.Filter) ([]*audit.Event, error) {
+	return s.events, nil
+}
+
+func Test_Mid(t *testing.T) {
+	newsId := uuid.MustParse("3b082d9d-1dc7-4d1f-907e-50d449a03d45")
+	trackedId := uuid.New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /news/{news_id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("PUT /news/{news_id}", func(w http.ResponseWriter, r *http.Request) {
+		audit.Track(r.Context(), newsId, "before", "updated")
+		audit.Track(r.Context(), newsId, "updated", "after")
+	})
+	mux.HandleFunc("POST /news", func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, audit.Audited(r.Context()))
+		audit.Track(r.Context(), trackedId, "", "created")
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("DELETE /news/{news_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	testCases := []struct {
+		name      string
+		method    string
+		target    string
+		principal *auth.Principal
+		tenant    *tenant.Tenant
+		expected  *audit.Event
+	}{
+		{
+			name:   "read",
+			method: http.MethodGet,
+			target: "/news/" + newsId.String(),
+		},
+		{
+			name:      "tracked write",
+			method:    http.MethodPost,
+			target:    "/news",
+			principal: &auth.Principal{Name: "alice", Role: auth.RoleEditor, Method: auth.MethodAPIKey, Tenant: "acme"},
+			tenant:    &tenant.Tenant{Id: "acme"},
+			expected: &audit.Event{
+				TenantId:   "acme",
+				Actor:      "alice",
+				AuthMethod: auth.MethodAPIKey,
+				Method:     http.MethodPost,
+				Route:      "POST /news",
+				Path:       "/news",
+				NewsId:     &trackedId,
+				Status:     http.StatusCreated,
+				AfterHash:  "created",
+			},
+		},
+		{
+			name:   "written several times",
+			method: http.MethodPut,
+			target: "/news/" + newsId.String(),
+			tenant: &tenant.Tenant{Id: tenant.Default},
+			expected: &audit.Event{
+				TenantId:   tenant.Default,
+				Method:     http.MethodPut,
+				Route:      "PUT /news/{news_id}",
+				Path:       "/news/" + newsId.String(),
+				NewsId:     &newsId,
+				Status:     http.StatusOK,
+				BeforeHash: "before",
+				AfterHash:  "after",
+			},
+		},
+		{
+			name:   "rejected write",
+			method: http.MethodDelete,
+			target: "/news/" + newsId.String(),
+			expected: &audit.Event{
+				Method: http.MethodDelete,
+				Route:  "DELETE /news/{news_id}",
+				Path:   "/news/" + newsId.String(),
+				NewsId: &newsId,
+				Status: http.StatusForbidden,
+			},
+		},
+		{
+			name:   "no route",
+			method: http.MethodPost,
+			target: "/unknown",
+			expected: &audit.Event{
+				Method: http.MethodPost,
+				Path:   "/unknown",
+				Status: http.StatusNotFound,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			s := &store{}
+			// The principal and the tenant are resolved after Mid runs.
+			identified := audit.Identify(mux)
+			h := logger.RequestIDMid(audit.Mid(s, mux, trusted, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx := r.Context()
+				if tc.principal != nil {
+					ctx = auth.CtxWithPrincipal(ctx, tc.principal)
+				}
+				identified(w, r.WithContext(tenant.CtxWithTenant(ctx, tc.tenant)))
+			})))
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tc.method, tc.target, http.NoBody)
+			r.RemoteAddr = "10.0.0.1:41234"
+			r.Header.Set("X-Forwarded-For", "203.0.113.7")
+			r.Header.Set(logger.RequestIDHeader, "req-1")
+
+			// Act
+			h(w, r)
+
+			// Assert
+			if tc.expected == nil {
+				assert.Empty(t, s.events)
+				return
+			}
+			require.Len(t, s.events, 1)
+			e := s.events[0]
+			assert.NotZero(t, e.OccurredAt)
+			assert.Equal(t, "203.0.113.7", e.IP)
+			assert.Equal(t, "req-1", e.RequestId)
+			e.OccurredAt, e.IP, e.RequestId = tc.expected.OccurredAt, "", ""
+			assert.Equal(t, tc.expected, e)
+		})
+	}
+}
+
+func Test_Mid_Panic(t *testing.T) {
+	// Arrange
+	s := &store{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /news", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	h := audit.Mid(s, mux, nil, mux)
+
+	// Act, Assert
+	assert.PanicsWithValue(t, "boom", func() {
+		h(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/news", http.NoBody))
+	})
+	require.Len(t, s.events, 1)
+	assert.Equal(t, http.StatusInternalServerError, s.events[0].Status)
+}
+
+func Test_Track_NotAudited(t *testing.T) {
+	ctx := context.Background()
+
+	assert.False(t, audit.Audited(ctx))
+	assert.NotPanics(t, func() { audit.Track(ctx, uuid.New(), "before", "after") })
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type Role string
+
+const (
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+const (
+	MethodAPIKey     = "api_key"
+	MethodClientCert = "client_cert"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Name   string
+	Role   Role
+	Method string
+	// Tenant is the only tenant the principal acts on. Principals without
+	// a tenant operate the platform, on the tenant of each request.
+	Tenant string
+}
+
+// Key identifies the principal across the tenants.
+func (p *Principal) Key() string {
+	if p.Tenant == "" {
+		return p.Name
+	}
+	return p.Name + "@" + p.Tenant
+}
+
+// Has reports whether the principal is allowed to act with the given role.
+// Admins can do everything editors can.
+func (p *Principal) Has(role Role) bool {
+	if p == nil {
+		return false
+	}
+	return p.Role == role || p.Role == RoleAdmin
+}
+
+// Editor reports whether the caller of the context sees all the news rather
+// than the public ones only.
+func Editor(ctx context.Context) bool {
+	p, _ := FromContext(ctx)
+	return p.Has(RoleEditor)
+}
+
+type CtxKey struct{}
+
+func CtxWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	if p == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, CtxKey{}, p)
+}
+
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(CtxKey{}).(*Principal)
+	return p, ok
+}
+
+// Keys maps API keys to the principal they authenticate.
+type Keys map[string]*Principal
+
+// ParseKeys parses a comma separated list of key=name:role entries, whose
+// role can be followed by @tenant to bind the principal to the tenant.
+func ParseKeys(s string) (Keys, error) {
+	return parsePrincipals(s, "api key", MethodAPIKey)
+}
+
+// Subjects maps the common names of the verified client certificates to the
+// principal they authenticate.
+type Subjects map[string]*Principal
+
+// ParseSubjects parses a comma separated list of common_name=name:role
+// entries, optionally bound to a tenant as ParseKeys does.
+func ParseSubjects(s string) (Subjects, error) {
+	return parsePrincipals(s, "client certificate", MethodClientCert)
+}
+
+// Principal returns the principal of the client certificate of the
+// connection, if it was verified and its common name is known.
+func (s Subjects) Principal(cs *tls.ConnectionState) (*Principal, bool) {
+	if cs == nil || len(cs.VerifiedChains) == 0 || len(cs.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	p, ok := s[cs.VerifiedChains[0][0].Subject.CommonName]
+	return p, ok
+}
+
+func parsePrincipals(s, kind, method string) (map[string]*Principal, error) {
+	principals := map[string]*Principal{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, principal, ok := strings.Cut(entry, "=")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid %s entry: %q", kind, entry)
+		}
+		name, role, ok := strings.Cut(principal, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid %s principal: %q", kind, principal)
+		}
+		role, tenant, bound := strings.Cut(role, "@")
+		if bound && tenant == "" {
+			return nil, fmt.Errorf("invalid %s tenant: %q", kind, principal)
+		}
+		switch Role(role) {
+		case RoleEditor, RoleAdmin:
+		default:
+			return nil, fmt.Errorf("invalid %s role: %q", kind, role)
+		}
+		principals[id] = &Principal{Name: name, Role: Role(role), Method: method, Tenant: tenant}
+	}
+	return principals, nil
+}
+
+// APIKey returns the API key of the request, in the Authorization bearer or
+// X-API-Key header.
+func APIKey(r *http.Request) string {
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return bearer
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// Mid authenticates requests carrying an API key in the Authorization bearer
+// or X-API-Key header. Requests without a key continue anonymously.
+func Mid(keys Keys, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := APIKey(r)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		p, ok := keys[key]
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(CtxWithPrincipal(r.Context(), p)))
+	}
+}
+
+// CertMid authenticates requests over connections with a verified client
+// certificate whose common name is known. An API key sent along takes
+// precedence, when auth.Mid runs after.
+func CertMid(subjects Subjects, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := subjects.Principal(r.TLS); ok {
+			r = r.WithContext(CtxWithPrincipal(r.Context(), p))
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// RequireRole rejects requests whose principal does not have the given role.
+func RequireRole(role Role, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := FromContext(r.Context())
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !p.Has(role) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// RequirePlatform rejects requests whose principal is bound to a tenant, for
+// the routes acting on all the tenants. It runs after RequireRole.
+func RequirePlatform(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := FromContext(r.Context()); ok && p.Tenant != "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
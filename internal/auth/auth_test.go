@@ -0,0 +1,196 @@
+package auth_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseKeys(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		expected    auth.Keys
+		expectedErr string
+	}{
+		{
+			name:     "empty",
+			input:    "",
+			expected: auth.Keys{},
+		},
+		{
+			name:  "valid",
+			input: "k1=alice:admin, k2=bob:editor",
+			expected: auth.Keys{
+				"k1": {Name: "alice", Role: auth.RoleAdmin, Method: auth.MethodAPIKey},
+				"k2": {Name: "bob", Role: auth.RoleEditor, Method: auth.MethodAPIKey},
+			},
+		},
+		{
+			name:  "tenant",
+			input: "k1=alice:editor@acme",
+			expected: auth.Keys{
+				"k1": {Name: "alice", Role: auth.RoleEditor, Method: auth.MethodAPIKey, Tenant: "acme"},
+			},
+		},
+		{
+			name:        "empty tenant",
+			input:       "k1=alice:editor@",
+			expectedErr: "invalid api key tenant",
+		},
+		{
+			name:        "missing principal",
+			input:       "k1",
+			expectedErr: "invalid api key entry",
+		},
+		{
+			name:        "missing role",
+			input:       "k1=alice",
+			expectedErr: "invalid api key principal",
+		},
+		{
+			name:        "unknown role",
+			input:       "k1=alice:root",
+			expectedErr: "invalid api key role",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keys, err := auth.ParseKeys(tc.input)
+
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, keys)
+			}
+		})
+	}
+}
+
+func Test_Mid(t *testing.T) {
+	keys := auth.Keys{
+		"admin-key":  {Name: "alice", Role: auth.RoleAdmin, Method: auth.MethodAPIKey},
+		"editor-key": {Name: "bob", Role: auth.RoleEditor, Method: auth.MethodAPIKey},
+		"acme-key":   {Name: "carol", Role: auth.RoleAdmin, Method: auth.MethodAPIKey, Tenant: "acme"},
+	}
+
+	testCases := []struct {
+		name           string
+		headers        map[string]string
+		role           auth.Role
+		platform       bool
+		expectedStatus int
+	}{
+		{
+			name:           "anonymous",
+			expectedStatus: http.StatusUnauthorized,
+			role:           auth.RoleEditor,
+		},
+		{
+			name:           "unknown key",
+			headers:        map[string]string{"X-API-Key": "nope"},
+			role:           auth.RoleEditor,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "editor as editor",
+			headers:        map[string]string{"X-API-Key": "editor-key"},
+			role:           auth.RoleEditor,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "editor as admin",
+			headers:        map[string]string{"Authorization": "Bearer editor-key"},
+			role:           auth.RoleAdmin,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "admin as editor",
+			headers:        map[string]string{"Authorization": "Bearer admin-key"},
+			role:           auth.RoleEditor,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "platform admin on the platform",
+			headers:        map[string]string{"X-API-Key": "admin-key"},
+			role:           auth.RoleAdmin,
+			platform:       true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "tenant admin on the platform",
+			headers:        map[string]string{"X-API-Key": "acme-key"},
+			role:           auth.RoleAdmin,
+			platform:       true,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+			var next http.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			if tc.platform {
+				next = auth.RequirePlatform(next)
+			}
+
+			// Act
+			auth.Mid(keys, auth.RequireRole(tc.role, next))(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_ParseSubjects(t *testing.T) {
+	subjects, err := auth.ParseSubjects("alice-laptop=alice:editor")
+	require.NoError(t, err)
+	assert.Equal(t, auth.Subjects{"alice-laptop": {Name: "alice", Role: auth.RoleEditor, Method: auth.MethodClientCert}}, subjects)
+
+	_, err = auth.ParseSubjects("alice-laptop=alice")
+	assert.ErrorContains(t, err, "invalid client certificate principal")
+}
+
+func Test_Subjects_Principal(t *testing.T) {
+	subjects := auth.Subjects{"alice-laptop": {Name: "alice", Role: auth.RoleEditor, Method: auth.MethodClientCert}}
+	leaf := &x509.Certificate{Subject: pkix.Name{CommonName: "alice-laptop"}}
+
+	testCases := []struct {
+		name     string
+		state    *tls.ConnectionState
+		expected string
+	}{
+		{name: "plain http"},
+		{name: "no client certificate", state: &tls.ConnectionState{}},
+		{name: "unverified", state: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}},
+		{name: "verified", state: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}, VerifiedChains: [][]*x509.Certificate{{leaf}}}, expected: "alice"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, ok := subjects.Principal(tc.state)
+
+			assert.Equal(t, tc.expected != "", ok)
+			if ok {
+				assert.Equal(t, tc.expected, p.Name)
+			}
+		})
+	}
+}
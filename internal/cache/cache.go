@@ -0,0 +1,225 @@
+// Package cache caches the reads of the news in front of their store.
+//
+// The news and the lists of news are cached by the tenant, and the ID and the
+// filter they are read with. Every write through the cache drops the cached
+// reads, and so does every news event notified by the writes of the other
+// replicas and of the workers, since any write can change any list. Reads of
+// the same missing key are coalesced into a single read of the store.
+//
+// Reads still change with the clock, as embargoes end and news expire, and
+// with the tags and the authors, which are written around the cache: cached
+// reads can be out of date by the TTL of the cache.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache keeps encoded values, in memory or in an external cache.
+type Cache interface {
+	// Get returns the value of the key, if it is cached and not expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte) error
+	// Purge drops all the values.
+	Purge(ctx context.Context) error
+}
+
+// Stats counts the reads of the news store.
+type Stats struct {
+	// Hits and Misses count the reads found in the cache or not.
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	// Loads counts the reads of the store: the misses not coalesced.
+	Loads         int64 `json:"loads"`
+	Invalidations int64 `json:"invalidations"`
+	// Entries, Bytes and Evictions describe the LRU cache, if used.
+	Entries   int   `json:"entries,omitempty"`
+	Bytes     int   `json:"bytes,omitempty"`
+	Evictions int64 `json:"evictions,omitempty"`
+}
+
+// NewsStore caches the reads by ID and the lists of the news store.
+type NewsStore struct {
+	handler.NewsStorer
+	cache Cache
+	group singleflight.Group
+	// generation is incremented by the invalidations, so that the reads
+	// started before are neither shared with the reads after nor cached.
+	generation atomic.Uint64
+	// invalidating is held by the invalidations, and read locked by the
+	// reads that check the generation and cache their value, so that no
+	// invalidation runs in between.
+	invalidating sync.RWMutex
+
+	hits, misses, loads, invalidations atomic.Int64
+}
+
+func NewNewsStore(ns handler.NewsStorer, c Cache) *NewsStore {
+	return &NewsStore{NewsStorer: ns, cache: c}
+}
+
+func (s *NewsStore) FindById(ctx context.Context, id uuid.UUID) (*news.Record, error) {
+	return read(ctx, s, tenantKey(ctx, "id:"+id.String()), func(ctx context.Context) (*news.Record, error) {
+		return s.NewsStorer.FindById(ctx, id)
+	})
+}
+
+func (s *NewsStore) FindAll(ctx context.Context, f news.Filter) ([]*news.Record, error) {
+	key, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return read(ctx, s, tenantKey(ctx, "all:"+string(key)), func(ctx context.Context) ([]*news.Record, error) {
+		return s.NewsStorer.FindAll(ctx, f)
+	})
+}
+
+// tenantKey scopes the key to the tenant of the context, whose reads are the
+// only ones to see its news.
+func tenantKey(ctx context.Context, key string) string {
+	return tenant.FromContext(ctx).Id + "/" + key
+}
+
+// read returns the cached value of the key, or reads it from the store with
+// find once for all the concurrent reads of the key, and caches it.
+func read[T any](ctx context.Context, s *NewsStore, key string, find func(context.Context) (T, error)) (T, error) {
+	log := logger.FromContext(ctx)
+	var v T
+	b, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		log.Error("failed to get cached news", "error", err, "key", key)
+	}
+	if ok {
+		if err := json.Unmarshal(b, &v); err == nil {
+			s.hits.Add(1)
+			return v, nil
+		}
+		log.Error("failed to decode cached news", "error", err, "key", key)
+	}
+	s.misses.Add(1)
+
+	generation := s.generation.Load()
+	ch := s.group.DoChan(strconv.FormatUint(generation, 10)+"|"+key, func() (any, error) {
+		s.loads.Add(1)
+		// The read is shared, it is not canceled with the request that
+		// happens to run it, but it is bounded by its deadline.
+		shared := context.WithoutCancel(ctx)
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			shared, cancel = context.WithDeadline(shared, deadline)
+			defer cancel()
+		}
+		ctx := shared
+		v, err := find(ctx)
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		s.invalidating.RLock()
+		defer s.invalidating.RUnlock()
+		if s.generation.Load() == generation {
+			if err := s.cache.Set(ctx, key, b); err != nil {
+				log.Error("failed to cache news", "error", err, "key", key)
+			}
+		}
+		return b, nil
+	})
+	var res singleflight.Result
+	select {
+	case res = <-ch:
+	case <-ctx.Done():
+		return v, news.NewCustomError(ctx.Err(), http.StatusInternalServerError)
+	}
+	if res.Err != nil {
+		return v, res.Err
+	}
+	// Each read decodes its own copy of the news.
+	err = json.Unmarshal(res.Val.([]byte), &v)
+	return v, err
+}
+
+func (s *NewsStore) Create(ctx context.Context, n *news.Record) (*news.Record, error) {
+	defer s.Invalidate(ctx)
+	return s.NewsStorer.Create(ctx, n)
+}
+
+func (s *NewsStore) UpdateById(ctx context.Context, id uuid.UUID, n *news.Record) error {
+	defer s.Invalidate(ctx)
+	return s.NewsStorer.UpdateById(ctx, id, n)
+}
+
+func (s *NewsStore) DeleteById(ctx context.Context, id uuid.UUID) error {
+	defer s.Invalidate(ctx)
+	return s.NewsStorer.DeleteById(ctx, id)
+}
+
+func (s *NewsStore) Transition(ctx context.Context, id uuid.UUID, to news.Status, actor string) (*news.Record, error) {
+	defer s.Invalidate(ctx)
+	return s.NewsStorer.Transition(ctx, id, to, actor)
+}
+
+func (s *NewsStore) Restore(ctx context.Context, id uuid.UUID) (*news.Record, error) {
+	defer s.Invalidate(ctx)
+	return s.NewsStorer.Restore(ctx, id)
+}
+
+// Invalidate drops the cached reads, once the reads being cached are.
+func (s *NewsStore) Invalidate(ctx context.Context) {
+	s.invalidating.Lock()
+	defer s.invalidating.Unlock()
+	s.generation.Add(1)
+	s.invalidations.Add(1)
+	if err := s.cache.Purge(ctx); err != nil {
+		logger.FromContext(ctx).Error("failed to purge news cache", "error", err)
+	}
+}
+
+// Notify invalidates the cache on the news events notified by the store, to
+// be passed to postgres.Listen.
+func (s *NewsStore) Notify(ctx context.Context, _ string) {
+	s.Invalidate(ctx)
+}
+
+// Stats returns the counts of the reads.
+func (s *NewsStore) Stats() Stats {
+	stats := Stats{
+		Hits:          s.hits.Load(),
+		Misses:        s.misses.Load(),
+		Loads:         s.loads.Load(),
+		Invalidations: s.invalidations.Load(),
+	}
+	if lru, ok := s.cache.(*LRU); ok {
+		stats.Entries, stats.Bytes = lru.Len()
+		stats.Evictions = lru.Evictions()
+	}
+	return stats
+}
+
+// Handler returns the stats of the cache of the news store.
+func Handler(s *NewsStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get cache stats")
+		if err := json.NewEncoder(w).Encode(s.Stats()); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package cache_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/cache"
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/store"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore counts the reads of the store, and holds them until release
+// is closed, if any.
+type countingStore struct {
+	handler.NewsStorer
+	reads   atomic.Int32
+	release chan struct{}
+}
+
+func (s *countingStore) FindById(ctx context.Context, id uuid.UUID) (*news.Record, error) {
+	s.reads.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	return s.NewsStorer.FindById(ctx, id)
+}
+
+func (s *countingStore) FindAll(ctx context.Context, f news.Filter) ([]*news.Record, error) {
+	s.reads.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	return s.NewsStorer.FindAll(ctx, f)
+}
+
+func newStore(t *testing.T) (*cache.NewsStore, *countingStore, *news.Record) {
+	t.Helper()
+	ns := &countingStore{NewsStorer: store.NewNewsStore()}
+	n, err := ns.Create(context.Background(), &news.Record{Author: "Batman", Title: "title", Content: "content", Tags: []string{"politics"}})
+	require.NoError(t, err)
+	return cache.NewNewsStore(ns, cache.NewLRU(100, 0, time.Minute, nil)), ns, n
+}
+
+func TestNewsStore_Reads(t *testing.T) {
+	ctx := context.Background()
+	s, ns, n := newStore(t)
+
+	// Arrange
+	first, err := s.FindById(ctx, n.Id)
+	require.NoError(t, err)
+	all, err := s.FindAll(ctx, news.Filter{Tag: "politics"})
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+
+	// Act
+	cached, err := s.FindById(ctx, n.Id)
+	require.NoError(t, err)
+	cachedAll, err := s.FindAll(ctx, news.Filter{Tag: "politics"})
+	require.NoError(t, err)
+	other, err := s.FindAll(ctx, news.Filter{Tag: "sports"})
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, int32(3), ns.reads.Load())
+	assert.Equal(t, n.Id, cached.Id)
+	assert.Equal(t, n.Title, cached.Title)
+	assert.Equal(t, []string{"politics"}, cached.Tags)
+	assert.Equal(t, n.Id, cachedAll[0].Id)
+	assert.Empty(t, other)
+	// The reads get their own copies.
+	assert.NotSame(t, first, cached)
+	cached.Tags[0] = "changed"
+	again, err := s.FindById(ctx, n.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"politics"}, again.Tags)
+	assert.Equal(t, cache.Stats{Hits: 3, Misses: 3, Loads: 3, Entries: 3, Bytes: s.Stats().Bytes}, s.Stats())
+}
+
+func TestNewsStore_Errors(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	s, ns, _ := newStore(t)
+	id := uuid.New()
+
+	// Act
+	_, err := s.FindById(ctx, id)
+	_, err2 := s.FindById(ctx, id)
+
+	// Assert
+	var dbErr *news.CustomError
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, http.StatusNotFound, dbErr.HttpStatusCode())
+	require.ErrorAs(t, err2, &dbErr)
+	assert.Equal(t, int32(2), ns.reads.Load())
+}
+
+func TestNewsStore_Tenants(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	s, ns, n := newStore(t)
+	_, err := s.FindById(ctx, n.Id)
+	require.NoError(t, err)
+	_, err = s.FindAll(ctx, news.Filter{})
+	require.NoError(t, err)
+	acme := tenant.CtxWithTenant(ctx, &tenant.Tenant{Id: "acme"})
+
+	// Act
+	_, err = s.FindById(acme, n.Id)
+	all, err2 := s.FindAll(acme, news.Filter{})
+
+	// Assert
+	var dbErr *news.CustomError
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, http.StatusNotFound, dbErr.HttpStatusCode())
+	require.NoError(t, err2)
+	assert.Empty(t, all)
+	assert.Equal(t, int32(4), ns.reads.Load())
+}
+
+func TestNewsStore_Invalidation(t *testing.T) {
+	testCases := []struct {
+		name  string
+		write func(ctx context.Context, s *cache.NewsStore, n *news.Record) error
+	}{
+		{
+			name: "create",
+			write: func(ctx context.Context, s *cache.NewsStore, _ *news.Record) error {
+				_, err := s.Create(ctx, &news.Record{Author: "Robin", Title: "other", Content: "content"})
+				return err
+			},
+		},
+		{
+			name: "update",
+			write: func(ctx context.Context, s *cache.NewsStore, n *news.Record) error {
+				n.Title = "updated"
+				return s.UpdateById(ctx, n.Id, n)
+			},
+		},
+		{
+			name: "delete",
+			write: func(ctx context.Context, s *cache.NewsStore, n *news.Record) error {
+				return s.DeleteById(ctx, n.Id)
+			},
+		},
+		{
+			name: "transition",
+			write: func(ctx context.Context, s *cache.NewsStore, n *news.Record) error {
+				_, err := s.Transition(ctx, n.Id, news.StatusInReview, "editor")
+				return err
+			},
+		},
+		{
+			name: "notification",
+			write: func(ctx context.Context, s *cache.NewsStore, _ *news.Record) error {
+				s.Notify(ctx, "1")
+				return nil
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			s, ns, n := newStore(t)
+			_, err := s.FindAll(ctx, news.Filter{})
+			require.NoError(t, err)
+
+			// Act
+			require.NoError(t, tc.write(ctx, s, n))
+			_, err = s.FindAll(ctx, news.Filter{})
+			require.NoError(t, err)
+
+			// Assert
+			assert.Equal(t, int32(2), ns.reads.Load())
+			assert.Equal(t, int64(1), s.Stats().Invalidations)
+		})
+	}
+}
+
+func TestNewsStore_Coalescing(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	s, ns, n := newStore(t)
+	ns.release = make(chan struct{})
+	var wg sync.WaitGroup
+	results := make([]*news.Record, 10)
+
+	// Act
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := s.FindById(ctx, n.Id)
+			assert.NoError(t, err)
+			results[i] = r
+		}()
+	}
+	require.Eventually(t, func() bool { return s.Stats().Misses == 10 }, time.Second, time.Millisecond)
+	close(ns.release)
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, int32(1), ns.reads.Load())
+	for _, r := range results {
+		assert.Equal(t, n.Id, r.Id)
+	}
+	assert.Equal(t, int64(1), s.Stats().Loads)
+}
+
+func TestNewsStore_InvalidatedRead(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	s, ns, n := newStore(t)
+	ns.release = make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := s.FindById(ctx, n.Id)
+		assert.NoError(t, err)
+	}()
+	require.Eventually(t, func() bool { return ns.reads.Load() == 1 }, time.Second, time.Millisecond)
+
+	// Act
+	s.Invalidate(ctx)
+	close(ns.release)
+	<-done
+
+	// Assert
+	// The read started before the invalidation may be out of date: it is
+	// not cached.
+	_, err := s.FindById(ctx, n.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), ns.reads.Load())
+}
+
+// blockingCache holds the sets until release is closed, once entered is.
+type blockingCache struct {
+	cache.Cache
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingCache) Set(ctx context.Context, key string, value []byte) error {
+	close(c.entered)
+	<-c.release
+	return c.Cache.Set(ctx, key, value)
+}
+
+func TestNewsStore_InvalidatedSet(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	ns := &countingStore{NewsStorer: store.NewNewsStore()}
+	n, err := ns.Create(ctx, &news.Record{Author: "Batman", Title: "title", Content: "content", Tags: []string{"politics"}})
+	require.NoError(t, err)
+	c := &blockingCache{Cache: cache.NewLRU(100, 0, time.Minute, nil), entered: make(chan struct{}), release: make(chan struct{})}
+	s := cache.NewNewsStore(ns, c)
+	read := make(chan struct{})
+	go func() {
+		defer close(read)
+		_, err := s.FindById(ctx, n.Id)
+		assert.NoError(t, err)
+	}()
+	<-c.entered
+
+	// Act
+	// The invalidation runs while the read, which checked the generation
+	// before, is being cached.
+	invalidated := make(chan struct{})
+	go func() {
+		defer close(invalidated)
+		s.Invalidate(ctx)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(c.release)
+	<-invalidated
+	<-read
+
+	// Assert
+	_, ok, err := c.Get(ctx, "default/id:"+n.Id.String())
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestNewsStore_Deadline(t *testing.T) {
+	// Arrange
+	s, ns, n := newStore(t)
+	ns.release = make(chan struct{})
+	defer close(ns.release)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Act
+	_, err := s.FindById(ctx, n.Id)
+
+	// Assert
+	// The read gives up with its request, even though the read of the store
+	// it waits for does not.
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	var ce *news.CustomError
+	require.ErrorAs(t, err, &ce)
+	assert.Equal(t, http.StatusServiceUnavailable, ce.HttpStatusCode())
+}
+
+// failingCache fails all its operations.
+type failingCache struct{}
+
+func (failingCache) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("cache down")
+}
+
+func (failingCache) Set(context.Context, string, []byte) error {
+	return errors.New("cache down")
+}
+
+func (failingCache) Purge(context.Context) error {
+	return errors.New("cache down")
+}
+
+func TestNewsStore_FailingCache(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	ns := &countingStore{NewsStorer: store.NewNewsStore()}
+	s := cache.NewNewsStore(ns, failingCache{})
+	n, err := s.Create(ctx, &news.Record{Author: "Batman", Title: "title", Content: "content"})
+	require.NoError(t, err)
+
+	// Act
+	found, err := s.FindById(ctx, n.Id)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, n.Id, found.Id)
+}
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := cache.NewLRU(2, 10, time.Minute, func() time.Time { return now })
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1")))
+	require.NoError(t, c.Set(ctx, "b", []byte("2")))
+	_, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+
+	// Past the entries limit, the least recently used value is evicted.
+	require.NoError(t, c.Set(ctx, "c", []byte("3")))
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok)
+	v, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+
+	// Past the bytes limit too.
+	require.NoError(t, c.Set(ctx, "d", []byte("12345678")))
+	entries, bytes := c.Len()
+	assert.Equal(t, 1, entries)
+	assert.Equal(t, 9, bytes)
+	assert.Equal(t, int64(3), c.Evictions())
+
+	// Values larger than the limit are not kept.
+	require.NoError(t, c.Set(ctx, "e", []byte("1234567890")))
+	_, ok, _ = c.Get(ctx, "e")
+	assert.False(t, ok)
+
+	// Values expire.
+	now = now.Add(time.Minute)
+	_, ok, _ = c.Get(ctx, "d")
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "f", []byte("1")))
+	require.NoError(t, c.Purge(ctx))
+	entries, bytes = c.Len()
+	assert.Zero(t, entries)
+	assert.Zero(t, bytes)
+}
+
+func TestHandler(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	s, _, n := newStore(t)
+	_, err := s.FindById(ctx, n.Id)
+	require.NoError(t, err)
+	_, err = s.FindById(ctx, n.Id)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	// Act
+	cache.Handler(s)(w, httptest.NewRequest(http.MethodGet, "/admin/cache", http.NoBody))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	var stats cache.Stats
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&stats))
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, 1, stats.Entries)
+}
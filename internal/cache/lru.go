@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// LRU keeps the values in memory, so that each replica caches its own reads.
+// The least recently used values are evicted past its limits, and the values
+// expire after its TTL.
+type LRU struct {
+	l          sync.Mutex
+	now        func() time.Time
+	maxEntries int
+	maxBytes   int
+	ttl        time.Duration
+	bytes      int
+	order      *list.List
+	entries    map[string]*list.Element
+	evictions  int64
+}
+
+// NewLRU returns a cache of at most maxEntries values and maxBytes bytes of
+// keys and values, no limit when 0, expiring after ttl at the times of now,
+// time.Now when nil.
+func NewLRU(maxEntries, maxBytes int, ttl time.Duration, now func() time.Time) *LRU {
+	if now == nil {
+		now = time.Now
+	}
+	return &LRU{
+		now:        now,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ttl:        ttl,
+		order:      list.New(),
+		entries:    map[string]*list.Element{},
+	}
+}
+
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*lruEntry)
+	if !c.now().Before(e.expiresAt) {
+		c.remove(el)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(el)
+	return e.value, true, nil
+}
+
+func (c *LRU) Set(_ context.Context, key string, value []byte) error {
+	c.l.Lock()
+	defer c.l.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	size := len(key) + len(value)
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return nil
+	}
+	e := &lruEntry{key: key, value: value, expiresAt: c.now().Add(c.ttl)}
+	c.entries[key] = c.order.PushFront(e)
+	c.bytes += size
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries || c.maxBytes > 0 && c.bytes > c.maxBytes {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+	return nil
+}
+
+func (c *LRU) Purge(_ context.Context) error {
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.order.Init()
+	clear(c.entries)
+	c.bytes = 0
+	return nil
+}
+
+func (c *LRU) remove(el *list.Element) {
+	e := c.order.Remove(el).(*lruEntry)
+	delete(c.entries, e.key)
+	c.bytes -= len(e.key) + len(e.value)
+}
+
+// Len returns the number of values kept, and their size in bytes with their
+// keys.
+func (c *LRU) Len() (entries, bytes int) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.order.Len(), c.bytes
+}
+
+// Evictions returns the number of values evicted past the limits.
+func (c *LRU) Evictions() int64 {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.evictions
+}
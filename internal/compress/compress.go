@@ -0,0 +1,354 @@
+// Package compress compresses the responses with the encoding negotiated
+// with the Accept-Encoding header of the request, and decompresses the
+// request bodies sent with a Content-Encoding.
+package compress
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/httpcache"
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// The encodings supported.
+const (
+	Zstd   = "zstd"
+	Brotli = "br"
+	Gzip   = "gzip"
+)
+
+// DefaultMinSize is the size of the smallest response compressed by default:
+// smaller responses barely shrink, if at all.
+const DefaultMinSize = 1024
+
+// Config configures the compression.
+type Config struct {
+	// Encodings are the encodings of the responses, in the order of
+	// preference of the server. Responses are not compressed when empty.
+	Encodings []string
+	// MinSize is the size of the smallest response compressed.
+	MinSize int
+}
+
+// ParseEncodings parses a list of encodings such as "zstd,br,gzip".
+func ParseEncodings(s string) ([]string, error) {
+	var encodings []string
+	for _, e := range strings.Split(s, ",") {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" {
+			continue
+		}
+		if _, ok := encoders[e]; !ok {
+			return nil, fmt.Errorf("unsupported encoding: %q", e)
+		}
+		encodings = append(encodings, e)
+	}
+	return encodings, nil
+}
+
+// compressible are the media types worth compressing, along with the text
+// types and the JSON and XML suffixes. The others, such as images and
+// archives, are usually compressed already.
+var compressible = []string{
+	"application/json",
+	"application/x-ndjson",
+	"application/xml",
+	"application/javascript",
+	"application/graphql-response+json",
+	"image/svg+xml",
+}
+
+// Compressible reports whether the responses of the content type are worth
+// compressing.
+func Compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "+json") ||
+		strings.HasSuffix(mediaType, "+xml") ||
+		slices.Contains(compressible, mediaType)
+}
+
+// Negotiate returns the encoding of the response to a request with the
+// Accept-Encoding header: the encoding with the highest quality, the first
+// in the order of preference of the server on ties. It returns "" when the
+// response is to be sent as is.
+func Negotiate(acceptEncoding string, encodings []string) string {
+	qualities := map[string]float64{}
+	wildcard := -1.0
+	for _, entry := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(entry, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if name == "*" {
+			wildcard = q
+		} else if name != "" {
+			qualities[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, e := range encodings {
+		q, ok := qualities[e]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = e, q
+		}
+	}
+	return best
+}
+
+// encoder is a compressing writer that can be reused.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+var encoders = map[string]*sync.Pool{
+	Zstd: {New: func() any {
+		e, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+		return e
+	}},
+	Brotli: {New: func() any {
+		return brotli.NewWriterLevel(nil, 4)
+	}},
+	Gzip: {New: func() any {
+		e, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return e
+	}},
+}
+
+// ErrUnsupportedEncoding is returned for request bodies in an encoding that
+// is not supported.
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// Mid compresses the responses worth compressing, and decompresses the
+// request bodies. WebSocket upgrades are left alone.
+func Mid(c Config, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if ce := r.Header.Get("Content-Encoding"); ce != "" && ce != "identity" {
+			body, err := decoder(ce, r.Body)
+			if err != nil {
+				logger.FromContext(r.Context()).Error("failed to decode request body", "error", err, "content_encoding", ce)
+				if errors.Is(err, ErrUnsupportedEncoding) {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					w.Write([]byte(err.Error()))
+					return
+				}
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			defer body.Close()
+			r.Body = body
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+		}
+
+		if r.Method == http.MethodHead || len(c.Encodings) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &writer{ResponseWriter: w, encoding: Negotiate(r.Header.Get("Accept-Encoding"), c.Encodings), minSize: c.MinSize}
+		next.ServeHTTP(cw, r)
+		// Not deferred: the response of a handler that panics is left to
+		// the recovery.
+		cw.close()
+	}
+}
+
+// decoder returns the reader of the body in the encoding.
+func decoder(encoding string, body io.ReadCloser) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case Gzip, "x-gzip":
+		return gzip.NewReader(body)
+	case Zstd:
+		d, err := zstd.NewReader(body, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	case Brotli:
+		return io.NopCloser(brotli.NewReader(body)), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+// writer buffers the start of the response until it is known whether it is
+// worth compressing: until MinSize bytes are written, the handler is done or
+// the response is flushed.
+type writer struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     encoder
+}
+
+func (w *writer) WriteHeader(status int) {
+	if w.status != 0 || w.decided {
+		return
+	}
+	if status < 200 {
+		// Informational responses, such as 103 Early Hints, go through.
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+	if !bodyAllowed(status) {
+		w.decide(false)
+	}
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.minSize {
+			return len(b), nil
+		}
+		if err := w.decide(false); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends the response so far, compressed if it is worth it: event
+// streams are, whatever their size so far.
+func (w *writer) Flush() {
+	w.FlushError()
+}
+
+func (w *writer) FlushError() error {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		if err := w.decide(true); err != nil {
+			return err
+		}
+	}
+	if w.enc != nil {
+		if err := w.enc.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide writes the header of the response, compressed or not, and the start
+// of its body.
+func (w *writer) decide(flushing bool) error {
+	w.decided = true
+	h := w.Header()
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 && bodyAllowed(w.status) {
+		// Sniffed here, since net/http would sniff the compressed body.
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	if w.status == http.StatusNotModified || bodyAllowed(w.status) && Compressible(h.Get("Content-Type")) {
+		httpcache.AddVary(h, "Accept-Encoding")
+	}
+	if w.encoding != "" && w.worthIt(flushing) {
+		w.enc = encoders[w.encoding].Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(w.buf)
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf)
+	}
+	w.buf = nil
+	return err
+}
+
+// worthIt reports whether the response is worth compressing.
+func (w *writer) worthIt(flushing bool) bool {
+	h := w.Header()
+	if !bodyAllowed(w.status) || h.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := h.Get("Content-Type")
+	if !Compressible(contentType) {
+		return false
+	}
+	if flushing && strings.HasPrefix(contentType, "text/event-stream") {
+		return true
+	}
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil && n < w.minSize {
+		return false
+	}
+	return len(w.buf) >= w.minSize
+}
+
+// close completes the response once the handler is done.
+func (w *writer) close() {
+	if w.status == 0 {
+		// The handler wrote nothing.
+		return
+	}
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.enc != nil {
+		w.enc.Close()
+		w.enc.Reset(nil)
+		encoders[w.encoding].Put(w.enc)
+		w.enc = nil
+	}
+}
+
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
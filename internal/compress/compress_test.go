@@ -0,0 +1,278 @@
+package compress_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/compress"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var config = compress.Config{Encodings: []string{compress.Zstd, compress.Brotli, compress.Gzip}, MinSize: 100}
+
+func Test_Negotiate(t *testing.T) {
+	testCases := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{acceptEncoding: "", expected: ""},
+		{acceptEncoding: "gzip", expected: compress.Gzip},
+		{acceptEncoding: "gzip, deflate, br", expected: compress.Brotli},
+		{acceptEncoding: "gzip, deflate, br, zstd", expected: compress.Zstd},
+		{acceptEncoding: "br;q=0.5, gzip;q=0.8", expected: compress.Gzip},
+		{acceptEncoding: "zstd;q=0, gzip", expected: compress.Gzip},
+		{acceptEncoding: "*", expected: compress.Zstd},
+		{acceptEncoding: "*;q=0.5, br", expected: compress.Brotli},
+		{acceptEncoding: "*;q=0", expected: ""},
+		{acceptEncoding: "identity", expected: ""},
+		{acceptEncoding: "GZIP", expected: compress.Gzip},
+		{acceptEncoding: "gzip;q=invalid", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.acceptEncoding, func(t *testing.T) {
+			assert.Equal(t, tc.expected, compress.Negotiate(tc.acceptEncoding, config.Encodings))
+		})
+	}
+}
+
+func Test_ParseEncodings(t *testing.T) {
+	encodings, err := compress.ParseEncodings("zstd, BR,gzip,")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zstd", "br", "gzip"}, encodings)
+
+	_, err = compress.ParseEncodings("deflate")
+	assert.ErrorContains(t, err, "unsupported encoding")
+}
+
+func decode(t *testing.T, encoding string, b []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case compress.Gzip:
+		gr, err := gzip.NewReader(bytes.NewReader(b))
+		require.NoError(t, err)
+		r = gr
+	case compress.Zstd:
+		zr, err := zstd.NewReader(bytes.NewReader(b))
+		require.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	case compress.Brotli:
+		r = brotli.NewReader(bytes.NewReader(b))
+	default:
+		return string(b)
+	}
+	decoded, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(decoded)
+}
+
+func Test_Mid(t *testing.T) {
+	large := strings.Repeat(`{"title":"news"}`, 100)
+
+	testCases := []struct {
+		name             string
+		method           string
+		acceptEncoding   string
+		contentType      string
+		status           int
+		etag             string
+		body             string
+		expectedEncoding string
+		expectedVary     string
+		expectedETag     string
+	}{
+		{name: "gzip", acceptEncoding: "gzip", contentType: "application/json", body: large, expectedEncoding: "gzip", expectedVary: "Accept-Encoding"},
+		{name: "brotli", acceptEncoding: "br", contentType: "application/json", body: large, expectedEncoding: "br", expectedVary: "Accept-Encoding"},
+		{name: "zstd", acceptEncoding: "gzip, br, zstd", contentType: "text/csv", body: large, expectedEncoding: "zstd", expectedVary: "Accept-Encoding"},
+		{name: "sniffed", acceptEncoding: "gzip", body: large, expectedEncoding: "gzip", expectedVary: "Accept-Encoding"},
+		{name: "not accepted", contentType: "application/json", body: large, expectedVary: "Accept-Encoding"},
+		{name: "small", acceptEncoding: "gzip", contentType: "application/json", body: `{"title":"news"}`, expectedVary: "Accept-Encoding"},
+		{name: "compressed already", acceptEncoding: "gzip", contentType: "image/png", body: large},
+		{name: "weak etag", acceptEncoding: "gzip", contentType: "application/json", etag: `"1"`, body: large, expectedEncoding: "gzip", expectedVary: "Accept-Encoding", expectedETag: `W/"1"`},
+		{name: "not modified", acceptEncoding: "gzip", status: http.StatusNotModified, etag: `W/"1"`, expectedVary: "Accept-Encoding", expectedETag: `W/"1"`},
+		{name: "no content", acceptEncoding: "gzip", contentType: "application/json", status: http.StatusNoContent},
+		{name: "head", method: http.MethodHead, acceptEncoding: "gzip", contentType: "application/json", body: large},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			h := compress.Mid(config, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.contentType != "" {
+					w.Header().Set("Content-Type", tc.contentType)
+				}
+				if tc.etag != "" {
+					w.Header().Set("ETag", tc.etag)
+				}
+				if tc.body != "" {
+					w.Header().Set("Content-Length", strconv.Itoa(len(tc.body)))
+				}
+				if tc.status != 0 {
+					w.WriteHeader(tc.status)
+				}
+				// Written in pieces, as encoders do.
+				for i := 0; i < len(tc.body); i += 64 {
+					w.Write([]byte(tc.body[i:min(i+64, len(tc.body))]))
+				}
+			}))
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tc.method, "/news", http.NoBody)
+			if tc.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+
+			// Act
+			h(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedEncoding, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, tc.expectedVary, w.Header().Get("Vary"))
+			assert.Equal(t, tc.expectedETag, w.Header().Get("ETag"))
+			assert.Equal(t, tc.body, decode(t, tc.expectedEncoding, w.Body.Bytes()))
+			if tc.expectedEncoding != "" {
+				assert.Empty(t, w.Header().Get("Content-Length"))
+				assert.Less(t, w.Body.Len(), len(tc.body))
+			}
+			if tc.status != 0 {
+				assert.Equal(t, tc.status, w.Code)
+			}
+		})
+	}
+}
+
+func Test_Mid_Stream(t *testing.T) {
+	// Arrange
+	events := make(chan string)
+	h := compress.Mid(config, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		rc := http.NewResponseController(w)
+		for e := range events {
+			io.WriteString(w, "data: "+e+"\n\n")
+			require.NoError(t, rc.Flush())
+		}
+	}))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	req, err := http.NewRequest(http.MethodGet, srv.URL, http.NoBody)
+	require.NoError(t, err)
+	// Set explicitly, so that the transport does not decompress.
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	// Act
+	go func() { events <- "first" }()
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Assert
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	gr, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	lines := bufio.NewReader(gr)
+	for _, e := range []string{"first", "second", "third"} {
+		if e != "first" {
+			go func() { events <- e }()
+		}
+		done := make(chan string)
+		go func() {
+			line, _ := lines.ReadString('\n')
+			lines.ReadString('\n')
+			done <- line
+		}()
+		select {
+		case line := <-done:
+			assert.Equal(t, "data: "+e+"\n", line)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("event %q not flushed", e)
+		}
+	}
+	close(events)
+}
+
+func Test_Mid_RequestBody(t *testing.T) {
+	body := `{"title":"news"}`
+	var gzipped, zstded bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write([]byte(body))
+	gw.Close()
+	zw, err := zstd.NewWriter(&zstded)
+	require.NoError(t, err)
+	zw.Write([]byte(body))
+	zw.Close()
+
+	testCases := []struct {
+		name            string
+		contentEncoding string
+		body            []byte
+		expectedStatus  int
+		expectedBody    string
+	}{
+		{name: "identity", body: []byte(body), expectedStatus: http.StatusOK, expectedBody: body},
+		{name: "gzip", contentEncoding: "gzip", body: gzipped.Bytes(), expectedStatus: http.StatusOK, expectedBody: body},
+		{name: "zstd", contentEncoding: "zstd", body: zstded.Bytes(), expectedStatus: http.StatusOK, expectedBody: body},
+		{name: "unsupported", contentEncoding: "deflate", body: []byte(body), expectedStatus: http.StatusUnsupportedMediaType},
+		{name: "invalid", contentEncoding: "gzip", body: []byte(body), expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			h := compress.Mid(config, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Empty(t, r.Header.Get("Content-Encoding"))
+				b, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				w.Write(b)
+			}))
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/news", bytes.NewReader(tc.body))
+			if tc.contentEncoding != "" {
+				r.Header.Set("Content-Encoding", tc.contentEncoding)
+			}
+
+			// Act
+			h(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func Test_Mid_Upgrade(t *testing.T) {
+	// Arrange
+	h := compress.Mid(config, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := w.(http.Hijacker)
+		assert.True(t, ok)
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/news/ws", http.NoBody)
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Accept-Encoding", "gzip")
+
+	// Act
+	h(&hijackRecorder{httptest.NewRecorder()}, r)
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (hijackRecorder) Hijack() (c net.Conn, rw *bufio.ReadWriter, err error) {
+	return nil, nil, nil
+}
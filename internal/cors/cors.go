@@ -0,0 +1,213 @@
+// Package cors lets the browsers of other origins call the API, as allowed by
+// a Cross-Origin Resource Sharing policy.
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/httpcache"
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+)
+
+// DefaultMethods are the methods allowed by default, along with the methods
+// the browsers never ask for.
+var DefaultMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// DefaultHeaders are the request headers allowed by default, along with the
+// headers the browsers never ask for.
+var DefaultHeaders = []string{
+	"Authorization",
+	"Content-Type",
+	"Content-Encoding",
+	"X-API-Key",
+	"Idempotency-Key",
+	"If-None-Match",
+	"If-Modified-Since",
+	"Last-Event-ID",
+	"X-Request-ID",
+	"X-Tenant-ID",
+}
+
+// DefaultExposedHeaders are the response headers exposed by default, along
+// with the headers the browsers always expose.
+var DefaultExposedHeaders = []string{
+	"ETag",
+	"Last-Modified",
+	"Location",
+	"Idempotent-Replayed",
+	"RateLimit-Policy",
+	"RateLimit-Limit",
+	"RateLimit-Remaining",
+	"RateLimit-Reset",
+	"Retry-After",
+	"X-Request-ID",
+}
+
+// Config is the CORS policy.
+type Config struct {
+	// Origins are the origins allowed, such as "https://editor.example.com",
+	// "https://*.example.com" for its subdomains, or "*" for any origin.
+	Origins []string
+	// Methods are the methods allowed, on the routes that have them.
+	Methods []string
+	// Headers are the request headers allowed.
+	Headers []string
+	// ExposedHeaders are the response headers exposed to the scripts.
+	ExposedHeaders []string
+	// Credentials allows the requests with cookies, client certificates or
+	// Authorization headers.
+	Credentials bool
+	// MaxAge is how long the browsers cache the preflight responses.
+	MaxAge time.Duration
+}
+
+// ParseOrigins parses a comma separated list of origins: "*", or a scheme and
+// a host, optionally with a port, whose first label can be "*" to allow its
+// subdomains, e.g. "https://editor.example.com, https://*.example.com".
+func ParseOrigins(s string) ([]string, error) {
+	var origins []string
+	for _, origin := range strings.Split(s, ",") {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		if origin == "" {
+			continue
+		}
+		if origin != "*" {
+			u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+			if err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" ||
+				u.Path != "" || u.RawQuery != "" || u.User != nil || strings.Contains(u.Host, "*") {
+				return nil, fmt.Errorf("invalid origin: %q", origin)
+			}
+		}
+		origins = append(origins, origin)
+	}
+	return origins, nil
+}
+
+// ParseList parses a comma separated list of methods or headers.
+func ParseList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// Validate reports the policies the browsers would reject.
+func (c Config) Validate() error {
+	if c.Credentials && slices.Contains(c.Origins, "*") {
+		return errors.New("credentials cannot be allowed for any origin")
+	}
+	return nil
+}
+
+// allowed reports whether the origin is allowed.
+func (c Config) allowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, o := range c.Origins {
+		if o == "*" || o == origin {
+			return true
+		}
+		scheme, host, ok := strings.Cut(o, "://*.")
+		if !ok {
+			continue
+		}
+		// The subdomains of host, at any depth, with the same scheme and
+		// port.
+		rest, ok := strings.CutPrefix(origin, scheme+"://")
+		if ok && strings.HasSuffix(rest, "."+host) && len(rest) > len(host)+1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Mid applies the policy to the requests, and answers the OPTIONS requests
+// of the routes of mux: the preflight requests of the browsers with the
+// methods and headers allowed, the others with the methods of the route.
+func Mid(c Config, mux *http.ServeMux, next http.Handler) http.HandlerFunc {
+	anyOrigin := slices.Contains(c.Origins, "*") && !c.Credentials
+	return func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		if !anyOrigin {
+			httpcache.AddVary(h, "Origin")
+		}
+		origin := r.Header.Get("Origin")
+		if r.Method != http.MethodOptions {
+			if origin != "" && c.allowed(origin) {
+				allowOrigin(c, h, origin, anyOrigin)
+				if len(c.ExposedHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", strings.Join(c.ExposedHeaders, ", "))
+				}
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		methods := routeMethods(mux, r)
+		if len(methods) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		requestMethod := r.Header.Get("Access-Control-Request-Method")
+		if origin == "" || requestMethod == "" {
+			h.Set("Allow", strings.Join(append(methods, http.MethodOptions), ", "))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		httpcache.AddVary(h, "Access-Control-Request-Method", "Access-Control-Request-Headers")
+		if !c.allowed(origin) {
+			logger.FromContext(r.Context()).Info("cors preflight rejected", "origin", origin)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var allowedMethods []string
+		for _, m := range methods {
+			if slices.ContainsFunc(c.Methods, func(allowed string) bool { return strings.EqualFold(allowed, m) }) {
+				allowedMethods = append(allowedMethods, m)
+			}
+		}
+		allowOrigin(c, h, origin, anyOrigin)
+		h.Set("Access-Control-Allow-Methods", strings.Join(allowedMethods, ", "))
+		if len(c.Headers) > 0 {
+			h.Set("Access-Control-Allow-Headers", strings.Join(c.Headers, ", "))
+		}
+		if c.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func allowOrigin(c Config, h http.Header, origin string, anyOrigin bool) {
+	if anyOrigin {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if c.Credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// routeMethods returns the methods of the routes of mux for the path of the
+// request.
+func routeMethods(mux *http.ServeMux, r *http.Request) []string {
+	var methods []string
+	for _, m := range []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		req := &http.Request{Method: m, URL: r.URL, Host: r.Host, Header: http.Header{}}
+		if _, pattern := mux.Handler(req); pattern != "" {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
@@ -0,0 +1,213 @@
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseOrigins(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		expected    []string
+		expectedErr bool
+	}{
+		{name: "empty", input: ""},
+		{name: "origins", input: "https://Editor.example.com, https://*.example.com,http://localhost:5173", expected: []string{"https://editor.example.com", "https://*.example.com", "http://localhost:5173"}},
+		{name: "any", input: "*", expected: []string{"*"}},
+		{name: "path", input: "https://example.com/editor", expectedErr: true},
+		{name: "no scheme", input: "example.com", expectedErr: true},
+		{name: "other scheme", input: "ftp://example.com", expectedErr: true},
+		{name: "wildcard not first", input: "https://editor.*.com", expectedErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			origins, err := cors.ParseOrigins(tc.input)
+
+			if tc.expectedErr {
+				assert.ErrorContains(t, err, "invalid origin")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, origins)
+		})
+	}
+}
+
+func Test_Config_Validate(t *testing.T) {
+	assert.NoError(t, cors.Config{Origins: []string{"*"}}.Validate())
+	assert.NoError(t, cors.Config{Origins: []string{"https://editor.example.com"}, Credentials: true}.Validate())
+	assert.Error(t, cors.Config{Origins: []string{"*"}, Credentials: true}.Validate())
+}
+
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	mux.HandleFunc("GET /news", ok)
+	mux.HandleFunc("POST /news", ok)
+	mux.HandleFunc("GET /news/{news_id}", ok)
+	mux.HandleFunc("DELETE /news/{news_id}", ok)
+	return mux
+}
+
+var config = cors.Config{
+	Origins:        []string{"https://editor.example.com", "https://*.preview.example.com"},
+	Methods:        []string{http.MethodGet, http.MethodPost, http.MethodPut},
+	Headers:        []string{"Authorization", "Content-Type"},
+	ExposedHeaders: []string{"ETag"},
+	Credentials:    true,
+	MaxAge:         10 * time.Minute,
+}
+
+func Test_Mid(t *testing.T) {
+	mux := newMux()
+	h := cors.Mid(config, mux, mux)
+
+	testCases := []struct {
+		name           string
+		origin         string
+		expectedOrigin string
+	}{
+		{name: "allowed origin", origin: "https://editor.example.com", expectedOrigin: "https://editor.example.com"},
+		{name: "allowed subdomain", origin: "https://pr-42.preview.example.com", expectedOrigin: "https://pr-42.preview.example.com"},
+		{name: "nested subdomain", origin: "https://a.pr-42.preview.example.com", expectedOrigin: "https://a.pr-42.preview.example.com"},
+		{name: "wildcard domain itself", origin: "https://preview.example.com"},
+		{name: "suffix of another domain", origin: "https://evilpreview.example.com"},
+		{name: "other scheme", origin: "http://editor.example.com"},
+		{name: "other port", origin: "https://editor.example.com:8443"},
+		{name: "rejected origin", origin: "https://evil.example.org"},
+		{name: "same origin"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/news", http.NoBody)
+			if tc.origin != "" {
+				r.Header.Set("Origin", tc.origin)
+			}
+
+			// Act
+			h(w, r)
+
+			// Assert
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "Origin", w.Header().Get("Vary"))
+			assert.Equal(t, tc.expectedOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			if tc.expectedOrigin != "" {
+				assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+				assert.Equal(t, "ETag", w.Header().Get("Access-Control-Expose-Headers"))
+			} else {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+			}
+		})
+	}
+}
+
+func Test_Mid_Preflight(t *testing.T) {
+	mux := newMux()
+	h := cors.Mid(config, mux, mux)
+
+	testCases := []struct {
+		name            string
+		target          string
+		origin          string
+		requestMethod   string
+		expectedStatus  int
+		expectedMethods string
+		expectedAllow   string
+	}{
+		{
+			name:            "allowed origin",
+			target:          "/news",
+			origin:          "https://editor.example.com",
+			requestMethod:   http.MethodPost,
+			expectedStatus:  http.StatusNoContent,
+			expectedMethods: "GET, POST",
+		},
+		{
+			name:            "methods of the route",
+			target:          "/news/3b082d9d-1dc7-4d1f-907e-50d449a03d45",
+			origin:          "https://pr-42.preview.example.com",
+			requestMethod:   http.MethodDelete,
+			expectedStatus:  http.StatusNoContent,
+			expectedMethods: "GET",
+		},
+		{
+			name:           "rejected origin",
+			target:         "/news",
+			origin:         "https://evil.example.org",
+			requestMethod:  http.MethodPost,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "unknown route",
+			target:         "/unknown",
+			origin:         "https://editor.example.com",
+			requestMethod:  http.MethodGet,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "not a preflight",
+			target:         "/news/3b082d9d-1dc7-4d1f-907e-50d449a03d45",
+			expectedStatus: http.StatusNoContent,
+			expectedAllow:  "GET, HEAD, DELETE, OPTIONS",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodOptions, tc.target, http.NoBody)
+			if tc.origin != "" {
+				r.Header.Set("Origin", tc.origin)
+			}
+			if tc.requestMethod != "" {
+				r.Header.Set("Access-Control-Request-Method", tc.requestMethod)
+				r.Header.Set("Access-Control-Request-Headers", "authorization,content-type")
+			}
+
+			// Act
+			h(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedMethods, w.Header().Get("Access-Control-Allow-Methods"))
+			assert.Equal(t, tc.expectedAllow, w.Header().Get("Allow"))
+			if tc.expectedMethods != "" {
+				assert.Equal(t, tc.origin, w.Header().Get("Access-Control-Allow-Origin"))
+				assert.Equal(t, "Authorization, Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+				assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+				assert.Equal(t, "Origin, Access-Control-Request-Method, Access-Control-Request-Headers", w.Header().Get("Vary"))
+			} else {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+}
+
+func Test_Mid_AnyOrigin(t *testing.T) {
+	// Arrange
+	mux := newMux()
+	h := cors.Mid(cors.Config{Origins: []string{"*"}, Methods: cors.DefaultMethods}, mux, mux)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/news", http.NoBody)
+	r.Header.Set("Origin", "https://anywhere.example.org")
+
+	// Act
+	h(w, r)
+
+	// Assert
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Vary"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
@@ -0,0 +1,80 @@
+package gql
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// newsColumns maps the fields of the News type to the columns they are
+// resolved from. The ID and creation time are always loaded, and the authors
+// are batched separately.
+var newsColumns = map[string]string{
+	"author":       "author",
+	"title":        "title",
+	"summary":      "summary",
+	"content":      "content",
+	"source":       "source",
+	"tags":         "tags",
+	"status":       "status",
+	"publishedAt":  "published_at",
+	"publishAt":    "publish_at",
+	"embargoUntil": "embargo_until",
+	"expiresAt":    "expires_at",
+	"updatedAt":    "updated_at",
+}
+
+// selectedColumns returns the columns of the news fields selected below each
+// of the paths, e.g. "edges.node" and "nodes" for a connection, or "" for the
+// field itself.
+func selectedColumns(info graphql.ResolveInfo, paths ...string) []string {
+	columns := []string{"id"}
+	for _, path := range paths {
+		selected := info.FieldASTs
+		if path != "" {
+			for _, name := range strings.Split(path, ".") {
+				var next []*ast.Field
+				for _, f := range selected {
+					for _, child := range selectedFields(f.SelectionSet, info.Fragments) {
+						if child.Name.Value == name {
+							next = append(next, child)
+						}
+					}
+				}
+				selected = next
+			}
+		}
+		for _, f := range selected {
+			for _, child := range selectedFields(f.SelectionSet, info.Fragments) {
+				if c, ok := newsColumns[child.Name.Value]; ok && !slices.Contains(columns, c) {
+					columns = append(columns, c)
+				}
+			}
+		}
+	}
+	return columns
+}
+
+// selectedFields returns the fields of the selection set, including the ones
+// of its fragments.
+func selectedFields(set *ast.SelectionSet, fragments map[string]ast.Definition) []*ast.Field {
+	if set == nil {
+		return nil
+	}
+	var fields []*ast.Field
+	for _, s := range set.Selections {
+		switch s := s.(type) {
+		case *ast.Field:
+			fields = append(fields, s)
+		case *ast.InlineFragment:
+			fields = append(fields, selectedFields(s.SelectionSet, fragments)...)
+		case *ast.FragmentSpread:
+			if fragment, ok := fragments[s.Name.Value].(*ast.FragmentDefinition); ok {
+				fields = append(fields, selectedFields(fragment.SelectionSet, fragments)...)
+			}
+		}
+	}
+	return fields
+}
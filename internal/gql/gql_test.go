@@ -0,0 +1,391 @@
+package gql_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/gql"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store keeps the news in memory, newest first, and records the filters and
+// author lookups it is called with.
+type store struct {
+	news           []*news.Record
+	authors        map[uuid.UUID][]*news.Author
+	filters        []news.Filter
+	authorsLookups [][]uuid.UUID
+	created        *news.Record
+	deleted        []uuid.UUID
+}
+
+func (s *store) Create(_ context.Context, n *news.Record) (*news.Record, error) {
+	n.Id = uuid.New()
+	n.Status = news.StatusDraft
+	s.created = n
+	return n, nil
+}
+
+func (s *store) FindAll(_ context.Context, f news.Filter) ([]*news.Record, error) {
+	s.filters = append(s.filters, f)
+	var found []*news.Record
+	for _, n := range s.news {
+		if len(f.Ids) > 0 && !slices.Contains(f.Ids, n.Id) {
+			continue
+		}
+		if f.After != nil && !n.CreatedAt.Before(f.After.CreatedAt) {
+			continue
+		}
+		if f.Limit > 0 && len(found) == f.Limit {
+			break
+		}
+		found = append(found, n)
+	}
+	return found, nil
+}
+
+func (s *store) UpdateById(_ context.Context, id uuid.UUID, n *news.Record) error {
+	if !slices.ContainsFunc(s.news, func(r *news.Record) bool { return r.Id == id }) {
+		return news.NewCustomError(sql.ErrNoRows, http.StatusNotFound)
+	}
+	n.Id = id
+	return nil
+}
+
+func (s *store) DeleteById(_ context.Context, id uuid.UUID) error {
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+func (s *store) FindAuthorsByNews(_ context.Context, ids ...uuid.UUID) (map[uuid.UUID][]*news.Author, error) {
+	s.authorsLookups = append(s.authorsLookups, ids)
+	byNews := map[uuid.UUID][]*news.Author{}
+	for _, id := range ids {
+		byNews[id] = s.authors[id]
+	}
+	return byNews, nil
+}
+
+type tagStore struct{}
+
+func (tagStore) FindAll(context.Context) ([]*news.Tag, error) {
+	return []*news.Tag{{Slug: "go", Name: "Go", Aliases: []string{}}}, nil
+}
+
+type authorStore struct{}
+
+func (authorStore) FindAll(context.Context) ([]*news.Author, error) {
+	return []*news.Author{{Slug: "alice", Name: "Alice"}}, nil
+}
+
+func (authorStore) FindBySlug(_ context.Context, slug string) (*news.Author, error) {
+	return &news.Author{Slug: slug, Name: "Alice"}, nil
+}
+
+func newStore() *store {
+	s := &store{authors: map[uuid.UUID][]*news.Author{}}
+	now := time.Now().UTC()
+	for i := range 3 {
+		n := &news.Record{
+			Id:        uuid.New(),
+			Title:     "title",
+			Content:   "content",
+			Tags:      []string{"go"},
+			Status:    news.StatusPublished,
+			CreatedAt: now.Add(-time.Duration(i) * time.Hour),
+		}
+		s.news = append(s.news, n)
+		s.authors[n.Id] = []*news.Author{{Slug: "alice", Name: "Alice"}}
+	}
+	return s
+}
+
+type response struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func do(t *testing.T, s *store, principal *auth.Principal, query string, variables map[string]any) response {
+	t.Helper()
+	schema, err := gql.NewSchema(s, tagStore{}, authorStore{})
+	require.NoError(t, err)
+	body, err := json.Marshal(gql.Request{Query: query, Variables: variables})
+	require.NoError(t, err)
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	r = r.WithContext(auth.CtxWithPrincipal(r.Context(), principal))
+	w := httptest.NewRecorder()
+
+	gql.Handler(schema)(w, r)
+
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	var resp response
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	return resp
+}
+
+func Test_AllNews(t *testing.T) {
+	testCases := []struct {
+		name            string
+		principal       *auth.Principal
+		query           string
+		expectedColumns []string
+		expectedPublic  bool
+		expectedLookups int
+	}{
+		{
+			name:            "selected columns",
+			query:           `{ allNews { edges { node { id title } } } }`,
+			expectedColumns: []string{"id", "title"},
+			expectedPublic:  true,
+		},
+		{
+			name:            "content and nodes",
+			query:           `{ allNews { nodes { content createdAt } edges { cursor node { summary } } } }`,
+			expectedColumns: []string{"id", "summary", "content"},
+			expectedPublic:  true,
+		},
+		{
+			name:            "fragments",
+			query:           `{ allNews { nodes { ...fields ... on News { tags } } } } fragment fields on News { title publishedAt }`,
+			expectedColumns: []string{"id", "title", "published_at", "tags"},
+			expectedPublic:  true,
+		},
+		{
+			name:            "authors batched",
+			query:           `{ allNews { nodes { authors { slug } } } }`,
+			expectedColumns: []string{"id"},
+			expectedPublic:  true,
+			expectedLookups: 1,
+		},
+		{
+			name:            "editor",
+			principal:       &auth.Principal{Name: "alice", Role: auth.RoleEditor},
+			query:           `{ allNews { nodes { id } } }`,
+			expectedColumns: []string{"id"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			s := newStore()
+
+			// Act
+			resp := do(t, s, tc.principal, tc.query, nil)
+
+			// Assert
+			require.Empty(t, resp.Errors)
+			require.Len(t, s.filters, 1)
+			assert.Equal(t, tc.expectedColumns, s.filters[0].Columns)
+			assert.Equal(t, tc.expectedPublic, s.filters[0].Public)
+			assert.Equal(t, gql.DefaultPageSize+1, s.filters[0].Limit)
+			assert.Len(t, s.authorsLookups, tc.expectedLookups)
+			if tc.expectedLookups > 0 {
+				assert.Len(t, s.authorsLookups[0], 3)
+			}
+		})
+	}
+}
+
+func Test_AllNews_Pagination(t *testing.T) {
+	// Arrange
+	s := newStore()
+	query := `query($after: String) { allNews(first: 2, after: $after) { edges { cursor node { id } } pageInfo { hasNextPage endCursor } } }`
+	type page struct {
+		AllNews struct {
+			Edges []struct {
+				Cursor string
+				Node   struct{ Id uuid.UUID }
+			}
+			PageInfo struct {
+				HasNextPage bool
+				EndCursor   string
+			}
+		}
+	}
+	decode := func(resp response) page {
+		require.Empty(t, resp.Errors)
+		var p page
+		require.NoError(t, json.Unmarshal(resp.Data["allNews"], &p.AllNews))
+		return p
+	}
+
+	// Act
+	first := decode(do(t, s, nil, query, nil))
+	second := decode(do(t, s, nil, query, map[string]any{"after": first.AllNews.PageInfo.EndCursor}))
+	invalid := do(t, s, nil, query, map[string]any{"after": "invalid"})
+
+	// Assert
+	require.Len(t, first.AllNews.Edges, 2)
+	assert.Equal(t, s.news[0].Id, first.AllNews.Edges[0].Node.Id)
+	assert.Equal(t, s.news[1].Id, first.AllNews.Edges[1].Node.Id)
+	assert.True(t, first.AllNews.PageInfo.HasNextPage)
+	assert.Equal(t, first.AllNews.Edges[1].Cursor, first.AllNews.PageInfo.EndCursor)
+
+	require.Len(t, second.AllNews.Edges, 1)
+	assert.Equal(t, s.news[2].Id, second.AllNews.Edges[0].Node.Id)
+	assert.False(t, second.AllNews.PageInfo.HasNextPage)
+	assert.Equal(t, &news.Cursor{CreatedAt: s.news[1].CreatedAt, Id: s.news[1].Id}, s.filters[1].After)
+
+	require.Len(t, invalid.Errors, 1)
+	assert.Equal(t, "invalid cursor", invalid.Errors[0].Message)
+}
+
+func Test_News_Batched(t *testing.T) {
+	// Arrange
+	s := newStore()
+	query := `query($a: ID!, $b: ID!, $c: ID!) {
+		a: news(id: $a) { title authors { slug } }
+		b: news(id: $b) { content authors { slug } }
+		c: news(id: $c) { id }
+	}`
+	missing := uuid.New()
+
+	// Act
+	resp := do(t, s, nil, query, map[string]any{"a": s.news[0].Id, "b": s.news[1].Id, "c": missing})
+
+	// Assert
+	require.Empty(t, resp.Errors)
+	require.Len(t, s.filters, 1)
+	assert.ElementsMatch(t, []uuid.UUID{s.news[0].Id, s.news[1].Id, missing}, s.filters[0].Ids)
+	assert.ElementsMatch(t, []string{"id", "title", "content"}, s.filters[0].Columns)
+	require.Len(t, s.authorsLookups, 1)
+	assert.ElementsMatch(t, []uuid.UUID{s.news[0].Id, s.news[1].Id}, s.authorsLookups[0])
+	assert.JSONEq(t, `{"title":"title","authors":[{"slug":"alice"}]}`, string(resp.Data["a"]))
+	assert.JSONEq(t, `null`, string(resp.Data["c"]))
+}
+
+func Test_Tags_Authors(t *testing.T) {
+	// Arrange
+	s := newStore()
+
+	// Act
+	resp := do(t, s, nil, `{ tags { slug news(first: 1) { nodes { title } } } author(slug: "Alice") { name news { nodes { id } } } }`, nil)
+
+	// Assert
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `[{"slug":"go","news":{"nodes":[{"title":"title"}]}}]`, string(resp.Data["tags"]))
+	require.Len(t, s.filters, 2)
+	// The top-level fields are not resolved in a set order.
+	tag := slices.IndexFunc(s.filters, func(f news.Filter) bool { return f.Tag != "" })
+	require.NotEqual(t, -1, tag)
+	assert.Equal(t, "go", s.filters[tag].Tag)
+	assert.Equal(t, 2, s.filters[tag].Limit)
+	assert.Equal(t, "alice", s.filters[1-tag].Author)
+}
+
+func Test_Mutations(t *testing.T) {
+	input := map[string]any{
+		"author":    "Alice",
+		"title":     "title",
+		"summary":   "summary",
+		"content":   "content",
+		"source":    "https://example.com",
+		"createdAt": "2026-10-19T12:00:00Z",
+		"tags":      []string{"Go"},
+	}
+	testCases := []struct {
+		name           string
+		query          string
+		variables      map[string]any
+		anonymous      bool
+		expectedError  string
+		expectedStatus float64
+		expectedData   string
+	}{
+		{
+			name:         "create",
+			query:        `mutation($input: NewsInput!) { createNews(input: $input) { title status tags } }`,
+			variables:    map[string]any{"input": input},
+			expectedData: `{"createNews":{"title":"title","status":"DRAFT","tags":["go"]}}`,
+		},
+		{
+			name:           "create anonymous",
+			query:          `mutation($input: NewsInput!) { createNews(input: $input) { id } }`,
+			variables:      map[string]any{"input": input},
+			anonymous:      true,
+			expectedError:  "Unauthorized",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "create invalid",
+			query:          `mutation($input: NewsInput!) { createNews(input: $input) { id } }`,
+			variables:      map[string]any{"input": map[string]any{"title": "", "summary": "", "content": "", "source": "", "createdAt": "", "tags": []string{}}},
+			expectedError:  "author is empty",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "update not found",
+			query:          `mutation($id: ID!, $input: NewsInput!) { updateNews(id: $id, input: $input) { id } }`,
+			variables:      map[string]any{"id": uuid.New(), "input": input},
+			expectedError:  "Not Found",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:         "delete",
+			query:        `mutation($id: ID!) { deleteNews(id: $id) }`,
+			variables:    map[string]any{"id": uuid.Nil},
+			expectedData: `{"deleteNews":true}`,
+		},
+		{
+			name:           "delete invalid id",
+			query:          `mutation { deleteNews(id: "42") }`,
+			expectedError:  "invalid id",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			s := newStore()
+			principal := &auth.Principal{Name: "alice", Role: auth.RoleEditor}
+			if tc.anonymous {
+				principal = nil
+			}
+
+			// Act
+			resp := do(t, s, principal, tc.query, tc.variables)
+
+			// Assert
+			if tc.expectedError != "" {
+				require.Len(t, resp.Errors, 1)
+				assert.Contains(t, resp.Errors[0].Message, tc.expectedError)
+				assert.Equal(t, tc.expectedStatus, resp.Errors[0].Extensions["status"])
+				return
+			}
+			require.Empty(t, resp.Errors)
+			data, err := json.Marshal(resp.Data)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expectedData, string(data))
+		})
+	}
+}
+
+func Test_Handler_GetMutation(t *testing.T) {
+	// Arrange
+	schema, err := gql.NewSchema(newStore(), tagStore{}, authorStore{})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/graphql?query=mutation%7BdeleteNews(id:%22x%22)%7D", http.NoBody)
+
+	// Act
+	gql.Handler(schema)(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+}
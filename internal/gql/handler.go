@@ -0,0 +1,160 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Request is the body of the GraphQL requests.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// loaders batch the loads of a request.
+type loaders struct {
+	news    *loader[uuid.UUID, *news.Record]
+	authors *loader[uuid.UUID, []*news.Author]
+
+	mu          sync.Mutex
+	newsColumns []string
+}
+
+type ctxKey struct{}
+
+func newLoaders(ns Store) *loaders {
+	l := &loaders{}
+	l.news = newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*news.Record, error) {
+		l.mu.Lock()
+		columns := l.newsColumns
+		l.mu.Unlock()
+		records, err := ns.FindAll(ctx, news.Filter{Ids: ids, Columns: columns, Public: !auth.Editor(ctx)})
+		if err != nil {
+			return nil, toError(ctx, err)
+		}
+		byId := make(map[uuid.UUID]*news.Record, len(records))
+		for _, n := range records {
+			byId[n.Id] = n
+		}
+		return byId, nil
+	})
+	l.authors = newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]*news.Author, error) {
+		byNews, err := ns.FindAuthorsByNews(ctx, ids...)
+		if err != nil {
+			return nil, toError(ctx, err)
+		}
+		for _, id := range ids {
+			if byNews[id] == nil {
+				byNews[id] = []*news.Author{}
+			}
+		}
+		return byNews, nil
+	})
+	return l
+}
+
+func (l *loaders) addNewsColumns(columns ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range columns {
+		if !slices.Contains(l.newsColumns, c) {
+			l.newsColumns = append(l.newsColumns, c)
+		}
+	}
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(ctxKey{}).(*loaders)
+}
+
+// Handler executes the GraphQL queries sent as JSON in POST requests, or in
+// the query string of GET requests. Mutations are only executed in POST
+// requests.
+func Handler(s *Schema) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("graphql")
+
+		var req Request
+		switch r.Method {
+		case http.MethodGet:
+			req.Query = r.URL.Query().Get("query")
+			req.OperationName = r.URL.Query().Get("operationName")
+			if v := r.URL.Query().Get("variables"); v != "" {
+				if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+					log.Error("failed to decode variables", "error", err)
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+			}
+		default:
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				log.Error("failed to decode request body", "error", err)
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+					return
+				}
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+
+		if r.Method == http.MethodGet && isMutation(req) {
+			log.Error("mutation in get request")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		ctx = context.WithValue(ctx, ctxKey{}, newLoaders(s.ns))
+		result := graphql.Do(graphql.Params{
+			Schema:         s.schema,
+			RequestString:  req.Query,
+			VariableValues: req.Variables,
+			OperationName:  req.OperationName,
+			Context:        ctx,
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// isMutation reports whether the operation of the request is a mutation.
+// Invalid requests are left to the executor to report.
+func isMutation(req Request) bool {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return false
+	}
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if req.OperationName == "" || op.Name != nil && op.Name.Value == req.OperationName {
+			if op.Operation == ast.OperationTypeMutation {
+				return true
+			}
+		}
+	}
+	return false
+}
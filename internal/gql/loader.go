@@ -0,0 +1,57 @@
+package gql
+
+import (
+	"context"
+	"sync"
+)
+
+// loader batches the loads of a request into a single fetch, dataloader
+// style. Load only records the key and returns a thunk: the executor resolves
+// the thunks once all the fields of a level are resolved, so that the first
+// thunk fetches the keys of the whole level.
+type loader[K comparable, V any] struct {
+	fetch   func(context.Context, []K) (map[K]V, error)
+	mu      sync.Mutex
+	pending []K
+	results map[K]V
+	errs    map[K]error
+}
+
+func newLoader[K comparable, V any](fetch func(context.Context, []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		results: map[K]V{},
+		errs:    map[K]error{},
+	}
+}
+
+func (l *loader[K, V]) Load(ctx context.Context, key K) func() (any, error) {
+	l.mu.Lock()
+	if _, ok := l.results[key]; !ok {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (any, error) {
+		v, err := l.get(ctx, key)
+		return v, err
+	}
+}
+
+func (l *loader[K, V]) get(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.pending) > 0 {
+		keys := l.pending
+		l.pending = nil
+		results, err := l.fetch(ctx, keys)
+		for _, k := range keys {
+			if err != nil {
+				l.errs[k] = err
+				continue
+			}
+			l.results[k] = results[k]
+		}
+	}
+	return l.results[key], l.errs[key]
+}
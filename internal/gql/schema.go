@@ -0,0 +1,511 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	// DefaultPageSize and MaxPageSize bound the first argument of the
+	// connections.
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type Store interface {
+	Create(context.Context, *news.Record) (*news.Record, error)
+	FindAll(context.Context, news.Filter) ([]*news.Record, error)
+	UpdateById(context.Context, uuid.UUID, *news.Record) error
+	DeleteById(context.Context, uuid.UUID) error
+	FindAuthorsByNews(context.Context, ...uuid.UUID) (map[uuid.UUID][]*news.Author, error)
+}
+
+type TagStore interface {
+	FindAll(context.Context) ([]*news.Tag, error)
+}
+
+type AuthorStore interface {
+	FindAll(context.Context) ([]*news.Author, error)
+	FindBySlug(context.Context, string) (*news.Author, error)
+}
+
+// Error is the error of a field, with the HTTP status of the equivalent REST
+// call in its extensions.
+type Error struct {
+	Message string
+	Status  int
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Extensions() map[string]any {
+	return map[string]any{"status": e.Status}
+}
+
+// toError maps the store errors to field errors, without leaking the
+// internal ones.
+func toError(ctx context.Context, err error) error {
+	status := http.StatusInternalServerError
+	var dbErr *news.CustomError
+	if errors.As(err, &dbErr) {
+		status = dbErr.HttpStatusCode()
+	}
+	if status >= http.StatusInternalServerError {
+		logger.FromContext(ctx).Error("failed to resolve field", "error", err)
+	}
+	return &Error{Message: http.StatusText(status), Status: status}
+}
+
+// connection is a page of news.
+type connection struct {
+	news        []*news.Record
+	hasNextPage bool
+}
+
+// Schema is the schema over the news, tags and authors, resolved with the
+// stores. The public only sees the news visible to it, while editors see all
+// of them.
+type Schema struct {
+	schema graphql.Schema
+	ns     Store
+	ts     TagStore
+	as     AuthorStore
+}
+
+func NewSchema(ns Store, ts TagStore, as AuthorStore) (*Schema, error) {
+	s := &Schema{ns: ns, ts: ts, as: as}
+
+	statusType := graphql.NewEnum(graphql.EnumConfig{
+		Name: "NewsStatus",
+		Values: graphql.EnumValueConfigMap{
+			"DRAFT":     {Value: news.StatusDraft},
+			"IN_REVIEW": {Value: news.StatusInReview},
+			"APPROVED":  {Value: news.StatusApproved},
+			"PUBLISHED": {Value: news.StatusPublished},
+			"ARCHIVED":  {Value: news.StatusArchived},
+		},
+	})
+
+	authorType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Author",
+		Fields: graphql.Fields{
+			"id":        {Type: graphql.NewNonNull(graphql.ID), Resolve: resolveId(func(a *news.Author) uuid.UUID { return a.Id })},
+			"slug":      {Type: graphql.NewNonNull(graphql.String)},
+			"name":      {Type: graphql.NewNonNull(graphql.String)},
+			"bio":       {Type: graphql.NewNonNull(graphql.String)},
+			"avatarUrl": {Type: graphql.NewNonNull(graphql.String)},
+			"contact":   {Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	newsType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "News",
+		Fields: graphql.Fields{
+			"id":           {Type: graphql.NewNonNull(graphql.ID), Resolve: resolveId(func(n *news.Record) uuid.UUID { return n.Id })},
+			"author":       {Type: graphql.NewNonNull(graphql.String)},
+			"title":        {Type: graphql.NewNonNull(graphql.String)},
+			"summary":      {Type: graphql.NewNonNull(graphql.String)},
+			"content":      {Type: graphql.NewNonNull(graphql.String)},
+			"source":       {Type: graphql.NewNonNull(graphql.String)},
+			"tags":         {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+			"status":       {Type: graphql.NewNonNull(statusType)},
+			"publishedAt":  {Type: graphql.DateTime, Resolve: resolveTime(func(n *news.Record) time.Time { return n.PublishedAt })},
+			"publishAt":    {Type: graphql.DateTime, Resolve: resolveTime(func(n *news.Record) time.Time { return n.PublishAt })},
+			"embargoUntil": {Type: graphql.DateTime, Resolve: resolveTime(func(n *news.Record) time.Time { return n.EmbargoUntil })},
+			"expiresAt":    {Type: graphql.DateTime, Resolve: resolveTime(func(n *news.Record) time.Time { return n.ExpiresAt })},
+			"createdAt":    {Type: graphql.NewNonNull(graphql.DateTime)},
+			"updatedAt":    {Type: graphql.DateTime, Resolve: resolveTime(func(n *news.Record) time.Time { return n.UpdatedAt })},
+			"authors":      {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(authorType))), Resolve: s.resolveAuthors},
+		},
+	})
+
+	connectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "NewsConnection",
+		Fields: graphql.Fields{
+			"edges": {
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.NewObject(graphql.ObjectConfig{
+					Name: "NewsEdge",
+					Fields: graphql.Fields{
+						"cursor": {Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (any, error) {
+							return news.CursorOf(p.Source.(*news.Record)).String(), nil
+						}},
+						"node": {Type: graphql.NewNonNull(newsType), Resolve: func(p graphql.ResolveParams) (any, error) {
+							return p.Source, nil
+						}},
+					},
+				})))),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*connection).news, nil
+				},
+			},
+			"nodes": {
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(newsType))),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*connection).news, nil
+				},
+			},
+			"pageInfo": {
+				Type: graphql.NewNonNull(graphql.NewObject(graphql.ObjectConfig{
+					Name: "PageInfo",
+					Fields: graphql.Fields{
+						"hasNextPage": {Type: graphql.NewNonNull(graphql.Boolean)},
+						"endCursor":   {Type: graphql.String},
+					},
+				})),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					c := p.Source.(*connection)
+					pageInfo := map[string]any{"hasNextPage": c.hasNextPage}
+					if len(c.news) > 0 {
+						pageInfo["endCursor"] = news.CursorOf(c.news[len(c.news)-1]).String()
+					}
+					return pageInfo, nil
+				},
+			},
+		},
+	})
+
+	connectionArgs := graphql.FieldConfigArgument{
+		"first": {Type: graphql.Int, DefaultValue: DefaultPageSize},
+		"after": {Type: graphql.String},
+	}
+
+	tagType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Tag",
+		Fields: graphql.Fields{
+			"slug":    {Type: graphql.NewNonNull(graphql.String)},
+			"name":    {Type: graphql.NewNonNull(graphql.String)},
+			"aliases": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+			"count":   {Type: graphql.NewNonNull(graphql.Int)},
+			"news": {
+				Type: graphql.NewNonNull(connectionType),
+				Args: connectionArgs,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return s.resolveConnection(p, news.Filter{Tag: p.Source.(*news.Tag).Slug})
+				},
+			},
+		},
+	})
+	authorType.AddFieldConfig("news", &graphql.Field{
+		Type: graphql.NewNonNull(connectionType),
+		Args: connectionArgs,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			return s.resolveConnection(p, news.Filter{Author: p.Source.(*news.Author).Slug})
+		},
+	})
+
+	newsInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "NewsInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"author":       {Type: graphql.String},
+			"authors":      {Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"title":        {Type: graphql.NewNonNull(graphql.String)},
+			"summary":      {Type: graphql.NewNonNull(graphql.String)},
+			"content":      {Type: graphql.NewNonNull(graphql.String)},
+			"source":       {Type: graphql.NewNonNull(graphql.String)},
+			"createdAt":    {Type: graphql.NewNonNull(graphql.String)},
+			"tags":         {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+			"publishAt":    {Type: graphql.String},
+			"embargoUntil": {Type: graphql.String},
+			"expiresAt":    {Type: graphql.String},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"news": {
+				Type:    newsType,
+				Args:    graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: s.resolveNews,
+			},
+			"allNews": {
+				Type: graphql.NewNonNull(connectionType),
+				Args: graphql.FieldConfigArgument{
+					"first":  connectionArgs["first"],
+					"after":  connectionArgs["after"],
+					"tag":    {Type: graphql.String},
+					"author": {Type: graphql.String},
+					"status": {Type: graphql.NewList(graphql.NewNonNull(statusType))},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					f := news.Filter{}
+					if tag, ok := p.Args["tag"].(string); ok {
+						f.Tag = news.Slugify(tag)
+					}
+					if author, ok := p.Args["author"].(string); ok {
+						f.Author = news.Slugify(author)
+					}
+					if statuses, ok := p.Args["status"].([]any); ok {
+						for _, status := range statuses {
+							f.Statuses = append(f.Statuses, status.(news.Status))
+						}
+					}
+					return s.resolveConnection(p, f)
+				},
+			},
+			"tags": {
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tagType))),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					tags, err := s.ts.FindAll(p.Context)
+					if err != nil {
+						return nil, toError(p.Context, err)
+					}
+					return tags, nil
+				},
+			},
+			"tag": {
+				Type: tagType,
+				Args: graphql.FieldConfigArgument{"slug": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					tags, err := s.ts.FindAll(p.Context)
+					if err != nil {
+						return nil, toError(p.Context, err)
+					}
+					slug := news.Slugify(p.Args["slug"].(string))
+					for _, tag := range tags {
+						if tag.Slug == slug {
+							return tag, nil
+						}
+					}
+					return nil, nil
+				},
+			},
+			"authors": {
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(authorType))),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					authors, err := s.as.FindAll(p.Context)
+					if err != nil {
+						return nil, toError(p.Context, err)
+					}
+					return authors, nil
+				},
+			},
+			"author": {
+				Type: authorType,
+				Args: graphql.FieldConfigArgument{"slug": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					author, err := s.as.FindBySlug(p.Context, news.Slugify(p.Args["slug"].(string)))
+					var dbErr *news.CustomError
+					if errors.As(err, &dbErr) && dbErr.HttpStatusCode() == http.StatusNotFound {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, toError(p.Context, err)
+					}
+					return author, nil
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createNews": {
+				Type:    graphql.NewNonNull(newsType),
+				Args:    graphql.FieldConfigArgument{"input": {Type: graphql.NewNonNull(newsInputType)}},
+				Resolve: s.createNews,
+			},
+			"updateNews": {
+				Type: graphql.NewNonNull(newsType),
+				Args: graphql.FieldConfigArgument{
+					"id":    {Type: graphql.NewNonNull(graphql.ID)},
+					"input": {Type: graphql.NewNonNull(newsInputType)},
+				},
+				Resolve: s.updateNews,
+			},
+			"deleteNews": {
+				Type:    graphql.NewNonNull(graphql.Boolean),
+				Args:    graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: s.deleteNews,
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+	if err != nil {
+		return nil, err
+	}
+	s.schema = schema
+	return s, nil
+}
+
+func resolveId[T any](id func(*T) uuid.UUID) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		return id(p.Source.(*T)).String(), nil
+	}
+}
+
+// resolveTime resolves the zero time to null.
+func resolveTime(t func(*news.Record) time.Time) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		if v := t(p.Source.(*news.Record)); !v.IsZero() {
+			return v, nil
+		}
+		return nil, nil
+	}
+}
+
+func parseId(p graphql.ResolveParams) (uuid.UUID, error) {
+	id, err := uuid.Parse(p.Args["id"].(string))
+	if err != nil {
+		return uuid.Nil, &Error{Message: "invalid id", Status: http.StatusBadRequest}
+	}
+	return id, nil
+}
+
+// resolveNews batches the news looked up by ID in a request into a single
+// query of the columns selected by all of them.
+func (s *Schema) resolveNews(p graphql.ResolveParams) (any, error) {
+	id, err := parseId(p)
+	if err != nil {
+		return nil, err
+	}
+	l := loadersFrom(p.Context)
+	l.addNewsColumns(selectedColumns(p.Info, "")...)
+	return l.news.Load(p.Context, id), nil
+}
+
+// resolveConnection returns a page of the news matching the filter, loading
+// the columns selected on its nodes only.
+func (s *Schema) resolveConnection(p graphql.ResolveParams, f news.Filter) (any, error) {
+	first, _ := p.Args["first"].(int)
+	if first < 1 || first > MaxPageSize {
+		return nil, &Error{Message: "first is not between 1 and 100", Status: http.StatusBadRequest}
+	}
+	if after, ok := p.Args["after"].(string); ok {
+		cursor, err := news.ParseCursor(after)
+		if err != nil {
+			return nil, &Error{Message: err.Error(), Status: http.StatusBadRequest}
+		}
+		f.After = cursor
+	}
+	f.Limit = first + 1
+	f.Columns = selectedColumns(p.Info, "edges.node", "nodes")
+	f.Public = !auth.Editor(p.Context)
+
+	records, err := s.ns.FindAll(p.Context, f)
+	if err != nil {
+		return nil, toError(p.Context, err)
+	}
+	c := &connection{news: records}
+	if len(records) > first {
+		c.news, c.hasNextPage = records[:first], true
+	}
+	return c, nil
+}
+
+// resolveAuthors batches the authors of the news of a request into a single
+// query, unless they are loaded already.
+func (s *Schema) resolveAuthors(p graphql.ResolveParams) (any, error) {
+	n := p.Source.(*news.Record)
+	if n.Authors != nil {
+		return n.Authors, nil
+	}
+	return loadersFrom(p.Context).authors.Load(p.Context, n.Id), nil
+}
+
+// authorize rejects the mutations whose principal cannot write the news of
+// the tenant of the request, like tenant.Require the requests.
+func authorize(ctx context.Context) error {
+	switch err := tenant.Authorize(ctx, auth.RoleEditor); {
+	case errors.Is(err, tenant.ErrUnauthenticated):
+		return &Error{Message: http.StatusText(http.StatusUnauthorized), Status: http.StatusUnauthorized}
+	case err != nil:
+		return &Error{Message: http.StatusText(http.StatusForbidden), Status: http.StatusForbidden}
+	}
+	return nil
+}
+
+func (s *Schema) createNews(p graphql.ResolveParams) (any, error) {
+	if err := authorize(p.Context); err != nil {
+		return nil, err
+	}
+	n, err := validate(p.Args["input"].(map[string]any))
+	if err != nil {
+		return nil, err
+	}
+	created, err := s.ns.Create(p.Context, n)
+	if err != nil {
+		return nil, toError(p.Context, err)
+	}
+	return created, nil
+}
+
+func (s *Schema) updateNews(p graphql.ResolveParams) (any, error) {
+	if err := authorize(p.Context); err != nil {
+		return nil, err
+	}
+	id, err := parseId(p)
+	if err != nil {
+		return nil, err
+	}
+	n, err := validate(p.Args["input"].(map[string]any))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ns.UpdateById(p.Context, id, n); err != nil {
+		return nil, toError(p.Context, err)
+	}
+	return n, nil
+}
+
+func (s *Schema) deleteNews(p graphql.ResolveParams) (any, error) {
+	if err := authorize(p.Context); err != nil {
+		return nil, err
+	}
+	id, err := parseId(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ns.DeleteById(p.Context, id); err != nil {
+		return nil, toError(p.Context, err)
+	}
+	return true, nil
+}
+
+// validate validates the news input like the body of POST /news.
+func validate(input map[string]any) (*news.Record, error) {
+	str := func(name string) string {
+		v, _ := input[name].(string)
+		return v
+	}
+	strs := func(name string) []string {
+		var values []string
+		list, _ := input[name].([]any)
+		for _, v := range list {
+			values = append(values, v.(string))
+		}
+		return values
+	}
+	body := handler.NewsPostReqBody{
+		Author:       str("author"),
+		Authors:      news.AuthorsNamed(strs("authors")),
+		Title:        str("title"),
+		Summary:      str("summary"),
+		Content:      str("content"),
+		Source:       str("source"),
+		CreatedAt:    str("createdAt"),
+		Tags:         strs("tags"),
+		PublishAt:    str("publishAt"),
+		EmbargoUntil: str("embargoUntil"),
+		ExpiresAt:    str("expiresAt"),
+	}
+	n, err := body.Validate()
+	if err != nil {
+		return nil, &Error{Message: err.Error(), Status: http.StatusBadRequest}
+	}
+	return n, nil
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/audit"
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -source=audit.go -destination=mocks/audit.go -package=mockshandler
+
+const (
+	// defaultAuditLimit is the number of audit events listed when the limit
+	// query parameter is not given, MaxAuditLimit the largest limit.
+	defaultAuditLimit = 100
+	MaxAuditLimit     = 1000
+	// auditExportBatchSize is the number of audit events exported per query.
+	auditExportBatchSize = 500
+)
+
+// auditExportBatchTimeout is the time the exports have to write each batch,
+// as they outlast the write timeout of the server.
+const auditExportBatchTimeout = time.Minute
+
+type AuditStorer interface {
+	Find(context.Context, audit.Filter) ([]*audit.Event, error)
+}
+
+// GetAuditEvents lists the audit events, oldest first, filtered by the actor,
+// news_id, from and to query parameters, and paged by the limit and after
+// ones.
+func GetAuditEvents(as AuditStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get audit events")
+		filter, err := parseAuditFilter(r)
+		if err != nil {
+			log.Error("failed to parse audit filter", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		filter.Limit = defaultAuditLimit
+		if l := r.URL.Query().Get("limit"); l != "" {
+			if filter.Limit, err = strconv.Atoi(l); err != nil || filter.Limit < 1 || filter.Limit > MaxAuditLimit {
+				log.Error("failed to parse limit", "error", err, "limit", l)
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(fmt.Sprintf("limit is not between 1 and %d: %s", MaxAuditLimit, l)))
+				return
+			}
+		}
+		events, err := as.Find(ctx, filter)
+		if err != nil {
+			log.Error("failed to get audit events", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		resp := AuditEventsResponse{Events: events}
+		if len(events) == filter.Limit {
+			resp.NextCursor = strconv.FormatInt(events[len(events)-1].Id, 10)
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// ExportAuditEvents streams the audit events filtered as GetAuditEvents
+// does, oldest first, as newline delimited JSON for the SIEMs to ingest. The
+// SIEMs can resume an export with the ID of the last event they got as the
+// after query parameter.
+func ExportAuditEvents(as AuditStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("export audit events")
+		filter, err := parseAuditFilter(r)
+		if err != nil {
+			log.Error("failed to parse audit filter", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		filter.Limit = auditExportBatchSize
+
+		rc := http.NewResponseController(w)
+		enc := json.NewEncoder(w)
+		started := false
+		for {
+			events, err := as.Find(ctx, filter)
+			if err != nil {
+				log.Error("failed to export audit events", "error", err, "after", filter.After)
+				if started {
+					return
+				}
+				var dbErr *news.CustomError
+				if errors.As(err, &dbErr) {
+					w.WriteHeader(dbErr.HttpStatusCode())
+					return
+				}
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if !started {
+				w.Header().Set("Content-Type", "application/x-ndjson")
+				w.Header().Set("Content-Disposition", `attachment; filename="audit.ndjson"`)
+				started = true
+			}
+			rc.SetWriteDeadline(time.Now().Add(auditExportBatchTimeout))
+			for _, e := range events {
+				if err := enc.Encode(e); err != nil {
+					log.Error("failed to encode audit event", "error", err)
+					return
+				}
+			}
+			if len(events) < filter.Limit {
+				return
+			}
+			rc.Flush()
+			filter.After = events[len(events)-1].Id
+		}
+	}
+}
+
+// parseAuditFilter parses the actor, news_id, from, to and after query
+// parameters. Times are in RFC 3339.
+func parseAuditFilter(r *http.Request) (audit.Filter, error) {
+	q := r.URL.Query()
+	f := audit.Filter{Actor: q.Get("actor")}
+	var err error
+	if id := q.Get("news_id"); id != "" {
+		if f.NewsId, err = uuid.Parse(id); err != nil {
+			return f, fmt.Errorf("invalid news_id: %s", id)
+		}
+	}
+	for name, t := range map[string]*time.Time{"from": &f.From, "to": &f.To} {
+		if v := q.Get(name); v != "" {
+			if *t, err = time.Parse(time.RFC3339, v); err != nil {
+				return f, fmt.Errorf("invalid %s: %s", name, v)
+			}
+		}
+	}
+	if after := q.Get("after"); after != "" {
+		if f.After, err = strconv.ParseInt(after, 10, 64); err != nil || f.After < 0 {
+			return f, fmt.Errorf("invalid after: %s", after)
+		}
+	}
+	return f, nil
+}
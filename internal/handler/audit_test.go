@@ -0,0 +1,165 @@
+package handler_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/audit"
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	mockshandler "github.com/TommyLearning/go-rest-api-project/internal/handler/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_GetAuditEvents(t *testing.T) {
+	newsId := uuid.New()
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		query          string
+		setup          func(tb testing.TB) *mockshandler.MockAuditStorer
+		expectedStatus int
+		expectedCursor string
+	}{
+		{
+			name:           "invalid news id",
+			query:          "?news_id=invalid",
+			setup:          func(tb testing.TB) *mockshandler.MockAuditStorer { return nil },
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid time",
+			query:          "?from=yesterday",
+			setup:          func(tb testing.TB) *mockshandler.MockAuditStorer { return nil },
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "limit too large",
+			query:          "?limit=1001",
+			setup:          func(tb testing.TB) *mockshandler.MockAuditStorer { return nil },
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "db error",
+			query: "",
+			setup: func(tb testing.TB) *mockshandler.MockAuditStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockAuditStorer(gomock.NewController(t))
+				ms.EXPECT().Find(gomock.Any(), audit.Filter{Limit: 100}).Return(nil, errors.New("db error"))
+				return ms
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:  "filtered",
+			query: "?actor=alice&news_id=" + newsId.String() + "&from=2026-10-01T00:00:00Z&after=7&limit=2",
+			setup: func(tb testing.TB) *mockshandler.MockAuditStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockAuditStorer(gomock.NewController(t))
+				ms.EXPECT().Find(gomock.Any(), audit.Filter{Actor: "alice", NewsId: newsId, From: from, After: 7, Limit: 2}).
+					Return([]*audit.Event{{Id: 8}, {Id: 9}}, nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+			expectedCursor: "9",
+		},
+		{
+			name:  "last page",
+			query: "?after=9&limit=2",
+			setup: func(tb testing.TB) *mockshandler.MockAuditStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockAuditStorer(gomock.NewController(t))
+				ms.EXPECT().Find(gomock.Any(), audit.Filter{After: 9, Limit: 2}).Return([]*audit.Event{{Id: 10}}, nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/admin/audit"+tc.query, http.NoBody)
+
+			// Act
+			handler.GetAuditEvents(tc.setup(t))(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			if tc.expectedStatus == http.StatusOK {
+				var resp handler.AuditEventsResponse
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+				assert.NotEmpty(t, resp.Events)
+				assert.Equal(t, tc.expectedCursor, resp.NextCursor)
+			}
+		})
+	}
+}
+
+// deadlineRecorder records the write deadlines set by the handlers.
+type deadlineRecorder struct {
+	*httptest.ResponseRecorder
+	deadlines []time.Time
+}
+
+func (r *deadlineRecorder) SetWriteDeadline(t time.Time) error {
+	r.deadlines = append(r.deadlines, t)
+	return nil
+}
+
+func Test_ExportAuditEvents(t *testing.T) {
+	// Arrange
+	first := make([]*audit.Event, 500)
+	for i := range first {
+		first[i] = &audit.Event{Id: int64(i + 1), Actor: "alice"}
+	}
+	ms := mockshandler.NewMockAuditStorer(gomock.NewController(t))
+	gomock.InOrder(
+		ms.EXPECT().Find(gomock.Any(), audit.Filter{Actor: "alice", Limit: 500}).Return(first, nil),
+		ms.EXPECT().Find(gomock.Any(), audit.Filter{Actor: "alice", After: 500, Limit: 500}).Return([]*audit.Event{{Id: 501, Actor: "alice"}}, nil),
+	)
+	w := &deadlineRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r := httptest.NewRequest(http.MethodGet, "/admin/audit/export?actor=alice", http.NoBody)
+
+	// Act
+	handler.ExportAuditEvents(ms)(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, w.deadlines, 2, "a deadline per batch")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), w.deadlines[1], 5*time.Second)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	var ids []int64
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var e audit.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		ids = append(ids, e.Id)
+	}
+	assert.Len(t, ids, 501)
+	assert.Equal(t, int64(501), ids[500])
+}
+
+func Test_ExportAuditEvents_Error(t *testing.T) {
+	// Arrange
+	ms := mockshandler.NewMockAuditStorer(gomock.NewController(t))
+	ms.EXPECT().Find(gomock.Any(), gomock.Any()).Return(nil, errors.New("db error"))
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/admin/audit/export", http.NoBody)
+
+	// Act
+	handler.ExportAuditEvents(ms)(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Type"))
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+)
+
+//go:generate mockgen -source=author.go -destination=mocks/author.go -package=mockshandler
+
+type AuthorStorer interface {
+	Create(context.Context, *news.Author) (*news.Author, error)
+	FindAll(context.Context) ([]*news.Author, error)
+	FindBySlug(context.Context, string) (*news.Author, error)
+	UpdateBySlug(context.Context, string, *news.Author) (*news.Author, error)
+}
+
+func PostAuthor(as AuthorStorer, strict bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("post author")
+
+		var reqBody AuthorReqBody
+		if err := decode(r, &reqBody, strict); err != nil {
+			log.Error("failed to decode request body", "error", err)
+			decodeError(w, err)
+			return
+		}
+
+		a, err := reqBody.Validate()
+		if err != nil {
+			log.Error("failed to validate request body", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		a, err = as.Create(ctx, a)
+		if err != nil {
+			log.Error("failed to create author", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(a); err != nil {
+			log.Error("failed to encode response", "error", err)
+			return
+		}
+	}
+}
+
+func GetAllAuthors(as AuthorStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get all authors")
+		a, err := as.FindAll(ctx)
+		if err != nil {
+			log.Error("failed to get all authors", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(AllAuthorsResponse{Authors: a}); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func GetAuthorBySlug(as AuthorStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get author by slug")
+		a, err := as.FindBySlug(ctx, r.PathValue("slug"))
+		if err != nil {
+			log.Error("failed to get author by slug", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(a); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func UpdateAuthorBySlug(as AuthorStorer, strict bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("update author by slug")
+
+		var reqBody AuthorReqBody
+		if err := decode(r, &reqBody, strict); err != nil {
+			log.Error("failed to decode request body", "error", err)
+			decodeError(w, err)
+			return
+		}
+
+		a, err := reqBody.Validate()
+		if err != nil {
+			log.Error("failed to validate request body", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		a, err = as.UpdateBySlug(ctx, r.PathValue("slug"), a)
+		if err != nil {
+			log.Error("failed to update author by slug", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(a); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func GetAuthorNews(ns NewsStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get author news")
+		filter := news.Filter{Author: news.Slugify(r.PathValue("slug")), Public: true}
+		if err := parsePage(r, &filter); err != nil {
+			log.Error("failed to parse page", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		n, err := ns.FindAll(ctx, filter)
+		if err != nil {
+			log.Error("failed to get author news", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		page := newPage(filter, n)
+		if notModified(w, r, listETag(page.News), lastModified(page.News...)) {
+			return
+		}
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
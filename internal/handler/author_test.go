@@ -0,0 +1,269 @@
+package handler_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	mockshandler "github.com/TommyLearning/go-rest-api-project/internal/handler/mocks"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_PostAuthor(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           io.Reader
+		setup          func(tb testing.TB) *mockshandler.MockAuthorStorer
+		expectedStatus int
+	}{
+		{
+			name: "invalid request body json",
+			body: strings.NewReader(`{`),
+			setup: func(tb testing.TB) *mockshandler.MockAuthorStorer {
+				tb.Helper()
+				return mockshandler.NewMockAuthorStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid request body",
+			body: strings.NewReader(`{"bio": "no name"}`),
+			setup: func(tb testing.TB) *mockshandler.MockAuthorStorer {
+				tb.Helper()
+				return mockshandler.NewMockAuthorStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "slug taken",
+			body: strings.NewReader(`{"name": "Jane Doe"}`),
+			setup: func(tb testing.TB) *mockshandler.MockAuthorStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockAuthorStorer(gomock.NewController(t))
+				ms.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, news.NewCustomError(errors.New("duplicate key"), http.StatusConflict))
+				return ms
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "success",
+			body: strings.NewReader(`{"name": "Jane Doe", "bio": "Reporter", "avatar_url": "https://example.com/jane.png"}`),
+			setup: func(tb testing.TB) *mockshandler.MockAuthorStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockAuthorStorer(gomock.NewController(t))
+				ms.EXPECT().Create(gomock.Any(), &news.Author{Name: "Jane Doe", Bio: "Reporter", AvatarURL: "https://example.com/jane.png"}).
+					Return(&news.Author{Slug: "jane-doe", Name: "Jane Doe"}, nil)
+				return ms
+			},
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", tc.body)
+
+			// Act
+			handler.PostAuthor(tc.setup(t), handler.DefaultStrict)(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_GetAllAuthors(t *testing.T) {
+	testCases := []struct {
+		name           string
+		setup          func(tb testing.TB) *mockshandler.MockAuthorStorer
+		expectedStatus int
+	}{
+		{
+			name: "db error",
+			setup: func(tb testing.TB) *mockshandler.MockAuthorStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockAuthorStorer(gomock.NewController(t))
+				ms.EXPECT().FindAll(gomock.Any()).Return(nil, errors.New("db error"))
+				return ms
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			setup: func(tb testing.TB) *mockshandler.MockAuthorStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockAuthorStorer(gomock.NewController(t))
+				ms.EXPECT().FindAll(gomock.Any()).Return([]*news.Author{{Slug: "jane-doe"}}, nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+			// Act
+			handler.GetAllAuthors(tc.setup(t))(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_GetAuthorBySlug(t *testing.T) {
+	testCases := []struct {
+		name           string
+		setup          func(tb testing.TB) *mockshandler.MockAuthorStorer
+		expectedStatus int
+	}{
+		{
+			name: "not found",
+			setup: func(tb testing.TB) *mockshandler.MockAuthorStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockAuthorStorer(gomock.NewController(t))
+				ms.EXPECT().FindBySlug(gomock.Any(), "jane-doe").Return(nil, news.NewCustomError(errors.New("no rows"), http.StatusNotFound))
+				return ms
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			setup: func(tb testing.TB) *mockshandler.MockAuthorStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockAuthorStorer(gomock.NewController(t))
+				ms.EXPECT().FindBySlug(gomock.Any(), "jane-doe").Return(&news.Author{Slug: "jane-doe"}, nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			r.SetPathValue("slug", "jane-doe")
+
+			// Act
+			handler.GetAuthorBySlug(tc.setup(t))(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_UpdateAuthorBySlug(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           io.Reader
+		setup          func(tb testing.TB) *mockshandler.MockAuthorStorer
+		expectedStatus int
+	}{
+		{
+			name: "invalid request body",
+			body: strings.NewReader(`{"name": "Jane Doe", "avatar_url": "jane.png"}`),
+			setup: func(tb testing.TB) *mockshandler.MockAuthorStorer {
+				tb.Helper()
+				return mockshandler.NewMockAuthorStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "db error",
+			body: strings.NewReader(`{"name": "Jane Doe"}`),
+			setup: func(tb testing.TB) *mockshandler.MockAuthorStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockAuthorStorer(gomock.NewController(t))
+				ms.EXPECT().UpdateBySlug(gomock.Any(), "jane-doe", gomock.Any()).Return(nil, errors.New("db error"))
+				return ms
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			body: strings.NewReader(`{"name": "Jane A. Doe", "contact": "jane@example.com"}`),
+			setup: func(tb testing.TB) *mockshandler.MockAuthorStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockAuthorStorer(gomock.NewController(t))
+				ms.EXPECT().UpdateBySlug(gomock.Any(), "jane-doe", &news.Author{Name: "Jane A. Doe", Contact: "jane@example.com"}).
+					Return(&news.Author{Slug: "jane-doe", Name: "Jane A. Doe"}, nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPut, "/", tc.body)
+			r.SetPathValue("slug", "jane-doe")
+
+			// Act
+			handler.UpdateAuthorBySlug(tc.setup(t), handler.DefaultStrict)(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_GetAuthorNews(t *testing.T) {
+	testCases := []struct {
+		name           string
+		setup          func(tb testing.TB) *mockshandler.MockNewsStorer
+		expectedStatus int
+	}{
+		{
+			name: "db error",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return(nil, errors.New("db error"))
+				return ms
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindAll(gomock.Any(), news.Filter{Author: "jane-doe", Public: true}).Return(nil, nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			r.SetPathValue("slug", "Jane Doe")
+
+			// Act
+			handler.GetAuthorNews(tc.setup(t))(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
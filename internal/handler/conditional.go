@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+)
+
+// listETag returns the weak ETag of a list of news: lists with as many news
+// last updated at the same time are assumed to be the same.
+func listETag(n []*news.Record) string {
+	return `W/"` + strconv.Itoa(len(n)) + "-" + strconv.FormatInt(lastModified(n...).UnixMicro(), 36) + `"`
+}
+
+// lastModified returns the time the last of the news was updated.
+func lastModified(n ...*news.Record) time.Time {
+	var t time.Time
+	for _, r := range n {
+		if r.UpdatedAt.After(t) {
+			t = r.UpdatedAt
+		}
+	}
+	return t
+}
+
+// notModified sets the validators of the response, the ETag if any and the
+// Last-Modified time, and reports whether the copy of the client is still
+// fresh, in which case it writes a 304 Not Modified. If-None-Match takes
+// precedence over If-Modified-Since, as in RFC 9110.
+func notModified(w http.ResponseWriter, r *http.Request, etag string, modified time.Time) bool {
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	fresh := false
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		fresh = etag != "" && matchETag(inm, etag)
+	} else if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modified.IsZero() {
+		fresh = !modified.Truncate(time.Second).After(ims)
+	}
+	if !fresh {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// matchETag reports whether the If-None-Match header matches the ETag, with
+// the weak comparison.
+func matchETag(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
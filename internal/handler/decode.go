@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// DefaultStrict makes the request bodies with fields unknown to their type
+// invalid, rather than ignoring the fields.
+const DefaultStrict = true
+
+var errTrailingData = errors.New("request body must contain a single JSON value")
+
+// decode decodes the JSON request body into v. The body must hold a single
+// JSON value, without fields unknown to v if strict.
+func decode(r *http.Request, v any, strict bool) error {
+	d := json.NewDecoder(r.Body)
+	if strict {
+		d.DisallowUnknownFields()
+	}
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return err
+		}
+		return errTrailingData
+	}
+	return nil
+}
+
+// decodeError writes the response to a request body that failed to decode:
+// 413 Request Entity Too Large for the bodies over the limit of their route,
+// 400 Bad Request otherwise.
+func decodeError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write([]byte(err.Error()))
+}
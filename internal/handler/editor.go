@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/google/uuid"
+)
+
+// GetEditorNews lists news in any editorial state, optionally filtered by a
+// comma separated list of states in the status query parameter, and by the
+// text in the q query parameter.
+func GetEditorNews(ns NewsStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get editor news")
+
+		filter := news.Filter{
+			Tag:   news.Slugify(r.URL.Query().Get("tag")),
+			Query: strings.TrimSpace(r.URL.Query().Get("q")),
+		}
+		if statuses := r.URL.Query().Get("status"); statuses != "" {
+			for _, s := range strings.Split(statuses, ",") {
+				status, err := news.ParseStatus(strings.TrimSpace(s))
+				if err != nil {
+					log.Error("failed to parse status", "error", err)
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(err.Error()))
+					return
+				}
+				filter.Statuses = append(filter.Statuses, status)
+			}
+		}
+		if err := parsePage(r, &filter); err != nil {
+			log.Error("failed to parse page", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		n, err := ns.FindAll(ctx, filter)
+		if err != nil {
+			log.Error("failed to get editor news", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		page := newPage(filter, n)
+		if notModified(w, r, listETag(page.News), lastModified(page.News...)) {
+			return
+		}
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// GetEditorNewsById returns a news whatever its editorial state.
+func GetEditorNewsById(ns NewsStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get editor news by id")
+		newsUUID, err := uuid.Parse(r.PathValue("news_id"))
+		if err != nil {
+			log.Error("failed to parse news id", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		n, err := ns.FindById(ctx, newsUUID)
+		if err != nil {
+			log.Error("failed to get news by id", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if notModified(w, r, "", n.UpdatedAt) {
+			return
+		}
+		if err := json.NewEncoder(w).Encode(n); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// TransitionNewsById moves a news to another editorial state on behalf of
+// the authenticated principal.
+func TransitionNewsById(ns NewsStorer, strict bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("transition news by id")
+		newsUUID, err := uuid.Parse(r.PathValue("news_id"))
+		if err != nil {
+			log.Error("failed to parse news id", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var reqBody TransitionReqBody
+		if err := decode(r, &reqBody, strict); err != nil {
+			log.Error("failed to decode request body", "error", err)
+			decodeError(w, err)
+			return
+		}
+		status, err := news.ParseStatus(reqBody.Status)
+		if err != nil {
+			log.Error("failed to validate request body", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		var actor string
+		if p, ok := auth.FromContext(ctx); ok {
+			actor = p.Name
+		}
+		n, err := ns.Transition(ctx, newsUUID, status, actor)
+		if err != nil {
+			log.Error("failed to transition news", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				w.Write([]byte(err.Error()))
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(n); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// RestoreNewsById undoes the deletion of a news and returns it.
+func RestoreNewsById(ns NewsStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("restore news by id")
+		newsUUID, err := uuid.Parse(r.PathValue("news_id"))
+		if err != nil {
+			log.Error("failed to parse news id", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		n, err := ns.Restore(ctx, newsUUID)
+		if err != nil {
+			log.Error("failed to restore news", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(n); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
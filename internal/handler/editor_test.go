@@ -0,0 +1,227 @@
+package handler_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	mockshandler "github.com/TommyLearning/go-rest-api-project/internal/handler/mocks"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_GetEditorNews(t *testing.T) {
+	testCases := []struct {
+		name           string
+		query          string
+		setup          func(tb testing.TB) *mockshandler.MockNewsStorer
+		expectedStatus int
+	}{
+		{
+			name:  "invalid status",
+			query: "?status=draft,live",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				return mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "db error",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return(nil, errors.New("db error"))
+				return ms
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:  "filter by status",
+			query: "?status=draft,%20in_review",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindAll(gomock.Any(), news.Filter{Statuses: []news.Status{news.StatusDraft, news.StatusInReview}}).Return(nil, nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "search",
+			query: "?q=%20election%20",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindAll(gomock.Any(), news.Filter{Query: "election"}).Return(nil, nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/"+tc.query, http.NoBody)
+
+			// Act
+			handler.GetEditorNews(tc.setup(t))(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_TransitionNewsById(t *testing.T) {
+	newsID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		newsID         string
+		body           io.Reader
+		setup          func(tb testing.TB) *mockshandler.MockNewsStorer
+		expectedStatus int
+	}{
+		{
+			name:   "invalid news id",
+			newsID: "invalid-uuid",
+			body:   strings.NewReader(`{"status": "in_review"}`),
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				return mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid status",
+			newsID: newsID.String(),
+			body:   strings.NewReader(`{"status": "live"}`),
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				return mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid transition",
+			newsID: newsID.String(),
+			body:   strings.NewReader(`{"status": "published"}`),
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().Transition(gomock.Any(), newsID, news.StatusPublished, "alice").
+					Return(nil, news.NewCustomError(errors.New("cannot move news from draft to published"), http.StatusConflict))
+				return ms
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "success",
+			newsID: newsID.String(),
+			body:   strings.NewReader(`{"status": "in_review"}`),
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().Transition(gomock.Any(), newsID, news.StatusInReview, "alice").
+					Return(&news.Record{Id: newsID, Status: news.StatusInReview}, nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", tc.body)
+			r.SetPathValue("news_id", tc.newsID)
+			r = r.WithContext(auth.CtxWithPrincipal(r.Context(), &auth.Principal{Name: "alice", Role: auth.RoleEditor}))
+
+			// Act
+			handler.TransitionNewsById(tc.setup(t), handler.DefaultStrict)(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_RestoreNewsById(t *testing.T) {
+	newsID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		newsID         string
+		setup          func(tb testing.TB) *mockshandler.MockNewsStorer
+		expectedStatus int
+	}{
+		{
+			name:   "invalid news id",
+			newsID: "invalid-uuid",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				return mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "not deleted",
+			newsID: newsID.String(),
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().Restore(gomock.Any(), newsID).Return(nil, news.NewCustomError(errors.New("not found"), http.StatusNotFound))
+				return ms
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "db error",
+			newsID: newsID.String(),
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().Restore(gomock.Any(), newsID).Return(nil, errors.New("db error"))
+				return ms
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:   "success",
+			newsID: newsID.String(),
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().Restore(gomock.Any(), newsID).Return(&news.Record{Id: newsID}, nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", http.NoBody)
+			r.SetPathValue("news_id", tc.newsID)
+
+			// Act
+			handler.RestoreNewsById(tc.setup(t))(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
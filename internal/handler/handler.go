@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"slices"
+	"strings"
 
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
 	"github.com/TommyLearning/go-rest-api-project/internal/logger"
 	"github.com/TommyLearning/go-rest-api-project/internal/news"
 
@@ -22,21 +25,24 @@ type NewsStorer interface {
 	// DeleteById(uuid.UUID) error
 	Create(context.Context, *news.Record) (*news.Record, error)
 	FindById(context.Context, uuid.UUID) (*news.Record, error)
-	FindAll(context.Context) ([]*news.Record, error)
+	FindAll(context.Context, news.Filter) ([]*news.Record, error)
 	DeleteById(context.Context, uuid.UUID) error
 	UpdateById(context.Context, uuid.UUID, *news.Record) error
+	FindSimilar(context.Context, uuid.UUID) ([]*news.Record, error)
+	Transition(context.Context, uuid.UUID, news.Status, string) (*news.Record, error)
+	Restore(context.Context, uuid.UUID) (*news.Record, error)
 }
 
-func PostNews(ns NewsStorer) http.HandlerFunc {
+func PostNews(ns NewsStorer, strict bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		log := logger.FromContext(ctx)
 		log.Info("post news")
 
 		var requestBody NewsPostReqBody
-		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		if err := decode(r, &requestBody, strict); err != nil {
 			log.Error("failed to decode request body", "error", err)
-			w.WriteHeader(http.StatusBadRequest)
+			decodeError(w, err)
 			return
 		}
 
@@ -68,7 +74,18 @@ func GetAllNews(ns NewsStorer) http.HandlerFunc {
 		ctx := r.Context()
 		log := logger.FromContext(ctx)
 		log.Info("get all news")
-		n, err := ns.FindAll(ctx)
+		filter := news.Filter{
+			Tag:    news.Slugify(r.URL.Query().Get("tag")),
+			Query:  strings.TrimSpace(r.URL.Query().Get("q")),
+			Public: true,
+		}
+		if err := parsePage(r, &filter); err != nil {
+			log.Error("failed to parse page", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		n, err := ns.FindAll(ctx, filter)
 		if err != nil {
 			log.Error("failed to get all news", "error", err)
 			var dbErr *news.CustomError
@@ -79,8 +96,11 @@ func GetAllNews(ns NewsStorer) http.HandlerFunc {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		allNewsResponse := AllNewsResponse{News: n}
-		if err := json.NewEncoder(w).Encode(allNewsResponse); err != nil {
+		page := newPage(filter, n)
+		if notModified(w, r, listETag(page.News), lastModified(page.News...)) {
+			return
+		}
+		if err := json.NewEncoder(w).Encode(page); err != nil {
 			log.Error("failed to encode response", "error", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
@@ -111,6 +131,14 @@ func GetNewsById(ns NewsStorer) http.HandlerFunc {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+		if !n.Public() {
+			log.Info("news is not public", "id", newsUUID)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if notModified(w, r, "", n.UpdatedAt) {
+			return
+		}
 
 		if err := json.NewEncoder(w).Encode(n); err != nil {
 			log.Error("failed to encode response", "error", err)
@@ -120,16 +148,22 @@ func GetNewsById(ns NewsStorer) http.HandlerFunc {
 	}
 }
 
-func UpdateNewsById(ns NewsStorer) http.HandlerFunc {
+func UpdateNewsById(ns NewsStorer, strict bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		log := logger.FromContext(ctx)
 		log.Info("update news by id")
+		newsUUID, err := uuid.Parse(r.PathValue("news_id"))
+		if err != nil {
+			log.Error("failed to parse news id", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
 
 		var newsReqBody NewsPostReqBody
-		if err := json.NewDecoder(r.Body).Decode(&newsReqBody); err != nil {
+		if err := decode(r, &newsReqBody, strict); err != nil {
 			log.Error("failed to decode the request", "error", err)
-			w.WriteHeader(http.StatusBadRequest)
+			decodeError(w, err)
 			return
 		}
 
@@ -140,11 +174,17 @@ func UpdateNewsById(ns NewsStorer) http.HandlerFunc {
 			w.Write([]byte(err.Error()))
 			return
 		}
+		if n.Id != uuid.Nil && n.Id != newsUUID {
+			log.Error("news id of the body differs from the path", "id", n.Id, "path_id", newsUUID)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("id differs from the news id of the path"))
+			return
+		}
 
-		if err2 := ns.UpdateById(ctx, n.Id, n); err2 != nil {
+		if err2 := ns.UpdateById(ctx, newsUUID, n); err2 != nil {
 			log.Error("failed to update news by id", "error", err2)
 			var dbErr *news.CustomError
-			if errors.As(err, &dbErr) {
+			if errors.As(err2, &dbErr) {
 				w.WriteHeader(dbErr.HttpStatusCode())
 				return
 			}
@@ -178,3 +218,58 @@ func DeleteNewsById(ns NewsStorer) http.HandlerFunc {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+func GetSimilarNews(ns NewsStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get similar news")
+		newsID := r.PathValue("news_id")
+		newsUUID, err := uuid.Parse(newsID)
+		if err != nil {
+			log.Error("failed to parse news id", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// The similar news of a news are as hidden as the news itself.
+		if !auth.Editor(ctx) {
+			source, err := ns.FindById(ctx, newsUUID)
+			if err != nil {
+				log.Error("failed to get news by id", "error", err)
+				var dbErr *news.CustomError
+				if errors.As(err, &dbErr) {
+					w.WriteHeader(dbErr.HttpStatusCode())
+					return
+				}
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if !source.Public() {
+				log.Info("news is not public", "id", newsUUID)
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+		}
+		n, err := ns.FindSimilar(ctx, newsUUID)
+		if err != nil {
+			log.Error("failed to get similar news", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		n = slices.DeleteFunc(n, func(r *news.Record) bool { return !r.Public() })
+		allNewsResponse := AllNewsResponse{News: n}
+		if notModified(w, r, listETag(n), lastModified(n...)) {
+			return
+		}
+		if err := json.NewEncoder(w).Encode(allNewsResponse); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -1,13 +1,18 @@
 package handler_test
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
 	"github.com/TommyLearning/go-rest-api-project/internal/handler"
 	mockshandler "github.com/TommyLearning/go-rest-api-project/internal/handler/mocks"
 	"github.com/TommyLearning/go-rest-api-project/internal/news"
@@ -20,6 +25,7 @@ func Test_PostNews(t *testing.T) {
 	testCases := []struct {
 		name           string
 		body           io.Reader
+		lenient        bool
 		setup          func(tb testing.TB) *mockshandler.MockNewsStorer
 		expectedStatus int
 	}{
@@ -49,6 +55,45 @@ func Test_PostNews(t *testing.T) {
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "unknown field",
+			body: strings.NewReader(`{"author": "code learn", "content": "news content", "title": "first news", "summary": "first news post", "created_at": "2024-04-07T05:13:27+00:00", "source": "https://example.com", "tags": ["politics"], "unknown": true}`),
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				return mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "unknown field not strict",
+			body:    strings.NewReader(`{"author": "code learn", "content": "news content", "title": "first news", "summary": "first news post", "created_at": "2024-04-07T05:13:27+00:00", "source": "https://example.com", "tags": ["politics"], "unknown": true}`),
+			lenient: true,
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, nil)
+				return ms
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "trailing data",
+			body: strings.NewReader(`{"author": "code learn", "content": "news content", "title": "first news", "summary": "first news post", "created_at": "2024-04-07T05:13:27+00:00", "source": "https://example.com", "tags": ["politics"]} {}`),
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				return mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "request body too large",
+			body: http.MaxBytesReader(nil, io.NopCloser(strings.NewReader(`{"author": "code learn", "content": "news content", "title": "first news", "summary": "first news post", "created_at": "2024-04-07T05:13:27+00:00", "source": "https://example.com", "tags": ["politics"]}`)), 64),
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				return mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
 		{
 			name: "db error",
 			body: strings.NewReader(`
@@ -122,7 +167,7 @@ func Test_PostNews(t *testing.T) {
 			r := httptest.NewRequest(http.MethodPost, "/", tc.body)
 
 			// Act
-			handler.PostNews(tc.setup(t))(w, r)
+			handler.PostNews(tc.setup(t), !tc.lenient)(w, r)
 
 			// Assert
 			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
@@ -141,7 +186,7 @@ func Test_GetAllNews(t *testing.T) {
 			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
 				tb.Helper()
 				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
-				ms.EXPECT().FindAll(gomock.Any()).Return(nil, errors.New("db error"))
+				ms.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return(nil, errors.New("db error"))
 				return ms
 			},
 			expectedStatus: http.StatusInternalServerError,
@@ -151,7 +196,7 @@ func Test_GetAllNews(t *testing.T) {
 			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
 				tb.Helper()
 				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
-				ms.EXPECT().FindAll(gomock.Any()).Return(nil, news.NewCustomError(errors.New("some error"), http.StatusBadRequest))
+				ms.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return(nil, news.NewCustomError(errors.New("some error"), http.StatusBadRequest))
 				return ms
 			},
 			expectedStatus: http.StatusBadRequest,
@@ -161,7 +206,7 @@ func Test_GetAllNews(t *testing.T) {
 			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
 				tb.Helper()
 				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
-				ms.EXPECT().FindAll(gomock.Any()).Return(nil, nil)
+				ms.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return(nil, nil)
 				return ms
 			},
 			expectedStatus: http.StatusOK,
@@ -183,6 +228,86 @@ func Test_GetAllNews(t *testing.T) {
 	}
 }
 
+func Test_GetAllNews_Page(t *testing.T) {
+	now := time.Now().UTC()
+	records := []*news.Record{
+		{Id: uuid.New(), CreatedAt: now},
+		{Id: uuid.New(), CreatedAt: now.Add(-time.Minute)},
+		{Id: uuid.New(), CreatedAt: now.Add(-2 * time.Minute)},
+	}
+	after := news.CursorOf(records[0])
+
+	testCases := []struct {
+		name               string
+		target             string
+		expectedFilter     news.Filter
+		records            []*news.Record
+		expectedStatus     int
+		expectedCount      int
+		expectedNextCursor string
+	}{
+		{
+			name:               "first page",
+			target:             "/news?limit=2",
+			expectedFilter:     news.Filter{Public: true, Limit: 3},
+			records:            records,
+			expectedStatus:     http.StatusOK,
+			expectedCount:      2,
+			expectedNextCursor: news.CursorOf(records[1]).String(),
+		},
+		{
+			name:           "last page",
+			target:         "/news?limit=2&after=" + after.String(),
+			expectedFilter: news.Filter{Public: true, Limit: 3, After: after},
+			records:        records[1:],
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+		},
+		{
+			name:           "invalid limit",
+			target:         "/news?limit=101",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid cursor",
+			target:         "/news?after=invalid",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			if tc.records != nil {
+				ms.EXPECT().FindAll(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f news.Filter) ([]*news.Record, error) {
+					assert.Equal(t, tc.expectedFilter.Limit, f.Limit)
+					if tc.expectedFilter.After != nil {
+						assert.Equal(t, tc.expectedFilter.After.String(), f.After.String())
+					} else {
+						assert.Nil(t, f.After)
+					}
+					return tc.records, nil
+				})
+			}
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tc.target, http.NoBody)
+
+			// Act
+			handler.GetAllNews(ms)(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			if tc.expectedStatus == http.StatusOK {
+				var resp handler.AllNewsResponse
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+				assert.Len(t, resp.News, tc.expectedCount)
+				assert.Equal(t, tc.expectedNextCursor, resp.NextCursor)
+			}
+		})
+	}
+}
+
 func Test_GetNewsByID(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -221,12 +346,47 @@ func Test_GetNewsByID(t *testing.T) {
 			newsID:         uuid.NewString(),
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "not published",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindById(gomock.Any(), gomock.Any()).Return(&news.Record{Status: news.StatusDraft}, nil)
+				return ms
+			},
+			newsID:         uuid.NewString(),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "under embargo",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindById(gomock.Any(), gomock.Any()).
+					Return(&news.Record{Status: news.StatusPublished, EmbargoUntil: time.Now().Add(time.Hour)}, nil)
+				return ms
+			},
+			newsID:         uuid.NewString(),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "expired",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindById(gomock.Any(), gomock.Any()).
+					Return(&news.Record{Status: news.StatusPublished, ExpiresAt: time.Now().Add(-time.Hour)}, nil)
+				return ms
+			},
+			newsID:         uuid.NewString(),
+			expectedStatus: http.StatusNotFound,
+		},
 		{
 			name: "success",
 			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
 				tb.Helper()
 				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
-				ms.EXPECT().FindById(gomock.Any(), gomock.Any()).Return(nil, nil)
+				ms.EXPECT().FindById(gomock.Any(), gomock.Any()).Return(&news.Record{Status: news.StatusPublished}, nil)
 				return ms
 			},
 			newsID:         uuid.NewString(),
@@ -250,9 +410,116 @@ func Test_GetNewsByID(t *testing.T) {
 	}
 }
 
+func Test_GetNewsByID_Conditional(t *testing.T) {
+	updatedAt := time.Date(2026, 10, 19, 12, 0, 0, 500, time.UTC)
+
+	testCases := []struct {
+		name           string
+		header         http.Header
+		expectedStatus int
+	}{
+		{
+			name:           "unconditional",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "not modified since",
+			header:         http.Header{"If-Modified-Since": {updatedAt.Format(http.TimeFormat)}},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "modified since",
+			header:         http.Header{"If-Modified-Since": {updatedAt.Add(-time.Second).Format(http.TimeFormat)}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid date",
+			header:         http.Header{"If-Modified-Since": {"yesterday"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "if-none-match takes precedence",
+			header: http.Header{
+				"If-None-Match":     {`W/"other"`},
+				"If-Modified-Since": {updatedAt.Format(http.TimeFormat)},
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			ms.EXPECT().FindById(gomock.Any(), gomock.Any()).Return(&news.Record{Status: news.StatusPublished, UpdatedAt: updatedAt}, nil)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			r.SetPathValue("news_id", uuid.NewString())
+			for name, values := range tc.header {
+				r.Header[name] = values
+			}
+
+			// Act
+			handler.GetNewsById(ms)(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			assert.Equal(t, "Mon, 19 Oct 2026 12:00:00 GMT", w.Header().Get("Last-Modified"))
+			if tc.expectedStatus == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+			}
+		})
+	}
+}
+
+func Test_GetAllNews_Conditional(t *testing.T) {
+	now := time.Now().UTC()
+	records := []*news.Record{
+		{Id: uuid.New(), UpdatedAt: now.Add(-time.Minute)},
+		{Id: uuid.New(), UpdatedAt: now},
+	}
+
+	// Arrange
+	ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+	ms.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return(records, nil).Times(4)
+	ms.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return(records[:1], nil)
+	w := httptest.NewRecorder()
+	handler.GetAllNews(ms)(w, httptest.NewRequest(http.MethodGet, "/news", http.NoBody))
+	etag := w.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"2-`), etag)
+	assert.Equal(t, now.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+
+	for _, tc := range []struct {
+		name           string
+		ifNoneMatch    string
+		expectedStatus int
+	}{
+		{name: "same list", ifNoneMatch: etag, expectedStatus: http.StatusNotModified},
+		{name: "any of the tags", ifNoneMatch: `"other", ` + strings.TrimPrefix(etag, "W/"), expectedStatus: http.StatusNotModified},
+		{name: "wildcard", ifNoneMatch: "*", expectedStatus: http.StatusNotModified},
+		{name: "changed list", ifNoneMatch: etag, expectedStatus: http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/news", http.NoBody)
+			r.Header.Set("If-None-Match", tc.ifNoneMatch)
+
+			// Act
+			handler.GetAllNews(ms)(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			if tc.expectedStatus == http.StatusOK {
+				assert.NotEqual(t, etag, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
 func Test_UpdateNewsByID(t *testing.T) {
 	testCases := []struct {
 		name           string
+		newsID         string
 		body           io.Reader
 		setup          func(tb testing.TB) *mockshandler.MockNewsStorer
 		expectedStatus int
@@ -341,21 +608,71 @@ func Test_UpdateNewsByID(t *testing.T) {
 			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
 				tb.Helper()
 				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
-				ms.EXPECT().UpdateById(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				ms.EXPECT().UpdateById(gomock.Any(), uuid.MustParse("3b082d9d-1dc7-4d1f-907e-50d449a03d45"), gomock.Any()).Return(nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "no id in the body",
+			body: strings.NewReader(`
+			{
+			"author": "code learn",
+			"title": "first news",
+			"content": "news content",
+			"summary": "first news post",
+			"created_at": "2024-04-07T05:13:27+00:00",
+			"source": "https://example.com",
+			"tags": ["politics"]
+			}`),
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().UpdateById(gomock.Any(), uuid.MustParse("3b082d9d-1dc7-4d1f-907e-50d449a03d45"), gomock.Any()).Return(nil)
 				return ms
 			},
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name: "id of the body differs from the path",
+			body: strings.NewReader(`
+			{
+			"id" : "0e8c5a3f-6f43-4a4e-9d57-0f4ad5b1c2d7",
+			"author": "code learn",
+			"title": "first news",
+			"content": "news content",
+			"summary": "first news post",
+			"created_at": "2024-04-07T05:13:27+00:00",
+			"source": "https://example.com",
+			"tags": ["politics"]
+			}`),
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				return mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid news id",
+			newsID: "invalid-uuid",
+			body:   strings.NewReader(`{}`),
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				return mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Arrange
 			w := httptest.NewRecorder()
-			r := httptest.NewRequest(http.MethodPost, "/", tc.body)
+			r := httptest.NewRequest(http.MethodPut, "/", tc.body)
+			r.SetPathValue("news_id", cmp.Or(tc.newsID, "3b082d9d-1dc7-4d1f-907e-50d449a03d45"))
 
 			// Act
-			handler.UpdateNewsById(tc.setup(t))(w, r)
+			handler.UpdateNewsById(tc.setup(t), handler.DefaultStrict)(w, r)
 
 			// Assert
 			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
@@ -429,3 +746,113 @@ func Test_DeleteNewsByID(t *testing.T) {
 		})
 	}
 }
+
+func Test_GetSimilarNews(t *testing.T) {
+	published := &news.Record{Status: news.StatusPublished}
+
+	testCases := []struct {
+		name           string
+		setup          func(tb testing.TB) *mockshandler.MockNewsStorer
+		newsID         string
+		editor         bool
+		expectedStatus int
+	}{
+		{
+			name: "invalid news id",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				return mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			},
+			newsID:         "invalid-uuid",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "source not found",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindById(gomock.Any(), gomock.Any()).Return(nil, news.NewCustomError(errors.New("some error"), http.StatusNotFound))
+				return ms
+			},
+			newsID:         uuid.NewString(),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "source not public",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindById(gomock.Any(), gomock.Any()).Return(&news.Record{Status: news.StatusDraft}, nil)
+				return ms
+			},
+			newsID:         uuid.NewString(),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "source not public to an editor",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindSimilar(gomock.Any(), gomock.Any()).Return([]*news.Record{}, nil)
+				return ms
+			},
+			newsID:         uuid.NewString(),
+			editor:         true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "db error",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindById(gomock.Any(), gomock.Any()).Return(published, nil)
+				ms.EXPECT().FindSimilar(gomock.Any(), gomock.Any()).Return(nil, errors.New("db error"))
+				return ms
+			},
+			newsID:         uuid.NewString(),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "db custom error",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindById(gomock.Any(), gomock.Any()).Return(published, nil)
+				ms.EXPECT().FindSimilar(gomock.Any(), gomock.Any()).Return(nil, news.NewCustomError(errors.New("some error"), http.StatusNotFound))
+				return ms
+			},
+			newsID:         uuid.NewString(),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindById(gomock.Any(), gomock.Any()).Return(published, nil)
+				ms.EXPECT().FindSimilar(gomock.Any(), gomock.Any()).Return([]*news.Record{}, nil)
+				return ms
+			},
+			newsID:         uuid.NewString(),
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			r.SetPathValue("news_id", tc.newsID)
+			if tc.editor {
+				r = r.WithContext(auth.CtxWithPrincipal(r.Context(), &auth.Principal{Name: "alice", Role: auth.RoleEditor}))
+			}
+
+			// Act
+			handler.GetSimilarNews(tc.setup(t))(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
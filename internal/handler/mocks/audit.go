@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: audit.go
+//
+// Generated by this command:
+//
+//	mockgen -source=audit.go -destination=mocks/audit.go -package=mockshandler
+//
+
+// Package mockshandler is a generated GoMock package.
+package mockshandler
+
+import (
+	context "context"
+	reflect "reflect"
+
+	audit "github.com/TommyLearning/go-rest-api-project/internal/audit"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuditStorer is a mock of AuditStorer interface.
+type MockAuditStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditStorerMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditStorerMockRecorder is the mock recorder for MockAuditStorer.
+type MockAuditStorerMockRecorder struct {
+	mock *MockAuditStorer
+}
+
+// NewMockAuditStorer creates a new mock instance.
+func NewMockAuditStorer(ctrl *gomock.Controller) *MockAuditStorer {
+	mock := &MockAuditStorer{ctrl: ctrl}
+	mock.recorder = &MockAuditStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditStorer) EXPECT() *MockAuditStorerMockRecorder {
+	return m.recorder
+}
+
+// Find mocks base method.
+func (m *MockAuditStorer) Find(arg0 context.Context, arg1 audit.Filter) ([]*audit.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", arg0, arg1)
+	ret0, _ := ret[0].([]*audit.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockAuditStorerMockRecorder) Find(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockAuditStorer)(nil).Find), arg0, arg1)
+}
@@ -0,0 +1,102 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: author.go
+//
+// Generated by this command:
+//
+//	mockgen -source=author.go -destination=mocks/author.go -package=mockshandler
+//
+
+// Package mockshandler is a generated GoMock package.
+package mockshandler
+
+import (
+	context "context"
+	reflect "reflect"
+
+	news "github.com/TommyLearning/go-rest-api-project/internal/news"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuthorStorer is a mock of AuthorStorer interface.
+type MockAuthorStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthorStorerMockRecorder
+	isgomock struct{}
+}
+
+// MockAuthorStorerMockRecorder is the mock recorder for MockAuthorStorer.
+type MockAuthorStorerMockRecorder struct {
+	mock *MockAuthorStorer
+}
+
+// NewMockAuthorStorer creates a new mock instance.
+func NewMockAuthorStorer(ctrl *gomock.Controller) *MockAuthorStorer {
+	mock := &MockAuthorStorer{ctrl: ctrl}
+	mock.recorder = &MockAuthorStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthorStorer) EXPECT() *MockAuthorStorerMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAuthorStorer) Create(arg0 context.Context, arg1 *news.Author) (*news.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(*news.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAuthorStorerMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAuthorStorer)(nil).Create), arg0, arg1)
+}
+
+// FindAll mocks base method.
+func (m *MockAuthorStorer) FindAll(arg0 context.Context) ([]*news.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAll", arg0)
+	ret0, _ := ret[0].([]*news.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAll indicates an expected call of FindAll.
+func (mr *MockAuthorStorerMockRecorder) FindAll(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockAuthorStorer)(nil).FindAll), arg0)
+}
+
+// FindBySlug mocks base method.
+func (m *MockAuthorStorer) FindBySlug(arg0 context.Context, arg1 string) (*news.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindBySlug", arg0, arg1)
+	ret0, _ := ret[0].(*news.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindBySlug indicates an expected call of FindBySlug.
+func (mr *MockAuthorStorerMockRecorder) FindBySlug(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBySlug", reflect.TypeOf((*MockAuthorStorer)(nil).FindBySlug), arg0, arg1)
+}
+
+// UpdateBySlug mocks base method.
+func (m *MockAuthorStorer) UpdateBySlug(arg0 context.Context, arg1 string, arg2 *news.Author) (*news.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBySlug", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*news.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateBySlug indicates an expected call of UpdateBySlug.
+func (mr *MockAuthorStorerMockRecorder) UpdateBySlug(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBySlug", reflect.TypeOf((*MockAuthorStorer)(nil).UpdateBySlug), arg0, arg1, arg2)
+}
@@ -72,18 +72,18 @@ func (mr *MockNewsStorerMockRecorder) DeleteById(arg0, arg1 any) *gomock.Call {
 }
 
 // FindAll mocks base method.
-func (m *MockNewsStorer) FindAll(arg0 context.Context) ([]*news.Record, error) {
+func (m *MockNewsStorer) FindAll(arg0 context.Context, arg1 news.Filter) ([]*news.Record, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindAll", arg0)
+	ret := m.ctrl.Call(m, "FindAll", arg0, arg1)
 	ret0, _ := ret[0].([]*news.Record)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindAll indicates an expected call of FindAll.
-func (mr *MockNewsStorerMockRecorder) FindAll(arg0 any) *gomock.Call {
+func (mr *MockNewsStorerMockRecorder) FindAll(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockNewsStorer)(nil).FindAll), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockNewsStorer)(nil).FindAll), arg0, arg1)
 }
 
 // FindById mocks base method.
@@ -101,6 +101,51 @@ func (mr *MockNewsStorerMockRecorder) FindById(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindById", reflect.TypeOf((*MockNewsStorer)(nil).FindById), arg0, arg1)
 }
 
+// FindSimilar mocks base method.
+func (m *MockNewsStorer) FindSimilar(arg0 context.Context, arg1 uuid.UUID) ([]*news.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSimilar", arg0, arg1)
+	ret0, _ := ret[0].([]*news.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindSimilar indicates an expected call of FindSimilar.
+func (mr *MockNewsStorerMockRecorder) FindSimilar(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSimilar", reflect.TypeOf((*MockNewsStorer)(nil).FindSimilar), arg0, arg1)
+}
+
+// Restore mocks base method.
+func (m *MockNewsStorer) Restore(arg0 context.Context, arg1 uuid.UUID) (*news.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1)
+	ret0, _ := ret[0].(*news.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockNewsStorerMockRecorder) Restore(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockNewsStorer)(nil).Restore), arg0, arg1)
+}
+
+// Transition mocks base method.
+func (m *MockNewsStorer) Transition(arg0 context.Context, arg1 uuid.UUID, arg2 news.Status, arg3 string) (*news.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transition", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*news.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Transition indicates an expected call of Transition.
+func (mr *MockNewsStorerMockRecorder) Transition(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transition", reflect.TypeOf((*MockNewsStorer)(nil).Transition), arg0, arg1, arg2, arg3)
+}
+
 // UpdateById mocks base method.
 func (m *MockNewsStorer) UpdateById(arg0 context.Context, arg1 uuid.UUID, arg2 *news.Record) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: sweeper.go
+//
+// Generated by this command:
+//
+//	mockgen -source=sweeper.go -destination=mocks/sweeper.go -package=mockshandler
+//
+
+// Package mockshandler is a generated GoMock package.
+package mockshandler
+
+import (
+	context "context"
+	reflect "reflect"
+
+	news "github.com/TommyLearning/go-rest-api-project/internal/news"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSweeperStorer is a mock of SweeperStorer interface.
+type MockSweeperStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockSweeperStorerMockRecorder
+	isgomock struct{}
+}
+
+// MockSweeperStorerMockRecorder is the mock recorder for MockSweeperStorer.
+type MockSweeperStorerMockRecorder struct {
+	mock *MockSweeperStorer
+}
+
+// NewMockSweeperStorer creates a new mock instance.
+func NewMockSweeperStorer(ctrl *gomock.Controller) *MockSweeperStorer {
+	mock := &MockSweeperStorer{ctrl: ctrl}
+	mock.recorder = &MockSweeperStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSweeperStorer) EXPECT() *MockSweeperStorerMockRecorder {
+	return m.recorder
+}
+
+// FindSweepStats mocks base method.
+func (m *MockSweeperStorer) FindSweepStats(arg0 context.Context) (*news.SweepStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSweepStats", arg0)
+	ret0, _ := ret[0].(*news.SweepStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindSweepStats indicates an expected call of FindSweepStats.
+func (mr *MockSweeperStorerMockRecorder) FindSweepStats(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSweepStats", reflect.TypeOf((*MockSweeperStorer)(nil).FindSweepStats), arg0)
+}
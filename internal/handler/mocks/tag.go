@@ -0,0 +1,87 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: tag.go
+//
+// Generated by this command:
+//
+//	mockgen -source=tag.go -destination=mocks/tag.go -package=mockshandler
+//
+
+// Package mockshandler is a generated GoMock package.
+package mockshandler
+
+import (
+	context "context"
+	reflect "reflect"
+
+	news "github.com/TommyLearning/go-rest-api-project/internal/news"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTagStorer is a mock of TagStorer interface.
+type MockTagStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockTagStorerMockRecorder
+	isgomock struct{}
+}
+
+// MockTagStorerMockRecorder is the mock recorder for MockTagStorer.
+type MockTagStorerMockRecorder struct {
+	mock *MockTagStorer
+}
+
+// NewMockTagStorer creates a new mock instance.
+func NewMockTagStorer(ctrl *gomock.Controller) *MockTagStorer {
+	mock := &MockTagStorer{ctrl: ctrl}
+	mock.recorder = &MockTagStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTagStorer) EXPECT() *MockTagStorerMockRecorder {
+	return m.recorder
+}
+
+// FindAll mocks base method.
+func (m *MockTagStorer) FindAll(arg0 context.Context) ([]*news.Tag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAll", arg0)
+	ret0, _ := ret[0].([]*news.Tag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAll indicates an expected call of FindAll.
+func (mr *MockTagStorerMockRecorder) FindAll(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockTagStorer)(nil).FindAll), arg0)
+}
+
+// Merge mocks base method.
+func (m *MockTagStorer) Merge(arg0 context.Context, arg1, arg2 string) (*news.Tag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Merge", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*news.Tag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Merge indicates an expected call of Merge.
+func (mr *MockTagStorerMockRecorder) Merge(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merge", reflect.TypeOf((*MockTagStorer)(nil).Merge), arg0, arg1, arg2)
+}
+
+// Rename mocks base method.
+func (m *MockTagStorer) Rename(arg0 context.Context, arg1 string, arg2 *news.Tag) (*news.Tag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rename", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*news.Tag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rename indicates an expected call of Rename.
+func (mr *MockTagStorerMockRecorder) Rename(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rename", reflect.TypeOf((*MockTagStorer)(nil).Rename), arg0, arg1, arg2)
+}
@@ -0,0 +1,132 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: webhook.go
+//
+// Generated by this command:
+//
+//	mockgen -source=webhook.go -destination=mocks/webhook.go -package=mockshandler
+//
+
+// Package mockshandler is a generated GoMock package.
+package mockshandler
+
+import (
+	context "context"
+	reflect "reflect"
+
+	news "github.com/TommyLearning/go-rest-api-project/internal/news"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockWebhookStorer is a mock of WebhookStorer interface.
+type MockWebhookStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookStorerMockRecorder
+	isgomock struct{}
+}
+
+// MockWebhookStorerMockRecorder is the mock recorder for MockWebhookStorer.
+type MockWebhookStorerMockRecorder struct {
+	mock *MockWebhookStorer
+}
+
+// NewMockWebhookStorer creates a new mock instance.
+func NewMockWebhookStorer(ctrl *gomock.Controller) *MockWebhookStorer {
+	mock := &MockWebhookStorer{ctrl: ctrl}
+	mock.recorder = &MockWebhookStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookStorer) EXPECT() *MockWebhookStorerMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockWebhookStorer) Create(arg0 context.Context, arg1 *news.Webhook) (*news.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(*news.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockWebhookStorerMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWebhookStorer)(nil).Create), arg0, arg1)
+}
+
+// DeleteById mocks base method.
+func (m *MockWebhookStorer) DeleteById(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteById", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteById indicates an expected call of DeleteById.
+func (mr *MockWebhookStorerMockRecorder) DeleteById(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteById", reflect.TypeOf((*MockWebhookStorer)(nil).DeleteById), arg0, arg1)
+}
+
+// FindAll mocks base method.
+func (m *MockWebhookStorer) FindAll(arg0 context.Context) ([]*news.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAll", arg0)
+	ret0, _ := ret[0].([]*news.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAll indicates an expected call of FindAll.
+func (mr *MockWebhookStorerMockRecorder) FindAll(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockWebhookStorer)(nil).FindAll), arg0)
+}
+
+// FindById mocks base method.
+func (m *MockWebhookStorer) FindById(arg0 context.Context, arg1 uuid.UUID) (*news.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindById", arg0, arg1)
+	ret0, _ := ret[0].(*news.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindById indicates an expected call of FindById.
+func (mr *MockWebhookStorerMockRecorder) FindById(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindById", reflect.TypeOf((*MockWebhookStorer)(nil).FindById), arg0, arg1)
+}
+
+// FindDeliveries mocks base method.
+func (m *MockWebhookStorer) FindDeliveries(arg0 context.Context, arg1 uuid.UUID, arg2 int) ([]*news.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDeliveries", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*news.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDeliveries indicates an expected call of FindDeliveries.
+func (mr *MockWebhookStorerMockRecorder) FindDeliveries(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDeliveries", reflect.TypeOf((*MockWebhookStorer)(nil).FindDeliveries), arg0, arg1, arg2)
+}
+
+// Redeliver mocks base method.
+func (m *MockWebhookStorer) Redeliver(arg0 context.Context, arg1 uuid.UUID, arg2 int64) (*news.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redeliver", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*news.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Redeliver indicates an expected call of Redeliver.
+func (mr *MockWebhookStorerMockRecorder) Redeliver(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeliver", reflect.TypeOf((*MockWebhookStorer)(nil).Redeliver), arg0, arg1, arg2)
+}
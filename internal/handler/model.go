@@ -1,11 +1,17 @@
 package handler
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/TommyLearning/go-rest-api-project/internal/audit"
 	"github.com/TommyLearning/go-rest-api-project/internal/news"
 	"github.com/google/uuid"
 )
@@ -13,16 +19,33 @@ import (
 type NewsPostReqBody struct {
 	Id        uuid.UUID `json:"id"`
 	Author    string    `json:"author"`
-	Title     string    `json:"title"`
-	Summary   string    `json:"summary"`
-	CreatedAt string    `json:"created_at"`
-	Content   string    `json:"content"`
-	Source    string    `json:"source"`
-	Tags      []string  `json:"tags"`
+	Title     string    `json:"title" openapi:"required"`
+	Summary   string    `json:"summary" openapi:"required"`
+	CreatedAt string    `json:"created_at" openapi:"required,format=date-time"`
+	Content   string    `json:"content" openapi:"required"`
+	Source    string    `json:"source" openapi:"required,format=uri"`
+	Tags      []string  `json:"tags" openapi:"required"`
+	// Authors are written like in the responses and found by slug, or by
+	// name for the new ones. Author is used when there are none.
+	Authors []*news.Author `json:"authors"`
+	// PublishAt schedules the publication of the news once approved.
+	PublishAt string `json:"publish_at" openapi:"format=date-time"`
+	// EmbargoUntil hides the news from the public until then.
+	EmbargoUntil string `json:"embargo_until" openapi:"format=date-time"`
+	// ExpiresAt hides the news from the public from then on.
+	ExpiresAt string `json:"expires_at" openapi:"format=date-time"`
 }
 
 func (n *NewsPostReqBody) Validate() (record *news.Record, errs error) {
-	if n.Author == "" {
+	var authors []*news.Author
+	for i, a := range n.Authors {
+		if a == nil || strings.TrimSpace(a.Slug) == "" && strings.TrimSpace(a.Name) == "" {
+			errs = errors.Join(errs, fmt.Errorf("author %d has neither slug nor name", i))
+			continue
+		}
+		authors = append(authors, &news.Author{Slug: strings.TrimSpace(a.Slug), Name: strings.TrimSpace(a.Name)})
+	}
+	if n.Author == "" && len(authors) == 0 {
 		errs = errors.Join(errs, fmt.Errorf("author is empty: %s", n.Author))
 	}
 	if n.Title == "" {
@@ -47,25 +70,241 @@ func (n *NewsPostReqBody) Validate() (record *news.Record, errs error) {
 	if err != nil {
 		errs = errors.Join(errs, err)
 	}
-	if len(n.Tags) == 0 {
+	tags := news.NormalizeTags(n.Tags)
+	if len(tags) == 0 {
 		errs = errors.Join(errs, errors.New("tags cannot be empty"))
 	}
+	var publishAt, embargoUntil, expiresAt time.Time
+	if n.PublishAt != "" {
+		if publishAt, err = time.Parse(time.RFC3339, n.PublishAt); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("invalid publish_at: %w", err))
+		}
+	}
+	if n.EmbargoUntil != "" {
+		if embargoUntil, err = time.Parse(time.RFC3339, n.EmbargoUntil); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("invalid embargo_until: %w", err))
+		}
+	}
+	if n.ExpiresAt != "" {
+		if expiresAt, err = time.Parse(time.RFC3339, n.ExpiresAt); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("invalid expires_at: %w", err))
+		} else if !publishAt.IsZero() && !expiresAt.After(publishAt) {
+			errs = errors.Join(errs, fmt.Errorf("expires_at is not after publish_at: %s", n.ExpiresAt))
+		}
+	}
 
 	if errs != nil {
 		return record, errs
 	}
 	return &news.Record{
-		Id:        n.Id,
-		Author:    n.Author,
-		Title:     n.Title,
-		Content:   n.Content,
-		Summary:   n.Summary,
-		CreatedAt: t,
-		Source:    url.String(),
-		Tags:      n.Tags,
+		Id:           n.Id,
+		Author:       n.Author,
+		Authors:      authors,
+		Title:        n.Title,
+		Content:      n.Content,
+		Summary:      n.Summary,
+		CreatedAt:    t,
+		Source:       url.String(),
+		Tags:         tags,
+		PublishAt:    publishAt,
+		EmbargoUntil: embargoUntil,
+		ExpiresAt:    expiresAt,
 	}, nil
 }
 
 type AllNewsResponse struct {
 	News []*news.Record `json:"news"`
+	// NextCursor is the after query parameter of the next page, if any.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// MaxPageLimit bounds the limit query parameter of the news lists.
+const MaxPageLimit = 100
+
+// parsePage sets the page of the filter from the limit and after query
+// parameters. The lists are not paged without a limit.
+func parsePage(r *http.Request, f *news.Filter) error {
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > MaxPageLimit {
+			return fmt.Errorf("limit is not between 1 and %d: %s", MaxPageLimit, l)
+		}
+		// The extra news tells whether there is a next page.
+		f.Limit = limit + 1
+	}
+	if after := r.URL.Query().Get("after"); after != "" {
+		cursor, err := news.ParseCursor(after)
+		if err != nil {
+			return err
+		}
+		f.After = cursor
+	}
+	return nil
+}
+
+// newPage returns the response of the news found with the filter.
+func newPage(f news.Filter, n []*news.Record) AllNewsResponse {
+	if f.Limit == 0 || len(n) < f.Limit {
+		return AllNewsResponse{News: n}
+	}
+	n = n[:f.Limit-1]
+	return AllNewsResponse{News: n, NextCursor: news.CursorOf(n[len(n)-1]).String()}
+}
+
+type AllTagsResponse struct {
+	Tags []*news.Tag `json:"tags"`
+}
+
+type TagPutReqBody struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+type TagMergeReqBody struct {
+	Into string `json:"into" openapi:"required"`
+}
+
+type AuthorReqBody struct {
+	Slug      string `json:"slug"`
+	Name      string `json:"name" openapi:"required"`
+	Bio       string `json:"bio"`
+	AvatarURL string `json:"avatar_url" openapi:"format=uri"`
+	Contact   string `json:"contact"`
+}
+
+func (a *AuthorReqBody) Validate() (author *news.Author, errs error) {
+	if strings.TrimSpace(a.Name) == "" {
+		errs = errors.Join(errs, fmt.Errorf("name is empty: %s", a.Name))
+	} else if news.Slugify(a.Slug) == "" && news.Slugify(a.Name) == "" {
+		errs = errors.Join(errs, fmt.Errorf("name has no letters or digits: %s", a.Name))
+	}
+	if a.AvatarURL != "" {
+		if u, err := url.Parse(a.AvatarURL); err != nil {
+			errs = errors.Join(errs, err)
+		} else if !u.IsAbs() {
+			errs = errors.Join(errs, fmt.Errorf("avatar url is not absolute: %s", a.AvatarURL))
+		}
+	}
+
+	if errs != nil {
+		return author, errs
+	}
+	return &news.Author{
+		Slug:      a.Slug,
+		Name:      strings.TrimSpace(a.Name),
+		Bio:       a.Bio,
+		AvatarURL: a.AvatarURL,
+		Contact:   a.Contact,
+	}, nil
+}
+
+type AllAuthorsResponse struct {
+	Authors []*news.Author `json:"authors"`
+}
+
+type TransitionReqBody struct {
+	Status string `json:"status" openapi:"required"`
+}
+
+type WebhookReqBody struct {
+	URL    string   `json:"url" openapi:"required,format=uri"`
+	Events []string `json:"events" openapi:"required"`
+	// Secret signs the payloads. A random one is generated when empty.
+	Secret string `json:"secret"`
+}
+
+func (wh *WebhookReqBody) Validate() (webhook *news.Webhook, errs error) {
+	if u, err := url.Parse(wh.URL); err != nil {
+		errs = errors.Join(errs, err)
+	} else if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		errs = errors.Join(errs, fmt.Errorf("url is not an absolute http url: %s", wh.URL))
+	}
+	if len(wh.Events) == 0 {
+		errs = errors.Join(errs, errors.New("events cannot be empty"))
+	}
+	var events []news.Event
+	for _, e := range wh.Events {
+		event, err := news.ParseEvent(e)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+
+	if errs != nil {
+		return webhook, errs
+	}
+	return &news.Webhook{
+		URL:    wh.URL,
+		Events: events,
+		Secret: wh.Secret,
+	}, nil
+}
+
+type AllWebhooksResponse struct {
+	Webhooks []*news.Webhook `json:"webhooks"`
+}
+
+type AllDeliveriesResponse struct {
+	Deliveries []*news.Delivery `json:"deliveries"`
+}
+
+type AuditEventsResponse struct {
+	Events []*audit.Event `json:"events"`
+	// NextCursor is the after query parameter of the next page, if any.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Types of the messages exchanged over the news WebSocket.
+const (
+	WSSubscribe    = "subscribe"
+	WSUnsubscribe  = "unsubscribe"
+	WSSubscribed   = "subscribed"
+	WSUnsubscribed = "unsubscribed"
+	WSEvent        = "event"
+	WSError        = "error"
+)
+
+// WSMessage is a message of the news WebSocket. Clients send subscribe and
+// unsubscribe messages with a topic, which are acknowledged or answered with
+// an error. Events are sent along with the subscribed topics they match.
+type WSMessage struct {
+	Type   string          `json:"type"`
+	Topic  string          `json:"topic,omitempty"`
+	Topics []string        `json:"topics,omitempty"`
+	Id     int64           `json:"id,omitempty"`
+	Event  news.Event      `json:"event,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Topics of the news WebSocket: all the news, or the news with a tag, by an
+// author or with an ID, as in "tag:go", "author:jane-doe" or "news:<id>".
+const (
+	TopicAll    = "all"
+	TopicTag    = "tag"
+	TopicAuthor = "author"
+	TopicNews   = "news"
+)
+
+// ParseTopic parses and normalizes a topic.
+func ParseTopic(s string) (string, error) {
+	if s == TopicAll {
+		return s, nil
+	}
+	kind, value, _ := strings.Cut(s, ":")
+	switch kind {
+	case TopicTag, TopicAuthor:
+		if value = news.Slugify(value); value != "" {
+			return kind + ":" + value, nil
+		}
+	case TopicNews:
+		if id, err := uuid.Parse(value); err == nil {
+			return kind + ":" + id.String(), nil
+		}
+	}
+	return "", fmt.Errorf("invalid topic: %q", s)
 }
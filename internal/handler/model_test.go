@@ -96,6 +96,138 @@ func TestNewsPostReqBody_Validate(t *testing.T) {
 				err: "tags cannot be empty",
 			},
 		},
+		{
+			name: "tags normalized away",
+			req: handler.NewsPostReqBody{
+				Author:    "test-author",
+				Title:     "test-title",
+				Content:   "test-content",
+				Summary:   "test-summary",
+				CreatedAt: "2024-04-07T05:13:27+00:00",
+				Source:    "https://google.com",
+				Tags:      []string{" ", "--"},
+			},
+			expectaions: expectaions{
+				err: "tags cannot be empty",
+			},
+		},
+		{
+			name: "tags normalized",
+			req: handler.NewsPostReqBody{
+				Author:    "test-author",
+				Title:     "test-title",
+				Content:   "test-content",
+				Summary:   "test-summary",
+				CreatedAt: "2024-04-07T05:13:27+00:00",
+				Source:    "https://google.com",
+				Tags:      []string{"Go", "go ", "Machine Learning"},
+			},
+			expectaions: expectaions{
+				news: &news.Record{
+					Author:  "test-author",
+					Title:   "test-title",
+					Content: "test-content",
+					Summary: "test-summary",
+					Tags:    []string{"go", "machine-learning"},
+				},
+			},
+		},
+		{
+			name: "multiple bylines",
+			req: handler.NewsPostReqBody{
+				Authors:   []*news.Author{{Name: "Jane Doe"}, {Slug: "john-roe"}},
+				Title:     "test-title",
+				Content:   "test-content",
+				Summary:   "test-summary",
+				CreatedAt: "2024-04-07T05:13:27+00:00",
+				Source:    "https://google.com",
+				Tags:      []string{"tag1"},
+			},
+			expectaions: expectaions{
+				news: &news.Record{
+					Authors: []*news.Author{{Name: "Jane Doe"}, {Slug: "john-roe"}},
+					Title:   "test-title",
+					Content: "test-content",
+					Summary: "test-summary",
+					Tags:    []string{"tag1"},
+				},
+			},
+		},
+		{
+			name: "author without slug nor name",
+			req: handler.NewsPostReqBody{
+				Authors:   []*news.Author{{Name: "Jane Doe"}, {Name: " "}},
+				Title:     "test-title",
+				Content:   "test-content",
+				Summary:   "test-summary",
+				CreatedAt: "2024-04-07T05:13:27+00:00",
+				Source:    "https://google.com",
+				Tags:      []string{"tag1"},
+			},
+			expectaions: expectaions{
+				err: "author 1 has neither slug nor name",
+			},
+		},
+		{
+			name: "publish at invalid",
+			req: handler.NewsPostReqBody{
+				Author:    "test-author",
+				Title:     "test-title",
+				Content:   "test-content",
+				Summary:   "test-summary",
+				CreatedAt: "2024-04-07T05:13:27+00:00",
+				Source:    "https://google.com",
+				Tags:      []string{"tag1"},
+				PublishAt: "tomorrow",
+			},
+			expectaions: expectaions{
+				err: "invalid publish_at",
+			},
+		},
+		{
+			name: "expires before publication",
+			req: handler.NewsPostReqBody{
+				Author:    "test-author",
+				Title:     "test-title",
+				Content:   "test-content",
+				Summary:   "test-summary",
+				CreatedAt: "2024-04-07T05:13:27+00:00",
+				Source:    "https://google.com",
+				Tags:      []string{"tag1"},
+				PublishAt: "2024-04-08T06:00:00Z",
+				ExpiresAt: "2024-04-08T05:00:00Z",
+			},
+			expectaions: expectaions{
+				err: "expires_at is not after publish_at",
+			},
+		},
+		{
+			name: "scheduled under embargo",
+			req: handler.NewsPostReqBody{
+				Author:       "test-author",
+				Title:        "test-title",
+				Content:      "test-content",
+				Summary:      "test-summary",
+				CreatedAt:    "2024-04-07T05:13:27+00:00",
+				Source:       "https://google.com",
+				Tags:         []string{"tag1"},
+				PublishAt:    "2024-04-08T06:00:00Z",
+				EmbargoUntil: "2024-04-08T09:00:00Z",
+				ExpiresAt:    "2024-04-09T06:00:00Z",
+			},
+			expectaions: expectaions{
+				news: &news.Record{
+					Author:       "test-author",
+					Title:        "test-title",
+					Content:      "test-content",
+					Summary:      "test-summary",
+					Tags:         []string{"tag1"},
+					PublishAt:    time.Date(2024, 4, 8, 6, 0, 0, 0, time.UTC),
+					EmbargoUntil: time.Date(2024, 4, 8, 9, 0, 0, 0, time.UTC),
+					ExpiresAt:    time.Date(2024, 4, 9, 6, 0, 0, 0, time.UTC),
+				},
+			},
+		},
 		{
 			name: "validate",
 			req: handler.NewsPostReqBody{
@@ -142,3 +274,132 @@ func TestNewsPostReqBody_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthorReqBody_Validate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		req      handler.AuthorReqBody
+		err      string
+		expected *news.Author
+	}{
+		{
+			name: "name empty",
+			req:  handler.AuthorReqBody{Name: " "},
+			err:  "name is empty",
+		},
+		{
+			name: "name without slug",
+			req:  handler.AuthorReqBody{Name: "!!!"},
+			err:  "name has no letters or digits",
+		},
+		{
+			name: "avatar url relative",
+			req:  handler.AuthorReqBody{Name: "Jane Doe", AvatarURL: "jane.png"},
+			err:  "avatar url is not absolute",
+		},
+		{
+			name: "validate",
+			req:  handler.AuthorReqBody{Name: " Jane Doe ", Slug: "jdoe", Bio: "Reporter", AvatarURL: "https://example.com/jane.png"},
+			expected: &news.Author{
+				Slug:      "jdoe",
+				Name:      "Jane Doe",
+				Bio:       "Reporter",
+				AvatarURL: "https://example.com/jane.png",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			author, err := tc.req.Validate()
+
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, author)
+			}
+		})
+	}
+}
+
+func TestWebhookReqBody_Validate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		req      handler.WebhookReqBody
+		err      string
+		expected *news.Webhook
+	}{
+		{
+			name: "url relative",
+			req:  handler.WebhookReqBody{URL: "/hook", Events: []string{"news.created"}},
+			err:  "url is not an absolute http url",
+		},
+		{
+			name: "url not http",
+			req:  handler.WebhookReqBody{URL: "ftp://example.com/hook", Events: []string{"news.created"}},
+			err:  "url is not an absolute http url",
+		},
+		{
+			name: "events empty",
+			req:  handler.WebhookReqBody{URL: "https://example.com/hook"},
+			err:  "events cannot be empty",
+		},
+		{
+			name: "event unknown",
+			req:  handler.WebhookReqBody{URL: "https://example.com/hook", Events: []string{"news.liked"}},
+			err:  "invalid event",
+		},
+		{
+			name: "validate",
+			req:  handler.WebhookReqBody{URL: "https://example.com/hook", Events: []string{"news.deleted", "news.updated", "news.deleted"}, Secret: "s3cr3t"},
+			expected: &news.Webhook{
+				URL:    "https://example.com/hook",
+				Events: []news.Event{news.EventDeleted, news.EventUpdated},
+				Secret: "s3cr3t",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			webhook, err := tc.req.Validate()
+
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, webhook)
+			}
+		})
+	}
+}
+
+func TestParseTopic(t *testing.T) {
+	testCases := []struct {
+		topic    string
+		err      string
+		expected string
+	}{
+		{topic: "all", expected: "all"},
+		{topic: "tag:Go Lang", expected: "tag:go-lang"},
+		{topic: "author:Jane Doe", expected: "author:jane-doe"},
+		{topic: "news:0B7A6C3E-7D2A-4D4F-9C52-6F3E8A9B1C2D", expected: "news:0b7a6c3e-7d2a-4d4f-9c52-6f3e8a9b1c2d"},
+		{topic: "tag:", err: "invalid topic"},
+		{topic: "news:42", err: "invalid topic"},
+		{topic: "likes:go", err: "invalid topic"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.topic, func(t *testing.T) {
+			topic, err := handler.ParseTopic(tc.topic)
+
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, topic)
+			}
+		})
+	}
+}
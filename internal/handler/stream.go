@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+)
+
+//go:generate mockgen -source=stream.go -destination=mocks/stream.go -package=mockshandler
+
+// DefaultHeartbeat is the interval of the comments keeping idle streams open
+// through proxies.
+const DefaultHeartbeat = 15 * time.Second
+
+type EventSubscriber interface {
+	Subscribe(context.Context, int64) (<-chan *news.OutboxEvent, error)
+}
+
+// StreamNews streams the news events of the tenant of the request as
+// Server-Sent Events, optionally filtered by tag or author slug. Clients
+// resume after the last event they received with the Last-Event-ID header.
+// The stream ends when the client lags too far behind, and clients are
+// expected to reconnect.
+func StreamNews(es EventSubscriber, heartbeat time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("stream news")
+
+		var after int64
+		if id := r.Header.Get("Last-Event-ID"); id != "" {
+			var err error
+			if after, err = strconv.ParseInt(id, 10, 64); err != nil {
+				log.Error("failed to parse last event id", "error", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		tag := news.Slugify(r.URL.Query().Get("tag"))
+		author := news.Slugify(r.URL.Query().Get("author"))
+		tenantId := tenant.FromContext(ctx).Id
+
+		events, err := es.Subscribe(ctx, after)
+		if err != nil {
+			log.Error("failed to subscribe to news events", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			log.Error("failed to flush stream", "error", err)
+			return
+		}
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			// The stream outlasts the write timeout of the server: each
+			// write has until the next heartbeat at least.
+			rc.SetWriteDeadline(time.Now().Add(2 * heartbeat))
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			case e, ok := <-events:
+				if !ok {
+					log.Info("news stream closed")
+					return
+				}
+				if e.TenantId != tenantId || !matches(e, tag, author) {
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Position, e.Event, e.Payload); err != nil {
+					return
+				}
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// matches reports whether the event is about a news with the tag and author
+// slugs. The events of deleted news carry no news, so they always match.
+func matches(e *news.OutboxEvent, tag, author string) bool {
+	if tag == "" && author == "" || e.Event == news.EventDeleted {
+		return true
+	}
+	var payload struct {
+		Data news.Record `json:"data"`
+	}
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		return false
+	}
+	if tag != "" && !slices.Contains(payload.Data.Tags, tag) {
+		return false
+	}
+	if author != "" && !slices.ContainsFunc(payload.Data.Authors, func(a *news.Author) bool { return a.Slug == author }) {
+		return false
+	}
+	return true
+}
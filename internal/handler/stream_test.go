@@ -0,0 +1,118 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	mockshandler "github.com/TommyLearning/go-rest-api-project/internal/handler/mocks"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_StreamNews(t *testing.T) {
+	events := func() <-chan *news.OutboxEvent {
+		ch := make(chan *news.OutboxEvent, 4)
+		ch <- &news.OutboxEvent{Id: 3, Position: 3, Event: news.EventCreated, TenantId: tenant.Default, Payload: json.RawMessage(`{"data":{"Tags":["go"],"authors":[{"slug":"alice"}]}}`)}
+		ch <- &news.OutboxEvent{Id: 4, Position: 4, Event: news.EventUpdated, TenantId: tenant.Default, Payload: json.RawMessage(`{"data":{"Tags":["rust"],"authors":[{"slug":"bob"}]}}`)}
+		ch <- &news.OutboxEvent{Id: 5, Position: 5, Event: news.EventDeleted, TenantId: tenant.Default, Payload: json.RawMessage(`{"data":{"Id":"0b7a6c3e-7d2a-4d4f-9c52-6f3e8a9b1c2d"}}`)}
+		ch <- &news.OutboxEvent{Id: 6, Position: 6, Event: news.EventCreated, TenantId: "acme", Payload: json.RawMessage(`{"data":{"Tags":["go"],"authors":[{"slug":"alice"}]}}`)}
+		close(ch)
+		return ch
+	}
+	created := "id: 3\nevent: news.created\ndata: {\"data\":{\"Tags\":[\"go\"],\"authors\":[{\"slug\":\"alice\"}]}}\n\n"
+	updated := "id: 4\nevent: news.updated\ndata: {\"data\":{\"Tags\":[\"rust\"],\"authors\":[{\"slug\":\"bob\"}]}}\n\n"
+	deleted := "id: 5\nevent: news.deleted\ndata: {\"data\":{\"Id\":\"0b7a6c3e-7d2a-4d4f-9c52-6f3e8a9b1c2d\"}}\n\n"
+
+	testCases := []struct {
+		name           string
+		query          string
+		lastEventId    string
+		setup          func(tb testing.TB) *mockshandler.MockEventSubscriber
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "invalid last event id",
+			lastEventId: "invalid",
+			setup: func(tb testing.TB) *mockshandler.MockEventSubscriber {
+				tb.Helper()
+				return mockshandler.NewMockEventSubscriber(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "subscribe error",
+			setup: func(tb testing.TB) *mockshandler.MockEventSubscriber {
+				tb.Helper()
+				ms := mockshandler.NewMockEventSubscriber(gomock.NewController(t))
+				ms.EXPECT().Subscribe(gomock.Any(), int64(0)).Return(nil, errors.New("db error"))
+				return ms
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:        "all events",
+			lastEventId: "2",
+			setup: func(tb testing.TB) *mockshandler.MockEventSubscriber {
+				tb.Helper()
+				ms := mockshandler.NewMockEventSubscriber(gomock.NewController(t))
+				ms.EXPECT().Subscribe(gomock.Any(), int64(2)).Return(events(), nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   created + updated + deleted,
+		},
+		{
+			name:  "by tag",
+			query: "?tag=Go",
+			setup: func(tb testing.TB) *mockshandler.MockEventSubscriber {
+				tb.Helper()
+				ms := mockshandler.NewMockEventSubscriber(gomock.NewController(t))
+				ms.EXPECT().Subscribe(gomock.Any(), int64(0)).Return(events(), nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   created + deleted,
+		},
+		{
+			name:  "by author",
+			query: "?author=bob",
+			setup: func(tb testing.TB) *mockshandler.MockEventSubscriber {
+				tb.Helper()
+				ms := mockshandler.NewMockEventSubscriber(gomock.NewController(t))
+				ms.EXPECT().Subscribe(gomock.Any(), int64(0)).Return(events(), nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   updated + deleted,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/news/stream"+tc.query, http.NoBody)
+			if tc.lastEventId != "" {
+				r.Header.Set("Last-Event-ID", tc.lastEventId)
+			}
+
+			// Act
+			handler.StreamNews(tc.setup(t), time.Hour)(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			if tc.expectedStatus == http.StatusOK {
+				assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
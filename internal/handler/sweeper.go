@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+)
+
+//go:generate mockgen -source=sweeper.go -destination=mocks/sweeper.go -package=mockshandler
+
+type SweeperStorer interface {
+	FindSweepStats(context.Context) (*news.SweepStats, error)
+}
+
+// GetSweepStats returns the last run and the counts of the expiry sweeper.
+func GetSweepStats(ss SweeperStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get sweep stats")
+		stats, err := ss.FindSweepStats(ctx)
+		if err != nil {
+			log.Error("failed to get sweep stats", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(stats); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
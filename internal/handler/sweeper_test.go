@@ -0,0 +1,66 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	mockshandler "github.com/TommyLearning/go-rest-api-project/internal/handler/mocks"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_GetSweepStats(t *testing.T) {
+	testCases := []struct {
+		name           string
+		setup          func(tb testing.TB) *mockshandler.MockSweeperStorer
+		expectedStatus int
+		expectedStats  *news.SweepStats
+	}{
+		{
+			name: "db error",
+			setup: func(tb testing.TB) *mockshandler.MockSweeperStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockSweeperStorer(gomock.NewController(t))
+				ms.EXPECT().FindSweepStats(gomock.Any()).Return(nil, errors.New("db error"))
+				return ms
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			setup: func(tb testing.TB) *mockshandler.MockSweeperStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockSweeperStorer(gomock.NewController(t))
+				ms.EXPECT().FindSweepStats(gomock.Any()).Return(&news.SweepStats{Runs: 3, LastAction: news.SweepArchive, LastCount: 2, Archived: 5}, nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+			expectedStats:  &news.SweepStats{Runs: 3, LastAction: news.SweepArchive, LastCount: 2, Archived: 5},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+			// Act
+			handler.GetSweepStats(tc.setup(t))(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			if tc.expectedStats != nil {
+				var stats news.SweepStats
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&stats))
+				assert.Equal(t, tc.expectedStats, &stats)
+			}
+		})
+	}
+}
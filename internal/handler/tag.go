@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+)
+
+//go:generate mockgen -source=tag.go -destination=mocks/tag.go -package=mockshandler
+
+type TagStorer interface {
+	FindAll(context.Context) ([]*news.Tag, error)
+	Rename(context.Context, string, *news.Tag) (*news.Tag, error)
+	Merge(context.Context, string, string) (*news.Tag, error)
+}
+
+func GetAllTags(ts TagStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get all tags")
+		t, err := ts.FindAll(ctx)
+		if err != nil {
+			log.Error("failed to get all tags", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(AllTagsResponse{Tags: t}); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func GetTagNews(ns NewsStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get tag news")
+		filter := news.Filter{Tag: news.Slugify(r.PathValue("slug")), Public: true}
+		if err := parsePage(r, &filter); err != nil {
+			log.Error("failed to parse page", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		n, err := ns.FindAll(ctx, filter)
+		if err != nil {
+			log.Error("failed to get tag news", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		page := newPage(filter, n)
+		if notModified(w, r, listETag(page.News), lastModified(page.News...)) {
+			return
+		}
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func RenameTag(ts TagStorer, strict bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("rename tag")
+
+		var reqBody TagPutReqBody
+		if err := decode(r, &reqBody, strict); err != nil {
+			log.Error("failed to decode request body", "error", err)
+			decodeError(w, err)
+			return
+		}
+
+		t, err := ts.Rename(ctx, r.PathValue("slug"), &news.Tag{Slug: reqBody.Slug, Name: reqBody.Name})
+		if err != nil {
+			log.Error("failed to rename tag", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				w.Write([]byte(err.Error()))
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(t); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func MergeTag(ts TagStorer, strict bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("merge tag")
+
+		var reqBody TagMergeReqBody
+		if err := decode(r, &reqBody, strict); err != nil {
+			log.Error("failed to decode request body", "error", err)
+			decodeError(w, err)
+			return
+		}
+		into := news.Slugify(reqBody.Into)
+		if into == "" {
+			log.Error("failed to validate request body", "error", "into is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("into is empty"))
+			return
+		}
+
+		t, err := ts.Merge(ctx, r.PathValue("slug"), into)
+		if err != nil {
+			log.Error("failed to merge tag", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				w.Write([]byte(err.Error()))
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(t); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package handler_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	mockshandler "github.com/TommyLearning/go-rest-api-project/internal/handler/mocks"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_GetAllTags(t *testing.T) {
+	testCases := []struct {
+		name           string
+		setup          func(tb testing.TB) *mockshandler.MockTagStorer
+		expectedStatus int
+	}{
+		{
+			name: "db error",
+			setup: func(tb testing.TB) *mockshandler.MockTagStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockTagStorer(gomock.NewController(t))
+				ms.EXPECT().FindAll(gomock.Any()).Return(nil, errors.New("db error"))
+				return ms
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			setup: func(tb testing.TB) *mockshandler.MockTagStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockTagStorer(gomock.NewController(t))
+				ms.EXPECT().FindAll(gomock.Any()).Return([]*news.Tag{{Slug: "go", Name: "Go", Count: 2}}, nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+			// Act
+			handler.GetAllTags(tc.setup(t))(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_GetTagNews(t *testing.T) {
+	testCases := []struct {
+		name           string
+		setup          func(tb testing.TB) *mockshandler.MockNewsStorer
+		slug           string
+		expectedStatus int
+	}{
+		{
+			name: "db error",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return(nil, errors.New("db error"))
+				return ms
+			},
+			slug:           "go",
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "slug normalized",
+			setup: func(tb testing.TB) *mockshandler.MockNewsStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+				ms.EXPECT().FindAll(gomock.Any(), news.Filter{Tag: "go", Public: true}).Return(nil, nil)
+				return ms
+			},
+			slug:           "Go ",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			r.SetPathValue("slug", tc.slug)
+
+			// Act
+			handler.GetTagNews(tc.setup(t))(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_RenameTag(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           io.Reader
+		setup          func(tb testing.TB) *mockshandler.MockTagStorer
+		expectedStatus int
+	}{
+		{
+			name: "invalid request body json",
+			body: strings.NewReader(`{`),
+			setup: func(tb testing.TB) *mockshandler.MockTagStorer {
+				tb.Helper()
+				return mockshandler.NewMockTagStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "slug taken",
+			body: strings.NewReader(`{"slug": "go", "name": "Go"}`),
+			setup: func(tb testing.TB) *mockshandler.MockTagStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockTagStorer(gomock.NewController(t))
+				ms.EXPECT().Rename(gomock.Any(), "golang", &news.Tag{Slug: "go", Name: "Go"}).
+					Return(nil, news.NewCustomError(errors.New("tag already exists"), http.StatusConflict))
+				return ms
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "success",
+			body: strings.NewReader(`{"slug": "go", "name": "Go"}`),
+			setup: func(tb testing.TB) *mockshandler.MockTagStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockTagStorer(gomock.NewController(t))
+				ms.EXPECT().Rename(gomock.Any(), "golang", gomock.Any()).Return(&news.Tag{Slug: "go", Name: "Go"}, nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPut, "/", tc.body)
+			r.SetPathValue("slug", "golang")
+
+			// Act
+			handler.RenameTag(tc.setup(t), handler.DefaultStrict)(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_MergeTag(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           io.Reader
+		setup          func(tb testing.TB) *mockshandler.MockTagStorer
+		expectedStatus int
+	}{
+		{
+			name: "invalid request body json",
+			body: strings.NewReader(`{`),
+			setup: func(tb testing.TB) *mockshandler.MockTagStorer {
+				tb.Helper()
+				return mockshandler.NewMockTagStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "into empty",
+			body: strings.NewReader(`{"into": " "}`),
+			setup: func(tb testing.TB) *mockshandler.MockTagStorer {
+				tb.Helper()
+				return mockshandler.NewMockTagStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "tag not found",
+			body: strings.NewReader(`{"into": "go"}`),
+			setup: func(tb testing.TB) *mockshandler.MockTagStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockTagStorer(gomock.NewController(t))
+				ms.EXPECT().Merge(gomock.Any(), "golang", "go").Return(nil, news.NewCustomError(errors.New("no rows"), http.StatusNotFound))
+				return ms
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			body: strings.NewReader(`{"into": "Go"}`),
+			setup: func(tb testing.TB) *mockshandler.MockTagStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockTagStorer(gomock.NewController(t))
+				ms.EXPECT().Merge(gomock.Any(), "golang", "go").Return(&news.Tag{Slug: "go", Aliases: []string{"golang"}}, nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", tc.body)
+			r.SetPathValue("slug", "golang")
+
+			// Act
+			handler.MergeTag(tc.setup(t), handler.DefaultStrict)(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
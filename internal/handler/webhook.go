@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -source=webhook.go -destination=mocks/webhook.go -package=mockshandler
+
+// defaultDeliveriesLimit is the number of deliveries listed when the limit
+// query parameter is not given.
+const defaultDeliveriesLimit = 100
+
+type WebhookStorer interface {
+	Create(context.Context, *news.Webhook) (*news.Webhook, error)
+	FindAll(context.Context) ([]*news.Webhook, error)
+	FindById(context.Context, uuid.UUID) (*news.Webhook, error)
+	DeleteById(context.Context, uuid.UUID) error
+	FindDeliveries(context.Context, uuid.UUID, int) ([]*news.Delivery, error)
+	Redeliver(context.Context, uuid.UUID, int64) (*news.Delivery, error)
+}
+
+// PostWebhook subscribes an endpoint to news events. The response is the only
+// one to carry the secret signing the payloads.
+func PostWebhook(ws WebhookStorer, strict bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("post webhook")
+
+		var reqBody WebhookReqBody
+		if err := decode(r, &reqBody, strict); err != nil {
+			log.Error("failed to decode request body", "error", err)
+			decodeError(w, err)
+			return
+		}
+
+		wh, err := reqBody.Validate()
+		if err != nil {
+			log.Error("failed to validate request body", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		wh, err = ws.Create(ctx, wh)
+		if err != nil {
+			log.Error("failed to create webhook", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(wh); err != nil {
+			log.Error("failed to encode response", "error", err)
+			return
+		}
+	}
+}
+
+func GetAllWebhooks(ws WebhookStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get all webhooks")
+		wh, err := ws.FindAll(ctx)
+		if err != nil {
+			log.Error("failed to get all webhooks", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(AllWebhooksResponse{Webhooks: wh}); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func GetWebhookById(ws WebhookStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get webhook by id")
+		webhookUUID, err := uuid.Parse(r.PathValue("webhook_id"))
+		if err != nil {
+			log.Error("failed to parse webhook id", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		wh, err := ws.FindById(ctx, webhookUUID)
+		if err != nil {
+			log.Error("failed to get webhook by id", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(wh); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func DeleteWebhookById(ws WebhookStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("delete webhook by id")
+		webhookUUID, err := uuid.Parse(r.PathValue("webhook_id"))
+		if err != nil {
+			log.Error("failed to parse webhook id", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := ws.DeleteById(ctx, webhookUUID); err != nil {
+			log.Error("failed to delete webhook by id", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetWebhookDeliveries lists the latest deliveries to the webhook, up to the
+// limit query parameter.
+func GetWebhookDeliveries(ws WebhookStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get webhook deliveries")
+		webhookUUID, err := uuid.Parse(r.PathValue("webhook_id"))
+		if err != nil {
+			log.Error("failed to parse webhook id", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		limit := defaultDeliveriesLimit
+		if l := r.URL.Query().Get("limit"); l != "" {
+			if limit, err = strconv.Atoi(l); err != nil || limit <= 0 {
+				log.Error("failed to parse limit", "error", err, "limit", l)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		d, err := ws.FindDeliveries(ctx, webhookUUID, limit)
+		if err != nil {
+			log.Error("failed to get webhook deliveries", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(AllDeliveriesResponse{Deliveries: d}); err != nil {
+			log.Error("failed to encode response", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// RedeliverWebhookDelivery schedules a delivery for a new series of attempts,
+// typically once it is dead.
+func RedeliverWebhookDelivery(ws WebhookStorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("redeliver webhook delivery")
+		webhookUUID, err := uuid.Parse(r.PathValue("webhook_id"))
+		if err != nil {
+			log.Error("failed to parse webhook id", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		deliveryID, err := strconv.ParseInt(r.PathValue("delivery_id"), 10, 64)
+		if err != nil {
+			log.Error("failed to parse delivery id", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		d, err := ws.Redeliver(ctx, webhookUUID, deliveryID)
+		if err != nil {
+			log.Error("failed to redeliver webhook delivery", "error", err)
+			var dbErr *news.CustomError
+			if errors.As(err, &dbErr) {
+				w.WriteHeader(dbErr.HttpStatusCode())
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(d); err != nil {
+			log.Error("failed to encode response", "error", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package handler_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	mockshandler "github.com/TommyLearning/go-rest-api-project/internal/handler/mocks"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_PostWebhook(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           io.Reader
+		setup          func(tb testing.TB) *mockshandler.MockWebhookStorer
+		expectedStatus int
+	}{
+		{
+			name: "invalid request body",
+			body: strings.NewReader(`{`),
+			setup: func(tb testing.TB) *mockshandler.MockWebhookStorer {
+				tb.Helper()
+				return mockshandler.NewMockWebhookStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown event",
+			body: strings.NewReader(`{"url": "https://example.com/hook", "events": ["news.liked"]}`),
+			setup: func(tb testing.TB) *mockshandler.MockWebhookStorer {
+				tb.Helper()
+				return mockshandler.NewMockWebhookStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "db error",
+			body: strings.NewReader(`{"url": "https://example.com/hook", "events": ["news.created"]}`),
+			setup: func(tb testing.TB) *mockshandler.MockWebhookStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockWebhookStorer(gomock.NewController(t))
+				ms.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, errors.New("db error"))
+				return ms
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			body: strings.NewReader(`{"url": "https://example.com/hook", "events": ["news.created", "news.published", "news.created"]}`),
+			setup: func(tb testing.TB) *mockshandler.MockWebhookStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockWebhookStorer(gomock.NewController(t))
+				ms.EXPECT().Create(gomock.Any(), &news.Webhook{
+					URL:    "https://example.com/hook",
+					Events: []news.Event{news.EventCreated, news.EventPublished},
+				}).Return(&news.Webhook{Id: uuid.New(), Secret: "s3cr3t"}, nil)
+				return ms
+			},
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", tc.body)
+
+			// Act
+			handler.PostWebhook(tc.setup(t), handler.DefaultStrict)(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_GetWebhookDeliveries(t *testing.T) {
+	webhookID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		webhookID      string
+		query          string
+		setup          func(tb testing.TB) *mockshandler.MockWebhookStorer
+		expectedStatus int
+	}{
+		{
+			name:      "invalid webhook id",
+			webhookID: "invalid-uuid",
+			setup: func(tb testing.TB) *mockshandler.MockWebhookStorer {
+				tb.Helper()
+				return mockshandler.NewMockWebhookStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "invalid limit",
+			webhookID: webhookID.String(),
+			query:     "?limit=-1",
+			setup: func(tb testing.TB) *mockshandler.MockWebhookStorer {
+				tb.Helper()
+				return mockshandler.NewMockWebhookStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "not found",
+			webhookID: webhookID.String(),
+			setup: func(tb testing.TB) *mockshandler.MockWebhookStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockWebhookStorer(gomock.NewController(t))
+				ms.EXPECT().FindDeliveries(gomock.Any(), webhookID, 100).Return(nil, news.NewCustomError(errors.New("not found"), http.StatusNotFound))
+				return ms
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:      "success",
+			webhookID: webhookID.String(),
+			query:     "?limit=10",
+			setup: func(tb testing.TB) *mockshandler.MockWebhookStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockWebhookStorer(gomock.NewController(t))
+				ms.EXPECT().FindDeliveries(gomock.Any(), webhookID, 10).Return([]*news.Delivery{{Id: 1, Status: news.DeliveryDead}}, nil)
+				return ms
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/"+tc.query, http.NoBody)
+			r.SetPathValue("webhook_id", tc.webhookID)
+
+			// Act
+			handler.GetWebhookDeliveries(tc.setup(t))(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_RedeliverWebhookDelivery(t *testing.T) {
+	webhookID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		deliveryID     string
+		setup          func(tb testing.TB) *mockshandler.MockWebhookStorer
+		expectedStatus int
+	}{
+		{
+			name:       "invalid delivery id",
+			deliveryID: "one",
+			setup: func(tb testing.TB) *mockshandler.MockWebhookStorer {
+				tb.Helper()
+				return mockshandler.NewMockWebhookStorer(gomock.NewController(t))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "not found",
+			deliveryID: "2",
+			setup: func(tb testing.TB) *mockshandler.MockWebhookStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockWebhookStorer(gomock.NewController(t))
+				ms.EXPECT().Redeliver(gomock.Any(), webhookID, int64(2)).Return(nil, news.NewCustomError(errors.New("not found"), http.StatusNotFound))
+				return ms
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:       "success",
+			deliveryID: "1",
+			setup: func(tb testing.TB) *mockshandler.MockWebhookStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockWebhookStorer(gomock.NewController(t))
+				ms.EXPECT().Redeliver(gomock.Any(), webhookID, int64(1)).Return(&news.Delivery{Id: 1, Status: news.DeliveryPending}, nil)
+				return ms
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", http.NoBody)
+			r.SetPathValue("webhook_id", webhookID.String())
+			r.SetPathValue("delivery_id", tc.deliveryID)
+
+			// Act
+			handler.RedeliverWebhookDelivery(tc.setup(t))(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_DeleteWebhookById(t *testing.T) {
+	webhookID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		setup          func(tb testing.TB) *mockshandler.MockWebhookStorer
+		expectedStatus int
+	}{
+		{
+			name: "not found",
+			setup: func(tb testing.TB) *mockshandler.MockWebhookStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockWebhookStorer(gomock.NewController(t))
+				ms.EXPECT().DeleteById(gomock.Any(), webhookID).Return(news.NewCustomError(errors.New("not found"), http.StatusNotFound))
+				return ms
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			setup: func(tb testing.TB) *mockshandler.MockWebhookStorer {
+				tb.Helper()
+				ms := mockshandler.NewMockWebhookStorer(gomock.NewController(t))
+				ms.EXPECT().DeleteById(gomock.Any(), webhookID).Return(nil)
+				return ms
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodDelete, "/", http.NoBody)
+			r.SetPathValue("webhook_id", webhookID.String())
+
+			// Act
+			handler.DeleteWebhookById(tc.setup(t))(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+		})
+	}
+}
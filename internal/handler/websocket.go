@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+const (
+	// DefaultPingInterval is the interval of the pings checking that the
+	// WebSocket clients are still there.
+	DefaultPingInterval = 30 * time.Second
+	// wsWriteTimeout bounds the writes to a client, so that a client that
+	// stops reading ends up lagging behind and dropped.
+	wsWriteTimeout = 10 * time.Second
+	// wsMaxTopics is the maximum number of topics of a connection.
+	wsMaxTopics = 100
+)
+
+// SubscribeNews upgrades the request to a WebSocket over which clients
+// subscribe to topics and receive the events of the news of the tenant of
+// the request matching them. Editors receive the events of all the news,
+// others only the events of the published news and of the deleted ones.
+// Clients that lag too far behind are disconnected with the status 1013 (try
+// again later).
+func SubscribeNews(es EventSubscriber, pingInterval time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("subscribe news")
+
+		editor := auth.Editor(ctx)
+		tenantId := tenant.FromContext(ctx).Id
+
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			log.Error("failed to accept websocket", "error", err)
+			return
+		}
+		defer conn.CloseNow()
+		conn.SetReadLimit(4096)
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		events, err := es.Subscribe(ctx, 0)
+		if err != nil {
+			log.Error("failed to subscribe to news events", "error", err)
+			conn.Close(websocket.StatusInternalError, "failed to subscribe")
+			return
+		}
+
+		var (
+			mu     sync.Mutex
+			topics = map[string]bool{}
+		)
+		go func() {
+			defer cancel()
+			for {
+				_, data, err := conn.Read(ctx)
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				reply := applyWSMessage(topics, data)
+				mu.Unlock()
+				if err := writeWS(ctx, conn, reply); err != nil {
+					return
+				}
+			}
+		}()
+
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				conn.Close(websocket.StatusNormalClosure, "")
+				return
+			case <-ticker.C:
+				pingCtx, cancelPing := context.WithTimeout(ctx, wsWriteTimeout)
+				err := conn.Ping(pingCtx)
+				cancelPing()
+				if err != nil {
+					log.Info("websocket ping failed", "error", err)
+					return
+				}
+			case e, ok := <-events:
+				if !ok {
+					log.Info("news subscription closed")
+					conn.Close(websocket.StatusTryAgainLater, "subscription closed")
+					return
+				}
+				if e.TenantId != tenantId {
+					continue
+				}
+				mu.Lock()
+				msg := eventWSMessage(topics, e, editor)
+				mu.Unlock()
+				if msg == nil {
+					continue
+				}
+				if err := writeWS(ctx, conn, msg); err != nil {
+					log.Info("failed to write websocket event", "error", err)
+					return
+				}
+			}
+		}
+	}
+}
+
+func writeWS(ctx context.Context, conn *websocket.Conn, msg *WSMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, wsWriteTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, conn, msg)
+}
+
+// applyWSMessage applies a subscribe or unsubscribe message to the topics and
+// returns the reply.
+func applyWSMessage(topics map[string]bool, data []byte) *WSMessage {
+	var msg WSMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return &WSMessage{Type: WSError, Error: "invalid message"}
+	}
+	topic, err := ParseTopic(msg.Topic)
+	if err != nil {
+		return &WSMessage{Type: WSError, Topic: msg.Topic, Error: err.Error()}
+	}
+	switch msg.Type {
+	case WSSubscribe:
+		if !topics[topic] && len(topics) >= wsMaxTopics {
+			return &WSMessage{Type: WSError, Topic: topic, Error: "too many topics"}
+		}
+		topics[topic] = true
+		return &WSMessage{Type: WSSubscribed, Topic: topic}
+	case WSUnsubscribe:
+		delete(topics, topic)
+		return &WSMessage{Type: WSUnsubscribed, Topic: topic}
+	default:
+		return &WSMessage{Type: WSError, Topic: topic, Error: fmt.Sprintf("invalid message type: %q", msg.Type)}
+	}
+}
+
+// eventWSMessage returns the message of the event for the topics it matches,
+// or nil if it matches none. The events of deleted news carry no news, so they
+// match the tag and author topics too.
+func eventWSMessage(topics map[string]bool, e *news.OutboxEvent, editor bool) *WSMessage {
+	if len(topics) == 0 {
+		return nil
+	}
+	var payload struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		return nil
+	}
+	var record news.Record
+	if e.Event != news.EventDeleted {
+		if err := json.Unmarshal(payload.Data, &record); err != nil {
+			return nil
+		}
+		if !editor && !record.Public() {
+			return nil
+		}
+	}
+
+	var matched []string
+	for topic := range topics {
+		if topicMatches(topic, e, &record) {
+			matched = append(matched, topic)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+	slices.Sort(matched)
+	return &WSMessage{Type: WSEvent, Topics: matched, Id: e.Position, Event: e.Event, Data: payload.Data}
+}
+
+func topicMatches(topic string, e *news.OutboxEvent, record *news.Record) bool {
+	if topic == TopicAll {
+		return true
+	}
+	kind, value, _ := strings.Cut(topic, ":")
+	switch {
+	case kind == TopicNews:
+		return value == e.NewsId.String()
+	case e.Event == news.EventDeleted:
+		return true
+	case kind == TopicTag:
+		return slices.Contains(record.Tags, value)
+	case kind == TopicAuthor:
+		return slices.ContainsFunc(record.Authors, func(a *news.Author) bool { return a.Slug == value })
+	default:
+		return false
+	}
+}
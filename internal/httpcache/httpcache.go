@@ -0,0 +1,125 @@
+// Package httpcache sets the Cache-Control policies of the routes, for the
+// clients and the CDNs to cache their responses.
+package httpcache
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+)
+
+// Policies are the Cache-Control headers of the routes, by pattern.
+type Policies map[string]string
+
+// ParsePolicies parses policies of the form
+// "GET /news=public, max-age=60; GET /news/{news_id}=public, max-age=300",
+// separated by semicolons since the directives are separated by commas.
+func ParsePolicies(s string) (Policies, error) {
+	p := Policies{}
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern, policy, ok := strings.Cut(entry, "=")
+		pattern, policy = strings.TrimSpace(pattern), strings.TrimSpace(policy)
+		if !ok || pattern == "" || policy == "" {
+			return nil, fmt.Errorf("invalid cache policy entry: %q", entry)
+		}
+		if method, _, _ := strings.Cut(pattern, " "); method != http.MethodGet {
+			return nil, errors.New("invalid cache policy entry: only GET routes can be cached: " + pattern)
+		}
+		p[pattern] = policy
+	}
+	return p, nil
+}
+
+// vary are the request headers the responses can depend on: the credentials,
+// through the principal of the request, and the header naming its tenant.
+var vary = []string{"Authorization", "X-API-Key", tenant.Header}
+
+// Mid sets the Cache-Control policy of the route of the request, as matched
+// by mux, on its successful and 304 Not Modified responses. Responses can
+// depend on the principal and the tenant of the request, so they also vary on
+// its credentials and tenant header: shared caches keep the anonymous
+// responses of each tenant apart.
+func Mid(p Policies, mux *http.ServeMux, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		_, pattern := mux.Handler(r)
+		policy, ok := p[pattern]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&writer{ResponseWriter: w, policy: policy}, r)
+	}
+}
+
+// AddVary adds the request headers to the Vary header of the response,
+// unless they are already there.
+func AddVary(h http.Header, names ...string) {
+	var vary []string
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				vary = append(vary, name)
+			}
+		}
+	}
+	added := false
+	for _, name := range names {
+		if !containsFold(vary, name) {
+			vary = append(vary, name)
+			added = true
+		}
+	}
+	if added {
+		h.Set("Vary", strings.Join(vary, ", "))
+	}
+}
+
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if n == "*" || strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// writer sets the policy on the response once its status is known.
+type writer struct {
+	http.ResponseWriter
+	policy      string
+	wroteHeader bool
+}
+
+func (w *writer) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		h := w.Header()
+		if (status >= 200 && status < 300 || status == http.StatusNotModified) && h.Get("Cache-Control") == "" {
+			h.Set("Cache-Control", w.policy)
+			AddVary(h, vary...)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
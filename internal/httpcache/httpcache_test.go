@@ -0,0 +1,158 @@
+package httpcache_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/httpcache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParsePolicies(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		expected    httpcache.Policies
+		expectedErr string
+	}{
+		{name: "empty", input: "", expected: httpcache.Policies{}},
+		{
+			name:  "policies",
+			input: "GET /news=public, max-age=60; GET /news/{news_id}=public, max-age=300, stale-while-revalidate=30;",
+			expected: httpcache.Policies{
+				"GET /news":           "public, max-age=60",
+				"GET /news/{news_id}": "public, max-age=300, stale-while-revalidate=30",
+			},
+		},
+		{name: "no policy", input: "GET /news", expectedErr: "invalid cache policy entry"},
+		{name: "empty policy", input: "GET /news=", expectedErr: "invalid cache policy entry"},
+		{name: "not a GET route", input: "POST /news=no-store", expectedErr: "only GET routes"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			p, err := httpcache.ParsePolicies(tc.input)
+
+			// Assert
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, p)
+		})
+	}
+}
+
+func Test_Mid(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /news", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("status") == "304" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if r.URL.Query().Get("status") == "500" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Vary", "Accept-Encoding")
+		w.Write([]byte("news"))
+	})
+	mux.HandleFunc("GET /news/{news_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "private")
+		w.Write([]byte("news"))
+	})
+	mux.HandleFunc("GET /tags", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tags"))
+	})
+	mux.HandleFunc("POST /news", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	h := httpcache.Mid(httpcache.Policies{
+		"GET /news":           "public, max-age=60",
+		"GET /news/{news_id}": "public, max-age=300",
+		"POST /news":          "public",
+	}, mux, mux)
+
+	testCases := []struct {
+		name                 string
+		method               string
+		target               string
+		expectedCacheControl string
+		expectedVary         string
+	}{
+		{
+			name:                 "policy",
+			method:               http.MethodGet,
+			target:               "/news",
+			expectedCacheControl: "public, max-age=60",
+			expectedVary:         "Accept-Encoding, Authorization, X-API-Key, X-Tenant-ID",
+		},
+		{
+			name:                 "head",
+			method:               http.MethodHead,
+			target:               "/news",
+			expectedCacheControl: "public, max-age=60",
+			expectedVary:         "Accept-Encoding, Authorization, X-API-Key, X-Tenant-ID",
+		},
+		{
+			name:                 "not modified",
+			method:               http.MethodGet,
+			target:               "/news?status=304",
+			expectedCacheControl: "public, max-age=60",
+			expectedVary:         "Authorization, X-API-Key, X-Tenant-ID",
+		},
+		{
+			name:   "error",
+			method: http.MethodGet,
+			target: "/news?status=500",
+		},
+		{
+			name:                 "set by the handler",
+			method:               http.MethodGet,
+			target:               "/news/1",
+			expectedCacheControl: "private",
+		},
+		{
+			name:   "no policy",
+			method: http.MethodGet,
+			target: "/tags",
+		},
+		{
+			name:   "not a read",
+			method: http.MethodPost,
+			target: "/news",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tc.method, tc.target, http.NoBody)
+
+			// Act
+			h(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedCacheControl, w.Header().Get("Cache-Control"))
+			assert.Equal(t, tc.expectedVary, w.Header().Get("Vary"))
+		})
+	}
+}
+
+func Test_AddVary(t *testing.T) {
+	h := http.Header{"Vary": {"accept-encoding", "Origin"}}
+
+	httpcache.AddVary(h, "Accept-Encoding", "Authorization")
+	httpcache.AddVary(h, "Authorization")
+
+	assert.Equal(t, "accept-encoding, Origin, Authorization", h.Get("Vary"))
+
+	h = http.Header{"Vary": {"*"}}
+	httpcache.AddVary(h, "Authorization")
+	assert.Equal(t, "*", h.Get("Vary"))
+}
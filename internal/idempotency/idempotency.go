@@ -0,0 +1,242 @@
+// Package idempotency makes the retries of writes safe with the
+// Idempotency-Key header.
+//
+// The first request with a key runs and its response is stored, keyed by the
+// key and the principal of the request. Its retries get the stored response
+// back, with an Idempotent-Replayed header, until the key expires. A retry
+// with another request gets a 422 Unprocessable Entity, and a retry while the
+// first request is running waits for its response or gets a 409 Conflict.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+)
+
+// MaxKeyLength is the length of the longest idempotency key.
+const MaxKeyLength = 255
+
+// pollInterval is the interval at which a retry checks whether the request
+// it waits for is done.
+const pollInterval = 50 * time.Millisecond
+
+// Key identifies the requests of a principal with the same idempotency key.
+type Key struct {
+	// Principal is the tenant of the requests and their principal, if any,
+	// as "tenant/principal".
+	Principal string
+	Key       string
+}
+
+// Response is a stored response.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Entry is the request that claimed a key, and its response once it is done.
+type Entry struct {
+	Hash      string
+	Response  *Response
+	ClaimedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Store keeps the entries of the keys.
+type Store interface {
+	// Claim claims the key for the request with the hash at now, until the
+	// entry expires after ttl. Keys whose entry expired, or whose request
+	// is still running after lease, are claimed again. It returns the
+	// entry of a key claimed by another request.
+	Claim(ctx context.Context, k Key, hash string, now time.Time, lease, ttl time.Duration) (found *Entry, claimed bool, err error)
+	// Complete stores the response of the request that claimed the key.
+	Complete(ctx context.Context, k Key, resp *Response) error
+	// Release frees the key, for the request to be retried.
+	Release(ctx context.Context, k Key) error
+	// Sweep deletes the entries expired at now and returns how many it
+	// deleted.
+	Sweep(ctx context.Context, now time.Time) (int64, error)
+}
+
+// Config configures the idempotency keys.
+type Config struct {
+	// TTL is how long the responses are replayed.
+	TTL time.Duration
+	// Wait is how long a retry waits for the running request with the same
+	// key before it gets a conflict.
+	Wait time.Duration
+	// Lease is how long a request holds its key, after which it is assumed
+	// to have crashed.
+	Lease time.Duration
+}
+
+var (
+	ErrKeyTooLong = errors.New("idempotency key is too long")
+	ErrMismatch   = errors.New("idempotency key was used for another request")
+	ErrInProgress = errors.New("request with the idempotency key is in progress")
+)
+
+// Mid runs the POST and PATCH requests with an Idempotency-Key header once,
+// and replays their response to their retries. Responses with a server
+// error are not stored, so that the requests can be retried.
+func Mid(s Store, c Config, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || r.Method != http.MethodPost && r.Method != http.MethodPatch {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > MaxKeyLength {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(ErrKeyTooLong.Error()))
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Error("failed to read request body", "error", err)
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		k := Key{Principal: tenant.FromContext(ctx).Id + "/", Key: key}
+		if p, ok := auth.FromContext(ctx); ok {
+			k.Principal += p.Key()
+		}
+		hash := hashOf(r, body)
+		deadline := time.Now().Add(c.Wait)
+		for {
+			entry, claimed, err := s.Claim(ctx, k, hash, time.Now(), c.Lease, c.TTL)
+			switch {
+			case err != nil:
+				log.Error("failed to claim idempotency key", "error", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			case claimed:
+				run(ctx, s, k, w, r, next)
+				return
+			case entry.Hash != hash:
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write([]byte(ErrMismatch.Error()))
+				return
+			case entry.Response != nil:
+				log.Info("idempotent response replayed", "idempotency_key", key)
+				replay(w, entry.Response)
+				return
+			case time.Now().After(deadline):
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(ErrInProgress.Error()))
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollInterval):
+			}
+		}
+	}
+}
+
+// run serves the request that claimed the key and stores its response.
+func run(ctx context.Context, s Store, k Key, w http.ResponseWriter, r *http.Request, next http.Handler) {
+	log := logger.FromContext(ctx)
+	rec := &recorder{ResponseWriter: w}
+	// Only the headers of next are stored, the ones of the middlewares before
+	// are set again on replays.
+	before := w.Header().Clone()
+	// The key is completed or released even if the client went away, and it
+	// is released when the handler panics.
+	ctx = context.WithoutCancel(ctx)
+	defer func() {
+		if v := recover(); v != nil {
+			if err := s.Release(ctx, k); err != nil {
+				log.Error("failed to release idempotency key", "error", err)
+			}
+			panic(v)
+		}
+	}()
+	next.ServeHTTP(rec, r)
+
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status >= http.StatusInternalServerError {
+		if err := s.Release(ctx, k); err != nil {
+			log.Error("failed to release idempotency key", "error", err)
+		}
+		return
+	}
+	header := http.Header{}
+	for name, values := range rec.Header() {
+		if !slices.Equal(values, before[name]) {
+			header[name] = slices.Clone(values)
+		}
+	}
+	resp := &Response{Status: status, Header: header, Body: rec.body.Bytes()}
+	if err := s.Complete(ctx, k, resp); err != nil {
+		log.Error("failed to store idempotent response", "error", err)
+	}
+}
+
+func replay(w http.ResponseWriter, resp *Response) {
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// hashOf returns the hash of the request: its method, path, query and body.
+func hashOf(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder writes the response through, and records its status and body.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -0,0 +1,302 @@
+package idempotency_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/idempotency"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var config = idempotency.Config{TTL: time.Hour, Wait: 0, Lease: time.Minute}
+
+// creator answers with a new id at each call.
+type creator struct {
+	calls  atomic.Int32
+	status int
+	block  chan struct{}
+}
+
+func (c *creator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := c.calls.Add(1)
+	if c.block != nil {
+		<-c.block
+	}
+	body, _ := io.ReadAll(r.Body)
+	status := c.status
+	if status == 0 {
+		status = http.StatusCreated
+	}
+	w.Header().Set("Location", fmt.Sprintf("/news/%d", n))
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "%d:%s", n, body)
+}
+
+func request(method, key, body, principal string) *http.Request {
+	r := httptest.NewRequest(method, "/news", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set("Idempotency-Key", key)
+	}
+	if principal != "" {
+		r = r.WithContext(auth.CtxWithPrincipal(r.Context(), &auth.Principal{Name: principal}))
+	}
+	return r
+}
+
+func inTenant(r *http.Request, id string) *http.Request {
+	return r.WithContext(tenant.CtxWithTenant(r.Context(), &tenant.Tenant{Id: id}))
+}
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	// A header of a middleware before, set on every response.
+	w.Header().Set("RateLimit-Remaining", "9")
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func Test_Mid(t *testing.T) {
+	testCases := []struct {
+		name           string
+		first          *http.Request
+		retry          *http.Request
+		status         int
+		expectedStatus int
+		expectedBody   string
+		expectedCalls  int32
+		replayed       bool
+	}{
+		{
+			name:           "replay",
+			first:          request(http.MethodPost, "k1", "a", "alice"),
+			retry:          request(http.MethodPost, "k1", "a", "alice"),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "1:a",
+			expectedCalls:  1,
+			replayed:       true,
+		},
+		{
+			name:           "replay client error",
+			first:          request(http.MethodPatch, "k1", "a", ""),
+			retry:          request(http.MethodPatch, "k1", "a", ""),
+			status:         http.StatusBadRequest,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "1:a",
+			expectedCalls:  1,
+			replayed:       true,
+		},
+		{
+			name:           "mismatch",
+			first:          request(http.MethodPost, "k1", "a", "alice"),
+			retry:          request(http.MethodPost, "k1", "b", "alice"),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   idempotency.ErrMismatch.Error(),
+			expectedCalls:  1,
+		},
+		{
+			name:           "server error released",
+			first:          request(http.MethodPost, "k1", "a", "alice"),
+			retry:          request(http.MethodPost, "k1", "a", "alice"),
+			status:         http.StatusServiceUnavailable,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   "2:a",
+			expectedCalls:  2,
+		},
+		{
+			name:           "keys per principal",
+			first:          request(http.MethodPost, "k1", "a", "alice"),
+			retry:          request(http.MethodPost, "k1", "a", "bob"),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "2:a",
+			expectedCalls:  2,
+		},
+		{
+			name:           "keys per tenant",
+			first:          request(http.MethodPost, "k1", "a", "alice"),
+			retry:          inTenant(request(http.MethodPost, "k1", "a", "alice"), "acme"),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "2:a",
+			expectedCalls:  2,
+		},
+		{
+			name:           "other key",
+			first:          request(http.MethodPost, "k1", "a", "alice"),
+			retry:          request(http.MethodPost, "k2", "a", "alice"),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "2:a",
+			expectedCalls:  2,
+		},
+		{
+			name:           "no key",
+			first:          request(http.MethodPost, "", "a", "alice"),
+			retry:          request(http.MethodPost, "", "a", "alice"),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "2:a",
+			expectedCalls:  2,
+		},
+		{
+			name:           "not a write",
+			first:          request(http.MethodDelete, "k1", "", "alice"),
+			retry:          request(http.MethodDelete, "k1", "", "alice"),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "2:",
+			expectedCalls:  2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			next := &creator{status: tc.status}
+			h := idempotency.Mid(idempotency.NewMemoryStore(), config, next)
+			first := serve(h, tc.first)
+
+			// Act
+			w := serve(h, tc.retry)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+			assert.Equal(t, tc.expectedCalls, next.calls.Load())
+			assert.Equal(t, "9", w.Header().Get("RateLimit-Remaining"))
+			if tc.replayed {
+				assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+				assert.Equal(t, first.Header().Get("Location"), w.Header().Get("Location"))
+				assert.Equal(t, first.Body.String(), w.Body.String())
+			} else {
+				assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+			}
+		})
+	}
+}
+
+func Test_Mid_KeyTooLong(t *testing.T) {
+	// Arrange
+	next := &creator{}
+	h := idempotency.Mid(idempotency.NewMemoryStore(), config, next)
+
+	// Act
+	w := serve(h, request(http.MethodPost, strings.Repeat("k", idempotency.MaxKeyLength+1), "a", ""))
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, idempotency.ErrKeyTooLong.Error(), w.Body.String())
+	assert.Zero(t, next.calls.Load())
+}
+
+func Test_Mid_InProgress(t *testing.T) {
+	testCases := []struct {
+		name           string
+		wait           time.Duration
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "conflict",
+			expectedStatus: http.StatusConflict,
+			expectedBody:   idempotency.ErrInProgress.Error(),
+		},
+		{
+			name:           "wait",
+			wait:           10 * time.Second,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "1:a",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			next := &creator{block: make(chan struct{})}
+			c := config
+			c.Wait = tc.wait
+			h := idempotency.Mid(idempotency.NewMemoryStore(), c, next)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				serve(h, request(http.MethodPost, "k1", "a", ""))
+			}()
+			require.Eventually(t, func() bool { return next.calls.Load() == 1 }, time.Second, time.Millisecond)
+			if tc.wait > 0 {
+				time.AfterFunc(100*time.Millisecond, func() { close(next.block) })
+			}
+
+			// Act
+			w := serve(h, request(http.MethodPost, "k1", "a", ""))
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+			if tc.wait == 0 {
+				close(next.block)
+			}
+			<-done
+			assert.Equal(t, int32(1), next.calls.Load())
+		})
+	}
+}
+
+func Test_Mid_Panic(t *testing.T) {
+	// Arrange
+	s := idempotency.NewMemoryStore()
+	h := idempotency.Mid(s, config, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	// Act
+	assert.Panics(t, func() { serve(h, request(http.MethodPost, "k1", "a", "")) })
+
+	// Assert
+	next := &creator{}
+	w := serve(idempotency.Mid(s, config, next), request(http.MethodPost, "k1", "a", ""))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, int32(1), next.calls.Load())
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := idempotency.NewMemoryStore()
+	now := time.Now()
+	k := idempotency.Key{Key: "k1"}
+
+	_, claimed, err := s.Claim(ctx, k, "hash", now, time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// Running past its lease, the request is assumed to have crashed.
+	found, claimed, err := s.Claim(ctx, k, "hash", now.Add(time.Second), time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Equal(t, "hash", found.Hash)
+	_, claimed, err = s.Claim(ctx, k, "hash", now.Add(2*time.Minute), time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// Done, it is replayed until it expires.
+	resp := &idempotency.Response{Status: http.StatusCreated}
+	require.NoError(t, s.Complete(ctx, k, resp))
+	found, claimed, err = s.Claim(ctx, k, "hash", now.Add(time.Hour), time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Equal(t, resp, found.Response)
+
+	swept, err := s.Sweep(ctx, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, swept)
+	swept, err = s.Sweep(ctx, now.Add(3*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), swept)
+	_, claimed, err = s.Claim(ctx, k, "other", now.Add(3*time.Hour), time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+}
@@ -0,0 +1,58 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the entries in memory, so that each replica replays its
+// own responses only.
+type MemoryStore struct {
+	l       sync.Mutex
+	entries map[Key]*Entry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[Key]*Entry{}}
+}
+
+func (s *MemoryStore) Claim(_ context.Context, k Key, hash string, now time.Time, lease, ttl time.Duration) (*Entry, bool, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	if e, ok := s.entries[k]; ok && now.Before(e.ExpiresAt) && (e.Response != nil || now.Before(e.ClaimedAt.Add(lease))) {
+		found := *e
+		return &found, false, nil
+	}
+	s.entries[k] = &Entry{Hash: hash, ClaimedAt: now, ExpiresAt: now.Add(ttl)}
+	return nil, true, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, k Key, resp *Response) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+	if e, ok := s.entries[k]; ok {
+		e.Response = resp
+	}
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, k Key) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+	delete(s.entries, k)
+	return nil
+}
+
+func (s *MemoryStore) Sweep(_ context.Context, now time.Time) (int64, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	var count int64
+	for k, e := range s.entries {
+		if !now.Before(e.ExpiresAt) {
+			delete(s.entries, k)
+			count++
+		}
+	}
+	return count, nil
+}
@@ -3,7 +3,10 @@ package logger_test
 import (
 	"context"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/TommyLearning/go-rest-api-project/internal/logger"
@@ -71,3 +74,45 @@ func Test_FromContext(t *testing.T) {
 		})
 	}
 }
+
+func Test_RequestIDMid(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{name: "request id of the client", header: "3f1c-42", expected: "3f1c-42"},
+		{name: "generated request id"},
+		{name: "invalid request id", header: "bad id\n"},
+		{name: "request id too long", header: strings.Repeat("a", 129)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			var got string
+			h := logger.RequestIDMid(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = logger.RequestID(r.Context())
+			}))
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/news", http.NoBody)
+			if tc.header != "" {
+				r.Header.Set(logger.RequestIDHeader, tc.header)
+			}
+
+			// Act
+			h(w, r)
+
+			// Assert
+			if got == "" || got != w.Header().Get(logger.RequestIDHeader) {
+				t.Errorf("expected the request id %q in the response, got %q", got, w.Header().Get(logger.RequestIDHeader))
+			}
+			if tc.expected != "" && got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+			if tc.expected == "" && got == tc.header {
+				t.Errorf("expected a generated request id, got %q", got)
+			}
+		})
+	}
+}
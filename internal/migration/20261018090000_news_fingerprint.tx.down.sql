@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS news_cluster_id_idx;
+
+ALTER TABLE news
+  DROP COLUMN IF EXISTS cluster_id,
+  DROP COLUMN IF EXISTS fingerprint;
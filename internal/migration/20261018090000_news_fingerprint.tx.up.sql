@@ -0,0 +1,5 @@
+ALTER TABLE news
+  ADD COLUMN IF NOT EXISTS fingerprint BIGINT,
+  ADD COLUMN IF NOT EXISTS cluster_id UUID;
+
+CREATE INDEX IF NOT EXISTS news_cluster_id_idx ON news (cluster_id);
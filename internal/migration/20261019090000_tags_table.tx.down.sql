@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS news_tags_idx;
+
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS tags (
+  slug TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  aliases TEXT[] NOT NULL DEFAULT '{}',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS tags_aliases_idx ON tags USING GIN (aliases);
+CREATE INDEX IF NOT EXISTS news_tags_idx ON news USING GIN (tags);
+
+-- Normalize the existing free-form tags into slugs, dropping duplicates.
+UPDATE news SET tags = ARRAY(
+  SELECT slug
+  FROM unnest(tags) WITH ORDINALITY AS u(tag, i),
+  LATERAL (SELECT trim(BOTH '-' FROM regexp_replace(lower(tag), '[^[:alnum:]]+', '-', 'g')) AS slug) AS s
+  WHERE slug <> ''
+  GROUP BY slug
+  ORDER BY min(i)
+);
+
+INSERT INTO tags (slug, name)
+SELECT DISTINCT tag, tag FROM news, unnest(news.tags) AS tag
+ON CONFLICT (slug) DO NOTHING;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS news_authors;
+
+DROP TABLE IF EXISTS authors;
@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS authors (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  slug TEXT NOT NULL UNIQUE,
+  name TEXT NOT NULL,
+  bio TEXT NOT NULL DEFAULT '',
+  avatar_url TEXT NOT NULL DEFAULT '',
+  contact TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS news_authors (
+  news_id UUID NOT NULL REFERENCES news (id) ON DELETE CASCADE,
+  author_id UUID NOT NULL REFERENCES authors (id) ON DELETE RESTRICT,
+  position INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (news_id, author_id)
+);
+
+CREATE INDEX IF NOT EXISTS news_authors_author_id_idx ON news_authors (author_id);
+
+-- Backfill the authors from the distinct author names, splitting the bylines
+-- on their commas like news.AuthorsOf, and link every news to its authors in
+-- the order of its byline.
+INSERT INTO authors (slug, name)
+SELECT DISTINCT ON (slug) slug, name
+FROM news,
+  LATERAL unnest(string_to_array(author, ',')) AS a(name_in_byline),
+  LATERAL (SELECT trim(name_in_byline) AS name) AS n,
+  LATERAL (SELECT trim(BOTH '-' FROM regexp_replace(lower(name), '[^[:alnum:]]+', '-', 'g')) AS slug) AS s
+WHERE slug <> ''
+ORDER BY slug, created_at
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO news_authors (news_id, author_id, position)
+SELECT DISTINCT ON (news.id, authors.id) news.id, authors.id, a.position - 1
+FROM news,
+  LATERAL unnest(string_to_array(news.author, ',')) WITH ORDINALITY AS a(name_in_byline, position)
+  JOIN authors ON authors.slug = trim(BOTH '-' FROM regexp_replace(lower(trim(a.name_in_byline)), '[^[:alnum:]]+', '-', 'g'))
+ORDER BY news.id, authors.id, a.position
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS news_transitions;
+
+DROP INDEX IF EXISTS news_status_idx;
+
+ALTER TABLE news
+  DROP COLUMN IF EXISTS published_at,
+  DROP COLUMN IF EXISTS status_changed_by,
+  DROP COLUMN IF EXISTS status_changed_at,
+  DROP COLUMN IF EXISTS status;
@@ -0,0 +1,25 @@
+-- Existing news were live the moment they were created, so they start out
+-- published. New news start out as drafts.
+ALTER TABLE news
+  ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'in_review', 'approved', 'published', 'archived')),
+  ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP WITH TIME ZONE,
+  ADD COLUMN IF NOT EXISTS status_changed_by TEXT NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS published_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE news ALTER COLUMN status SET DEFAULT 'draft';
+
+UPDATE news SET published_at = created_at WHERE status = 'published';
+
+CREATE INDEX IF NOT EXISTS news_status_idx ON news (status);
+
+CREATE TABLE IF NOT EXISTS news_transitions (
+  id BIGSERIAL PRIMARY KEY,
+  news_id UUID NOT NULL REFERENCES news (id) ON DELETE CASCADE,
+  from_status TEXT NOT NULL,
+  to_status TEXT NOT NULL,
+  actor TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS news_transitions_news_id_idx ON news_transitions (news_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS news_publish_at_idx;
+
+ALTER TABLE news
+  DROP COLUMN IF EXISTS embargo_until,
+  DROP COLUMN IF EXISTS publish_at;
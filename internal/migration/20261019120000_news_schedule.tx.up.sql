@@ -0,0 +1,6 @@
+ALTER TABLE news
+  ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP WITH TIME ZONE,
+  ADD COLUMN IF NOT EXISTS embargo_until TIMESTAMP WITH TIME ZONE;
+
+-- The scheduler only looks at approved news that are due.
+CREATE INDEX IF NOT EXISTS news_publish_at_idx ON news (publish_at) WHERE status = 'approved';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS sweep_stats;
+
+DROP INDEX IF EXISTS news_expires_at_idx;
+
+ALTER TABLE news DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,16 @@
+ALTER TABLE news ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS news_expires_at_idx ON news (expires_at) WHERE deleted_at IS NULL;
+
+-- A single row aggregating the runs of the expiry sweeper of every replica.
+CREATE TABLE IF NOT EXISTS sweep_stats (
+  name TEXT PRIMARY KEY,
+  runs BIGINT NOT NULL DEFAULT 0,
+  last_action TEXT NOT NULL DEFAULT '',
+  last_started_at TIMESTAMP WITH TIME ZONE,
+  last_finished_at TIMESTAMP WITH TIME ZONE,
+  last_count INT NOT NULL DEFAULT 0,
+  last_error TEXT NOT NULL DEFAULT '',
+  archived BIGINT NOT NULL DEFAULT 0,
+  deleted BIGINT NOT NULL DEFAULT 0
+);
@@ -0,0 +1,10 @@
+-- Token buckets of the rate limits, shared by the replicas. Full buckets are
+-- swept, since they are the same as no bucket.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limits (
+  key TEXT PRIMARY KEY,
+  tokens DOUBLE PRECISION NOT NULL,
+  allowed BOOLEAN NOT NULL,
+  per_second DOUBLE PRECISION NOT NULL,
+  burst INT NOT NULL,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
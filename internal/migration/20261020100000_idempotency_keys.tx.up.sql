@@ -0,0 +1,15 @@
+-- Requests with an Idempotency-Key header, and their response once they are
+-- done, replayed to the retries until they expire.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  principal TEXT NOT NULL DEFAULT '',
+  key TEXT NOT NULL,
+  request_hash TEXT NOT NULL,
+  status INT,
+  headers JSONB,
+  body BYTEA,
+  claimed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (principal, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
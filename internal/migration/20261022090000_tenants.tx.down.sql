@@ -0,0 +1,10 @@
+DROP POLICY IF EXISTS news_tenant_isolation ON news;
+ALTER TABLE news NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE news DISABLE ROW LEVEL SECURITY;
+
+DROP INDEX IF EXISTS audit_events_tenant_id_idx;
+DROP INDEX IF EXISTS news_tenant_id_idx;
+
+ALTER TABLE audit_events DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE outbox DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE news DROP COLUMN IF EXISTS tenant_id;
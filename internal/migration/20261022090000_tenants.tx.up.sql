@@ -0,0 +1,20 @@
+-- Several publications hosted on one deployment: the news, their events and
+-- the audit log belong to a tenant, the news written before to the default
+-- one.
+ALTER TABLE news ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS news_tenant_id_idx ON news (tenant_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_events_tenant_id_idx ON audit_events (tenant_id, id);
+
+-- Second line of defense behind the scoping of the queries: the transactions
+-- only see and write the news of the tenant of their app.tenant_id setting,
+-- or of every tenant when it is '*', and none when it is not set. It applies
+-- to the owner of the table too, but not to superusers.
+ALTER TABLE news ENABLE ROW LEVEL SECURITY;
+ALTER TABLE news FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY news_tenant_isolation ON news
+  USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+  WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS outbox_delivered_at_idx;
+
+ALTER TABLE outbox
+  DROP COLUMN IF EXISTS locked_until;
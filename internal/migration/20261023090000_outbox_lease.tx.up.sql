@@ -0,0 +1,6 @@
+-- Events are claimed for a lease while they are relayed outside of any
+-- transaction, and swept once delivered for long enough.
+ALTER TABLE outbox
+  ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS outbox_delivered_at_idx ON outbox (delivered_at) WHERE delivered_at IS NOT NULL;
@@ -0,0 +1,2 @@
+-- The authors of the bylines are kept: they are the ones the news written
+-- since are linked to.
@@ -0,0 +1,45 @@
+-- The authors backfill used to take each byline for one author, so that the
+-- news written by several authors before the authors table are linked to a
+-- single author named after the whole byline, unlike the news written since.
+-- Link them to each of the authors of their byline instead, and drop the
+-- authors of the whole bylines left untouched and unlinked.
+SELECT set_config('app.tenant_id', '*', true);
+
+CREATE TEMPORARY TABLE byline_authors ON COMMIT DROP AS
+SELECT news.id AS news_id, authors.id AS author_id
+FROM news
+JOIN news_authors ON news_authors.news_id = news.id
+JOIN authors ON authors.id = news_authors.author_id
+WHERE news.author LIKE '%,%'
+  AND authors.slug = trim(BOTH '-' FROM regexp_replace(lower(news.author), '[^[:alnum:]]+', '-', 'g'));
+
+INSERT INTO authors (slug, name)
+SELECT DISTINCT ON (slug) slug, name
+FROM news
+  JOIN byline_authors ON byline_authors.news_id = news.id,
+  LATERAL unnest(string_to_array(news.author, ',')) AS a(name_in_byline),
+  LATERAL (SELECT trim(name_in_byline) AS name) AS n,
+  LATERAL (SELECT trim(BOTH '-' FROM regexp_replace(lower(name), '[^[:alnum:]]+', '-', 'g')) AS slug) AS s
+WHERE slug <> ''
+ORDER BY slug, news.created_at
+ON CONFLICT (slug) DO NOTHING;
+
+DELETE FROM news_authors
+USING byline_authors
+WHERE news_authors.news_id = byline_authors.news_id
+  AND news_authors.author_id = byline_authors.author_id;
+
+INSERT INTO news_authors (news_id, author_id, position)
+SELECT DISTINCT ON (news.id, authors.id) news.id, authors.id, a.position - 1
+FROM news
+  JOIN byline_authors ON byline_authors.news_id = news.id,
+  LATERAL unnest(string_to_array(news.author, ',')) WITH ORDINALITY AS a(name_in_byline, position)
+  JOIN authors ON authors.slug = trim(BOTH '-' FROM regexp_replace(lower(trim(a.name_in_byline)), '[^[:alnum:]]+', '-', 'g'))
+ORDER BY news.id, authors.id, a.position
+ON CONFLICT DO NOTHING;
+
+DELETE FROM authors
+USING byline_authors
+WHERE authors.id = byline_authors.author_id
+  AND authors.bio = '' AND authors.avatar_url = '' AND authors.contact = ''
+  AND NOT EXISTS (SELECT FROM news_authors WHERE news_authors.author_id = authors.id);
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS outbox_position ON outbox;
+DROP FUNCTION IF EXISTS outbox_position();
+
+DROP INDEX IF EXISTS outbox_position_idx;
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS position;
+
+DROP SEQUENCE IF EXISTS outbox_position_seq;
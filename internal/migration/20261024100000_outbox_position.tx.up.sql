@@ -0,0 +1,29 @@
+-- The streams resume after the position of the last event they sent. The IDs
+-- of the events are taken as they are inserted, so that an event can commit
+-- after events of higher IDs were streamed, and be skipped by the clients
+-- resuming after them. The positions are taken as the transactions commit,
+-- one transaction at a time, in the order they commit.
+CREATE SEQUENCE IF NOT EXISTS outbox_position_seq;
+
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS position BIGINT;
+
+-- The events recorded before keep their ID, which the clients resume after.
+UPDATE outbox SET position = id WHERE position IS NULL;
+SELECT setval('outbox_position_seq', max(id)) FROM outbox HAVING max(id) IS NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS outbox_position_idx ON outbox (position);
+
+CREATE OR REPLACE FUNCTION outbox_position() RETURNS trigger AS $$
+BEGIN
+  -- Held until the transaction commits, after the positions of the events
+  -- of the transactions that commit before are taken.
+  PERFORM pg_advisory_xact_lock(hashtext('outbox_position'));
+  UPDATE outbox SET position = nextval('outbox_position_seq') WHERE id = NEW.id;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER outbox_position
+  AFTER INSERT ON outbox
+  DEFERRABLE INITIALLY DEFERRED
+  FOR EACH ROW EXECUTE FUNCTION outbox_position();
@@ -0,0 +1,22 @@
+-- The webhooks belong to a tenant and receive the events of its news only.
+-- The webhooks subscribed before the tenants, like their news, belong to the
+-- default one.
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+UPDATE webhook_deliveries AS d SET tenant_id = w.tenant_id FROM webhooks AS w WHERE w.id = d.webhook_id;
+
+CREATE INDEX IF NOT EXISTS webhooks_tenant_id_idx ON webhooks (tenant_id, created_at);
+
+-- The same second line of defense as the news.
+ALTER TABLE webhooks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhooks FORCE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY webhooks_tenant_isolation ON webhooks
+  USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+  WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+CREATE POLICY webhook_deliveries_tenant_isolation ON webhook_deliveries
+  USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+  WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
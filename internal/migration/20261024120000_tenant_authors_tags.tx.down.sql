@@ -0,0 +1,33 @@
+DROP POLICY IF EXISTS tags_tenant_isolation ON tags;
+DROP POLICY IF EXISTS news_authors_tenant_isolation ON news_authors;
+DROP POLICY IF EXISTS authors_tenant_isolation ON authors;
+ALTER TABLE tags NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE tags DISABLE ROW LEVEL SECURITY;
+ALTER TABLE news_authors NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE news_authors DISABLE ROW LEVEL SECURITY;
+ALTER TABLE authors NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE authors DISABLE ROW LEVEL SECURITY;
+
+-- Keep one author and one tag per slug, the one of the first tenant.
+DELETE FROM tags USING tags AS kept
+WHERE kept.slug = tags.slug AND kept.tenant_id < tags.tenant_id;
+
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_pkey;
+ALTER TABLE tags ADD PRIMARY KEY (slug);
+
+UPDATE news_authors SET author_id = kept.id
+FROM authors AS original, authors AS kept
+WHERE original.id = news_authors.author_id
+  AND kept.slug = original.slug
+  AND kept.tenant_id = (SELECT min(tenant_id) FROM authors WHERE slug = original.slug)
+  AND kept.id <> original.id;
+
+DELETE FROM authors USING authors AS kept
+WHERE kept.slug = authors.slug AND kept.tenant_id < authors.tenant_id;
+
+ALTER TABLE authors DROP CONSTRAINT IF EXISTS authors_tenant_id_slug_key;
+ALTER TABLE authors ADD CONSTRAINT authors_slug_key UNIQUE (slug);
+
+ALTER TABLE tags DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE news_authors DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE authors DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,56 @@
+-- The authors and the tags belong to a tenant, like their news, so that each
+-- publication manages its own. The authors and tags shared before are copied
+-- into the other tenants of the news using them, and the originals stay in the
+-- default one.
+SELECT set_config('app.tenant_id', '*', true);
+
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE news_authors ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE tags ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+UPDATE news_authors SET tenant_id = news.tenant_id FROM news WHERE news.id = news_authors.news_id;
+
+ALTER TABLE authors DROP CONSTRAINT IF EXISTS authors_slug_key;
+ALTER TABLE authors ADD CONSTRAINT authors_tenant_id_slug_key UNIQUE (tenant_id, slug);
+
+INSERT INTO authors (tenant_id, slug, name, bio, avatar_url, contact, created_at, updated_at)
+SELECT DISTINCT news_authors.tenant_id, authors.slug, authors.name, authors.bio, authors.avatar_url, authors.contact, authors.created_at, authors.updated_at
+FROM news_authors
+JOIN authors ON authors.id = news_authors.author_id
+WHERE news_authors.tenant_id <> authors.tenant_id
+ON CONFLICT (tenant_id, slug) DO NOTHING;
+
+UPDATE news_authors SET author_id = copy.id
+FROM authors AS original, authors AS copy
+WHERE original.id = news_authors.author_id
+  AND original.tenant_id <> news_authors.tenant_id
+  AND copy.tenant_id = news_authors.tenant_id
+  AND copy.slug = original.slug;
+
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_pkey;
+ALTER TABLE tags ADD PRIMARY KEY (tenant_id, slug);
+
+INSERT INTO tags (tenant_id, slug, name, aliases, created_at, updated_at)
+SELECT DISTINCT news.tenant_id, tags.slug, tags.name, tags.aliases, tags.created_at, tags.updated_at
+FROM news
+JOIN tags ON tags.slug = ANY(news.tags)
+WHERE news.tenant_id <> tags.tenant_id
+ON CONFLICT (tenant_id, slug) DO NOTHING;
+
+-- The same second line of defense as the news.
+ALTER TABLE authors ENABLE ROW LEVEL SECURITY;
+ALTER TABLE authors FORCE ROW LEVEL SECURITY;
+ALTER TABLE news_authors ENABLE ROW LEVEL SECURITY;
+ALTER TABLE news_authors FORCE ROW LEVEL SECURITY;
+ALTER TABLE tags ENABLE ROW LEVEL SECURITY;
+ALTER TABLE tags FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY authors_tenant_isolation ON authors
+  USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+  WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+CREATE POLICY news_authors_tenant_isolation ON news_authors
+  USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+  WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+CREATE POLICY tags_tenant_isolation ON tags
+  USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+  WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
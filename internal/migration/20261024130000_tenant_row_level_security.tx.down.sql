@@ -0,0 +1,15 @@
+DROP POLICY IF EXISTS audit_events_tenant_isolation ON audit_events;
+DROP POLICY IF EXISTS outbox_tenant_isolation ON outbox;
+DROP POLICY IF EXISTS idempotency_keys_tenant_isolation ON idempotency_keys;
+DROP POLICY IF EXISTS news_transitions_tenant_isolation ON news_transitions;
+ALTER TABLE audit_events NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE audit_events DISABLE ROW LEVEL SECURITY;
+ALTER TABLE outbox NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE outbox DISABLE ROW LEVEL SECURITY;
+ALTER TABLE idempotency_keys NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE idempotency_keys DISABLE ROW LEVEL SECURITY;
+ALTER TABLE news_transitions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE news_transitions DISABLE ROW LEVEL SECURITY;
+
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE news_transitions DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,34 @@
+-- The transitions of the news and the idempotency keys belong to a tenant
+-- too, and every table of the tenants gets the same second line of defense as
+-- the news. The rate limits and the sweep stats stay shared by the tenants.
+SELECT set_config('app.tenant_id', '*', true);
+
+ALTER TABLE news_transitions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+UPDATE news_transitions SET tenant_id = news.tenant_id FROM news WHERE news.id = news_transitions.news_id;
+
+-- The principals of the keys start with the tenant of their requests.
+UPDATE idempotency_keys SET tenant_id = split_part(principal, '/', 1) WHERE principal LIKE '%/%';
+
+ALTER TABLE news_transitions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE news_transitions FORCE ROW LEVEL SECURITY;
+ALTER TABLE idempotency_keys ENABLE ROW LEVEL SECURITY;
+ALTER TABLE idempotency_keys FORCE ROW LEVEL SECURITY;
+ALTER TABLE outbox ENABLE ROW LEVEL SECURITY;
+ALTER TABLE outbox FORCE ROW LEVEL SECURITY;
+ALTER TABLE audit_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_events FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY news_transitions_tenant_isolation ON news_transitions
+  USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+  WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+CREATE POLICY idempotency_keys_tenant_isolation ON idempotency_keys
+  USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+  WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+CREATE POLICY outbox_tenant_isolation ON outbox
+  USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+  WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+CREATE POLICY audit_events_tenant_isolation ON audit_events
+  USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+  WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
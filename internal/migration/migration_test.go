@@ -0,0 +1,130 @@
+package migration_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/migration"
+	"github.com/TommyLearning/go-rest-api-project/internal/postgres"
+	"github.com/docker/go-connections/nat"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	pgtc "github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+)
+
+var db *bun.DB
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	ctr, err := pgtc.Run(
+		ctx,
+		"postgres:16-alpine",
+		pgtc.WithDatabase("postgres"),
+		pgtc.WithUsername("postgres"),
+		pgtc.WithPassword("postgres"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second),
+		),
+	)
+	if err != nil {
+		panic(err)
+	}
+	p, err := ctr.MappedPort(ctx, nat.Port("5432/tcp"))
+	if err != nil {
+		panic(err)
+	}
+	db, err = postgres.NewDB(&postgres.Config{
+		Host:     "localhost",
+		DBName:   "postgres",
+		User:     "postgres",
+		Password: "postgres",
+		Port:     p.Port(),
+		SSLMode:  "disable",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	if err := db.Close(); err != nil {
+		panic(err)
+	}
+	if err := ctr.Terminate(ctx); err != nil {
+		panic(err)
+	}
+	os.Exit(code)
+}
+
+// migrateTo applies the migrations up to the one of the given name, included,
+// or all of them if name is empty.
+func migrateTo(tb testing.TB, name string) {
+	tb.Helper()
+	ctx := context.Background()
+	migrations := migrate.NewMigrations()
+	for _, m := range migration.New().Sorted() {
+		if name == "" || m.Name <= name {
+			migrations.Add(m)
+		}
+	}
+	m := migrate.NewMigrator(db, migrations, migrate.WithMarkAppliedOnSuccess(true))
+	require.NoError(tb, m.Init(ctx))
+	_, err := m.Migrate(ctx)
+	require.NoError(tb, err)
+}
+
+func authorsOf(tb testing.TB, newsId uuid.UUID) []string {
+	tb.Helper()
+	var slugs []string
+	err := db.NewRaw(`SELECT authors.slug FROM news_authors
+		JOIN authors ON authors.id = news_authors.author_id
+		WHERE news_authors.news_id = ? ORDER BY news_authors.position`, newsId).Scan(context.Background(), &slugs)
+	require.NoError(tb, err)
+	return slugs
+}
+
+func TestMigrations_AuthorBylines(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	migrateTo(t, "20261019090000")
+	insertNews := func(author string) uuid.UUID {
+		var id uuid.UUID
+		err := db.NewRaw(`INSERT INTO news (author, title, summary, content, source, tags)
+			VALUES (?, 'title', 'summary', 'content', 'https://example.com', '{}') RETURNING id`, author).Scan(ctx, &id)
+		require.NoError(t, err)
+		return id
+	}
+	several := insertNews("Alice Smith, Bob Jones")
+	single := insertNews("Bob Jones")
+	// News backfilled before the bylines were split are linked to an author
+	// named after the whole byline.
+	unsplit := insertNews("Carol White, Dan Brown")
+	migrateTo(t, "20261019100000")
+	_, err := db.ExecContext(ctx, "DELETE FROM news_authors WHERE news_id = ?", unsplit)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `WITH author AS (
+			INSERT INTO authors (slug, name) VALUES ('carol-white-dan-brown', 'Carol White, Dan Brown') RETURNING id
+		)
+		INSERT INTO news_authors (news_id, author_id, position) SELECT ?, id, 0 FROM author`, unsplit)
+	require.NoError(t, err)
+
+	// Act
+	migrateTo(t, "")
+
+	// Assert
+	assert.Equal(t, []string{"alice-smith", "bob-jones"}, authorsOf(t, several))
+	assert.Equal(t, []string{"bob-jones"}, authorsOf(t, single))
+	assert.Equal(t, []string{"carol-white", "dan-brown"}, authorsOf(t, unsplit))
+	var slugs []string
+	require.NoError(t, db.NewRaw("SELECT slug FROM authors ORDER BY slug").Scan(ctx, &slugs))
+	assert.Equal(t, []string{"alice-smith", "bob-jones", "carol-white", "dan-brown"}, slugs)
+}
@@ -0,0 +1,160 @@
+package news
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/audit"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// AuditEvent is the row of an audit event. The table only accepts inserts.
+type AuditEvent struct {
+	bun.BaseModel `bun:"table:audit_events,alias:audit_event"`
+
+	Id         int64     `bun:"id,pk,autoincrement"`
+	TenantId   string    `bun:"tenant_id,notnull"`
+	OccurredAt time.Time `bun:"occurred_at,notnull"`
+	Actor      string    `bun:"actor,notnull"`
+	AuthMethod string    `bun:"auth_method,notnull"`
+	IP         string    `bun:"ip,notnull"`
+	RequestId  string    `bun:"request_id,notnull"`
+	Method     string    `bun:"method,notnull"`
+	Route      string    `bun:"route,notnull"`
+	Path       string    `bun:"path,notnull"`
+	NewsId     uuid.UUID `bun:"news_id,type:uuid,nullzero"`
+	Status     int       `bun:"status,notnull"`
+	BeforeHash string    `bun:"before_hash,notnull"`
+	AfterHash  string    `bun:"after_hash,notnull"`
+}
+
+// AuditStore keeps the audit events in the database.
+type AuditStore struct {
+	db bun.IDB
+}
+
+func NewAuditStore(db bun.IDB) *AuditStore {
+	return &AuditStore{
+		db: db,
+	}
+}
+
+func (s AuditStore) Append(ctx context.Context, e *audit.Event) error {
+	row := &AuditEvent{
+		TenantId:   e.TenantId,
+		OccurredAt: e.OccurredAt,
+		Actor:      e.Actor,
+		AuthMethod: e.AuthMethod,
+		IP:         e.IP,
+		RequestId:  e.RequestId,
+		Method:     e.Method,
+		Route:      e.Route,
+		Path:       e.Path,
+		Status:     e.Status,
+		BeforeHash: e.BeforeHash,
+		AfterHash:  e.AfterHash,
+	}
+	if e.NewsId != nil {
+		row.NewsId = *e.NewsId
+	}
+	// The requests rejected before their tenant is resolved are recorded
+	// with no tenant.
+	err := inTenant(tenant.CtxWithTenant(ctx, &tenant.Tenant{Id: e.TenantId}), s.db, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().Model(row).Returning("id").Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return NewCustomError(err, http.StatusInternalServerError)
+	}
+	e.Id = row.Id
+	return nil
+}
+
+// Find returns the events of the tenant of the context matching the filter,
+// oldest first.
+func (s AuditStore) Find(ctx context.Context, f audit.Filter) ([]*audit.Event, error) {
+	var rows []*AuditEvent
+	err := inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		q := whereTenant(ctx, tx.NewSelect().Model(&rows)).Order("id")
+		if f.Actor != "" {
+			q = q.Where("actor = ?", f.Actor)
+		}
+		if f.NewsId != uuid.Nil {
+			q = q.Where("news_id = ?", f.NewsId)
+		}
+		if !f.From.IsZero() {
+			q = q.Where("occurred_at >= ?", f.From)
+		}
+		if !f.To.IsZero() {
+			q = q.Where("occurred_at < ?", f.To)
+		}
+		if f.After > 0 {
+			q = q.Where("id > ?", f.After)
+		}
+		if f.Limit > 0 {
+			q = q.Limit(f.Limit)
+		}
+		return q.Scan(ctx)
+	})
+	if err != nil {
+		return nil, NewCustomError(err, http.StatusInternalServerError)
+	}
+	events := make([]*audit.Event, 0, len(rows))
+	for _, row := range rows {
+		e := &audit.Event{
+			Id:         row.Id,
+			TenantId:   row.TenantId,
+			OccurredAt: row.OccurredAt,
+			Actor:      row.Actor,
+			AuthMethod: row.AuthMethod,
+			IP:         row.IP,
+			RequestId:  row.RequestId,
+			Method:     row.Method,
+			Route:      row.Route,
+			Path:       row.Path,
+			Status:     row.Status,
+			BeforeHash: row.BeforeHash,
+			AfterHash:  row.AfterHash,
+		}
+		if row.NewsId != uuid.Nil {
+			e.NewsId = &row.NewsId
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// auditHash returns the SHA-256 of the row of the news as JSON, deleted or
+// not, locking it for the rest of the transaction, or "" if the tenant of the
+// context has no such news. It only hashes the news for the audited requests.
+func auditHash(ctx context.Context, db bun.IDB, id uuid.UUID) (string, error) {
+	if !audit.Audited(ctx) {
+		return "", nil
+	}
+	var hash string
+	err := db.NewRaw("SELECT encode(sha256(convert_to(row_to_json(n)::text, 'UTF8')), 'hex') FROM news AS n WHERE n.id = ? AND ? IN (n.tenant_id, '*') FOR UPDATE", id, tenantOf(ctx)).
+		Scan(ctx, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return hash, err
+}
+
+// track hashes the news once written and tracks the write in the audit event
+// of the request, if audited. It runs in the transaction of the write.
+func track(ctx context.Context, db bun.IDB, id uuid.UUID, before string) error {
+	if !audit.Audited(ctx) {
+		return nil
+	}
+	after, err := auditHash(ctx, db, id)
+	if err != nil {
+		return err
+	}
+	audit.Track(ctx, id, before, after)
+	return nil
+}
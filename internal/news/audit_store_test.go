@@ -0,0 +1,97 @@
+package news_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/audit"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditStore(t *testing.T) {
+	ctx := context.Background()
+	s := news.NewAuditStore(db)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	newsId := uuid.New()
+
+	events := []*audit.Event{
+		{TenantId: tenant.Default, OccurredAt: now, Actor: "audit-alice", Method: http.MethodPost, Route: "POST /news", Path: "/news", Status: http.StatusCreated},
+		{TenantId: tenant.Default, OccurredAt: now.Add(time.Minute), Actor: "audit-alice", Method: http.MethodPut, Route: "PUT /news/{news_id}", NewsId: &newsId, Status: http.StatusOK, BeforeHash: "before", AfterHash: "after"},
+		{TenantId: tenant.Default, OccurredAt: now.Add(2 * time.Minute), Actor: "audit-bob", Method: http.MethodDelete, Route: "DELETE /news/{news_id}", NewsId: &newsId, Status: http.StatusForbidden},
+	}
+	for _, e := range events {
+		require.NoError(t, s.Append(ctx, e))
+		assert.NotZero(t, e.Id)
+	}
+	// The events of the other tenants are not found.
+	require.NoError(t, s.Append(ctx, &audit.Event{TenantId: "audit-acme", OccurredAt: now, Actor: "audit-alice", Method: http.MethodPost, Status: http.StatusCreated}))
+
+	found, err := s.Find(ctx, audit.Filter{Actor: "audit-alice"})
+	require.NoError(t, err)
+	assert.Equal(t, events[:2], found)
+
+	found, err = s.Find(ctx, audit.Filter{NewsId: newsId, From: now.Add(time.Minute), To: now.Add(2 * time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, events[1:2], found)
+
+	found, err = s.Find(ctx, audit.Filter{NewsId: newsId, After: events[1].Id, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, events[2:], found)
+
+	// The events cannot be changed nor deleted.
+	_, err = db.NewUpdate().Model((*news.AuditEvent)(nil)).Set("status = 200").Where("id = ?", events[2].Id).Exec(ctx)
+	assert.ErrorContains(t, err, "append-only")
+	_, err = db.NewDelete().Model((*news.AuditEvent)(nil)).Where("id = ?", events[2].Id).Exec(ctx)
+	assert.ErrorContains(t, err, "append-only")
+}
+
+func TestStore_Audit(t *testing.T) {
+	ctx := context.Background()
+	s := news.NewStore(db)
+	auditStore := news.NewAuditStore(db)
+	n, err := s.Create(ctx, &news.Record{
+		Author:  "test-author",
+		Title:   "Audited",
+		Summary: "test-summary",
+		Content: "test-content",
+		Source:  "https://www.example.com",
+		Tags:    []string{"audit"},
+	})
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /news/{news_id}", func(w http.ResponseWriter, r *http.Request) {
+		n.Title = "Audited again"
+		require.NoError(t, s.UpdateById(r.Context(), n.Id, n))
+	})
+	mux.HandleFunc("DELETE /news/{news_id}", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, s.DeleteById(r.Context(), n.Id))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	h := audit.Mid(auditStore, mux, nil, mux)
+
+	// Act
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		h(httptest.NewRecorder(), httptest.NewRequest(method, "/news/"+n.Id.String(), http.NoBody))
+	}
+
+	// Assert
+	found, err := auditStore.Find(ctx, audit.Filter{NewsId: n.Id})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	updated, deleted := found[0], found[1]
+	assert.Equal(t, http.StatusOK, updated.Status)
+	assert.Len(t, updated.BeforeHash, 64)
+	assert.Len(t, updated.AfterHash, 64)
+	assert.NotEqual(t, updated.BeforeHash, updated.AfterHash)
+	assert.Equal(t, http.StatusNoContent, deleted.Status)
+	assert.Equal(t, updated.AfterHash, deleted.BeforeHash)
+	assert.NotEqual(t, deleted.BeforeHash, deleted.AfterHash, "deleted news are only marked as deleted")
+}
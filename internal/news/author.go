@@ -0,0 +1,66 @@
+package news
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Author is a profile of a writer of the news of a tenant. Slugs are unique
+// within the tenant.
+type Author struct {
+	bun.BaseModel `bun:"table:authors,alias:author"`
+	Id            uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	TenantId      string    `bun:"tenant_id,nullzero,notnull,default:'default'" json:"-"`
+	Slug          string    `bun:"slug,notnull" json:"slug"`
+	Name          string    `bun:"name,nullzero,notnull" json:"name"`
+	Bio           string    `bun:"bio,notnull" json:"bio"`
+	AvatarURL     string    `bun:"avatar_url,notnull" json:"avatar_url"`
+	Contact       string    `bun:"contact,notnull" json:"contact"`
+	CreatedAt     time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt     time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}
+
+// NewsAuthor links a news to one of its authors. Position orders the bylines.
+type NewsAuthor struct {
+	bun.BaseModel `bun:"table:news_authors"`
+	NewsId        uuid.UUID `bun:"news_id,pk,type:uuid"`
+	AuthorId      uuid.UUID `bun:"author_id,pk,type:uuid"`
+	TenantId      string    `bun:"tenant_id,nullzero,notnull,default:'default'"`
+	Position      int       `bun:"position,notnull"`
+}
+
+// Byline joins the names of the authors as stored in the author column.
+func Byline(authors []*Author) string {
+	names := make([]string, 0, len(authors))
+	for _, a := range authors {
+		names = append(names, a.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// AuthorsOf returns the authors named in a byline of the author column, the
+// reverse of Byline.
+func AuthorsOf(byline string) []*Author {
+	var authors []*Author
+	for _, name := range strings.Split(byline, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			authors = append(authors, &Author{Name: name})
+		}
+	}
+	return authors
+}
+
+// AuthorsNamed returns the authors with the given names, skipping the blank
+// ones, to be resolved by slug when the news are written.
+func AuthorsNamed(names []string) []*Author {
+	var authors []*Author
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			authors = append(authors, &Author{Name: name})
+		}
+	}
+	return authors
+}
@@ -0,0 +1,101 @@
+package news
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/uptrace/bun"
+)
+
+type AuthorStore struct {
+	db bun.IDB
+}
+
+func NewAuthorStore(db bun.IDB) *AuthorStore {
+	return &AuthorStore{
+		db: db,
+	}
+}
+
+// Create author profile in the tenant of the context.
+func (s AuthorStore) Create(ctx context.Context, author *Author) (*Author, error) {
+	tenantId, err := ownTenant(ctx, "authors")
+	if err != nil {
+		return nil, err
+	}
+	author.TenantId = tenantId
+	author.Slug = Slugify(author.Slug)
+	if author.Slug == "" {
+		author.Slug = Slugify(author.Name)
+	}
+	err = inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		return tx.NewInsert().Model(author).Returning("*").Scan(ctx, author)
+	})
+	if err != nil {
+		return nil, toCustomError(err)
+	}
+	return author, nil
+}
+
+func (s AuthorStore) FindAll(ctx context.Context) (authors []*Author, err error) {
+	authors = []*Author{}
+	err = inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		return whereTenant(ctx, tx.NewSelect().Model(&authors)).Order("name").Scan(ctx)
+	})
+	if err != nil {
+		return nil, NewCustomError(err, http.StatusInternalServerError)
+	}
+	return authors, nil
+}
+
+func (s AuthorStore) FindBySlug(ctx context.Context, slug string) (*Author, error) {
+	var author Author
+	err := inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		return whereTenant(ctx, tx.NewSelect().Model(&author)).Where("slug = ?", slug).Scan(ctx)
+	})
+	if err != nil {
+		return nil, toCustomError(err)
+	}
+	return &author, nil
+}
+
+// UpdateBySlug updates the author profile of the tenant of the context. The
+// bylines of the news written by the author follow a change of name.
+func (s AuthorStore) UpdateBySlug(ctx context.Context, slug string, author *Author) (*Author, error) {
+	if _, err := ownTenant(ctx, "authors"); err != nil {
+		return nil, err
+	}
+	author.Slug = Slugify(author.Slug)
+	if author.Slug == "" {
+		author.Slug = slug
+	}
+
+	err := inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		err := whereTenant(ctx, tx.NewUpdate().Model(author)).
+			Column("slug", "name", "bio", "avatar_url", "contact").
+			Set("updated_at = current_timestamp").
+			Where("slug = ?", slug).
+			Returning("*").
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		var rewritten []*Record
+		err = tx.NewRaw(`UPDATE news SET author = (
+				SELECT string_agg(a.name, ', ' ORDER BY na.position)
+				FROM news_authors na JOIN authors a ON a.id = na.author_id
+				WHERE na.news_id = news.id
+			) WHERE id IN (SELECT news_id FROM news_authors WHERE author_id = ?) AND tenant_id = ? RETURNING *`,
+			author.Id, author.TenantId,
+		).Scan(ctx, &rewritten)
+		if err != nil {
+			return err
+		}
+		return emitUpdated(ctx, tx, rewritten)
+	})
+	if err != nil {
+		return nil, toCustomError(err)
+	}
+	return author, nil
+}
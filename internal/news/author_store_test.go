@@ -0,0 +1,176 @@
+package news_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorStore(t *testing.T) {
+	ctx := context.Background()
+	s := news.NewStore(db)
+	as := news.NewAuthorStore(db)
+
+	jane, err := as.Create(ctx, &news.Author{Name: "Jane Doe", Bio: "Reporter"})
+	require.NoError(t, err)
+	assert.Equal(t, "jane-doe", jane.Slug)
+
+	_, err = as.Create(ctx, &news.Author{Name: "Jane  Doe"})
+	var storeErr *news.CustomError
+	require.ErrorAs(t, err, &storeErr)
+	assert.Equal(t, http.StatusConflict, storeErr.HttpStatusCode())
+
+	n, err := s.Create(ctx, &news.Record{
+		Authors: []*news.Author{{Name: "jane-doe"}, {Name: "John Roe"}},
+		Title:   "Two bylines",
+		Summary: "test-summary",
+		Content: "Written by two reporters.",
+		Source:  "https://www.example.com",
+		Tags:    []string{"tag1"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, s.DeleteById(ctx, n.Id))
+	})
+	assert.Equal(t, "Jane Doe, John Roe", n.Author)
+
+	t.Run("bylines loaded", func(t *testing.T) {
+		got, err := s.FindById(ctx, n.Id)
+		require.NoError(t, err)
+		require.Len(t, got.Authors, 2)
+		assert.Equal(t, jane.Id, got.Authors[0].Id)
+		assert.Equal(t, "john-roe", got.Authors[1].Slug)
+	})
+
+	t.Run("news by author", func(t *testing.T) {
+		byJohn, err := s.FindAll(ctx, news.Filter{Author: "john-roe"})
+		require.NoError(t, err)
+		require.Len(t, byJohn, 1)
+		assert.Equal(t, n.Id, byJohn[0].Id)
+	})
+
+	t.Run("rename follows bylines", func(t *testing.T) {
+		updated, err := as.UpdateBySlug(ctx, "jane-doe", &news.Author{Name: "Jane A. Doe", Bio: "Editor"})
+		require.NoError(t, err)
+		assert.Equal(t, "jane-doe", updated.Slug)
+		assert.Equal(t, "Editor", updated.Bio)
+
+		got, err := s.FindById(ctx, n.Id)
+		require.NoError(t, err)
+		assert.Equal(t, "Jane A. Doe, John Roe", got.Author)
+
+		event, got := lastEvent(t, n.Id)
+		assert.Equal(t, news.EventUpdated, event)
+		assert.Equal(t, "Jane A. Doe, John Roe", got.Author)
+		require.Len(t, got.Authors, 2)
+		assert.Equal(t, "Jane A. Doe", got.Authors[0].Name)
+	})
+
+	t.Run("authors by slug", func(t *testing.T) {
+		bySlug, err := s.Create(ctx, &news.Record{
+			Authors: []*news.Author{{Slug: "jane-doe"}},
+			Title:   "By slug",
+			Summary: "test-summary",
+			Content: "Written by a known reporter.",
+			Source:  "https://www.example.com",
+			Tags:    []string{"tag1"},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			assert.NoError(t, s.DeleteById(ctx, bySlug.Id))
+		})
+		assert.Equal(t, "Jane A. Doe", bySlug.Author)
+
+		_, err = s.Create(ctx, &news.Record{
+			Authors: []*news.Author{{Slug: "nobody"}},
+			Title:   "Unknown slug",
+			Summary: "test-summary",
+			Content: "Written by nobody.",
+			Source:  "https://www.example.com",
+			Tags:    []string{"tag1"},
+		})
+		require.ErrorAs(t, err, &storeErr)
+		assert.Equal(t, http.StatusBadRequest, storeErr.HttpStatusCode())
+	})
+
+	t.Run("byline of several authors", func(t *testing.T) {
+		byline, err := s.Create(ctx, &news.Record{
+			Author:  "Jane A. Doe, Richard Moe",
+			Title:   "Byline",
+			Summary: "test-summary",
+			Content: "Written by two reporters too.",
+			Source:  "https://www.example.com",
+			Tags:    []string{"tag1"},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			assert.NoError(t, s.DeleteById(ctx, byline.Id))
+		})
+		require.Len(t, byline.Authors, 2)
+		assert.Equal(t, "jane-a-doe", byline.Authors[0].Slug)
+		assert.Equal(t, "richard-moe", byline.Authors[1].Slug)
+
+		_, err = as.FindBySlug(ctx, "jane-a-doe-richard-moe")
+		require.ErrorAs(t, err, &storeErr)
+		assert.Equal(t, http.StatusNotFound, storeErr.HttpStatusCode())
+	})
+
+	t.Run("find by slug", func(t *testing.T) {
+		_, err := as.FindBySlug(ctx, "unknown")
+		require.ErrorAs(t, err, &storeErr)
+		assert.Equal(t, http.StatusNotFound, storeErr.HttpStatusCode())
+
+		batman, err := as.FindBySlug(ctx, "batman")
+		require.NoError(t, err)
+		assert.Equal(t, "Batman", batman.Name)
+	})
+}
+
+func TestAuthorStore_TenantIsolation(t *testing.T) {
+	// Arrange
+	s := news.NewStore(db)
+	as := news.NewAuthorStore(db)
+	acme, globex := inTenant("iso-acme"), inTenant("iso-globex")
+	mine, err := as.Create(acme, &news.Author{Name: "Tenant Writer"})
+	require.NoError(t, err)
+	theirs, err := as.Create(globex, &news.Author{Name: "Tenant Writer"})
+	require.NoError(t, err)
+	assert.NotEqual(t, mine.Id, theirs.Id)
+
+	n, err := s.Create(globex, &news.Record{
+		Authors: []*news.Author{{Slug: "tenant-writer"}},
+		Title:   "Written elsewhere",
+		Summary: "test-summary",
+		Content: "test-content",
+		Source:  "https://www.example.com",
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, s.DeleteById(globex, n.Id))
+	})
+	require.Len(t, n.Authors, 1)
+	assert.Equal(t, theirs.Id, n.Authors[0].Id)
+
+	// Act
+	updated, err := as.UpdateBySlug(acme, "tenant-writer", &news.Author{Name: "Renamed Writer"})
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, mine.Id, updated.Id)
+	found, err := as.FindBySlug(globex, "tenant-writer")
+	require.NoError(t, err)
+	assert.Equal(t, "Tenant Writer", found.Name)
+	got, err := s.FindById(globex, n.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Tenant Writer", got.Author)
+
+	all, err := as.FindAll(globex)
+	require.NoError(t, err)
+	for _, a := range all {
+		assert.NotEqual(t, mine.Id, a.Id)
+	}
+}
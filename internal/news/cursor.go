@@ -0,0 +1,47 @@
+package news
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cursor is the position of a news in the pages of FindAll.
+type Cursor struct {
+	CreatedAt time.Time
+	Id        uuid.UUID
+}
+
+// CursorOf returns the cursor of the news.
+func CursorOf(n *Record) *Cursor {
+	return &Cursor{CreatedAt: n.CreatedAt, Id: n.Id}
+}
+
+// String encodes the cursor into an opaque token.
+func (c *Cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.Format(time.RFC3339Nano) + "|" + c.Id.String()))
+}
+
+// ParseCursor decodes a cursor encoded by String.
+func ParseCursor(s string) (*Cursor, error) {
+	errInvalid := errors.New("invalid cursor")
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalid
+	}
+	createdAt, id, ok := strings.Cut(string(b), "|")
+	if !ok {
+		return nil, errInvalid
+	}
+	var c Cursor
+	if c.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, errInvalid
+	}
+	if c.Id, err = uuid.Parse(id); err != nil {
+		return nil, errInvalid
+	}
+	return &c, nil
+}
@@ -0,0 +1,24 @@
+package news_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCursor(t *testing.T) {
+	n := &news.Record{Id: uuid.New(), CreatedAt: time.Date(2026, 10, 19, 12, 0, 0, 123456000, time.UTC)}
+
+	c, err := news.ParseCursor(news.CursorOf(n).String())
+	require.NoError(t, err)
+	assert.Equal(t, news.CursorOf(n), c)
+
+	for _, s := range []string{"", "invalid", "MjAyNg"} {
+		_, err := news.ParseCursor(s)
+		assert.EqualError(t, err, "invalid cursor", s)
+	}
+}
@@ -1,11 +1,27 @@
 package news
 
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
 type CustomError struct {
 	err        error
 	httpStatus int
 }
 
+// NewCustomError returns an error with the status of its response. Errors of
+// queries that ran out of the time of their request are 503 Service
+// Unavailable rather than 500 Internal Server Error, as http.TimeoutHandler
+// responds.
 func NewCustomError(err error, httpStatus int) *CustomError {
+	if httpStatus == http.StatusInternalServerError && errors.Is(err, context.DeadlineExceeded) {
+		httpStatus = http.StatusServiceUnavailable
+	}
 	return &CustomError{
 		err:        err,
 		httpStatus: httpStatus,
@@ -23,3 +39,23 @@ func (ce *CustomError) Unwrap() error {
 func (ce *CustomError) HttpStatusCode() int {
 	return ce.httpStatus
 }
+
+// uniqueViolation is the Postgres error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// toCustomError keeps the status of a CustomError and maps any other error to
+// a not found, conflict or internal server error.
+func toCustomError(err error) *CustomError {
+	var ce *CustomError
+	if errors.As(err, &ce) {
+		return ce
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return NewCustomError(err, http.StatusNotFound)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return NewCustomError(err, http.StatusConflict)
+	}
+	return NewCustomError(err, http.StatusInternalServerError)
+}
@@ -0,0 +1,134 @@
+package news
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/idempotency"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/uptrace/bun"
+)
+
+// IdempotencyKey is the request that claimed an idempotency key, and its
+// response once it is done.
+type IdempotencyKey struct {
+	bun.BaseModel `bun:"table:idempotency_keys"`
+
+	Principal   string      `bun:"principal,pk"`
+	Key         string      `bun:"key,pk"`
+	TenantId    string      `bun:"tenant_id,nullzero,notnull,default:'default'"`
+	RequestHash string      `bun:"request_hash,notnull"`
+	Status      int         `bun:"status,nullzero"`
+	Headers     http.Header `bun:"headers,type:jsonb"`
+	Body        []byte      `bun:"body"`
+	ClaimedAt   time.Time   `bun:"claimed_at,notnull"`
+	ExpiresAt   time.Time   `bun:"expires_at,notnull"`
+}
+
+// IdempotencyStore keeps the idempotency keys in the database, so that the
+// responses are replayed by all the replicas. The keys belong to the tenant of
+// the context.
+type IdempotencyStore struct {
+	db bun.IDB
+}
+
+func NewIdempotencyStore(db bun.IDB) *IdempotencyStore {
+	return &IdempotencyStore{
+		db: db,
+	}
+}
+
+// Claim inserts the key, or takes over its row when it expired or its
+// request ran past its lease, in a single statement. Otherwise, it returns
+// the row of the key.
+func (s IdempotencyStore) Claim(ctx context.Context, k idempotency.Key, hash string, now time.Time, lease, ttl time.Duration) (*idempotency.Entry, bool, error) {
+	row := &IdempotencyKey{Principal: k.Principal, Key: k.Key, TenantId: tenantOf(ctx), RequestHash: hash, ClaimedAt: now, ExpiresAt: now.Add(ttl)}
+	var claimed bool
+	found := &IdempotencyKey{}
+	err := inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		r, err := tx.NewInsert().
+			Model(row).
+			On("CONFLICT (principal, key) DO UPDATE").
+			Set("request_hash = EXCLUDED.request_hash").
+			Set("status = NULL").
+			Set("headers = NULL").
+			Set("body = NULL").
+			Set("claimed_at = EXCLUDED.claimed_at").
+			Set("expires_at = EXCLUDED.expires_at").
+			Where("idempotency_key.expires_at <= ? OR (idempotency_key.status IS NULL AND idempotency_key.claimed_at <= ?)", now, now.Add(-lease)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if n, err := r.RowsAffected(); err != nil || n == 1 {
+			claimed = err == nil
+			return err
+		}
+		return whereTenant(ctx, tx.NewSelect().Model(found)).Where("principal = ? AND key = ?", k.Principal, k.Key).Scan(ctx)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Released in the meantime: the caller claims it on its next try.
+		return &idempotency.Entry{Hash: hash, ClaimedAt: now, ExpiresAt: now}, false, nil
+	}
+	if err != nil {
+		return nil, false, NewCustomError(err, http.StatusInternalServerError)
+	}
+	if claimed {
+		return nil, true, nil
+	}
+	e := &idempotency.Entry{Hash: found.RequestHash, ClaimedAt: found.ClaimedAt, ExpiresAt: found.ExpiresAt}
+	if found.Status != 0 {
+		e.Response = &idempotency.Response{Status: found.Status, Header: found.Headers, Body: found.Body}
+	}
+	return e, false, nil
+}
+
+func (s IdempotencyStore) Complete(ctx context.Context, k idempotency.Key, resp *idempotency.Response) error {
+	err := inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		_, err := whereTenant(ctx, tx.NewUpdate().
+			Model(&IdempotencyKey{Principal: k.Principal, Key: k.Key, Status: resp.Status, Headers: resp.Header, Body: resp.Body}).
+			Column("status", "headers", "body")).
+			WherePK().
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return NewCustomError(err, http.StatusInternalServerError)
+	}
+	return nil
+}
+
+func (s IdempotencyStore) Release(ctx context.Context, k idempotency.Key) error {
+	err := inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		_, err := whereTenant(ctx, tx.NewDelete().Model(&IdempotencyKey{Principal: k.Principal, Key: k.Key})).
+			WherePK().
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return NewCustomError(err, http.StatusInternalServerError)
+	}
+	return nil
+}
+
+// Sweep deletes the expired keys of every tenant.
+func (s IdempotencyStore) Sweep(ctx context.Context, now time.Time) (swept int64, err error) {
+	err = inTenant(tenant.CtxWithTenant(ctx, tenant.All), s.db, func(ctx context.Context, tx bun.Tx) error {
+		r, err := tx.NewDelete().
+			Model((*IdempotencyKey)(nil)).
+			Where("expires_at <= ?", now).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		swept, err = r.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, NewCustomError(err, http.StatusInternalServerError)
+	}
+	return swept, nil
+}
@@ -0,0 +1,69 @@
+package news_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/idempotency"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	s := news.NewIdempotencyStore(db)
+	now := time.Now().Truncate(time.Microsecond)
+	k := idempotency.Key{Principal: "alice", Key: "store-test"}
+
+	// The first request claims the key.
+	found, claimed, err := s.Claim(ctx, k, "hash", now, time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Nil(t, found)
+
+	// Its retries find it running.
+	found, claimed, err = s.Claim(ctx, k, "hash", now.Add(time.Second), time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	require.NotNil(t, found)
+	assert.Equal(t, "hash", found.Hash)
+	assert.Nil(t, found.Response)
+
+	// Other principals have their own keys.
+	_, claimed, err = s.Claim(ctx, idempotency.Key{Principal: "bob", Key: k.Key}, "other", now, time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// Then its response.
+	resp := &idempotency.Response{Status: http.StatusCreated, Header: http.Header{"Location": {"/news/1"}}, Body: []byte("created")}
+	require.NoError(t, s.Complete(ctx, k, resp))
+	found, claimed, err = s.Claim(ctx, k, "other", now.Add(2*time.Minute), time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	require.NotNil(t, found)
+	assert.Equal(t, "hash", found.Hash)
+	assert.Equal(t, resp, found.Response)
+
+	// The key is claimed again once it expired.
+	_, claimed, err = s.Claim(ctx, k, "other", now.Add(2*time.Hour), time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// Or once its request ran past its lease.
+	_, claimed, err = s.Claim(ctx, k, "again", now.Add(2*time.Hour+2*time.Minute), time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// Or once released.
+	require.NoError(t, s.Release(ctx, k))
+	_, claimed, err = s.Claim(ctx, k, "released", now, time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	swept, err := s.Sweep(ctx, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), swept)
+}
@@ -0,0 +1,82 @@
+package news
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// NotifyChannel is the Postgres channel notified with the ID of every event
+// recorded in the outbox, once its transaction commits.
+const NotifyChannel = "news_events"
+
+// OutboxEvent is a news event recorded in the transaction of the write it
+// describes, then relayed to the sinks. Events are relayed at least once:
+// consumers dedupe them by EventId.
+type OutboxEvent struct {
+	bun.BaseModel `bun:"table:outbox,alias:outbox"`
+	Id            int64           `bun:"id,pk,autoincrement" json:"-"`
+	EventId       uuid.UUID       `bun:"event_id,type:uuid,notnull" json:"id"`
+	Event         Event           `bun:"event,notnull" json:"event"`
+	TenantId      string          `bun:"tenant_id,nullzero,notnull,default:'default'" json:"tenant_id"`
+	NewsId        uuid.UUID       `bun:"news_id,type:uuid,notnull" json:"news_id"`
+	Payload       json.RawMessage `bun:"payload,type:jsonb,notnull" json:"payload"`
+	CreatedAt     time.Time       `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	DeliveredAt   time.Time       `bun:"delivered_at,nullzero" json:"-"`
+	LockedUntil   time.Time       `bun:"locked_until,nullzero" json:"-"`
+	// Position orders the events as their transactions commit, unlike Id.
+	// It is taken at commit: the streams resume after it.
+	Position int64 `bun:"position,nullzero" json:"-"`
+}
+
+// emit records the event of the news of the tenant in the outbox and notifies
+// NotifyChannel. It runs in the transaction of the write the event describes,
+// so that events are relayed and notified if and only if the write is
+// committed.
+func emit(ctx context.Context, db bun.IDB, event Event, tenantId string, newsId uuid.UUID, data any) error {
+	id := uuid.New()
+	payload, err := json.Marshal(EventPayload{Id: id, Event: event, TenantId: tenantId, CreatedAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return err
+	}
+	e := &OutboxEvent{EventId: id, Event: event, TenantId: tenantId, NewsId: newsId, Payload: payload}
+	if _, err := db.NewInsert().Model(e).Returning("id").Exec(ctx); err != nil {
+		return err
+	}
+	_, err = db.NewRaw("SELECT pg_notify(?, ?)", NotifyChannel, strconv.FormatInt(e.Id, 10)).Exec(ctx)
+	return err
+}
+
+// emitUpdated records the update events of the news rewritten in bulk, such
+// as by renaming a tag, so that the caches, streams and webhooks see them too.
+// Deleted news have no events.
+func emitUpdated(ctx context.Context, tx bun.Tx, news []*Record) error {
+	if err := NewStore(tx).loadAuthors(ctx, news...); err != nil {
+		return err
+	}
+	for _, n := range news {
+		if !n.DeletedAt.IsZero() {
+			continue
+		}
+		if err := emit(ctx, tx, EventUpdated, n.TenantId, n.Id, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Record decodes the news of the event. The news of the deleted events only
+// has its ID.
+func (e *OutboxEvent) Record() (*Record, error) {
+	var payload struct {
+		Data Record `json:"data"`
+	}
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		return nil, err
+	}
+	return &payload.Data, nil
+}
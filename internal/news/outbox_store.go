@@ -0,0 +1,152 @@
+package news
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/uptrace/bun"
+)
+
+// DefaultOutboxLease is how long the events relayed by a replica are claimed
+// for.
+const DefaultOutboxLease = time.Minute
+
+type OutboxStore struct {
+	db bun.IDB
+	// Lease bounds the time relaying a batch of events takes. The events of
+	// a replica that dies while relaying them are relayed again once it
+	// expires.
+	Lease time.Duration
+}
+
+func NewOutboxStore(db bun.IDB) *OutboxStore {
+	return &OutboxStore{
+		db:    db,
+		Lease: DefaultOutboxLease,
+	}
+}
+
+// Relay passes up to limit undelivered events of every tenant, oldest first, to
+// relay and marks them delivered if it succeeds. The events are claimed for the lease
+// by a statement of their own, so that relay runs outside of any transaction,
+// and released if it fails. Events claimed by another replica are skipped, so
+// replicas may relay events out of order.
+func (s OutboxStore) Relay(ctx context.Context, limit int, relay func(context.Context, []*OutboxEvent) error) (int, error) {
+	var events []*OutboxEvent
+	err := s.inAllTenants(ctx, func(ctx context.Context, tx bun.Tx) error {
+		unclaimed := tx.NewSelect().
+			Model((*OutboxEvent)(nil)).
+			Column("id").
+			Where("delivered_at IS NULL").
+			WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.Where("locked_until IS NULL").WhereOr("locked_until <= current_timestamp")
+			}).
+			Order("id").
+			Limit(limit).
+			For("UPDATE SKIP LOCKED")
+		return tx.NewUpdate().
+			Model((*OutboxEvent)(nil)).
+			Set("locked_until = current_timestamp + make_interval(secs => ?)", s.Lease.Seconds()).
+			Where("id IN (?)", unclaimed).
+			Returning("*").
+			Scan(ctx, &events)
+	})
+	if err != nil {
+		return 0, NewCustomError(err, http.StatusInternalServerError)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+	slices.SortFunc(events, func(a, b *OutboxEvent) int { return cmp.Compare(a.Id, b.Id) })
+	ids := make([]int64, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.Id)
+	}
+
+	relayCtx, cancel := context.WithTimeout(ctx, s.Lease)
+	err = relay(relayCtx, events)
+	cancel()
+	if err != nil {
+		releaseErr := s.inAllTenants(context.WithoutCancel(ctx), func(ctx context.Context, tx bun.Tx) error {
+			_, err := tx.NewUpdate().
+				Model((*OutboxEvent)(nil)).
+				Set("locked_until = NULL").
+				Where("id IN (?)", bun.In(ids)).
+				Exec(ctx)
+			return err
+		})
+		return 0, NewCustomError(errors.Join(err, releaseErr), http.StatusInternalServerError)
+	}
+
+	err = s.inAllTenants(context.WithoutCancel(ctx), func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model((*OutboxEvent)(nil)).
+			Set("delivered_at = current_timestamp").
+			Set("locked_until = NULL").
+			Where("id IN (?)", bun.In(ids)).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, NewCustomError(err, http.StatusInternalServerError)
+	}
+	return len(events), nil
+}
+
+// SweepDelivered deletes the events of every tenant delivered before the
+// given time, which the streams can no longer replay, and returns how many it
+// deleted.
+func (s OutboxStore) SweepDelivered(ctx context.Context, before time.Time) (swept int64, err error) {
+	err = s.inAllTenants(ctx, func(ctx context.Context, tx bun.Tx) error {
+		r, err := tx.NewDelete().
+			Model((*OutboxEvent)(nil)).
+			Where("delivered_at < ?", before).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		swept, err = r.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, NewCustomError(err, http.StatusInternalServerError)
+	}
+	return swept, nil
+}
+
+// FindEvents returns the events with the given IDs, in the order they
+// committed. The streams filter the events of every tenant for their
+// subscribers.
+func (s OutboxStore) FindEvents(ctx context.Context, ids ...int64) (events []*OutboxEvent, err error) {
+	err = s.inAllTenants(ctx, func(ctx context.Context, tx bun.Tx) error {
+		return tx.NewSelect().Model(&events).Where("id IN (?)", bun.In(ids)).Order("position").Scan(ctx)
+	})
+	if err != nil {
+		return nil, NewCustomError(err, http.StatusInternalServerError)
+	}
+	return events, nil
+}
+
+// FindEventsAfter returns up to limit events committed after the one at the
+// given position, in the order they committed. Events committing later get
+// later positions, so that none is skipped by reading after the last one.
+func (s OutboxStore) FindEventsAfter(ctx context.Context, position int64, limit int) (events []*OutboxEvent, err error) {
+	err = s.inAllTenants(ctx, func(ctx context.Context, tx bun.Tx) error {
+		return tx.NewSelect().Model(&events).Where("position > ?", position).Order("position").Limit(limit).Scan(ctx)
+	})
+	if err != nil {
+		return nil, NewCustomError(err, http.StatusInternalServerError)
+	}
+	return events, nil
+}
+
+// inAllTenants runs fn in a transaction bound to every tenant: the outbox is
+// relayed and streamed by workers shared by the tenants.
+func (s OutboxStore) inAllTenants(ctx context.Context, fn func(ctx context.Context, tx bun.Tx) error) error {
+	return inTenant(tenant.CtxWithTenant(ctx, tenant.All), s.db, fn)
+}
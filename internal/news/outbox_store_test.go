@@ -0,0 +1,183 @@
+package news_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxStore(t *testing.T) {
+	ctx := context.Background()
+	s := news.NewStore(db)
+	outboxStore := news.NewOutboxStore(db)
+
+	drain := func() []*news.OutboxEvent {
+		var events []*news.OutboxEvent
+		for {
+			count, err := outboxStore.Relay(ctx, 100, func(_ context.Context, e []*news.OutboxEvent) error {
+				events = append(events, e...)
+				return nil
+			})
+			require.NoError(t, err)
+			if count == 0 {
+				return events
+			}
+		}
+	}
+	drain()
+
+	record := func() *news.Record {
+		return &news.Record{
+			Author:  "test-author",
+			Title:   "Outboxed",
+			Summary: "test-summary",
+			Content: "test-content",
+			Source:  "https://www.example.com",
+			Tags:    []string{"outbox"},
+		}
+	}
+
+	// Events are rolled back along with the caller's transaction.
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	rolledBack, err := s.WithTx(tx).Create(ctx, record())
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+	_, err = s.FindById(ctx, rolledBack.Id)
+	assert.Error(t, err)
+	assert.Empty(t, drain())
+
+	// And committed along with it.
+	tx, err = db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	n, err := s.WithTx(tx).Create(ctx, record())
+	require.NoError(t, err)
+	n.Content = "updated-content"
+	require.NoError(t, s.WithTx(tx).UpdateById(ctx, n.Id, n))
+	require.NoError(t, s.WithTx(tx).DeleteById(ctx, n.Id))
+	require.NoError(t, tx.Commit())
+
+	// A failing relay leaves the events undelivered.
+	_, err = outboxStore.Relay(ctx, 100, func(context.Context, []*news.OutboxEvent) error {
+		return errors.New("sink down")
+	})
+	assert.Error(t, err)
+
+	// Claimed events are skipped by the other replicas while relayed.
+	_, err = outboxStore.Relay(ctx, 1, func(_ context.Context, claimed []*news.OutboxEvent) error {
+		_, err := outboxStore.Relay(ctx, 100, func(_ context.Context, e []*news.OutboxEvent) error {
+			assert.Len(t, e, 2)
+			assert.NotEqual(t, claimed[0].Id, e[0].Id)
+			return errors.New("sink down")
+		})
+		assert.Error(t, err)
+		return errors.New("sink down")
+	})
+	assert.Error(t, err)
+
+	// Until their lease expires.
+	expiring := news.NewOutboxStore(db)
+	expiring.Lease = time.Millisecond
+	_, err = expiring.Relay(ctx, 100, func(context.Context, []*news.OutboxEvent) error {
+		time.Sleep(10 * time.Millisecond)
+		_, err := outboxStore.Relay(ctx, 100, func(_ context.Context, e []*news.OutboxEvent) error {
+			assert.Len(t, e, 3)
+			return errors.New("sink down")
+		})
+		assert.Error(t, err)
+		return errors.New("replica too slow")
+	})
+	assert.Error(t, err)
+
+	events := drain()
+	require.Len(t, events, 3)
+	for i, event := range []news.Event{news.EventCreated, news.EventUpdated, news.EventDeleted} {
+		assert.Equal(t, event, events[i].Event)
+		assert.Equal(t, n.Id, events[i].NewsId)
+	}
+	assert.Empty(t, drain())
+
+	// Events are found by ID for the streams.
+	found, err := outboxStore.FindEvents(ctx, events[1].Id)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, news.EventUpdated, found[0].Event)
+	found, err = outboxStore.FindEventsAfter(ctx, events[0].Position, 1)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, events[1].Id, found[0].Id)
+
+	// Delivered events are swept once retained long enough.
+	swept, err := outboxStore.SweepDelivered(ctx, events[2].CreatedAt.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, swept)
+	swept, err = outboxStore.SweepDelivered(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, swept, int64(3))
+	found, err = outboxStore.FindEvents(ctx, events[0].Id, events[1].Id, events[2].Id)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestOutboxStore_CommitOrder(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	s := news.NewStore(db)
+	outboxStore := news.NewOutboxStore(db)
+	record := func() *news.Record {
+		return &news.Record{
+			Author:  "test-author",
+			Title:   "Commit order",
+			Summary: "test-summary",
+			Content: "test-content " + uuid.NewString(),
+			Source:  "https://www.example.com",
+			Tags:    []string{"outbox"},
+		}
+	}
+	latest, err := outboxStore.FindEventsAfter(ctx, 0, 1_000_000)
+	require.NoError(t, err)
+	var start int64
+	if len(latest) > 0 {
+		start = latest[len(latest)-1].Position
+	}
+	// The first transaction records its event first, but commits last.
+	first, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer first.Rollback()
+	late, err := s.WithTx(first).Create(ctx, record())
+	require.NoError(t, err)
+	early, err := s.Create(ctx, record())
+	require.NoError(t, err)
+	streamed, err := outboxStore.FindEventsAfter(ctx, start, 100)
+	require.NoError(t, err)
+	require.Len(t, streamed, 1)
+	assert.Equal(t, early.Id, streamed[0].NewsId)
+
+	// Act
+	require.NoError(t, first.Commit())
+
+	// Assert
+	// Resuming after the event streamed finds the one committed since.
+	resumed, err := outboxStore.FindEventsAfter(ctx, streamed[0].Position, 100)
+	require.NoError(t, err)
+	require.Len(t, resumed, 1)
+	assert.Equal(t, late.Id, resumed[0].NewsId)
+	assert.Less(t, resumed[0].Id, streamed[0].Id)
+}
+
+// lastEvent returns the last event recorded in the outbox for the news.
+func lastEvent(tb testing.TB, newsId uuid.UUID) (news.Event, *news.Record) {
+	tb.Helper()
+	var e news.OutboxEvent
+	err := db.NewSelect().Model(&e).Where("news_id = ?", newsId).Order("id DESC").Limit(1).Scan(context.Background())
+	require.NoError(tb, err)
+	n, err := e.Record()
+	require.NoError(tb, err)
+	return e.Event, n
+}
@@ -0,0 +1,57 @@
+package news
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/uptrace/bun"
+)
+
+// RateLimitStore keeps the token buckets of the rate limits in the database,
+// so that the limits hold across replicas.
+type RateLimitStore struct {
+	db bun.IDB
+}
+
+func NewRateLimitStore(db bun.IDB) *RateLimitStore {
+	return &RateLimitStore{
+		db: db,
+	}
+}
+
+// Take takes a token from the bucket of the key in a single statement, the
+// bucket being refilled for the time elapsed since its last take at the
+// database clock.
+func (s RateLimitStore) Take(ctx context.Context, key string, perSecond float64, burst int) (tokens float64, ok bool, err error) {
+	err = s.db.NewRaw(`
+INSERT INTO rate_limits AS rl (key, tokens, allowed, per_second, burst, updated_at)
+VALUES (?0, ?2 - 1, true, ?1, ?2, now())
+ON CONFLICT (key) DO UPDATE SET
+  tokens = CASE
+    WHEN LEAST(?2, rl.tokens + EXTRACT(EPOCH FROM now() - rl.updated_at) * ?1) >= 1
+    THEN LEAST(?2, rl.tokens + EXTRACT(EPOCH FROM now() - rl.updated_at) * ?1) - 1
+    ELSE LEAST(?2, rl.tokens + EXTRACT(EPOCH FROM now() - rl.updated_at) * ?1)
+  END,
+  allowed = LEAST(?2, rl.tokens + EXTRACT(EPOCH FROM now() - rl.updated_at) * ?1) >= 1,
+  per_second = ?1,
+  burst = ?2,
+  updated_at = now()
+RETURNING tokens, allowed`, key, perSecond, burst).Scan(ctx, &tokens, &ok)
+	if err != nil {
+		return 0, false, NewCustomError(err, http.StatusInternalServerError)
+	}
+	return tokens, ok, nil
+}
+
+// Sweep deletes the buckets that are full again, which are the same as no
+// bucket, and returns how many it deleted.
+func (s RateLimitStore) Sweep(ctx context.Context) (int64, error) {
+	r, err := s.db.NewDelete().
+		TableExpr("rate_limits").
+		Where("tokens + EXTRACT(EPOCH FROM now() - updated_at) * per_second >= burst").
+		Exec(ctx)
+	if err != nil {
+		return 0, NewCustomError(err, http.StatusInternalServerError)
+	}
+	return r.RowsAffected()
+}
@@ -0,0 +1,57 @@
+package news_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitStore(t *testing.T) {
+	ctx := context.Background()
+	s := news.NewRateLimitStore(db)
+
+	// A bucket of 3 tokens, refilled too slowly to matter.
+	for i, expectedOk := range []bool{true, true, true, false, false} {
+		tokens, ok, err := s.Take(ctx, "test|slow", 0.001, 3)
+		require.NoError(t, err)
+		assert.Equal(t, expectedOk, ok, "take %d", i)
+		assert.Less(t, tokens, float64(3-i))
+		assert.GreaterOrEqual(t, tokens, 0.0)
+	}
+
+	// The replicas share the bucket.
+	var wg sync.WaitGroup
+	var l sync.Mutex
+	taken := 0
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := s.Take(ctx, "test|shared", 0.001, 4)
+			assert.NoError(t, err)
+			if ok {
+				l.Lock()
+				taken++
+				l.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 4, taken)
+
+	// Buckets refilled faster than they are taken from stay full, and are
+	// swept.
+	_, ok, err := s.Take(ctx, "test|fast", 1e6, 1)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, ok, err = s.Take(ctx, "test|fast", 1e6, 1)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	swept, err := s.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), swept)
+}
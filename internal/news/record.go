@@ -8,15 +8,43 @@ import (
 )
 
 type Record struct {
-	bun.BaseModel `bun:"table:news"`
-	Id            uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()"`
-	Author        string    `bun:"author,nullzero,notnull"`
-	Title         string    `bun:"title,nullzero,notnull"`
-	Summary       string    `bun:"summary,nullzero,notnull"`
-	Content       string    `bun:"content,nullzero,notnull"`
-	Source        string    `bun:"source,nullzero,notnull"`
-	Tags          []string  `bun:"tags,nullzero,notnull,array"`
-	CreatedAt     time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp"`
-	UpdatedAt     time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp"`
-	DeletedAt     time.Time `bun:"deleted_at,nullzero,soft_delete"`
+	bun.BaseModel   `bun:"table:news"`
+	Id              uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()"`
+	TenantId        string    `bun:"tenant_id,nullzero,notnull,default:'default'" json:"-"`
+	Author          string    `bun:"author,nullzero,notnull"`
+	Authors         []*Author `bun:"-" json:"authors"`
+	Title           string    `bun:"title,nullzero,notnull"`
+	Summary         string    `bun:"summary,nullzero,notnull"`
+	Content         string    `bun:"content,nullzero,notnull"`
+	Source          string    `bun:"source,nullzero,notnull"`
+	Tags            []string  `bun:"tags,nullzero,notnull,array"`
+	Fingerprint     int64     `bun:"fingerprint,nullzero" json:"-"`
+	ClusterId       uuid.UUID `bun:"cluster_id,type:uuid,nullzero" json:"cluster_id"`
+	Status          Status    `bun:"status,nullzero,notnull,default:'draft'" json:"status"`
+	StatusChangedAt time.Time `bun:"status_changed_at,nullzero" json:"status_changed_at"`
+	StatusChangedBy string    `bun:"status_changed_by,notnull" json:"status_changed_by"`
+	PublishedAt     time.Time `bun:"published_at,nullzero" json:"published_at"`
+	PublishAt       time.Time `bun:"publish_at,nullzero" json:"publish_at"`
+	EmbargoUntil    time.Time `bun:"embargo_until,nullzero" json:"embargo_until"`
+	ExpiresAt       time.Time `bun:"expires_at,nullzero" json:"expires_at"`
+	CreatedAt       time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt       time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp"`
+	DeletedAt       time.Time `bun:"deleted_at,nullzero,soft_delete"`
+}
+
+// Public reports whether the news can be shown to the public: it has to be
+// published, out of embargo and not expired.
+func (r *Record) Public() bool {
+	now := time.Now()
+	return r != nil && r.Status == StatusPublished && !r.Embargoed(now) && !r.Expired(now)
+}
+
+// Embargoed reports whether the news is still under embargo at t.
+func (r *Record) Embargoed(t time.Time) bool {
+	return r.EmbargoUntil.After(t)
+}
+
+// Expired reports whether the news has expired at t.
+func (r *Record) Expired(t time.Time) bool {
+	return !r.ExpiresAt.IsZero() && !r.ExpiresAt.After(t)
 }
@@ -0,0 +1,50 @@
+package news
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// DefaultSimilarityThreshold is the maximum Hamming distance between two
+// fingerprints for the articles to be considered near-duplicates. Unrelated
+// articles are around 32 bits apart.
+const DefaultSimilarityThreshold = 10
+
+// SimHash computes a 64-bit fingerprint over the words of the title and
+// content of an article. Articles with small wording changes produce
+// fingerprints with a small Hamming distance.
+func SimHash(title, content string) uint64 {
+	words := strings.FieldsFunc(strings.ToLower(title+" "+content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	var weights [64]int
+	h := fnv.New64a()
+	for _, word := range words {
+		h.Reset()
+		_, _ = h.Write([]byte(word))
+		sum := h.Sum64()
+		for b := range 64 {
+			if sum&(1<<b) != 0 {
+				weights[b]++
+			} else {
+				weights[b]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for b, w := range weights {
+		if w > 0 {
+			fingerprint |= 1 << b
+		}
+	}
+	return fingerprint
+}
+
+// HammingDistance returns the number of differing bits between two fingerprints.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
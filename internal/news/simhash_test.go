@@ -0,0 +1,60 @@
+package news_test
+
+import (
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimHash(t *testing.T) {
+	testCases := []struct {
+		name        string
+		a, b        [2]string
+		maxDistance int
+		minDistance int
+	}{
+		{
+			name:        "identical",
+			a:           [2]string{"Storm hits coast", "A strong storm hit the northern coast on Monday, cutting power to thousands of homes."},
+			b:           [2]string{"Storm hits coast", "A strong storm hit the northern coast on Monday, cutting power to thousands of homes."},
+			maxDistance: 0,
+		},
+		{
+			name:        "case and punctuation only",
+			a:           [2]string{"Storm hits coast", "A strong storm hit the northern coast on Monday, cutting power to thousands of homes."},
+			b:           [2]string{"STORM HITS COAST!", "A strong storm hit the northern coast on Monday; cutting power to thousands of homes"},
+			maxDistance: 0,
+		},
+		{
+			name: "slight rewording",
+			a: [2]string{"Storm hits coast", "A strong storm hit the northern coast on Monday, cutting power to thousands of homes " +
+				"and forcing schools to close while emergency crews worked through the night to clear fallen trees from the roads."},
+			b: [2]string{"Storm hits coast", "A strong storm hit the northern coast on Monday, cutting power to thousands of homes " +
+				"and forcing schools to close while emergency teams worked through the night to clear fallen trees from the roads."},
+			maxDistance: news.DefaultSimilarityThreshold,
+		},
+		{
+			name:        "unrelated",
+			a:           [2]string{"Storm hits coast", "A strong storm hit the northern coast on Monday, cutting power to thousands of homes."},
+			b:           [2]string{"Central bank holds rates", "The central bank left interest rates unchanged, citing slowing inflation."},
+			maxDistance: 64,
+			minDistance: news.DefaultSimilarityThreshold + 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := news.HammingDistance(news.SimHash(tc.a[0], tc.a[1]), news.SimHash(tc.b[0], tc.b[1]))
+
+			assert.LessOrEqual(t, d, tc.maxDistance)
+			assert.GreaterOrEqual(t, d, tc.minDistance)
+		})
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	assert.Equal(t, 0, news.HammingDistance(0b1011, 0b1011))
+	assert.Equal(t, 2, news.HammingDistance(0b1011, 0b1000))
+	assert.Equal(t, 64, news.HammingDistance(0, ^uint64(0)))
+}
@@ -0,0 +1,66 @@
+package news
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/postgres"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Status is the editorial state of a news. Only published news are visible
+// to the public.
+type Status string
+
+const (
+	StatusDraft     Status = "draft"
+	StatusInReview  Status = "in_review"
+	StatusApproved  Status = "approved"
+	StatusPublished Status = "published"
+	StatusArchived  Status = "archived"
+)
+
+// SchedulerActor is recorded as the actor of the transitions made when
+// scheduled news are published.
+const SchedulerActor = "scheduler"
+
+// publishLockKey guards PublishDue so that a single replica publishes due
+// news at a time.
+var publishLockKey = postgres.LockKey("news.publish_due")
+
+// transitions lists the states each state can move to. Reviewers can send a
+// news back to draft.
+var transitions = map[Status][]Status{
+	StatusDraft:     {StatusInReview},
+	StatusInReview:  {StatusApproved, StatusDraft},
+	StatusApproved:  {StatusPublished},
+	StatusPublished: {StatusArchived},
+	StatusArchived:  {},
+}
+
+func ParseStatus(s string) (Status, error) {
+	status := Status(s)
+	if _, ok := transitions[status]; !ok {
+		return "", fmt.Errorf("invalid status: %q", s)
+	}
+	return status, nil
+}
+
+// CanTransitionTo reports whether a news can move from s to the given state.
+func (s Status) CanTransitionTo(to Status) bool {
+	return slices.Contains(transitions[s], to)
+}
+
+// Transition records a change of editorial state.
+type Transition struct {
+	bun.BaseModel `bun:"table:news_transitions"`
+	Id            int64     `bun:"id,pk,autoincrement" json:"id"`
+	NewsId        uuid.UUID `bun:"news_id,type:uuid,notnull" json:"news_id"`
+	TenantId      string    `bun:"tenant_id,nullzero,notnull,default:'default'" json:"-"`
+	From          Status    `bun:"from_status,notnull" json:"from"`
+	To            Status    `bun:"to_status,notnull" json:"to"`
+	Actor         string    `bun:"actor,notnull" json:"actor"`
+	CreatedAt     time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
@@ -0,0 +1,40 @@
+package news_test
+
+import (
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus_CanTransitionTo(t *testing.T) {
+	testCases := []struct {
+		from, to news.Status
+		allowed  bool
+	}{
+		{from: news.StatusDraft, to: news.StatusInReview, allowed: true},
+		{from: news.StatusInReview, to: news.StatusApproved, allowed: true},
+		{from: news.StatusInReview, to: news.StatusDraft, allowed: true},
+		{from: news.StatusApproved, to: news.StatusPublished, allowed: true},
+		{from: news.StatusPublished, to: news.StatusArchived, allowed: true},
+		{from: news.StatusDraft, to: news.StatusPublished},
+		{from: news.StatusApproved, to: news.StatusArchived},
+		{from: news.StatusArchived, to: news.StatusPublished},
+		{from: news.StatusPublished, to: news.StatusPublished},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.from)+" to "+string(tc.to), func(t *testing.T) {
+			assert.Equal(t, tc.allowed, tc.from.CanTransitionTo(tc.to))
+		})
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	s, err := news.ParseStatus("in_review")
+	assert.NoError(t, err)
+	assert.Equal(t, news.StatusInReview, s)
+
+	_, err = news.ParseStatus("live")
+	assert.ErrorContains(t, err, "invalid status")
+}
@@ -4,10 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/TommyLearning/go-rest-api-project/internal/postgres"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
 )
 
 type Store struct {
@@ -20,56 +27,812 @@ func NewStore(db bun.IDB) *Store {
 	}
 }
 
-// Create news record.
+// WithTx returns a store running in tx, so that callers can commit or roll
+// back news writes, along with the events they record in the outbox, together
+// with their own queries.
+func (s Store) WithTx(tx bun.Tx) *Store {
+	return NewStore(tx)
+}
+
+// Create news record in the tenant of the context, within its quota.
 func (s Store) Create(ctx context.Context, news *Record) (*Record, error) {
+	t := tenant.FromContext(ctx)
+	if t.Id == tenant.All.Id {
+		return nil, NewCustomError(errors.New("news cannot be created in all the tenants"), http.StatusInternalServerError)
+	}
 	news.Id = uuid.New()
-	if err := s.db.NewInsert().Model(news).Returning("*").Scan(ctx, news); err != nil {
-		return nil, NewCustomError(err, http.StatusInternalServerError)
+	news.TenantId = t.Id
+	news.Status = StatusDraft
+	err := inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		txStore := NewStore(tx)
+		if err := txStore.checkQuota(ctx, t); err != nil {
+			return err
+		}
+		if err := txStore.resolveTags(ctx, news); err != nil {
+			return err
+		}
+		if err := txStore.resolveAuthors(ctx, news); err != nil {
+			return err
+		}
+		if err := txStore.fingerprint(ctx, news); err != nil {
+			return err
+		}
+		if err := tx.NewInsert().Model(news).Returning("*").Scan(ctx, news); err != nil {
+			return err
+		}
+		if err := txStore.linkAuthors(ctx, news); err != nil {
+			return err
+		}
+		if err := track(ctx, tx, news.Id, ""); err != nil {
+			return err
+		}
+		return emit(ctx, tx, EventCreated, news.TenantId, news.Id, news)
+	})
+	if err != nil {
+		return nil, toCustomError(err)
 	}
 	return news, nil
 }
 
-func (s Store) FindById(ctx context.Context, id uuid.UUID) (news *Record, err error) {
-	err = s.db.NewSelect().Model(&news).Where("id = ?", id).Scan(ctx)
+// ErrQuotaExceeded is returned when creating news beyond the quota of the
+// tenant.
+var ErrQuotaExceeded = errors.New("news quota of the tenant exceeded")
+
+// checkQuota fails if the tenant already has its maximum number of news. The
+// creations and restorations of the tenant wait for each other to check it,
+// until their transaction ends.
+func (s Store) checkQuota(ctx context.Context, t *tenant.Tenant) error {
+	if t.MaxNews == 0 {
+		return nil
+	}
+	if _, err := s.db.NewRaw("SELECT pg_advisory_xact_lock(hashtext(?))", "news_quota:"+t.Id).Exec(ctx); err != nil {
+		return err
+	}
+	count, err := whereTenant(ctx, s.db.NewSelect().Model((*Record)(nil))).Count(ctx)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return news, NewCustomError(err, http.StatusNotFound)
+		return err
+	}
+	if count >= t.MaxNews {
+		return NewCustomError(ErrQuotaExceeded, http.StatusForbidden)
+	}
+	return nil
+}
+
+func (s Store) FindById(ctx context.Context, id uuid.UUID) (news *Record, err error) {
+	err = inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		if err := whereTenant(ctx, tx.NewSelect().Model(&news)).Where("id = ?", id).Scan(ctx); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return NewCustomError(err, http.StatusNotFound)
+			}
+			return NewCustomError(err, http.StatusInternalServerError)
 		}
+		return NewStore(tx).loadAuthors(ctx, news)
+	})
+	if err != nil {
+		return nil, toCustomError(err)
+	}
+	return news, nil
+}
 
-		return news, NewCustomError(err, http.StatusInternalServerError)
+// Filter narrows down the news returned by FindAll.
+type Filter struct {
+	// Ids matches the news with any of the IDs.
+	Ids []uuid.UUID
+	// Tag matches news tagged with the slug or one of its aliases.
+	Tag string
+	// Author matches news with the author slug in their bylines.
+	Author string
+	// Statuses matches news in any of the editorial states.
+	Statuses []Status
+	// Public matches the news visible to the public only.
+	Public bool
+	// Query matches news with the text in their title, summary or content,
+	// case-insensitively.
+	Query string
+	// Columns loads only the columns, along with the ID and creation time,
+	// and leaves the authors out. All the columns and the authors are loaded
+	// by default.
+	Columns []string
+	// After and Limit page through the news, newest first. After is the
+	// cursor of the last news of the previous page.
+	After *Cursor
+	Limit int
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (s Store) FindAll(ctx context.Context, f Filter) (news []*Record, err error) {
+	err = inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		news, err = NewStore(tx).findAll(ctx, f)
+		return err
+	})
+	if err != nil {
+		return nil, toCustomError(err)
 	}
 	return news, nil
 }
 
-func (s Store) FindAll(ctx context.Context) (news []*Record, err error) {
-	err = s.db.NewSelect().Model(&news).Scan(ctx, &news)
-	return news, err
+func (s Store) findAll(ctx context.Context, f Filter) (news []*Record, err error) {
+	q := whereTenant(ctx, s.db.NewSelect().Model(&news))
+	if len(f.Columns) > 0 {
+		q = q.Column("id", "created_at")
+		for _, c := range f.Columns {
+			if c != "id" && c != "created_at" {
+				q = q.Column(c)
+			}
+		}
+	}
+	if len(f.Ids) > 0 {
+		q = q.Where("id IN (?)", bun.In(f.Ids))
+	}
+	if f.Tag != "" {
+		q = q.Where("tags && array_append(ARRAY(SELECT slug FROM tags WHERE ? = ANY(aliases) AND tags.tenant_id = news.tenant_id), ?)", f.Tag, f.Tag)
+	}
+	if f.Author != "" {
+		q = q.Where("id IN (SELECT na.news_id FROM news_authors AS na JOIN authors AS a ON a.id = na.author_id WHERE a.slug = ?)", f.Author)
+	}
+	if len(f.Statuses) > 0 {
+		q = q.Where("status IN (?)", bun.In(f.Statuses))
+	}
+	if f.Public {
+		q = q.Where("status = ?", StatusPublished).
+			Where("embargo_until IS NULL OR embargo_until <= current_timestamp").
+			Where("expires_at IS NULL OR expires_at > current_timestamp")
+	}
+	if f.Query != "" {
+		pattern := "%" + likeEscaper.Replace(f.Query) + "%"
+		q = q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("title ILIKE ?", pattern).
+				WhereOr("summary ILIKE ?", pattern).
+				WhereOr("content ILIKE ?", pattern)
+		})
+	}
+	if f.After != nil || f.Limit > 0 {
+		q = q.Order("created_at DESC", "id DESC")
+	}
+	if f.After != nil {
+		q = q.Where("(created_at, id) < (?, ?)", f.After.CreatedAt, f.After.Id)
+	}
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	if err = q.Scan(ctx, &news); err != nil {
+		return news, err
+	}
+	if len(f.Columns) > 0 {
+		return news, nil
+	}
+	if err := s.loadAuthors(ctx, news...); err != nil {
+		return nil, err
+	}
+	return news, nil
 }
 
 func (s Store) DeleteById(ctx context.Context, id uuid.UUID) (err error) {
-	_, err = s.db.NewDelete().Model(&Record{}).Where("id = ?", id).Returning("NULL").Exec(ctx)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil
+	err = inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		before, err := auditHash(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		r, err := whereTenant(ctx, tx.NewDelete().Model(&Record{})).Where("id = ?", id).Exec(ctx)
+		if err != nil {
+			return err
 		}
+		rowsAffected, err := r.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return err
+		}
+		if err := track(ctx, tx, id, before); err != nil {
+			return err
+		}
+		return emit(ctx, tx, EventDeleted, tenantOf(ctx), id, deletedRecord{Id: id})
+	})
+	if err != nil {
 		return NewCustomError(err, http.StatusInternalServerError)
 	}
 	return nil
 }
 
+// Restore undoes the deletion of the news, within the quota of the tenant of
+// the context. Restored news are announced as created again, since consumers
+// dropped them when they were deleted. News that are not deleted are not
+// found.
+func (s Store) Restore(ctx context.Context, id uuid.UUID) (*Record, error) {
+	news := &Record{}
+	err := inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		if err := NewStore(tx).checkQuota(ctx, tenant.FromContext(ctx)); err != nil {
+			return err
+		}
+		before, err := auditHash(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		err = whereTenant(ctx, tx.NewUpdate().Model(news)).
+			WhereDeleted().
+			Set("deleted_at = NULL").
+			Set("updated_at = current_timestamp").
+			Where("id = ?", id).
+			Returning("*").
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+		if err := track(ctx, tx, id, before); err != nil {
+			return err
+		}
+		if err := emit(ctx, tx, EventCreated, news.TenantId, news.Id, news); err != nil {
+			return err
+		}
+		return NewStore(tx).loadAuthors(ctx, news)
+	})
+	if err != nil {
+		return nil, toCustomError(err)
+	}
+	return news, nil
+}
+
 // UpdateById update news by it's ID.
 func (s Store) UpdateById(ctx context.Context, id uuid.UUID, news *Record) (err error) {
-	r, err := s.db.NewUpdate().Model(news).Where("id = ?", id).Returning("NULL").Exec(ctx)
+	news.Id = id
+	news.TenantId = tenantOf(ctx)
+	err = inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		before, err := auditHash(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		txStore := NewStore(tx)
+		if err := txStore.resolveTags(ctx, news); err != nil {
+			return err
+		}
+		if err := txStore.resolveAuthors(ctx, news); err != nil {
+			return err
+		}
+		if err := txStore.fingerprint(ctx, news); err != nil {
+			return err
+		}
+
+		q := whereTenant(ctx, tx.NewUpdate().Model(news)).
+			ExcludeColumn("tenant_id", "updated_at", "status", "status_changed_at", "status_changed_by", "published_at").
+			Set("updated_at = current_timestamp")
+		if news.CreatedAt.IsZero() {
+			q = q.ExcludeColumn("created_at")
+		}
+		r, err := q.Where("id = ?", id).Returning("*").Exec(ctx)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := r.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return NewCustomError(sql.ErrNoRows, http.StatusNotFound)
+		}
+		if err := txStore.linkAuthors(ctx, news); err != nil {
+			return err
+		}
+		if err := track(ctx, tx, id, before); err != nil {
+			return err
+		}
+		return emit(ctx, tx, EventUpdated, news.TenantId, news.Id, news)
+	})
 	if err != nil {
-		return NewCustomError(err, http.StatusInternalServerError)
+		return toCustomError(err)
+	}
+	return nil
+}
+
+// Transition moves the news to another editorial state on behalf of actor
+// and records the transition. Transitions not allowed from the current state
+// are a conflict.
+func (s Store) Transition(ctx context.Context, id uuid.UUID, to Status, actor string) (*Record, error) {
+	news := &Record{}
+	err := inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		if err := whereTenant(ctx, tx.NewSelect().Model(news)).Where("id = ?", id).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+		before, err := auditHash(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		from := news.Status
+		if !from.CanTransitionTo(to) {
+			return NewCustomError(fmt.Errorf("cannot move news from %s to %s", from, to), http.StatusConflict)
+		}
+
+		q := tx.NewUpdate().
+			Model(news).
+			Set("status = ?", to).
+			Set("status_changed_at = current_timestamp").
+			Set("status_changed_by = ?", actor).
+			Set("updated_at = current_timestamp").
+			WherePK()
+		if to == StatusPublished {
+			q = q.Set("published_at = current_timestamp")
+		}
+		if err := q.Returning("*").Scan(ctx); err != nil {
+			return err
+		}
+
+		if _, err := tx.NewInsert().Model(&Transition{NewsId: id, TenantId: news.TenantId, From: from, To: to, Actor: actor}).Exec(ctx); err != nil {
+			return err
+		}
+		if err := track(ctx, tx, id, before); err != nil {
+			return err
+		}
+		event := EventUpdated
+		if to == StatusPublished {
+			event = EventPublished
+		}
+		if err := emit(ctx, tx, event, news.TenantId, news.Id, news); err != nil {
+			return err
+		}
+		return NewStore(tx).loadAuthors(ctx, news)
+	})
+	if err != nil {
+		return nil, toCustomError(err)
+	}
+	return news, nil
+}
+
+// PublishDue publishes the approved news of every tenant whose publish_at has
+// passed on behalf of SchedulerActor. Only one caller at a time does the
+// work, the others publish nothing.
+func (s Store) PublishDue(ctx context.Context) (news []*Record, err error) {
+	ctx = tenant.CtxWithTenant(ctx, tenant.All)
+	err = inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		locked, err := postgres.TryAdvisoryXactLock(ctx, tx, publishLockKey)
+		if err != nil || !locked {
+			return err
+		}
+
+		err = tx.NewUpdate().
+			Model((*Record)(nil)).
+			Set("status = ?", StatusPublished).
+			Set("status_changed_at = current_timestamp").
+			Set("status_changed_by = ?", SchedulerActor).
+			Set("published_at = current_timestamp").
+			Set("updated_at = current_timestamp").
+			Where("status = ?", StatusApproved).
+			Where("publish_at <= current_timestamp").
+			Returning("*").
+			Scan(ctx, &news)
+		if err != nil || len(news) == 0 {
+			return err
+		}
+
+		transitions := make([]*Transition, 0, len(news))
+		for _, n := range news {
+			transitions = append(transitions, &Transition{NewsId: n.Id, TenantId: n.TenantId, From: StatusApproved, To: StatusPublished, Actor: SchedulerActor})
+		}
+		if _, err = tx.NewInsert().Model(&transitions).Exec(ctx); err != nil {
+			return err
+		}
+		for _, n := range news {
+			if err := emit(ctx, tx, EventPublished, n.TenantId, n.Id, n); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, NewCustomError(err, http.StatusInternalServerError)
+	}
+	return news, nil
+}
+
+// SweepExpired archives or soft-deletes the expired news of every tenant in
+// batches of batchSize, calling swept for each of them once its batch is
+// committed. Rows being swept by another replica are skipped.
+func (s Store) SweepExpired(ctx context.Context, action SweepAction, batchSize int, swept func(*Record)) (count int, err error) {
+	ctx = tenant.CtxWithTenant(ctx, tenant.All)
+	for {
+		var batch []*Record
+		err = inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+			q := tx.NewSelect().
+				Model(&batch).
+				Where("expires_at <= current_timestamp").
+				OrderExpr("expires_at").
+				Limit(batchSize).
+				For("UPDATE SKIP LOCKED")
+			if action == SweepArchive {
+				q = q.Where("status = ?", StatusPublished)
+			}
+			if err := q.Scan(ctx); err != nil || len(batch) == 0 {
+				return err
+			}
+
+			ids := make([]uuid.UUID, 0, len(batch))
+			for _, n := range batch {
+				ids = append(ids, n.Id)
+			}
+			if action == SweepDelete {
+				if _, err := tx.NewDelete().Model((*Record)(nil)).Where("id IN (?)", bun.In(ids)).Exec(ctx); err != nil {
+					return err
+				}
+				for _, n := range batch {
+					if err := emit(ctx, tx, EventDeleted, n.TenantId, n.Id, deletedRecord{Id: n.Id}); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			_, err := tx.NewUpdate().
+				Model((*Record)(nil)).
+				Set("status = ?", StatusArchived).
+				Set("status_changed_at = current_timestamp").
+				Set("status_changed_by = ?", SweeperActor).
+				Set("updated_at = current_timestamp").
+				Where("id IN (?)", bun.In(ids)).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+			transitions := make([]*Transition, 0, len(batch))
+			for _, n := range batch {
+				n.Status = StatusArchived
+				transitions = append(transitions, &Transition{NewsId: n.Id, TenantId: n.TenantId, From: StatusPublished, To: StatusArchived, Actor: SweeperActor})
+			}
+			if _, err = tx.NewInsert().Model(&transitions).Exec(ctx); err != nil {
+				return err
+			}
+			for _, n := range batch {
+				if err := emit(ctx, tx, EventUpdated, n.TenantId, n.Id, n); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return count, NewCustomError(err, http.StatusInternalServerError)
+		}
+
+		for _, n := range batch {
+			swept(n)
+		}
+		count += len(batch)
+		if len(batch) < batchSize || ctx.Err() != nil {
+			return count, nil
+		}
+	}
+}
+
+// RecordSweep adds the outcome of a sweeper run to the stats.
+func (s Store) RecordSweep(ctx context.Context, run SweepRun) error {
+	stats := &SweepStats{
+		Name:           sweepStatsName,
+		Runs:           1,
+		LastAction:     run.Action,
+		LastStartedAt:  run.StartedAt,
+		LastFinishedAt: time.Now(),
+		LastCount:      run.Count,
+	}
+	if run.Err != nil {
+		stats.LastError = run.Err.Error()
+	}
+	switch run.Action {
+	case SweepArchive:
+		stats.Archived = int64(run.Count)
+	case SweepDelete:
+		stats.Deleted = int64(run.Count)
 	}
 
-	rowsAffected, err := r.RowsAffected()
+	_, err := s.db.NewInsert().
+		Model(stats).
+		On("CONFLICT (name) DO UPDATE").
+		Set("runs = sweep_stats.runs + 1").
+		Set("last_action = EXCLUDED.last_action").
+		Set("last_started_at = EXCLUDED.last_started_at").
+		Set("last_finished_at = EXCLUDED.last_finished_at").
+		Set("last_count = EXCLUDED.last_count").
+		Set("last_error = EXCLUDED.last_error").
+		Set("archived = sweep_stats.archived + EXCLUDED.archived").
+		Set("deleted = sweep_stats.deleted + EXCLUDED.deleted").
+		Exec(ctx)
 	if err != nil {
 		return NewCustomError(err, http.StatusInternalServerError)
 	}
-	if rowsAffected == 0 {
-		return NewCustomError(err, http.StatusNotFound)
+	return nil
+}
+
+// FindSweepStats returns the sweeper stats, all zero before its first run.
+func (s Store) FindSweepStats(ctx context.Context) (*SweepStats, error) {
+	stats := &SweepStats{Name: sweepStatsName}
+	err := s.db.NewSelect().Model(stats).WherePK().Scan(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, NewCustomError(err, http.StatusInternalServerError)
+	}
+	return stats, nil
+}
+
+// FindSimilar returns the near-duplicates of the news with the given ID,
+// closest first.
+func (s Store) FindSimilar(ctx context.Context, id uuid.UUID) (news []*Record, err error) {
+	err = inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		news, err = NewStore(tx).findSimilar(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, toCustomError(err)
+	}
+	return news, nil
+}
+
+func (s Store) findSimilar(ctx context.Context, id uuid.UUID) (news []*Record, err error) {
+	n, err := s.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	news = []*Record{}
+	err = whereTenant(ctx, s.db.NewSelect().Model(&news)).
+		Where("id <> ?", id).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			if n.ClusterId != uuid.Nil {
+				q = q.WhereOr("cluster_id = ?", n.ClusterId)
+			}
+			return q.WhereOr("bit_count((fingerprint # ?)::bit(64)) <= ?", n.Fingerprint, DefaultSimilarityThreshold)
+		}).
+		OrderExpr("bit_count((fingerprint # ?)::bit(64))", n.Fingerprint).
+		Scan(ctx)
+	if err != nil {
+		return nil, NewCustomError(err, http.StatusInternalServerError)
+	}
+	if err := s.loadAuthors(ctx, news...); err != nil {
+		return nil, err
+	}
+	return news, nil
+}
+
+// fingerprint computes the SimHash of the news and assigns it to the cluster
+// of its closest near-duplicate, or to a new cluster if there is none.
+func (s Store) fingerprint(ctx context.Context, news *Record) error {
+	news.Fingerprint = int64(SimHash(news.Title, news.Content)) //nolint:gosec // stored bit for bit in a BIGINT column
+
+	var match Record
+	err := whereTenant(ctx, s.db.NewSelect().Model(&match)).
+		Column("id", "cluster_id").
+		Where("id <> ?", news.Id).
+		Where("fingerprint IS NOT NULL").
+		Where("bit_count((fingerprint # ?)::bit(64)) <= ?", news.Fingerprint, DefaultSimilarityThreshold).
+		OrderExpr("bit_count((fingerprint # ?)::bit(64))", news.Fingerprint).
+		Limit(1).
+		Scan(ctx)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		news.ClusterId = news.Id
+	case err != nil:
+		return fmt.Errorf("find nearest fingerprint: %w", err)
+	case match.ClusterId == uuid.Nil:
+		news.ClusterId = match.Id
+	default:
+		news.ClusterId = match.ClusterId
+	}
+	return nil
+}
+
+// BackfillFingerprints fingerprints and clusters, in batches of batchSize,
+// the news of every tenant stored before fingerprints were computed, oldest
+// first so that they join the clusters of the news they duplicate. Rows being
+// backfilled by another replica are skipped. It returns the number of news
+// fingerprinted.
+func (s Store) BackfillFingerprints(ctx context.Context, batchSize int) (count int, err error) {
+	ctx = tenant.CtxWithTenant(ctx, tenant.All)
+	for {
+		var batch []*Record
+		err = inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+			err := tx.NewSelect().
+				Model(&batch).
+				WhereAllWithDeleted().
+				Where("fingerprint IS NULL").
+				OrderExpr("created_at, id").
+				Limit(batchSize).
+				For("UPDATE SKIP LOCKED").
+				Scan(ctx)
+			if err != nil {
+				return err
+			}
+			txStore := NewStore(tx)
+			for _, n := range batch {
+				// Clusters do not span tenants.
+				ctx := tenant.CtxWithTenant(ctx, &tenant.Tenant{Id: n.TenantId})
+				if err := txStore.fingerprint(ctx, n); err != nil {
+					return err
+				}
+				_, err := tx.NewUpdate().
+					Model(n).
+					Column("fingerprint", "cluster_id").
+					WherePK().
+					WhereAllWithDeleted().
+					Exec(ctx)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return count, NewCustomError(err, http.StatusInternalServerError)
+		}
+		count += len(batch)
+		if len(batch) < batchSize {
+			return count, nil
+		}
+	}
+}
+
+// resolveTags normalizes the tags of the news, replaces aliases by their
+// canonical slug and registers the tags seen for the first time in the tenant
+// of the news.
+func (s Store) resolveTags(ctx context.Context, news *Record) error {
+	news.Tags = NormalizeTags(news.Tags)
+	if len(news.Tags) == 0 {
+		return nil
+	}
+
+	var known []*Tag
+	err := s.db.NewSelect().
+		Model(&known).
+		Where("tenant_id = ?", news.TenantId).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("slug IN (?)", bun.In(news.Tags)).WhereOr("aliases && ?", pgdialect.Array(news.Tags))
+		}).
+		Scan(ctx)
+	if err != nil {
+		return fmt.Errorf("find tags: %w", err)
+	}
+
+	canonical := make(map[string]string, len(known))
+	for _, t := range known {
+		canonical[t.Slug] = t.Slug
+		for _, a := range t.Aliases {
+			canonical[a] = t.Slug
+		}
+	}
+
+	resolved := make([]string, 0, len(news.Tags))
+	var missing []*Tag
+	for _, t := range news.Tags {
+		slug, ok := canonical[t]
+		if !ok {
+			slug = t
+			canonical[t] = t
+			missing = append(missing, &Tag{TenantId: news.TenantId, Slug: t, Name: t, Aliases: []string{}})
+		}
+		if !slices.Contains(resolved, slug) {
+			resolved = append(resolved, slug)
+		}
+	}
+	news.Tags = resolved
+
+	if len(missing) > 0 {
+		if _, err := s.db.NewInsert().Model(&missing).On("CONFLICT (tenant_id, slug) DO NOTHING").Exec(ctx); err != nil {
+			return fmt.Errorf("create tags: %w", err)
+		}
+	}
+	return nil
+}
+
+// resolveAuthors finds the authors of the news by slug in its tenant, creating
+// the missing ones, and keeps the author column as the joined byline. News without
+// authors get them from the byline of the author column. Authors given by
+// slug only have to exist.
+func (s Store) resolveAuthors(ctx context.Context, news *Record) error {
+	if len(news.Authors) == 0 {
+		news.Authors = AuthorsOf(news.Author)
+	}
+
+	resolved := make([]*Author, 0, len(news.Authors))
+	for _, a := range news.Authors {
+		slug := Slugify(a.Slug)
+		if slug == "" {
+			slug = Slugify(a.Name)
+		}
+		if slug == "" {
+			return NewCustomError(fmt.Errorf("invalid author name: %q", a.Name), http.StatusBadRequest)
+		}
+
+		author := &Author{TenantId: news.TenantId, Slug: slug, Name: strings.TrimSpace(a.Name)}
+		var err error
+		if author.Name == "" {
+			err = s.db.NewSelect().Model(author).Where("tenant_id = ?", news.TenantId).Where("slug = ?", slug).Scan(ctx)
+			if errors.Is(err, sql.ErrNoRows) {
+				return NewCustomError(fmt.Errorf("unknown author: %q", slug), http.StatusBadRequest)
+			}
+		} else {
+			err = s.db.NewInsert().
+				Model(author).
+				On("CONFLICT (tenant_id, slug) DO UPDATE").
+				Set("slug = EXCLUDED.slug").
+				Returning("*").
+				Scan(ctx)
+		}
+		if err != nil {
+			return fmt.Errorf("find author %q: %w", slug, err)
+		}
+		if !slices.ContainsFunc(resolved, func(r *Author) bool { return r.Id == author.Id }) {
+			resolved = append(resolved, author)
+		}
+	}
+
+	news.Authors = resolved
+	if len(resolved) > 0 {
+		news.Author = Byline(resolved)
 	}
 	return nil
 }
+
+// linkAuthors replaces the bylines of the news with its authors.
+func (s Store) linkAuthors(ctx context.Context, news *Record) error {
+	if _, err := s.db.NewDelete().Model((*NewsAuthor)(nil)).Where("news_id = ?", news.Id).Exec(ctx); err != nil {
+		return fmt.Errorf("delete bylines: %w", err)
+	}
+	if len(news.Authors) == 0 {
+		return nil
+	}
+
+	links := make([]*NewsAuthor, 0, len(news.Authors))
+	for i, a := range news.Authors {
+		links = append(links, &NewsAuthor{NewsId: news.Id, AuthorId: a.Id, TenantId: news.TenantId, Position: i})
+	}
+	if _, err := s.db.NewInsert().Model(&links).Exec(ctx); err != nil {
+		return fmt.Errorf("create bylines: %w", err)
+	}
+	return nil
+}
+
+type bylineRow struct {
+	Author `bun:",extend"`
+	NewsId uuid.UUID `bun:"news_id,type:uuid"`
+}
+
+// loadAuthors fills the authors of the news in a single query.
+func (s Store) loadAuthors(ctx context.Context, news ...*Record) error {
+	if len(news) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(news))
+	for _, n := range news {
+		ids = append(ids, n.Id)
+	}
+	byNews, err := s.FindAuthorsByNews(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	for _, n := range news {
+		n.Authors = byNews[n.Id]
+		if n.Authors == nil {
+			n.Authors = []*Author{}
+		}
+	}
+	return nil
+}
+
+// FindAuthorsByNews returns the authors of the news of the tenant of the
+// context in a single query, in the order of their bylines.
+func (s Store) FindAuthorsByNews(ctx context.Context, ids ...uuid.UUID) (map[uuid.UUID][]*Author, error) {
+	if len(ids) == 0 {
+		return map[uuid.UUID][]*Author{}, nil
+	}
+	var rows []*bylineRow
+	err := inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		return whereTenant(ctx, tx.NewSelect().Model(&rows)).
+			ColumnExpr("author.*").
+			ColumnExpr("na.news_id").
+			Join("JOIN news_authors AS na ON na.author_id = author.id").
+			Where("na.news_id IN (?)", bun.In(ids)).
+			Order("na.position").
+			Scan(ctx)
+	})
+	if err != nil {
+		return nil, NewCustomError(fmt.Errorf("find authors: %w", err), http.StatusInternalServerError)
+	}
+
+	byNews := make(map[uuid.UUID][]*Author, len(ids))
+	for _, row := range rows {
+		byNews[row.NewsId] = append(byNews[row.NewsId], &row.Author)
+	}
+	return byNews, nil
+}
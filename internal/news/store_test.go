@@ -2,6 +2,7 @@ package news_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -14,6 +15,7 @@ import (
 	"github.com/docker/go-connections/nat"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 	pgtc "github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -176,7 +178,7 @@ func TestStore_FindAll(t *testing.T) {
 
 	for _, tc := range testCases {
 		s := news.NewStore(db)
-		allNews, err := s.FindAll(context.Background())
+		allNews, err := s.FindAll(context.Background(), news.Filter{})
 
 		assert.NoError(t, err)
 		assert.Len(t, allNews, len(tc.expectedNews))
@@ -258,6 +260,415 @@ func TestStore_UpdatedByID(t *testing.T) {
 	}
 }
 
+func TestStore_FindSimilar(t *testing.T) {
+	s := news.NewStore(db)
+	ctx := context.Background()
+
+	original, err := s.Create(ctx, &news.Record{
+		Author:  "Reuters",
+		Title:   "Storm hits coast",
+		Summary: "A storm hit the coast",
+		Content: "A strong storm hit the northern coast on Monday, cutting power to thousands of homes and forcing schools to close.",
+		Source:  "https://www.example.com",
+		Tags:    []string{"weather"},
+	})
+	assert.NoError(t, err)
+	duplicate, err := s.Create(ctx, &news.Record{
+		Author:  "AP",
+		Title:   "Storm hits coast",
+		Summary: "A storm hit the coast",
+		Content: "A strong storm hit the northern coast on Monday, cutting power to thousands of houses and forcing schools to close.",
+		Source:  "https://www.example.org",
+		Tags:    []string{"weather"},
+	})
+	assert.NoError(t, err)
+	unrelated, err := s.Create(ctx, &news.Record{
+		Author:  "AP",
+		Title:   "Central bank holds rates",
+		Summary: "Rates unchanged",
+		Content: "The central bank left interest rates unchanged on Tuesday, citing slowing inflation.",
+		Source:  "https://www.example.org",
+		Tags:    []string{"economy"},
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		for _, n := range []*news.Record{original, duplicate, unrelated} {
+			assert.NoError(t, s.DeleteById(ctx, n.Id))
+		}
+	})
+
+	assert.Equal(t, original.Id, original.ClusterId)
+	assert.Equal(t, original.ClusterId, duplicate.ClusterId)
+	assert.Equal(t, unrelated.Id, unrelated.ClusterId)
+
+	similar, err := s.FindSimilar(ctx, original.Id)
+	assert.NoError(t, err)
+	assert.Len(t, similar, 1)
+	assert.Equal(t, duplicate.Id, similar[0].Id)
+
+	_, err = s.FindSimilar(ctx, uuid.New())
+	var storeErr *news.CustomError
+	assert.ErrorAs(t, err, &storeErr)
+	assert.Equal(t, http.StatusNotFound, storeErr.HttpStatusCode())
+}
+
+func TestStore_BackfillFingerprints(t *testing.T) {
+	s := news.NewStore(db)
+	ctx := context.Background()
+
+	var created []*news.Record
+	for _, source := range []string{"https://www.example.com", "https://www.example.org"} {
+		n, err := s.Create(ctx, &news.Record{
+			Author:  "Reuters",
+			Title:   "Bridge reopens",
+			Summary: "The bridge reopened",
+			Content: "The old bridge over the river reopened to traffic on Friday after two years of repairs.",
+			Source:  source,
+			Tags:    []string{"city"},
+		})
+		require.NoError(t, err)
+		created = append(created, n)
+	}
+	t.Cleanup(func() {
+		for _, n := range created {
+			assert.NoError(t, s.DeleteById(ctx, n.Id))
+		}
+	})
+	// News stored before fingerprints were computed.
+	_, err := db.NewUpdate().
+		Model((*news.Record)(nil)).
+		Set("fingerprint = NULL").
+		Set("cluster_id = NULL").
+		Where("id IN (?)", bun.In(ids(created))).
+		Exec(ctx)
+	require.NoError(t, err)
+
+	count, err := s.BackfillFingerprints(ctx, 1)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, count, len(created))
+
+	first, err := s.FindById(ctx, created[0].Id)
+	require.NoError(t, err)
+	second, err := s.FindById(ctx, created[1].Id)
+	require.NoError(t, err)
+	assert.Equal(t, created[0].Fingerprint, first.Fingerprint)
+	assert.Equal(t, first.Id, first.ClusterId)
+	assert.Equal(t, first.ClusterId, second.ClusterId)
+
+	count, err = s.BackfillFingerprints(ctx, 1)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestStore_Transition(t *testing.T) {
+	s := news.NewStore(db)
+	ctx := context.Background()
+
+	n, err := s.Create(ctx, &news.Record{
+		Author:  "test-author",
+		Title:   "Embargoed budget",
+		Summary: "test-summary",
+		Content: "The budget will be presented next week.",
+		Source:  "https://www.example.com",
+		Tags:    []string{"economy"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, s.DeleteById(ctx, n.Id))
+	})
+	assert.Equal(t, news.StatusDraft, n.Status)
+
+	public, err := s.FindAll(ctx, news.Filter{Public: true})
+	require.NoError(t, err)
+	assert.NotContains(t, ids(public), n.Id)
+
+	_, err = s.Transition(ctx, n.Id, news.StatusPublished, "alice")
+	var storeErr *news.CustomError
+	require.ErrorAs(t, err, &storeErr)
+	assert.Equal(t, http.StatusConflict, storeErr.HttpStatusCode())
+
+	for _, to := range []news.Status{news.StatusInReview, news.StatusApproved, news.StatusPublished} {
+		n, err = s.Transition(ctx, n.Id, to, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, to, n.Status)
+		assert.Equal(t, "alice", n.StatusChangedBy)
+		assert.NotEqual(t, time.Time{}, n.StatusChangedAt)
+	}
+	assert.NotEqual(t, time.Time{}, n.PublishedAt)
+
+	public, err = s.FindAll(ctx, news.Filter{Public: true})
+	require.NoError(t, err)
+	assert.Contains(t, ids(public), n.Id)
+
+	// Updating the content keeps the editorial state.
+	n.Content = "The budget was presented today."
+	require.NoError(t, s.UpdateById(ctx, n.Id, n))
+	n, err = s.FindById(ctx, n.Id)
+	require.NoError(t, err)
+	assert.Equal(t, news.StatusPublished, n.Status)
+
+	var transitions []*news.Transition
+	require.NoError(t, db.NewSelect().Model(&transitions).Where("news_id = ?", n.Id).Order("id").Scan(ctx))
+	require.Len(t, transitions, 3)
+	assert.Equal(t, news.StatusDraft, transitions[0].From)
+	assert.Equal(t, news.StatusPublished, transitions[2].To)
+}
+
+func TestStore_PublishDue(t *testing.T) {
+	s := news.NewStore(db)
+	ctx := context.Background()
+
+	n, err := s.Create(ctx, &news.Record{
+		Author:       "test-author",
+		Title:        "Quarterly results",
+		Summary:      "test-summary",
+		Content:      "The results are in.",
+		Source:       "https://www.example.com",
+		Tags:         []string{"economy"},
+		PublishAt:    time.Now().Add(-time.Minute),
+		EmbargoUntil: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, s.DeleteById(ctx, n.Id))
+	})
+
+	// Drafts are not published even when due.
+	published, err := s.PublishDue(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, ids(published), n.Id)
+
+	for _, to := range []news.Status{news.StatusInReview, news.StatusApproved} {
+		_, err = s.Transition(ctx, n.Id, to, "alice")
+		require.NoError(t, err)
+	}
+
+	// Another replica holding the lock does the work.
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	locked, err := postgres.TryAdvisoryXactLock(ctx, tx, postgres.LockKey("news.publish_due"))
+	require.NoError(t, err)
+	require.True(t, locked)
+	published, err = s.PublishDue(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, published)
+	require.NoError(t, tx.Rollback())
+
+	published, err = s.PublishDue(ctx)
+	require.NoError(t, err)
+	require.Contains(t, ids(published), n.Id)
+
+	n, err = s.FindById(ctx, n.Id)
+	require.NoError(t, err)
+	assert.Equal(t, news.StatusPublished, n.Status)
+	assert.Equal(t, news.SchedulerActor, n.StatusChangedBy)
+	assert.False(t, n.Public())
+
+	public, err := s.FindAll(ctx, news.Filter{Public: true})
+	require.NoError(t, err)
+	assert.NotContains(t, ids(public), n.Id)
+
+	// Lifting the embargo makes the news public.
+	n.EmbargoUntil = time.Time{}
+	require.NoError(t, s.UpdateById(ctx, n.Id, n))
+	public, err = s.FindAll(ctx, news.Filter{Public: true})
+	require.NoError(t, err)
+	assert.Contains(t, ids(public), n.Id)
+}
+
+func TestStore_SweepExpired(t *testing.T) {
+	s := news.NewStore(db)
+	ctx := context.Background()
+
+	create := func(title string) *news.Record {
+		n, err := s.Create(ctx, &news.Record{
+			Author:    "test-author",
+			Title:     title,
+			Summary:   "test-summary",
+			Content:   "Live coverage of " + title,
+			Source:    "https://www.example.com",
+			Tags:      []string{"live"},
+			ExpiresAt: time.Now().Add(-time.Minute),
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			assert.NoError(t, s.DeleteById(ctx, n.Id))
+		})
+		return n
+	}
+	published := []*news.Record{create("the election night"), create("the cup final")}
+	draft := create("the flood")
+	for _, n := range published {
+		for _, to := range []news.Status{news.StatusInReview, news.StatusApproved, news.StatusPublished} {
+			_, err := s.Transition(ctx, n.Id, to, "alice")
+			require.NoError(t, err)
+		}
+	}
+
+	public, err := s.FindAll(ctx, news.Filter{Public: true})
+	require.NoError(t, err)
+	assert.NotContains(t, ids(public), published[0].Id)
+
+	// Archiving only touches published news, one batch at a time.
+	var swept []*news.Record
+	count, err := s.SweepExpired(ctx, news.SweepArchive, 1, func(n *news.Record) { swept = append(swept, n) })
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.ElementsMatch(t, ids(published), ids(swept))
+	for _, n := range published {
+		n, err = s.FindById(ctx, n.Id)
+		require.NoError(t, err)
+		assert.Equal(t, news.StatusArchived, n.Status)
+		assert.Equal(t, news.SweeperActor, n.StatusChangedBy)
+	}
+
+	// Deleting touches the remaining expired news whatever their state.
+	swept = nil
+	count, err = s.SweepExpired(ctx, news.SweepDelete, 10, func(n *news.Record) { swept = append(swept, n) })
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Contains(t, ids(swept), draft.Id)
+	_, err = s.FindById(ctx, draft.Id)
+	assert.Error(t, err)
+
+	require.NoError(t, s.RecordSweep(ctx, news.SweepRun{Action: news.SweepArchive, StartedAt: time.Now(), Count: 2}))
+	require.NoError(t, s.RecordSweep(ctx, news.SweepRun{Action: news.SweepDelete, StartedAt: time.Now(), Count: 3, Err: errors.New("interrupted")}))
+	stats, err := s.FindSweepStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Runs)
+	assert.Equal(t, news.SweepDelete, stats.LastAction)
+	assert.Equal(t, 3, stats.LastCount)
+	assert.Equal(t, "interrupted", stats.LastError)
+	assert.Equal(t, int64(2), stats.Archived)
+	assert.Equal(t, int64(3), stats.Deleted)
+}
+
+func TestStore_FindAll_Page(t *testing.T) {
+	s := news.NewStore(db)
+	ctx := context.Background()
+
+	var created []*news.Record
+	for range 3 {
+		n, err := s.Create(ctx, &news.Record{
+			Author:  "test-author",
+			Title:   "Paged",
+			Summary: "test-summary",
+			Content: "test-content",
+			Source:  "https://www.example.com",
+			Tags:    []string{"paging"},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			assert.NoError(t, s.DeleteById(ctx, n.Id))
+		})
+		created = append(created, n)
+	}
+
+	// Only the columns asked for are loaded, and the authors are left out.
+	first, err := s.FindAll(ctx, news.Filter{Tag: "paging", Columns: []string{"title"}, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	assert.Equal(t, "Paged", first[0].Title)
+	assert.Empty(t, first[0].Content)
+	assert.Nil(t, first[0].Authors)
+	assert.False(t, first[0].CreatedAt.Before(first[1].CreatedAt))
+
+	last := first[1]
+	second, err := s.FindAll(ctx, news.Filter{Tag: "paging", After: &news.Cursor{CreatedAt: last.CreatedAt, Id: last.Id}, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+	assert.ElementsMatch(t, ids(created), append(ids(first), second[0].Id))
+
+	byId, err := s.FindAll(ctx, news.Filter{Ids: []uuid.UUID{created[0].Id}})
+	require.NoError(t, err)
+	require.Len(t, byId, 1)
+	authors, err := s.FindAuthorsByNews(ctx, created[0].Id)
+	require.NoError(t, err)
+	assert.Equal(t, byId[0].Authors, authors[created[0].Id])
+}
+
+func TestStore_FindAll_Query(t *testing.T) {
+	s := news.NewStore(db)
+	ctx := context.Background()
+
+	n, err := s.Create(ctx, &news.Record{
+		Author:  "test-author",
+		Title:   "Quarterly 100% Results",
+		Summary: "test-summary",
+		Content: "The figures of the_quarter",
+		Source:  "https://www.example.com",
+		Tags:    []string{"query"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, s.DeleteById(ctx, n.Id))
+	})
+
+	testCases := []struct {
+		query    string
+		expected []uuid.UUID
+	}{
+		{query: "quarterly", expected: []uuid.UUID{n.Id}},
+		{query: "FIGURES", expected: []uuid.UUID{n.Id}},
+		{query: "100%", expected: []uuid.UUID{n.Id}},
+		{query: "the_quarter", expected: []uuid.UUID{n.Id}},
+		{query: "the quarter", expected: []uuid.UUID{}},
+		{query: "0%r", expected: []uuid.UUID{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			found, err := s.FindAll(ctx, news.Filter{Tag: "query", Query: tc.query})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.expected, ids(found))
+		})
+	}
+}
+
+func TestStore_Restore(t *testing.T) {
+	s := news.NewStore(db)
+	ctx := context.Background()
+
+	n, err := s.Create(ctx, &news.Record{
+		Author:  "test-author",
+		Title:   "Restored",
+		Summary: "test-summary",
+		Content: "test-content",
+		Source:  "https://www.example.com",
+		Tags:    []string{"restore"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, s.DeleteById(ctx, n.Id))
+	})
+
+	// News that are not deleted cannot be restored.
+	_, err = s.Restore(ctx, n.Id)
+	var dbErr *news.CustomError
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, http.StatusNotFound, dbErr.HttpStatusCode())
+
+	require.NoError(t, s.DeleteById(ctx, n.Id))
+	_, err = s.FindById(ctx, n.Id)
+	require.Error(t, err)
+
+	restored, err := s.Restore(ctx, n.Id)
+	require.NoError(t, err)
+	assertOnNews(t, n, restored)
+	assert.Equal(t, n.Authors, restored.Authors)
+	found, err := s.FindById(ctx, n.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Restored", found.Title)
+}
+
+func ids(news []*news.Record) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(news))
+	for _, n := range news {
+		ids = append(ids, n.Id)
+	}
+	return ids
+}
+
 func assertOnNews(tb testing.TB, expected, got *news.Record) {
 	tb.Helper()
 	assert.Equal(tb, expected.Author, got.Author)
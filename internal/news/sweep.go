@@ -0,0 +1,56 @@
+package news
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// SweepAction is what the sweeper does with expired news.
+type SweepAction string
+
+const (
+	// SweepArchive archives expired published news.
+	SweepArchive SweepAction = "archive"
+	// SweepDelete soft-deletes expired news whatever their state.
+	SweepDelete SweepAction = "delete"
+)
+
+// SweeperActor is recorded as the actor of the transitions made when expired
+// news are archived.
+const SweeperActor = "sweeper"
+
+func ParseSweepAction(s string) (SweepAction, error) {
+	switch a := SweepAction(s); a {
+	case SweepArchive, SweepDelete:
+		return a, nil
+	default:
+		return "", fmt.Errorf("invalid sweep action: %q", s)
+	}
+}
+
+// sweepStatsName is the key of the single row of sweep_stats.
+const sweepStatsName = "expired"
+
+// SweepRun is the outcome of a single run of the sweeper.
+type SweepRun struct {
+	Action    SweepAction
+	StartedAt time.Time
+	Count     int
+	Err       error
+}
+
+// SweepStats aggregates the runs of the sweeper across all replicas.
+type SweepStats struct {
+	bun.BaseModel  `bun:"table:sweep_stats,alias:sweep_stats"`
+	Name           string      `bun:"name,pk" json:"-"`
+	Runs           int64       `bun:"runs,notnull" json:"runs"`
+	LastAction     SweepAction `bun:"last_action,notnull" json:"last_action"`
+	LastStartedAt  time.Time   `bun:"last_started_at,nullzero" json:"last_started_at"`
+	LastFinishedAt time.Time   `bun:"last_finished_at,nullzero" json:"last_finished_at"`
+	LastCount      int         `bun:"last_count,notnull" json:"last_count"`
+	LastError      string      `bun:"last_error,notnull" json:"last_error"`
+	Archived       int64       `bun:"archived,notnull" json:"archived"`
+	Deleted        int64       `bun:"deleted,notnull" json:"deleted"`
+}
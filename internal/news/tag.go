@@ -0,0 +1,47 @@
+package news
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/uptrace/bun"
+)
+
+// Tag of the news of a tenant, with the former slugs it was renamed or merged
+// from as aliases.
+type Tag struct {
+	bun.BaseModel `bun:"table:tags,alias:tag"`
+	TenantId      string    `bun:"tenant_id,pk" json:"-"`
+	Slug          string    `bun:"slug,pk" json:"slug"`
+	Name          string    `bun:"name,nullzero,notnull" json:"name"`
+	Aliases       []string  `bun:"aliases,notnull,array" json:"aliases"`
+	Count         int       `bun:"count,scanonly" json:"count"`
+	CreatedAt     time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt     time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}
+
+// Slugify lowercases s and joins its words with dashes, so "Go ", "go" and
+// "GO" all become "go".
+func Slugify(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// NormalizeTags slugifies the tags, dropping empty and repeated ones while
+// keeping their order.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, t := range tags {
+		slug := Slugify(t)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		normalized = append(normalized, slug)
+	}
+	return normalized
+}
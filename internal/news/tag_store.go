@@ -0,0 +1,163 @@
+package news
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+type TagStore struct {
+	db bun.IDB
+}
+
+func NewTagStore(db bun.IDB) *TagStore {
+	return &TagStore{
+		db: db,
+	}
+}
+
+// FindAll returns the tags of the tenant of the context with the number of
+// news using them.
+func (s TagStore) FindAll(ctx context.Context) (tags []*Tag, err error) {
+	tags = []*Tag{}
+	err = inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		return whereTenant(ctx, tx.NewSelect().Model(&tags)).
+			ColumnExpr("tag.*").
+			ColumnExpr("(SELECT count(*) FROM news WHERE tag.slug = ANY(news.tags) AND news.deleted_at IS NULL AND news.tenant_id = tag.tenant_id) AS count").
+			Order("slug").
+			Scan(ctx)
+	})
+	if err != nil {
+		return nil, NewCustomError(err, http.StatusInternalServerError)
+	}
+	return tags, nil
+}
+
+// Rename changes the slug and display name of a tag of the tenant of the
+// context and rewrites its news using it. The old slug is kept as an alias.
+func (s TagStore) Rename(ctx context.Context, slug string, tag *Tag) (*Tag, error) {
+	tenantId, err := ownTenant(ctx, "tags")
+	if err != nil {
+		return nil, err
+	}
+	tag.Slug = Slugify(tag.Slug)
+	if tag.Slug == "" {
+		tag.Slug = slug
+	}
+
+	err = inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		var current Tag
+		if err := whereTenant(ctx, tx.NewSelect().Model(&current)).Where("slug = ?", slug).For("UPDATE").Scan(ctx); err != nil {
+			return fmt.Errorf("find tag %q: %w", slug, err)
+		}
+		if tag.Name == "" {
+			tag.Name = current.Name
+		}
+		tag.Aliases = current.Aliases
+		if tag.Aliases == nil {
+			tag.Aliases = []string{}
+		}
+
+		if tag.Slug != slug {
+			exists, err := whereTenant(ctx, tx.NewSelect().Model((*Tag)(nil))).Where("slug = ?", tag.Slug).Exists(ctx)
+			if err != nil {
+				return fmt.Errorf("find tag %q: %w", tag.Slug, err)
+			}
+			if exists {
+				return NewCustomError(fmt.Errorf("tag %q already exists, merge instead", tag.Slug), http.StatusConflict)
+			}
+			tag.Aliases = appendAliases(tag.Aliases, tag.Slug, slug)
+			var retagged []*Record
+			if err := tx.NewRaw(
+				"UPDATE news SET tags = array_replace(tags, ?, ?), updated_at = current_timestamp WHERE ? = ANY(tags) AND tenant_id = ? RETURNING *",
+				slug, tag.Slug, slug, tenantId,
+			).Scan(ctx, &retagged); err != nil {
+				return fmt.Errorf("rewrite news tags: %w", err)
+			}
+			if err := emitUpdated(ctx, tx, retagged); err != nil {
+				return err
+			}
+		}
+
+		return whereTenant(ctx, tx.NewUpdate().Model(tag)).
+			Set("slug = ?", tag.Slug).
+			Set("name = ?", tag.Name).
+			Set("aliases = ?", pgdialect.Array(tag.Aliases)).
+			Set("updated_at = current_timestamp").
+			Where("slug = ?", slug).
+			Returning("*").
+			Scan(ctx)
+	})
+	if err != nil {
+		return nil, toCustomError(err)
+	}
+	return tag, nil
+}
+
+// Merge folds the tag from into the tag into, both of the tenant of the
+// context: its news tagged with from are retagged, and from and its aliases
+// become aliases of into.
+func (s TagStore) Merge(ctx context.Context, from, into string) (*Tag, error) {
+	tenantId, err := ownTenant(ctx, "tags")
+	if err != nil {
+		return nil, err
+	}
+	if from == into {
+		return nil, NewCustomError(errors.New("cannot merge a tag into itself"), http.StatusBadRequest)
+	}
+
+	var tag Tag
+	err = inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		var source Tag
+		if err := whereTenant(ctx, tx.NewSelect().Model(&source)).Where("slug = ?", from).For("UPDATE").Scan(ctx); err != nil {
+			return fmt.Errorf("find tag %q: %w", from, err)
+		}
+		if err := whereTenant(ctx, tx.NewSelect().Model(&tag)).Where("slug = ?", into).For("UPDATE").Scan(ctx); err != nil {
+			return fmt.Errorf("find tag %q: %w", into, err)
+		}
+
+		// Replace the tag and drop the duplicates this may create, keeping
+		// the original order of the tags.
+		var retagged []*Record
+		if err := tx.NewRaw(`UPDATE news SET tags = ARRAY(
+				SELECT t FROM unnest(array_replace(tags, ?, ?)) WITH ORDINALITY AS u(t, i) GROUP BY t ORDER BY min(i)
+			), updated_at = current_timestamp WHERE ? = ANY(tags) AND tenant_id = ? RETURNING *`,
+			from, into, from, tenantId,
+		).Scan(ctx, &retagged); err != nil {
+			return fmt.Errorf("rewrite news tags: %w", err)
+		}
+		if err := emitUpdated(ctx, tx, retagged); err != nil {
+			return err
+		}
+
+		tag.Aliases = appendAliases(tag.Aliases, tag.Slug, append([]string{source.Slug}, source.Aliases...)...)
+		if _, err := tx.NewDelete().Model(&source).WherePK().Exec(ctx); err != nil {
+			return fmt.Errorf("delete tag %q: %w", from, err)
+		}
+		return tx.NewUpdate().
+			Model(&tag).
+			Column("aliases").
+			Set("updated_at = current_timestamp").
+			WherePK().
+			Returning("*").
+			Scan(ctx)
+	})
+	if err != nil {
+		return nil, toCustomError(err)
+	}
+	return &tag, nil
+}
+
+func appendAliases(aliases []string, slug string, add ...string) []string {
+	for _, a := range add {
+		if a != slug && !slices.Contains(aliases, a) {
+			aliases = append(aliases, a)
+		}
+	}
+	return aliases
+}
@@ -0,0 +1,170 @@
+package news_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagStore(t *testing.T) {
+	ctx := context.Background()
+	s := news.NewStore(db)
+	ts := news.NewTagStore(db)
+
+	first, err := s.Create(ctx, &news.Record{
+		Author:  "test-author",
+		Title:   "Generics in practice",
+		Summary: "test-summary",
+		Content: "Type parameters one release later.",
+		Source:  "https://www.example.com",
+		Tags:    []string{"Golang ", "compilers"},
+	})
+	require.NoError(t, err)
+	second, err := s.Create(ctx, &news.Record{
+		Author:  "test-author",
+		Title:   "Release notes",
+		Summary: "test-summary",
+		Content: "The toolchain ships a new garbage collector.",
+		Source:  "https://www.example.com",
+		Tags:    []string{"go-lang", "golang"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, s.DeleteById(ctx, first.Id))
+		assert.NoError(t, s.DeleteById(ctx, second.Id))
+	})
+	assert.Equal(t, []string{"golang", "compilers"}, first.Tags)
+	assert.Equal(t, []string{"go-lang", "golang"}, second.Tags)
+
+	t.Run("rename", func(t *testing.T) {
+		tag, err := ts.Rename(ctx, "golang", &news.Tag{Slug: "Go", Name: "Go"})
+		require.NoError(t, err)
+		assert.Equal(t, "go", tag.Slug)
+		assert.Equal(t, "Go", tag.Name)
+		assert.Equal(t, []string{"golang"}, tag.Aliases)
+
+		n, err := s.FindById(ctx, first.Id)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"go", "compilers"}, n.Tags)
+
+		event, n := lastEvent(t, first.Id)
+		assert.Equal(t, news.EventUpdated, event)
+		assert.Equal(t, []string{"go", "compilers"}, n.Tags)
+	})
+
+	t.Run("rename to existing slug", func(t *testing.T) {
+		_, err := ts.Rename(ctx, "go-lang", &news.Tag{Slug: "go"})
+		var storeErr *news.CustomError
+		require.ErrorAs(t, err, &storeErr)
+		assert.Equal(t, http.StatusConflict, storeErr.HttpStatusCode())
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		tag, err := ts.Merge(ctx, "go-lang", "go")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"golang", "go-lang"}, tag.Aliases)
+
+		n, err := s.FindById(ctx, second.Id)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"go"}, n.Tags)
+
+		event, n := lastEvent(t, second.Id)
+		assert.Equal(t, news.EventUpdated, event)
+		assert.Equal(t, []string{"go"}, n.Tags)
+	})
+
+	t.Run("alias resolved on write and read", func(t *testing.T) {
+		n := &news.Record{
+			Author:  "test-author",
+			Title:   "Release notes",
+			Summary: "test-summary",
+			Content: "The toolchain ships a new garbage collector.",
+			Source:  "https://www.example.com",
+			Tags:    []string{"go-lang"},
+		}
+		require.NoError(t, s.UpdateById(ctx, second.Id, n))
+		assert.Equal(t, []string{"go"}, n.Tags)
+
+		tagged, err := s.FindAll(ctx, news.Filter{Tag: "golang"})
+		require.NoError(t, err)
+		assert.Len(t, tagged, 2)
+	})
+
+	t.Run("counts", func(t *testing.T) {
+		tags, err := ts.FindAll(ctx)
+		require.NoError(t, err)
+		counts := map[string]int{}
+		for _, tag := range tags {
+			counts[tag.Slug] = tag.Count
+		}
+		assert.Equal(t, 2, counts["go"])
+		assert.Equal(t, 1, counts["compilers"])
+		assert.NotContains(t, counts, "go-lang")
+	})
+
+	t.Run("merge unknown tag", func(t *testing.T) {
+		_, err := ts.Merge(ctx, "unknown", "go")
+		var storeErr *news.CustomError
+		require.ErrorAs(t, err, &storeErr)
+		assert.Equal(t, http.StatusNotFound, storeErr.HttpStatusCode())
+	})
+}
+
+func TestTagStore_TenantIsolation(t *testing.T) {
+	// Arrange
+	s := news.NewStore(db)
+	ts := news.NewTagStore(db)
+	acme, globex := inTenant("iso-acme"), inTenant("iso-globex")
+	mine, err := s.Create(acme, &news.Record{
+		Author:  "test-author",
+		Title:   "Tagged here",
+		Summary: "test-summary",
+		Content: "test-content",
+		Source:  "https://www.example.com",
+		Tags:    []string{"tenant-tag"},
+	})
+	require.NoError(t, err)
+	theirs, err := s.Create(globex, &news.Record{
+		Author:  "test-author",
+		Title:   "Tagged elsewhere",
+		Summary: "test-summary",
+		Content: "test-content",
+		Source:  "https://www.example.com",
+		Tags:    []string{"tenant-tag"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, s.DeleteById(acme, mine.Id))
+		assert.NoError(t, s.DeleteById(globex, theirs.Id))
+	})
+
+	// Act
+	_, err = ts.Rename(acme, "tenant-tag", &news.Tag{Slug: "renamed-tag"})
+	require.NoError(t, err)
+
+	// Assert
+	n, err := s.FindById(acme, mine.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"renamed-tag"}, n.Tags)
+	n, err = s.FindById(globex, theirs.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tenant-tag"}, n.Tags)
+
+	// The tags of the tenant are not the ones of the others.
+	tagged, err := s.FindAll(globex, news.Filter{Tag: "renamed-tag"})
+	require.NoError(t, err)
+	assert.Empty(t, tagged)
+
+	tags, err := ts.FindAll(globex)
+	require.NoError(t, err)
+	for _, tag := range tags {
+		assert.NotEqual(t, "renamed-tag", tag.Slug)
+	}
+
+	_, err = ts.Merge(globex, "renamed-tag", "tenant-tag")
+	assertStatus(t, http.StatusNotFound, err)
+}
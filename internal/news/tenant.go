@@ -0,0 +1,55 @@
+package news
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/uptrace/bun"
+)
+
+// tenantSetting is the Postgres setting holding the tenant of a transaction,
+// that the row-level security policies of the tables of the tenants check. It
+// is "*" for the transactions of every tenant, and the policies hide every row
+// when it is not set.
+const tenantSetting = "app.tenant_id"
+
+// tenantOf returns the ID of the tenant of the context.
+func tenantOf(ctx context.Context) string {
+	return tenant.FromContext(ctx).Id
+}
+
+// ownTenant returns the ID of the tenant of the context, to write the rows
+// belonging to it. tenant.All owns none.
+func ownTenant(ctx context.Context, rows string) (string, error) {
+	id := tenantOf(ctx)
+	if id == tenant.All.Id {
+		return "", NewCustomError(fmt.Errorf("%s cannot be written in all the tenants", rows), http.StatusInternalServerError)
+	}
+	return id, nil
+}
+
+// inTenant runs fn in a transaction bound to the tenant of the context. The
+// queries still have to be scoped to the tenant: the row-level security
+// policy is a second line of defense, and it does not apply to superusers.
+func inTenant(ctx context.Context, db bun.IDB, fn func(ctx context.Context, tx bun.Tx) error) error {
+	return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewRaw("SELECT set_config(?, ?, true)", tenantSetting, tenantOf(ctx)).Exec(ctx); err != nil {
+			return fmt.Errorf("set tenant: %w", err)
+		}
+		return fn(ctx, tx)
+	})
+}
+
+// whereTenant scopes the query of news to the tenant of the context, unless
+// it is tenant.All.
+func whereTenant[Q interface {
+	Where(query string, args ...any) Q
+}](ctx context.Context, q Q) Q {
+	id := tenantOf(ctx)
+	if id == tenant.All.Id {
+		return q
+	}
+	return q.Where("?TableAlias.tenant_id = ?", id)
+}
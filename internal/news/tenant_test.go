@@ -0,0 +1,267 @@
+package news_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func inTenant(id string) context.Context {
+	return tenant.CtxWithTenant(context.Background(), &tenant.Tenant{Id: id})
+}
+
+func assertStatus(tb testing.TB, expected int, err error) {
+	tb.Helper()
+	var dbErr *news.CustomError
+	require.ErrorAs(tb, err, &dbErr)
+	assert.Equal(tb, expected, dbErr.HttpStatusCode())
+}
+
+func TestStore_TenantIsolation(t *testing.T) {
+	// Arrange
+	s := news.NewStore(db)
+	acme, globex := inTenant("iso-acme"), inTenant("iso-globex")
+	n, err := s.Create(acme, &news.Record{
+		Author:  "test-author",
+		Title:   "Isolated",
+		Summary: "test-summary",
+		Content: "test-content",
+		Source:  "https://www.example.com",
+		Tags:    []string{"isolation"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "iso-acme", n.TenantId)
+
+	// Act, Assert
+	_, err = s.FindById(globex, n.Id)
+	assertStatus(t, http.StatusNotFound, err)
+
+	all, err := s.FindAll(globex, news.Filter{Ids: []uuid.UUID{n.Id}})
+	require.NoError(t, err)
+	assert.Empty(t, all)
+
+	_, err = s.FindSimilar(globex, n.Id)
+	assertStatus(t, http.StatusNotFound, err)
+
+	authors, err := s.FindAuthorsByNews(globex, n.Id)
+	require.NoError(t, err)
+	assert.Empty(t, authors)
+
+	hijacked := *n
+	hijacked.Title = "Hijacked"
+	err = s.UpdateById(globex, n.Id, &hijacked)
+	assertStatus(t, http.StatusNotFound, err)
+
+	_, err = s.Transition(globex, n.Id, news.StatusInReview, "intruder")
+	assertStatus(t, http.StatusNotFound, err)
+
+	// Deleting news that are not found is a no-op.
+	require.NoError(t, s.DeleteById(globex, n.Id))
+	_, err = s.FindById(acme, n.Id)
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteById(acme, n.Id))
+	_, err = s.Restore(globex, n.Id)
+	assertStatus(t, http.StatusNotFound, err)
+
+	// The news of the tenant are unchanged.
+	restored, err := s.Restore(acme, n.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Isolated", restored.Title)
+	assert.Equal(t, news.StatusDraft, restored.Status)
+	assert.Equal(t, "iso-acme", restored.TenantId)
+	all, err = s.FindAll(acme, news.Filter{Ids: []uuid.UUID{n.Id}})
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{n.Id}, ids(all))
+
+	// The news of the other tenants are not theirs either.
+	all, err = s.FindAll(acme, news.Filter{Ids: []uuid.UUID{uuid.MustParse("17628bea-9d11-47f9-986e-16703a87e451")}})
+	require.NoError(t, err)
+	assert.Empty(t, all)
+
+	// The events of the news carry their tenant.
+	var events []*news.OutboxEvent
+	require.NoError(t, db.NewSelect().Model(&events).Where("news_id = ?", n.Id).Scan(context.Background()))
+	require.NotEmpty(t, events)
+	for _, e := range events {
+		assert.Equal(t, "iso-acme", e.TenantId)
+	}
+}
+
+func TestStore_Quota(t *testing.T) {
+	// Arrange
+	s := news.NewStore(db)
+	ctx := tenant.CtxWithTenant(context.Background(), &tenant.Tenant{Id: "quota", MaxNews: 2})
+	create := func() (*news.Record, error) {
+		return s.Create(ctx, &news.Record{
+			Author:  "test-author",
+			Title:   "Quota " + uuid.NewString(),
+			Summary: "test-summary",
+			Content: "test-content " + uuid.NewString(),
+			Source:  "https://www.example.com",
+			Tags:    []string{"quota"},
+		})
+	}
+	first, err := create()
+	require.NoError(t, err)
+	_, err = create()
+	require.NoError(t, err)
+
+	// Act
+	_, err = create()
+
+	// Assert
+	assertStatus(t, http.StatusForbidden, err)
+	assert.True(t, errors.Is(err, news.ErrQuotaExceeded))
+	// The quota only counts the news of the tenant that are not deleted.
+	_, err = s.Create(inTenant("quota-other"), &news.Record{
+		Author:  "test-author",
+		Title:   "Other",
+		Summary: "test-summary",
+		Content: "test-content",
+		Source:  "https://www.example.com",
+		Tags:    []string{"quota"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteById(ctx, first.Id))
+	_, err = create()
+	require.NoError(t, err)
+}
+
+func TestStore_Quota_Restore(t *testing.T) {
+	// Arrange
+	s := news.NewStore(db)
+	ctx := tenant.CtxWithTenant(context.Background(), &tenant.Tenant{Id: "quota-restore", MaxNews: 1})
+	create := func() *news.Record {
+		n, err := s.Create(ctx, &news.Record{
+			Author:  "test-author",
+			Title:   "Quota " + uuid.NewString(),
+			Summary: "test-summary",
+			Content: "test-content " + uuid.NewString(),
+			Source:  "https://www.example.com",
+			Tags:    []string{"quota"},
+		})
+		require.NoError(t, err)
+		return n
+	}
+	deleted := create()
+	require.NoError(t, s.DeleteById(ctx, deleted.Id))
+	other := create()
+
+	// Act
+	_, err := s.Restore(ctx, deleted.Id)
+
+	// Assert
+	assertStatus(t, http.StatusForbidden, err)
+	assert.True(t, errors.Is(err, news.ErrQuotaExceeded))
+	require.NoError(t, s.DeleteById(ctx, other.Id))
+	_, err = s.Restore(ctx, deleted.Id)
+	require.NoError(t, err)
+}
+
+// TestStore_RowLevelSecurity runs raw queries that forget to scope the news
+// as a role without the superuser privileges that bypass the policies.
+func TestStore_RowLevelSecurity(t *testing.T) {
+	ctx := context.Background()
+	_, err := db.ExecContext(ctx, `DO $$ BEGIN
+		IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'news_rls_test') THEN
+			CREATE ROLE news_rls_test NOLOGIN;
+		END IF;
+	END $$`)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "GRANT SELECT, INSERT, UPDATE, DELETE ON news TO news_rls_test")
+	require.NoError(t, err)
+	n, err := news.NewStore(db).Create(inTenant("rls-acme"), &news.Record{
+		Author:  "test-author",
+		Title:   "Row-level security",
+		Summary: "test-summary",
+		Content: "test-content",
+		Source:  "https://www.example.com",
+		Tags:    []string{"rls"},
+	})
+	require.NoError(t, err)
+
+	// asTenant runs fn as the role, in a transaction bound to the tenant, if
+	// any, then rolls it back.
+	asTenant := func(id string, fn func(tx bun.Tx)) {
+		t.Helper()
+		tx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		_, err = tx.ExecContext(ctx, "SET LOCAL ROLE news_rls_test")
+		require.NoError(t, err)
+		if id != "" {
+			_, err = tx.ExecContext(ctx, "SELECT set_config('app.tenant_id', ?, true)", id)
+			require.NoError(t, err)
+		}
+		fn(tx)
+	}
+	count := func(tx bun.Tx) int {
+		t.Helper()
+		var count int
+		require.NoError(t, tx.QueryRowContext(ctx, "SELECT count(*) FROM news WHERE id = ?", n.Id).Scan(&count))
+		return count
+	}
+
+	asTenant("rls-acme", func(tx bun.Tx) {
+		assert.Equal(t, 1, count(tx))
+	})
+	asTenant("rls-globex", func(tx bun.Tx) {
+		assert.Equal(t, 0, count(tx))
+		r, err := tx.ExecContext(ctx, "UPDATE news SET title = 'Hijacked' WHERE id = ?", n.Id)
+		require.NoError(t, err)
+		affected, err := r.RowsAffected()
+		require.NoError(t, err)
+		assert.Zero(t, affected)
+	})
+	asTenant("rls-globex", func(tx bun.Tx) {
+		_, err := tx.ExecContext(ctx, "INSERT INTO news (tenant_id, author, title, summary, content, source, tags) VALUES ('rls-acme', 'intruder', 'Planted', '', '', '', '{}')")
+		assert.ErrorContains(t, err, "row-level security")
+	})
+	asTenant("", func(tx bun.Tx) {
+		assert.Equal(t, 0, count(tx))
+	})
+	asTenant(tenant.All.Id, func(tx bun.Tx) {
+		assert.Equal(t, 1, count(tx))
+	})
+}
+
+// TestTenantColumns guards the schema: every table has a tenant_id and the
+// row-level security policy of the tenants, but the ones shared by the
+// tenants.
+func TestTenantColumns(t *testing.T) {
+	shared := []string{
+		// The buckets limit the clients whatever tenant they call.
+		"rate_limits",
+		// The sweeper runs over the news of every tenant at once.
+		"sweep_stats",
+	}
+	var missing []string
+	err := db.NewRaw(`SELECT t.table_name FROM information_schema.tables AS t
+		WHERE t.table_schema = 'public' AND t.table_type = 'BASE TABLE'
+		AND NOT EXISTS (
+			SELECT FROM information_schema.columns AS c
+			WHERE c.table_schema = t.table_schema AND c.table_name = t.table_name AND c.column_name = 'tenant_id'
+		)
+		ORDER BY t.table_name`).Scan(context.Background(), &missing)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, shared, missing)
+
+	var unprotected []string
+	err = db.NewRaw(`SELECT c.relname FROM pg_class AS c
+		JOIN pg_namespace AS n ON n.oid = c.relnamespace
+		WHERE n.nspname = 'public' AND c.relkind = 'r'
+		AND EXISTS (SELECT FROM pg_attribute AS a WHERE a.attrelid = c.oid AND a.attname = 'tenant_id' AND NOT a.attisdropped)
+		AND NOT (c.relrowsecurity AND c.relforcerowsecurity AND EXISTS (SELECT FROM pg_policy AS p WHERE p.polrelid = c.oid))
+		ORDER BY c.relname`).Scan(context.Background(), &unprotected)
+	require.NoError(t, err)
+	assert.Empty(t, unprotected)
+}
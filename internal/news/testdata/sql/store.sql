@@ -2,17 +2,246 @@ CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
 CREATE TABLE IF NOT EXISTS news (
                                     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id TEXT NOT NULL DEFAULT 'default',
     author TEXT NOT NULL,
     title TEXT NOT NULL,
     summary TEXT NOT NULL,
     content TEXT NOT NULL,
     source TEXT NOT NULL,
     tags TEXT[] NOT NULL,
+    fingerprint BIGINT,
+    cluster_id UUID,
+    status TEXT NOT NULL DEFAULT 'draft',
+    status_changed_at TIMESTAMP WITH TIME ZONE,
+    status_changed_by TEXT NOT NULL DEFAULT '',
+    published_at TIMESTAMP WITH TIME ZONE,
+    publish_at TIMESTAMP WITH TIME ZONE,
+    embargo_until TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
     created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
     deleted_at TIMESTAMP WITH TIME ZONE,
     updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
     );
 
+CREATE TABLE IF NOT EXISTS sweep_stats (
+    name TEXT PRIMARY KEY,
+    runs BIGINT NOT NULL DEFAULT 0,
+    last_action TEXT NOT NULL DEFAULT '',
+    last_started_at TIMESTAMP WITH TIME ZONE,
+    last_finished_at TIMESTAMP WITH TIME ZONE,
+    last_count INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    archived BIGINT NOT NULL DEFAULT 0,
+    deleted BIGINT NOT NULL DEFAULT 0
+    );
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id TEXT NOT NULL DEFAULT 'default',
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+    );
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id UUID NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    tenant_id TEXT NOT NULL DEFAULT 'default',
+    event_id UUID NOT NULL,
+    event TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    last_attempt_at TIMESTAMP WITH TIME ZONE,
+    response_status INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+    );
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE,
+    event TEXT NOT NULL,
+    tenant_id TEXT NOT NULL DEFAULT 'default',
+    news_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    position BIGINT UNIQUE
+    );
+
+CREATE SEQUENCE IF NOT EXISTS outbox_position_seq;
+
+CREATE OR REPLACE FUNCTION outbox_position() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('outbox_position'));
+    UPDATE outbox SET position = nextval('outbox_position_seq') WHERE id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER outbox_position
+    AFTER INSERT ON outbox
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION outbox_position();
+
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_event_idx ON webhook_deliveries (webhook_id, event_id);
+
+CREATE TABLE IF NOT EXISTS tags (
+    tenant_id TEXT NOT NULL DEFAULT 'default',
+    slug TEXT NOT NULL,
+    name TEXT NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, slug)
+    );
+
+CREATE TABLE IF NOT EXISTS news_transitions (
+    id BIGSERIAL PRIMARY KEY,
+    news_id UUID NOT NULL REFERENCES news (id) ON DELETE CASCADE,
+    tenant_id TEXT NOT NULL DEFAULT 'default',
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+    );
+
+CREATE TABLE IF NOT EXISTS authors (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id TEXT NOT NULL DEFAULT 'default',
+    slug TEXT NOT NULL,
+    name TEXT NOT NULL,
+    bio TEXT NOT NULL DEFAULT '',
+    avatar_url TEXT NOT NULL DEFAULT '',
+    contact TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT authors_tenant_id_slug_key UNIQUE (tenant_id, slug)
+    );
+
+CREATE TABLE IF NOT EXISTS news_authors (
+    news_id UUID NOT NULL REFERENCES news (id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES authors (id) ON DELETE RESTRICT,
+    tenant_id TEXT NOT NULL DEFAULT 'default',
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (news_id, author_id)
+    );
+
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limits (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    per_second DOUBLE PRECISION NOT NULL,
+    burst INT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+    );
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    principal TEXT NOT NULL DEFAULT '',
+    key TEXT NOT NULL,
+    tenant_id TEXT NOT NULL DEFAULT 'default',
+    request_hash TEXT NOT NULL,
+    status INT,
+    headers JSONB,
+    body BYTEA,
+    claimed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (principal, key)
+    );
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL DEFAULT 'default',
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    actor TEXT NOT NULL,
+    auth_method TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    request_id TEXT NOT NULL,
+    method TEXT NOT NULL,
+    route TEXT NOT NULL,
+    path TEXT NOT NULL,
+    news_id UUID,
+    status INT NOT NULL,
+    before_hash TEXT NOT NULL,
+    after_hash TEXT NOT NULL
+    );
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+ALTER TABLE news ENABLE ROW LEVEL SECURITY;
+ALTER TABLE news FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY news_tenant_isolation ON news
+    USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+    WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+
+ALTER TABLE webhooks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhooks FORCE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY webhooks_tenant_isolation ON webhooks
+    USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+    WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+CREATE POLICY webhook_deliveries_tenant_isolation ON webhook_deliveries
+    USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+    WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+
+ALTER TABLE authors ENABLE ROW LEVEL SECURITY;
+ALTER TABLE authors FORCE ROW LEVEL SECURITY;
+ALTER TABLE news_authors ENABLE ROW LEVEL SECURITY;
+ALTER TABLE news_authors FORCE ROW LEVEL SECURITY;
+ALTER TABLE tags ENABLE ROW LEVEL SECURITY;
+ALTER TABLE tags FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY authors_tenant_isolation ON authors
+    USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+    WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+CREATE POLICY news_authors_tenant_isolation ON news_authors
+    USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+    WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+CREATE POLICY tags_tenant_isolation ON tags
+    USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+    WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+
+ALTER TABLE news_transitions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE news_transitions FORCE ROW LEVEL SECURITY;
+ALTER TABLE idempotency_keys ENABLE ROW LEVEL SECURITY;
+ALTER TABLE idempotency_keys FORCE ROW LEVEL SECURITY;
+ALTER TABLE outbox ENABLE ROW LEVEL SECURITY;
+ALTER TABLE outbox FORCE ROW LEVEL SECURITY;
+ALTER TABLE audit_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_events FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY news_transitions_tenant_isolation ON news_transitions
+    USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+    WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+CREATE POLICY idempotency_keys_tenant_isolation ON idempotency_keys
+    USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+    WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+CREATE POLICY outbox_tenant_isolation ON outbox
+    USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+    WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+CREATE POLICY audit_events_tenant_isolation ON audit_events
+    USING (current_setting('app.tenant_id', true) IN (tenant_id, '*'))
+    WITH CHECK (current_setting('app.tenant_id', true) IN (tenant_id, '*'));
+
 INSERT INTO news (id, author, title, summary, content, source, tags, created_at, updated_at)
 VALUES (
            '17628bea-9d11-47f9-986e-16703a87e451',
@@ -47,4 +276,16 @@ VALUES (
            ARRAY ['tag1', 'Superhero'],
            NOW(),
            NOW(),
-           NOW());
\ No newline at end of file
+           NOW());
+
+INSERT INTO authors (id, slug, name)
+VALUES ('5b0c3c1e-5d8a-4a86-9a57-3f5f6f0b1a01', 'batman', 'Batman'),
+       ('5b0c3c1e-5d8a-4a86-9a57-3f5f6f0b1a02', 'superman', 'Superman'),
+       ('5b0c3c1e-5d8a-4a86-9a57-3f5f6f0b1a03', 'spiderman', 'Spiderman');
+
+INSERT INTO news_authors (news_id, author_id, position)
+VALUES ('17628bea-9d11-47f9-986e-16703a87e451', '5b0c3c1e-5d8a-4a86-9a57-3f5f6f0b1a01', 0),
+       ('bde0c593-0df6-4eba-9326-3f00be67aade', '5b0c3c1e-5d8a-4a86-9a57-3f5f6f0b1a02', 0),
+       ('f710bc79-9ad3-4e0f-8dab-e43d94b42fbb', '5b0c3c1e-5d8a-4a86-9a57-3f5f6f0b1a03', 0);
+
+UPDATE news SET status = 'published', published_at = created_at;
\ No newline at end of file
@@ -0,0 +1,90 @@
+package news
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Event is a change in the lifecycle of a news that webhooks subscribe to.
+type Event string
+
+const (
+	EventCreated   Event = "news.created"
+	EventUpdated   Event = "news.updated"
+	EventDeleted   Event = "news.deleted"
+	EventPublished Event = "news.published"
+)
+
+func ParseEvent(s string) (Event, error) {
+	switch e := Event(s); e {
+	case EventCreated, EventUpdated, EventDeleted, EventPublished:
+		return e, nil
+	default:
+		return "", fmt.Errorf("invalid event: %q", s)
+	}
+}
+
+// EventPayload is the body posted to the webhooks. Data is the news, or only
+// its ID once deleted.
+type EventPayload struct {
+	Id        uuid.UUID `json:"id"`
+	Event     Event     `json:"event"`
+	TenantId  string    `json:"tenant_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// deletedRecord is the data of the events of deleted news, keyed like Record.
+type deletedRecord struct {
+	Id uuid.UUID
+}
+
+// Webhook is a subscription of an endpoint to the news events of its tenant.
+// The secret signs the payloads and is only returned when the webhook is
+// created.
+type Webhook struct {
+	bun.BaseModel `bun:"table:webhooks,alias:webhook"`
+	Id            uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	TenantId      string    `bun:"tenant_id,nullzero,notnull,default:'default'" json:"-"`
+	URL           string    `bun:"url,notnull" json:"url"`
+	Secret        string    `bun:"secret,notnull" json:"secret,omitempty"`
+	Events        []Event   `bun:"events,notnull,array" json:"events"`
+	CreatedAt     time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt     time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}
+
+// DeliveryStatus is the state of the delivery of an event to a webhook.
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead is the dead-letter state of deliveries that failed too
+	// many times. They are only retried when redelivered.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// Delivery is the delivery of an event to a webhook, along with the outcome
+// of its last attempt.
+type Delivery struct {
+	bun.BaseModel  `bun:"table:webhook_deliveries,alias:delivery"`
+	Id             int64           `bun:"id,pk,autoincrement" json:"id"`
+	WebhookId      uuid.UUID       `bun:"webhook_id,type:uuid,notnull" json:"webhook_id"`
+	TenantId       string          `bun:"tenant_id,nullzero,notnull,default:'default'" json:"-"`
+	Webhook        *Webhook        `bun:"-" json:"-"`
+	EventId        uuid.UUID       `bun:"event_id,type:uuid,notnull" json:"event_id"`
+	Event          Event           `bun:"event,notnull" json:"event"`
+	Payload        json.RawMessage `bun:"payload,type:jsonb,notnull" json:"payload"`
+	Status         DeliveryStatus  `bun:"status,notnull" json:"status"`
+	Attempts       int             `bun:"attempts,notnull" json:"attempts"`
+	NextAttemptAt  time.Time       `bun:"next_attempt_at,nullzero" json:"next_attempt_at"`
+	LastAttemptAt  time.Time       `bun:"last_attempt_at,nullzero" json:"last_attempt_at"`
+	ResponseStatus int             `bun:"response_status,notnull" json:"response_status"`
+	LastError      string          `bun:"last_error,notnull" json:"last_error"`
+	DeliveredAt    time.Time       `bun:"delivered_at,nullzero" json:"delivered_at"`
+	CreatedAt      time.Time       `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
@@ -0,0 +1,212 @@
+package news
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type WebhookStore struct {
+	db bun.IDB
+}
+
+func NewWebhookStore(db bun.IDB) *WebhookStore {
+	return &WebhookStore{
+		db: db,
+	}
+}
+
+// Create webhook subscription to the events of the tenant of the context,
+// with a random secret unless one is given.
+func (s WebhookStore) Create(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	tenantId, err := ownTenant(ctx, "webhooks")
+	if err != nil {
+		return nil, err
+	}
+	if webhook.Secret == "" {
+		webhook.Secret = rand.Text()
+	}
+	webhook.TenantId = tenantId
+	err = inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		return tx.NewInsert().Model(webhook).Returning("*").Scan(ctx, webhook)
+	})
+	if err != nil {
+		return nil, toCustomError(err)
+	}
+	return webhook, nil
+}
+
+func (s WebhookStore) FindAll(ctx context.Context) (webhooks []*Webhook, err error) {
+	webhooks = []*Webhook{}
+	err = inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		return whereTenant(ctx, tx.NewSelect().Model(&webhooks)).ExcludeColumn("secret").Order("created_at").Scan(ctx)
+	})
+	if err != nil {
+		return nil, NewCustomError(err, http.StatusInternalServerError)
+	}
+	return webhooks, nil
+}
+
+func (s WebhookStore) FindById(ctx context.Context, id uuid.UUID) (*Webhook, error) {
+	var webhook Webhook
+	err := inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		return whereTenant(ctx, tx.NewSelect().Model(&webhook)).ExcludeColumn("secret").Where("id = ?", id).Scan(ctx)
+	})
+	if err != nil {
+		return nil, toCustomError(err)
+	}
+	return &webhook, nil
+}
+
+// DeleteById deletes the webhook along with its deliveries.
+func (s WebhookStore) DeleteById(ctx context.Context, id uuid.UUID) error {
+	err := inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		r, err := whereTenant(ctx, tx.NewDelete().Model((*Webhook)(nil))).Where("id = ?", id).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := r.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return NewCustomError(sql.ErrNoRows, http.StatusNotFound)
+		}
+		return nil
+	})
+	if err != nil {
+		return toCustomError(err)
+	}
+	return nil
+}
+
+// FindDeliveries returns the latest deliveries to the webhook, newest first.
+func (s WebhookStore) FindDeliveries(ctx context.Context, webhookId uuid.UUID, limit int) ([]*Delivery, error) {
+	if _, err := s.FindById(ctx, webhookId); err != nil {
+		return nil, err
+	}
+	deliveries := []*Delivery{}
+	err := inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		return whereTenant(ctx, tx.NewSelect().Model(&deliveries)).
+			Where("webhook_id = ?", webhookId).
+			Order("id DESC").
+			Limit(limit).
+			Scan(ctx)
+	})
+	if err != nil {
+		return nil, NewCustomError(err, http.StatusInternalServerError)
+	}
+	return deliveries, nil
+}
+
+// Redeliver schedules the delivery for a new series of attempts, whatever its
+// state.
+func (s WebhookStore) Redeliver(ctx context.Context, webhookId uuid.UUID, id int64) (*Delivery, error) {
+	delivery := &Delivery{}
+	err := inTenant(ctx, s.db, func(ctx context.Context, tx bun.Tx) error {
+		return whereTenant(ctx, tx.NewUpdate().Model(delivery)).
+			Set("status = ?", DeliveryPending).
+			Set("attempts = 0").
+			Set("next_attempt_at = current_timestamp").
+			Where("id = ?", id).
+			Where("webhook_id = ?", webhookId).
+			Returning("*").
+			Scan(ctx)
+	})
+	if err != nil {
+		return nil, toCustomError(err)
+	}
+	return delivery, nil
+}
+
+// ClaimDue claims up to limit pending deliveries of every tenant that are
+// due, along with their webhook. Claimed deliveries are not due again before
+// lease has passed, so that other replicas leave them alone while they are
+// being sent, and are retried if the process dies in the meantime.
+func (s WebhookStore) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*Delivery, error) {
+	var deliveries []*Delivery
+	var webhooks []*Webhook
+	err := inTenant(tenant.CtxWithTenant(ctx, tenant.All), s.db, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewUpdate().
+			Model((*Delivery)(nil)).
+			Set("next_attempt_at = current_timestamp + make_interval(secs => ?)", lease.Seconds()).
+			Where(`id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = ? AND next_attempt_at <= current_timestamp
+				ORDER BY next_attempt_at
+				LIMIT ?
+				FOR UPDATE SKIP LOCKED
+			)`, DeliveryPending, limit).
+			Returning("*").
+			Scan(ctx, &deliveries)
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, 0, len(deliveries))
+		for _, d := range deliveries {
+			ids = append(ids, d.WebhookId)
+		}
+		return tx.NewSelect().Model(&webhooks).Where("id IN (?)", bun.In(ids)).Scan(ctx)
+	})
+	if err != nil {
+		return nil, NewCustomError(err, http.StatusInternalServerError)
+	}
+	if len(deliveries) == 0 {
+		return []*Delivery{}, nil
+	}
+	byId := make(map[uuid.UUID]*Webhook, len(webhooks))
+	for _, w := range webhooks {
+		byId[w.Id] = w
+	}
+	for _, d := range deliveries {
+		d.Webhook = byId[d.WebhookId]
+	}
+	return deliveries, nil
+}
+
+// RecordAttempt saves the outcome of an attempt to deliver, whatever the
+// tenant of the delivery.
+func (s WebhookStore) RecordAttempt(ctx context.Context, delivery *Delivery) error {
+	err := inTenant(tenant.CtxWithTenant(ctx, tenant.All), s.db, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model(delivery).
+			Column("status", "attempts", "next_attempt_at", "last_attempt_at", "response_status", "last_error", "delivered_at").
+			WherePK().
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return NewCustomError(err, http.StatusInternalServerError)
+	}
+	return nil
+}
+
+// Enqueue schedules the delivery of the events to the webhooks of their tenant
+// subscribed to them. Events already enqueued for a webhook are skipped, so
+// that events relayed more than once are delivered once.
+func (s WebhookStore) Enqueue(ctx context.Context, events []*OutboxEvent) error {
+	err := inTenant(tenant.CtxWithTenant(ctx, tenant.All), s.db, func(ctx context.Context, tx bun.Tx) error {
+		for _, e := range events {
+			_, err := tx.NewRaw(`INSERT INTO webhook_deliveries (webhook_id, tenant_id, event_id, event, payload, status, next_attempt_at)
+				SELECT id, tenant_id, ?::uuid, ?, ?::jsonb, ?, current_timestamp FROM webhooks WHERE ? = ANY(events) AND tenant_id = ?
+				ON CONFLICT (webhook_id, event_id) DO NOTHING`,
+				e.EventId, e.Event, string(e.Payload), DeliveryPending, e.Event, e.TenantId,
+			).Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return NewCustomError(err, http.StatusInternalServerError)
+	}
+	return nil
+}
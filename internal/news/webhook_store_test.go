@@ -0,0 +1,178 @@
+package news_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookStore(t *testing.T) {
+	ctx := context.Background()
+	s := news.NewStore(db)
+	ws := news.NewWebhookStore(db)
+	outboxStore := news.NewOutboxStore(db)
+
+	// Relay the events of the other tests before subscribing.
+	for {
+		count, err := outboxStore.Relay(ctx, 100, func(context.Context, []*news.OutboxEvent) error { return nil })
+		require.NoError(t, err)
+		if count == 0 {
+			break
+		}
+	}
+
+	received := make(chan news.EventPayload, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		if !webhook.Verify("s3cr3t", timestamp, body, r.Header.Get(webhook.HeaderSignature)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var payload news.EventPayload
+		_ = json.Unmarshal(body, &payload)
+		received <- payload
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(receiver.Close)
+
+	wh, err := ws.Create(ctx, &news.Webhook{URL: receiver.URL, Secret: "s3cr3t", Events: []news.Event{news.EventCreated, news.EventDeleted}})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, ws.DeleteById(ctx, wh.Id))
+	})
+	other, err := ws.Create(ctx, &news.Webhook{URL: "http://127.0.0.1:1/unreachable", Events: []news.Event{news.EventUpdated}})
+	require.NoError(t, err)
+	assert.NotEmpty(t, other.Secret)
+	t.Cleanup(func() {
+		assert.NoError(t, ws.DeleteById(ctx, other.Id))
+	})
+
+	found, err := ws.FindById(ctx, wh.Id)
+	require.NoError(t, err)
+	assert.Empty(t, found.Secret)
+
+	// Writes enqueue the events for the subscribed webhooks only.
+	n, err := s.Create(ctx, &news.Record{
+		Author:  "test-author",
+		Title:   "Hooked",
+		Summary: "test-summary",
+		Content: "test-content",
+		Source:  "https://www.example.com",
+		Tags:    []string{"webhooks"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteById(ctx, n.Id))
+
+	deliveries, err := ws.FindDeliveries(ctx, wh.Id, 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	// Events relayed more than once are enqueued once.
+	var events []*news.OutboxEvent
+	count, err := outboxStore.Relay(ctx, 100, func(ctx context.Context, e []*news.OutboxEvent) error {
+		events = e
+		return ws.Enqueue(ctx, e)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.NoError(t, ws.Enqueue(ctx, events))
+
+	deliveries, err = ws.FindDeliveries(ctx, wh.Id, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, news.EventDeleted, deliveries[0].Event)
+	assert.Equal(t, news.EventCreated, deliveries[1].Event)
+	deliveries, err = ws.FindDeliveries(ctx, other.Id, 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	d := webhook.NewDispatcher(ws, receiver.Client())
+	require.NoError(t, d.Dispatch(ctx))
+	for _, event := range []news.Event{news.EventCreated, news.EventDeleted} {
+		select {
+		case payload := <-received:
+			assert.Equal(t, event, payload.Event)
+		case <-time.After(time.Second):
+			t.Fatalf("%s not received", event)
+		}
+	}
+
+	deliveries, err = ws.FindDeliveries(ctx, wh.Id, 10)
+	require.NoError(t, err)
+	for _, delivery := range deliveries {
+		assert.Equal(t, news.DeliveryDelivered, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusNoContent, delivery.ResponseStatus)
+	}
+
+	// Redelivered deliveries are sent again.
+	delivery, err := ws.Redeliver(ctx, wh.Id, deliveries[0].Id)
+	require.NoError(t, err)
+	assert.Equal(t, news.DeliveryPending, delivery.Status)
+	require.NoError(t, d.Dispatch(ctx))
+	select {
+	case payload := <-received:
+		assert.Equal(t, news.EventDeleted, payload.Event)
+	case <-time.After(time.Second):
+		t.Fatal("redelivery not received")
+	}
+
+	_, err = ws.Redeliver(ctx, other.Id, deliveries[0].Id)
+	var storeErr *news.CustomError
+	require.ErrorAs(t, err, &storeErr)
+	assert.Equal(t, http.StatusNotFound, storeErr.HttpStatusCode())
+}
+
+func TestWebhookStore_TenantIsolation(t *testing.T) {
+	// Arrange
+	ws := news.NewWebhookStore(db)
+	acme, globex := inTenant("iso-acme"), inTenant("iso-globex")
+	wh, err := ws.Create(acme, &news.Webhook{URL: "http://127.0.0.1:1/unreachable", Events: []news.Event{news.EventCreated}})
+	require.NoError(t, err)
+	assert.Equal(t, "iso-acme", wh.TenantId)
+	t.Cleanup(func() {
+		assert.NoError(t, ws.DeleteById(acme, wh.Id))
+	})
+
+	// Act, Assert
+	// The events of the other tenants are not delivered to the webhook.
+	event := func(tenantId string) *news.OutboxEvent {
+		return &news.OutboxEvent{EventId: uuid.New(), Event: news.EventCreated, TenantId: tenantId, Payload: json.RawMessage(`{}`)}
+	}
+	require.NoError(t, ws.Enqueue(context.Background(), []*news.OutboxEvent{event("iso-globex"), event("iso-acme")}))
+	deliveries, err := ws.FindDeliveries(acme, wh.Id, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, "iso-acme", deliveries[0].TenantId)
+
+	// The other tenants cannot see nor change the webhook.
+	all, err := ws.FindAll(globex)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+
+	_, err = ws.FindById(globex, wh.Id)
+	assertStatus(t, http.StatusNotFound, err)
+
+	_, err = ws.FindDeliveries(globex, wh.Id, 10)
+	assertStatus(t, http.StatusNotFound, err)
+
+	_, err = ws.Redeliver(globex, wh.Id, deliveries[0].Id)
+	assertStatus(t, http.StatusNotFound, err)
+
+	err = ws.DeleteById(globex, wh.Id)
+	assertStatus(t, http.StatusNotFound, err)
+	_, err = ws.FindById(acme, wh.Id)
+	require.NoError(t, err)
+}
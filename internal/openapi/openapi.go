@@ -0,0 +1,284 @@
+// Package openapi generates the OpenAPI 3.1 document of the API from the
+// description of its routes and the Go types of their bodies, serves it, and
+// validates the requests against it.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of the OpenAPI specification of the documents.
+const Version = "3.1.0"
+
+// Document is an OpenAPI document, limited to what the API uses.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem maps the lowercase HTTP methods of a path to their operation.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationId string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Explode     *bool   `json:"explode,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Name        string `json:"name,omitempty"`
+	In          string `json:"in,omitempty"`
+	Scheme      string `json:"scheme,omitempty"`
+}
+
+// Schema is a JSON Schema, limited to the keywords generated from Go types.
+// The empty schema matches any value.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Raw is the content type of a response body that is not JSON, such as an
+// event stream.
+type Raw string
+
+// Route documents a route of the API.
+type Route struct {
+	// Pattern is the pattern the route is registered with, e.g.
+	// "GET /news/{news_id}".
+	Pattern     string
+	Id          string
+	Summary     string
+	Description string
+	Tag         string
+	// Role is the role the route requires, if any. 401 and 403 responses are
+	// documented for such routes.
+	Role string
+	// Params documents the query parameters and the path parameters that are
+	// not plain strings.
+	Params []*Parameter
+	// Body is a value of the type of the JSON request body, if any.
+	Body any
+	// Responses maps the status codes to a value of the type of their JSON
+	// body, nil for an empty body, or Raw for other content types.
+	Responses map[int]any
+}
+
+// Enum lists the values of a type with a fixed set of values.
+type Enum struct {
+	typ    reflect.Type
+	values []any
+}
+
+func EnumOf[T any](values ...T) Enum {
+	e := Enum{typ: reflect.TypeFor[T]()}
+	for _, v := range values {
+		e.values = append(e.values, v)
+	}
+	return e
+}
+
+// Security scheme names, for API keys sent in the X-API-Key header or as
+// bearer tokens, and for client certificates.
+const (
+	SchemeAPIKey    = "apiKey"
+	SchemeBearer    = "bearer"
+	SchemeMutualTLS = "mutualTLS"
+)
+
+var pathParamRe = regexp.MustCompile(`\{([^}]+)\}`)
+
+// New returns the document of the routes. The schemas of the bodies are
+// generated from their Go types, and shared in the components.
+func New(info Info, routes []Route, enums ...Enum) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]*SecurityScheme{
+				SchemeAPIKey:    {Type: "apiKey", Name: "X-API-Key", In: "header"},
+				SchemeBearer:    {Type: "http", Scheme: "bearer", Description: "API key sent as a bearer token."},
+				SchemeMutualTLS: {Type: "mutualTLS", Description: "Client certificate, over HTTPS."},
+			},
+		},
+	}
+	g := newGenerator(doc.Components.Schemas, enums)
+	ids := map[string]bool{}
+	for _, route := range routes {
+		method, path, ok := strings.Cut(route.Pattern, " ")
+		if !ok {
+			return nil, fmt.Errorf("route %q has no method", route.Pattern)
+		}
+		if route.Id == "" || ids[route.Id] {
+			return nil, fmt.Errorf("route %q has no unique id", route.Pattern)
+		}
+		ids[route.Id] = true
+		item := doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		method = strings.ToLower(method)
+		if (*item)[method] != nil {
+			return nil, fmt.Errorf("route %q is documented twice", route.Pattern)
+		}
+		op, err := g.operation(route, path)
+		if err != nil {
+			return nil, fmt.Errorf("route %q: %w", route.Pattern, err)
+		}
+		(*item)[method] = op
+	}
+	return doc, nil
+}
+
+func (g *generator) operation(route Route, path string) (*Operation, error) {
+	op := &Operation{
+		OperationId: route.Id,
+		Summary:     route.Summary,
+		Description: route.Description,
+		Responses:   map[string]*Response{},
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+	}
+
+	for _, m := range pathParamRe.FindAllStringSubmatch(path, -1) {
+		p := &Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}}
+		for _, documented := range route.Params {
+			if documented.In == "path" && documented.Name == p.Name {
+				p = documented
+			}
+		}
+		op.Parameters = append(op.Parameters, p)
+	}
+	for _, p := range route.Params {
+		switch p.In {
+		case "path":
+			if !strings.Contains(path, "{"+p.Name+"}") {
+				return nil, fmt.Errorf("path parameter %q is not in the path", p.Name)
+			}
+		case "query", "header":
+			op.Parameters = append(op.Parameters, p)
+		default:
+			return nil, fmt.Errorf("parameter %q is in %q", p.Name, p.In)
+		}
+	}
+
+	if route.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: g.schemaOf(reflect.TypeOf(route.Body))}},
+		}
+	}
+
+	responses := map[int]any{}
+	if route.Role != "" {
+		op.Security = []map[string][]string{{SchemeAPIKey: {}}, {SchemeBearer: {}}, {SchemeMutualTLS: {}}}
+		if op.Description != "" {
+			op.Description += "\n\n"
+		}
+		op.Description += fmt.Sprintf("Requires the %s role.", route.Role)
+		responses[http.StatusUnauthorized] = nil
+		responses[http.StatusForbidden] = nil
+	}
+	if route.Body != nil {
+		// Bodies over the limit of their route are rejected.
+		responses[http.StatusRequestEntityTooLarge] = nil
+	}
+	for status, body := range route.Responses {
+		responses[status] = body
+	}
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("no responses")
+	}
+	for status, body := range responses {
+		resp := &Response{Description: http.StatusText(status)}
+		switch body := body.(type) {
+		case nil:
+		case Raw:
+			resp.Content = map[string]*MediaType{string(body): {Schema: &Schema{Type: "string"}}}
+		default:
+			resp.Content = map[string]*MediaType{"application/json": {Schema: g.schemaOf(reflect.TypeOf(body))}}
+		}
+		op.Responses[strconv.Itoa(status)] = resp
+	}
+	return op, nil
+}
+
+// Patterns returns the patterns of the operations of the document, in the
+// format of Route.Pattern.
+func (d *Document) Patterns() []string {
+	var patterns []string
+	for path, item := range d.Paths {
+		for method := range *item {
+			patterns = append(patterns, strings.ToUpper(method)+" "+path)
+		}
+	}
+	return patterns
+}
+
+// Resolve returns the schema a reference points to, or the schema itself if
+// it is not a reference.
+func (d *Document) Resolve(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = d.Components.Schemas[strings.TrimPrefix(s.Ref, refPrefix)]
+	}
+	return s
+}
@@ -0,0 +1,161 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/openapi"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type state string
+
+type item struct {
+	Id        uuid.UUID       `json:"id"`
+	Name      string          `json:"name" openapi:"required"`
+	URL       string          `json:"url" openapi:"format=uri"`
+	State     state           `json:"state"`
+	Count     int64           `json:"count,omitempty"`
+	Labels    map[string]bool `json:"labels"`
+	Children  []*item         `json:"children"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time
+	Internal  string `json:"-"`
+	private   string
+}
+
+type page struct {
+	Items []item `json:"items"`
+}
+
+func TestNew(t *testing.T) {
+	// Arrange
+	routes := []openapi.Route{
+		{
+			Pattern:   "POST /items",
+			Id:        "createItem",
+			Body:      item{},
+			Responses: map[int]any{http.StatusCreated: item{}, http.StatusBadRequest: openapi.Raw("text/plain")},
+		},
+		{
+			Pattern:   "GET /items/{item_id}",
+			Id:        "getItem",
+			Role:      "editor",
+			Params:    []*openapi.Parameter{{Name: "item_id", In: "path", Required: true, Schema: &openapi.Schema{Type: "string", Format: "uuid"}}},
+			Responses: map[int]any{http.StatusOK: item{}},
+		},
+		{
+			Pattern:   "GET /items",
+			Id:        "listItems",
+			Params:    []*openapi.Parameter{{Name: "state", In: "query", Schema: &openapi.Schema{Type: "string"}}},
+			Responses: map[int]any{http.StatusOK: page{}},
+		},
+		{
+			Pattern:   "DELETE /items/{slug}",
+			Id:        "deleteItem",
+			Responses: map[int]any{http.StatusNoContent: nil},
+		},
+	}
+
+	// Act
+	doc, err := openapi.New(openapi.Info{Title: "Items", Version: "1"}, routes, openapi.EnumOf[state]("on", "off"))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, openapi.Version, doc.OpenAPI)
+	assert.ElementsMatch(t, []string{"POST /items", "GET /items/{item_id}", "GET /items", "DELETE /items/{slug}"}, doc.Patterns())
+
+	assert.Equal(t, &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"id":        {Type: "string", Format: "uuid"},
+			"name":      {Type: "string"},
+			"url":       {Type: "string", Format: "uri"},
+			"state":     {Ref: "#/components/schemas/state"},
+			"count":     {Type: "integer", Format: "int64"},
+			"labels":    {Type: "object", AdditionalProperties: &openapi.Schema{Type: "boolean"}},
+			"children":  {Type: "array", Items: &openapi.Schema{Ref: "#/components/schemas/item"}},
+			"data":      {},
+			"CreatedAt": {Type: "string", Format: "date-time"},
+		},
+		Required: []string{"name"},
+	}, doc.Components.Schemas["item"])
+	assert.Equal(t, &openapi.Schema{Type: "string", Enum: []any{state("on"), state("off")}}, doc.Components.Schemas["state"])
+
+	create := (*doc.Paths["/items"])["post"]
+	assert.Equal(t, "createItem", create.OperationId)
+	assert.Equal(t, "#/components/schemas/item", create.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/item", create.Responses["201"].Content["application/json"].Schema.Ref)
+	assert.Equal(t, &openapi.Schema{Type: "string"}, create.Responses["400"].Content["text/plain"].Schema)
+	assert.Contains(t, create.Responses, "413")
+	assert.Empty(t, create.Security)
+
+	get := (*doc.Paths["/items/{item_id}"])["get"]
+	assert.Equal(t, "uuid", get.Parameters[0].Schema.Format)
+	assert.Equal(t, "Requires the editor role.", get.Description)
+	assert.NotEmpty(t, get.Security)
+	assert.Contains(t, get.Responses, "401")
+	assert.Contains(t, get.Responses, "403")
+
+	del := (*doc.Paths["/items/{slug}"])["delete"]
+	assert.Equal(t, []*openapi.Parameter{{Name: "slug", In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}}}, del.Parameters)
+	assert.Nil(t, del.Responses["204"].Content)
+
+	_, err = json.Marshal(doc)
+	assert.NoError(t, err)
+}
+
+func TestNew_Errors(t *testing.T) {
+	ok := map[int]any{http.StatusOK: nil}
+
+	testCases := []struct {
+		name   string
+		routes []openapi.Route
+	}{
+		{
+			name:   "no method",
+			routes: []openapi.Route{{Pattern: "/items", Id: "listItems", Responses: ok}},
+		},
+		{
+			name: "duplicate id",
+			routes: []openapi.Route{
+				{Pattern: "GET /items", Id: "listItems", Responses: ok},
+				{Pattern: "POST /items", Id: "listItems", Responses: ok},
+			},
+		},
+		{
+			name: "duplicate pattern",
+			routes: []openapi.Route{
+				{Pattern: "GET /items", Id: "listItems", Responses: ok},
+				{Pattern: "GET /items", Id: "getItems", Responses: ok},
+			},
+		},
+		{
+			name: "unknown path parameter",
+			routes: []openapi.Route{{
+				Pattern:   "GET /items",
+				Id:        "listItems",
+				Params:    []*openapi.Parameter{{Name: "item_id", In: "path"}},
+				Responses: ok,
+			}},
+		},
+		{
+			name:   "no responses",
+			routes: []openapi.Route{{Pattern: "GET /items", Id: "listItems"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			_, err := openapi.New(openapi.Info{}, tc.routes)
+
+			// Assert
+			assert.Error(t, err)
+		})
+	}
+}
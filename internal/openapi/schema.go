@@ -0,0 +1,167 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const refPrefix = "#/components/schemas/"
+
+// generator generates the schemas of Go types, following the encoding/json
+// rules. Named struct types are stored in the components and referenced.
+//
+// Fields are documented with the openapi struct tag: "required" marks the
+// fields a request body must have, and "format=..." sets the format of a
+// string field, e.g. `openapi:"required,format=uri"`.
+type generator struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+	enums      map[reflect.Type][]any
+}
+
+func newGenerator(components map[string]*Schema, enums []Enum) *generator {
+	g := &generator{
+		components: components,
+		names:      map[reflect.Type]string{},
+		enums:      map[reflect.Type][]any{},
+	}
+	for _, e := range enums {
+		g.enums[e.typ] = e.values
+	}
+	return g
+}
+
+var (
+	timeType   = reflect.TypeFor[time.Time]()
+	uuidType   = reflect.TypeFor[uuid.UUID]()
+	rawType    = reflect.TypeFor[json.RawMessage]()
+	byteSliceT = reflect.TypeFor[[]byte]()
+
+	marshaler     = reflect.TypeFor[json.Marshaler]()
+	textMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+func (g *generator) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if values, ok := g.enums[t]; ok {
+		typ := "integer"
+		if t.Kind() == reflect.String {
+			typ = "string"
+		}
+		return g.component(t, func() *Schema { return &Schema{Type: typ, Enum: values} })
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case rawType:
+		return &Schema{}
+	case byteSliceT:
+		return &Schema{Type: "string", Format: "byte"}
+	}
+	if implements(t, marshaler) {
+		// The JSON of custom marshalers is unknown.
+		return &Schema{}
+	}
+	if implements(t, textMarshaler) {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.component(t, func() *Schema { return g.structSchema(t) })
+	default:
+		return &Schema{}
+	}
+}
+
+// component returns a reference to the schema of the named type t in the
+// components, generating it on first use.
+func (g *generator) component(t reflect.Type, generate func() *Schema) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := g.components[name]; taken {
+			name = strings.ReplaceAll(t.String(), ".", "_")
+		}
+		g.names[t] = name
+		// Registered before it is generated, for the recursive types.
+		g.components[name] = &Schema{}
+		*g.components[name] = *generate()
+	}
+	return &Schema{Ref: refPrefix + name}
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.addFields(s, t)
+	return s
+}
+
+// addFields adds the fields of t to s, including the ones of its embedded
+// structs, like encoding/json.
+func (g *generator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			g.addFields(s, ft)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := g.schemaOf(f.Type)
+		for _, opt := range strings.Split(f.Tag.Get("openapi"), ",") {
+			switch {
+			case opt == "required":
+				s.Required = append(s.Required, name)
+			case strings.HasPrefix(opt, "format="):
+				fs.Format = strings.TrimPrefix(opt, "format=")
+			}
+		}
+		s.Properties[name] = fs
+	}
+}
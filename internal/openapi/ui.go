@@ -0,0 +1,58 @@
+package openapi
+
+import (
+	"embed"
+	"encoding/json"
+	"html/template"
+	"net/http"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+)
+
+// The pages load Swagger UI and Redoc from their CDN, at pinned versions.
+//
+//go:embed ui/*.html
+var uiFS embed.FS
+
+var uiTemplates = template.Must(template.ParseFS(uiFS, "ui/*.html"))
+
+// Handler serves the document as JSON.
+func Handler(doc *Document) http.HandlerFunc {
+	body, err := json.Marshal(doc)
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get openapi document")
+		if err != nil {
+			log.Error("failed to encode openapi document", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+// SwaggerUI serves the Swagger UI page of the document at specURL.
+func SwaggerUI(doc *Document, specURL string) http.HandlerFunc {
+	return page("swagger.html", doc, specURL)
+}
+
+// Redoc serves the Redoc page of the document at specURL.
+func Redoc(doc *Document, specURL string) http.HandlerFunc {
+	return page("redoc.html", doc, specURL)
+}
+
+func page(name string, doc *Document, specURL string) http.HandlerFunc {
+	data := struct{ Title, SpecURL string }{Title: doc.Info.Title, SpecURL: specURL}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Info("get api docs", "page", name)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := uiTemplates.ExecuteTemplate(w, name, data); err != nil {
+			log.Error("failed to render api docs", "error", err)
+			return
+		}
+	}
+}
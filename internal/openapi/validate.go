@@ -0,0 +1,277 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/google/uuid"
+)
+
+// Validate rejects with 400 Bad Request, and the list of errors, the requests
+// whose path or query parameters or JSON body do not match their operation in
+// the document, and with 413 Request Entity Too Large the bodies over their
+// limit. The requests of the routes missing from the document are
+// passed through.
+//
+// Empty strings stand for unset values, as in the handlers, so their format
+// is not checked.
+func Validate(doc *Document, next http.Handler) http.HandlerFunc {
+	mux := http.NewServeMux()
+	ops := map[string]*Operation{}
+	for _, pattern := range doc.Patterns() {
+		method, path, _ := strings.Cut(pattern, " ")
+		ops[pattern] = (*doc.Paths[path])[strings.ToLower(method)]
+		mux.Handle(pattern, next)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		op, ok := ops[pattern]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err := validateRequest(doc, op, pattern, r); err != nil {
+			log := logger.FromContext(r.Context())
+			log.Error("failed to validate request", "error", err)
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+func validateRequest(doc *Document, op *Operation, pattern string, r *http.Request) error {
+	var errs error
+	values := pathValues(pattern, r.URL.Path)
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		var raw []string
+		switch p.In {
+		case "path":
+			raw = []string{values[p.Name]}
+		case "query":
+			raw = query[p.Name]
+		case "header":
+			raw = r.Header.Values(p.Name)
+		}
+		if len(raw) == 0 {
+			if p.Required {
+				errs = errors.Join(errs, fmt.Errorf("%s parameter %s is required", p.In, p.Name))
+			}
+			continue
+		}
+		for _, v := range raw {
+			if err := validateParam(doc, doc.Resolve(p.Schema), v); err != nil {
+				errs = errors.Join(errs, fmt.Errorf("%s parameter %s: %w", p.In, p.Name, err))
+			}
+		}
+	}
+
+	if op.RequestBody != nil {
+		if err := validateBody(doc, op.RequestBody, r); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errs
+}
+
+// pathValues returns the values of the wildcards of the pattern in the path.
+// The mux that matched the request only reports the pattern.
+func pathValues(pattern, path string) map[string]string {
+	_, patternPath, _ := strings.Cut(pattern, " ")
+	names := strings.Split(strings.Trim(patternPath, "/"), "/")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	values := map[string]string{}
+	for i, name := range names {
+		if i >= len(segments) {
+			break
+		}
+		if name, ok := strings.CutPrefix(name, "{"); ok {
+			v, err := url.PathUnescape(segments[i])
+			if err != nil {
+				v = segments[i]
+			}
+			values[strings.TrimSuffix(name, "}")] = v
+		}
+	}
+	return values
+}
+
+// validateParam validates a parameter, whose arrays are comma separated.
+func validateParam(doc *Document, s *Schema, v string) error {
+	if s == nil {
+		return nil
+	}
+	if s.Type == "array" {
+		var errs error
+		for _, item := range strings.Split(v, ",") {
+			errs = errors.Join(errs, validateParam(doc, doc.Resolve(s.Items), strings.TrimSpace(item)))
+		}
+		return errs
+	}
+	var value any = v
+	switch s.Type {
+	case "integer", "number":
+		value = json.Number(v)
+	case "boolean":
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", v)
+		}
+		value = b
+	}
+	return validateValue(doc, s, value, "")
+}
+
+func validateBody(doc *Document, body *RequestBody, r *http.Request) error {
+	media, ok := body.Content["application/json"]
+	if !ok {
+		return nil
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	if len(bytes.TrimSpace(data)) == 0 {
+		if body.Required {
+			return errors.New("body is required")
+		}
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("body is not valid JSON: %w", err)
+	}
+	return validateValue(doc, media.Schema, value, "body")
+}
+
+// validateValue validates a value decoded from JSON, with numbers as
+// json.Number, against the schema. Nulls are accepted as unset values, like
+// encoding/json does.
+func validateValue(doc *Document, s *Schema, value any, path string) error {
+	s = doc.Resolve(s)
+	if s == nil || value == nil {
+		return nil
+	}
+	fail := func(format string, args ...any) error {
+		if path == "" {
+			return fmt.Errorf(format, args...)
+		}
+		return fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...))
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fail("expected an object")
+		}
+		var errs error
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				errs = errors.Join(errs, fail("%s is required", name))
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(obj)) {
+			v := obj[name]
+			if ps, ok := s.Properties[name]; ok {
+				errs = errors.Join(errs, validateValue(doc, ps, v, join(path, name)))
+			} else if s.AdditionalProperties != nil {
+				errs = errors.Join(errs, validateValue(doc, s.AdditionalProperties, v, join(path, name)))
+			}
+		}
+		return errs
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return fail("expected an array")
+		}
+		var errs error
+		for i, v := range arr {
+			errs = errors.Join(errs, validateValue(doc, s.Items, v, fmt.Sprintf("%s[%d]", path, i)))
+		}
+		return errs
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fail("expected a string")
+		}
+		if str != "" {
+			if err := checkFormat(s.Format, str); err != nil {
+				return fail("%v", err)
+			}
+		}
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return fail("expected an integer")
+		}
+		if _, err := n.Int64(); err != nil {
+			return fail("%q is not an integer", n)
+		}
+	case "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			return fail("expected a number")
+		}
+		if _, err := n.Float64(); err != nil {
+			return fail("%q is not a number", n)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fail("expected a boolean")
+		}
+	}
+
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return fmt.Sprint(e) == fmt.Sprint(value) }) {
+		return fail("%v is not one of %v", value, s.Enum)
+	}
+	return nil
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func checkFormat(format, s string) error {
+	switch format {
+	case "uuid":
+		if _, err := uuid.Parse(s); err != nil {
+			return fmt.Errorf("%q is not a uuid", s)
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return fmt.Errorf("%q is not a RFC 3339 date-time", s)
+		}
+	case "uri":
+		if u, err := url.Parse(s); err != nil || !u.IsAbs() {
+			return fmt.Errorf("%q is not an absolute uri", s)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package openapi_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type body struct {
+	Name   string   `json:"name" openapi:"required"`
+	URL    string   `json:"url" openapi:"format=uri"`
+	State  state    `json:"state"`
+	Tags   []string `json:"tags"`
+	Weight int      `json:"weight"`
+}
+
+func TestValidate(t *testing.T) {
+	doc, err := openapi.New(openapi.Info{}, []openapi.Route{
+		{
+			Pattern:   "PUT /items/{item_id}",
+			Id:        "updateItem",
+			Params:    []*openapi.Parameter{{Name: "item_id", In: "path", Required: true, Schema: &openapi.Schema{Type: "string", Format: "uuid"}}},
+			Body:      body{},
+			Responses: map[int]any{http.StatusOK: nil},
+		},
+		{
+			Pattern: "GET /items",
+			Id:      "listItems",
+			Params: []*openapi.Parameter{
+				{Name: "limit", In: "query", Schema: &openapi.Schema{Type: "integer"}},
+				{Name: "state", In: "query", Schema: &openapi.Schema{Type: "array", Items: &openapi.Schema{Ref: "#/components/schemas/state"}}},
+			},
+			Responses: map[int]any{http.StatusOK: nil},
+		},
+	}, openapi.EnumOf[state]("on", "off"))
+	require.NoError(t, err)
+
+	const id = "1b4e28ba-2fa1-11d2-883f-0016d3cca427"
+
+	testCases := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "valid body",
+			method:         http.MethodPut,
+			target:         "/items/" + id,
+			body:           `{"name": "item", "url": "https://example.com", "state": "on", "tags": ["a"], "weight": 2}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"name": "item", "url": "https://example.com", "state": "on", "tags": ["a"], "weight": 2}`,
+		},
+		{
+			name:           "nulls and empty strings",
+			method:         http.MethodPut,
+			target:         "/items/" + id,
+			body:           `{"name": "item", "url": "", "tags": null}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"name": "item", "url": "", "tags": null}`,
+		},
+		{
+			name:           "invalid body",
+			method:         http.MethodPut,
+			target:         "/items/" + id,
+			body:           `{"url": "example", "state": "maybe", "tags": [1], "weight": 1.5}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: "body: name is required\n" +
+				"body.state: maybe is not one of [on off]\n" +
+				"body.tags[0]: expected a string\n" +
+				`body.url: "example" is not an absolute uri` + "\n" +
+				`body.weight: "1.5" is not an integer`,
+		},
+		{
+			name:           "invalid json",
+			method:         http.MethodPut,
+			target:         "/items/" + id,
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "body is not valid JSON: unexpected EOF",
+		},
+		{
+			name:           "no body",
+			method:         http.MethodPut,
+			target:         "/items/" + id,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "body is required",
+		},
+		{
+			name:           "invalid path parameter",
+			method:         http.MethodPut,
+			target:         "/items/invalid",
+			body:           `{"name": "item"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `path parameter item_id: "invalid" is not a uuid`,
+		},
+		{
+			name:           "valid query parameters",
+			method:         http.MethodGet,
+			target:         "/items?limit=10&state=on,off",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid query parameters",
+			method:         http.MethodGet,
+			target:         "/items?limit=ten&state=on,maybe",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: `query parameter limit: "ten" is not an integer` + "\n" +
+				"query parameter state: maybe is not one of [on off]",
+		},
+		{
+			name:           "undocumented route",
+			method:         http.MethodPost,
+			target:         "/other",
+			body:           `{`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// The body is still readable after the validation.
+				b, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				w.Write(b)
+			})
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+
+			// Act
+			openapi.Validate(doc, next)(w, req)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+)
+
+// DefaultBatchSize is the number of events claimed and relayed at once.
+const DefaultBatchSize = 100
+
+// Sink receives the events relayed from the outbox. A failing sink makes the
+// whole batch to be relayed again to every sink, so sinks must tolerate
+// duplicates.
+type Sink interface {
+	Publish(ctx context.Context, events []*news.OutboxEvent) error
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(ctx context.Context, events []*news.OutboxEvent) error
+
+func (f SinkFunc) Publish(ctx context.Context, events []*news.OutboxEvent) error {
+	return f(ctx, events)
+}
+
+type Store interface {
+	Relay(ctx context.Context, limit int, relay func(context.Context, []*news.OutboxEvent) error) (int, error)
+}
+
+// Relay moves the events from the outbox to the sinks.
+type Relay struct {
+	store     Store
+	sinks     []Sink
+	BatchSize int
+}
+
+func NewRelay(store Store, sinks ...Sink) *Relay {
+	return &Relay{
+		store:     store,
+		sinks:     sinks,
+		BatchSize: DefaultBatchSize,
+	}
+}
+
+// Dispatch relays the undelivered events, batch after batch, until none is
+// left.
+func (r *Relay) Dispatch(ctx context.Context) error {
+	for {
+		count, err := r.store.Relay(ctx, r.BatchSize, r.publish)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			logger.FromContext(ctx).Info("outbox events relayed", "count", count)
+		}
+		if count < r.BatchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, events []*news.OutboxEvent) error {
+	var errs error
+	for _, s := range r.sinks {
+		errs = errors.Join(errs, s.Publish(ctx, events))
+	}
+	return errs
+}
+
+// Bus is an in-process sink passing the events to its subscribers.
+type Bus struct {
+	mu     sync.RWMutex
+	nextId int
+	subs   map[int]func(*news.OutboxEvent)
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		subs: map[int]func(*news.OutboxEvent){},
+	}
+}
+
+// Subscribe calls fn with every event published from now on, until the
+// returned function is called. fn must not block.
+func (b *Bus) Subscribe(fn func(*news.OutboxEvent)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextId
+	b.nextId++
+	b.subs[id] = fn
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, id)
+	}
+}
+
+func (b *Bus) Publish(_ context.Context, events []*news.OutboxEvent) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, e := range events {
+		for _, fn := range b.subs {
+			fn(e)
+		}
+	}
+	return nil
+}
+
+// FileSink appends the events to a file as newline delimited JSON.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{
+		path: path,
+	}
+}
+
+func (s *FileSink) Publish(_ context.Context, events []*news.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open outbox file: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("write outbox file: %w", err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("sync outbox file: %w", err)
+	}
+	return f.Close()
+}
+
+// HTTPSink posts the events as a JSON array to an endpoint, which must
+// answer with a 2xx status.
+type HTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPSink(url string, client *http.Client) *HTTPSink {
+	return &HTTPSink{
+		url:    url,
+		client: client,
+	}
+}
+
+func (s *HTTPSink) Publish(ctx context.Context, events []*news.OutboxEvent) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post outbox events: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("post outbox events: unexpected status: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package outbox_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/outbox"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store relays its events like news.OutboxStore, keeping the batches that
+// failed.
+type store struct {
+	events []*news.OutboxEvent
+}
+
+func (s *store) Relay(ctx context.Context, limit int, relay func(context.Context, []*news.OutboxEvent) error) (int, error) {
+	batch := s.events[:min(limit, len(s.events))]
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	if err := relay(ctx, batch); err != nil {
+		return 0, err
+	}
+	s.events = s.events[len(batch):]
+	return len(batch), nil
+}
+
+func newEvents(n int) []*news.OutboxEvent {
+	events := make([]*news.OutboxEvent, 0, n)
+	for range n {
+		events = append(events, &news.OutboxEvent{
+			EventId: uuid.New(),
+			Event:   news.EventCreated,
+			NewsId:  uuid.New(),
+			Payload: json.RawMessage(`{"data":{}}`),
+		})
+	}
+	return events
+}
+
+func Test_Relay_Dispatch(t *testing.T) {
+	testCases := []struct {
+		name          string
+		sinkErr       error
+		expectedErr   string
+		expectedLeft  int
+		expectedCalls int
+	}{
+		{
+			name:          "relayed in batches",
+			expectedCalls: 3,
+		},
+		{
+			name:          "sink error",
+			sinkErr:       errors.New("sink down"),
+			expectedErr:   "sink down",
+			expectedLeft:  5,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			s := &store{events: newEvents(5)}
+			bus := outbox.NewBus()
+			var received []*news.OutboxEvent
+			bus.Subscribe(func(e *news.OutboxEvent) { received = append(received, e) })
+			calls := 0
+			failing := outbox.SinkFunc(func(context.Context, []*news.OutboxEvent) error {
+				calls++
+				return tc.sinkErr
+			})
+			r := outbox.NewRelay(s, bus, failing)
+			r.BatchSize = 2
+
+			// Act
+			err := r.Dispatch(context.Background())
+
+			// Assert
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, received, 5)
+			}
+			assert.Len(t, s.events, tc.expectedLeft)
+			assert.Equal(t, tc.expectedCalls, calls)
+		})
+	}
+}
+
+func Test_Bus_Subscribe(t *testing.T) {
+	// Arrange
+	bus := outbox.NewBus()
+	var first, second int
+	unsubscribe := bus.Subscribe(func(*news.OutboxEvent) { first++ })
+	bus.Subscribe(func(*news.OutboxEvent) { second++ })
+
+	// Act
+	require.NoError(t, bus.Publish(context.Background(), newEvents(2)))
+	unsubscribe()
+	require.NoError(t, bus.Publish(context.Background(), newEvents(1)))
+
+	// Assert
+	assert.Equal(t, 2, first)
+	assert.Equal(t, 3, second)
+}
+
+func Test_FileSink_Publish(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "outbox.ndjson")
+	sink := outbox.NewFileSink(path)
+	events := newEvents(3)
+
+	// Act
+	require.NoError(t, sink.Publish(context.Background(), events[:2]))
+	require.NoError(t, sink.Publish(context.Background(), events[2:]))
+
+	// Assert
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var ids []uuid.UUID
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e news.OutboxEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		ids = append(ids, e.EventId)
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, []uuid.UUID{events[0].EventId, events[1].EventId, events[2].EventId}, ids)
+}
+
+func Test_HTTPSink_Publish(t *testing.T) {
+	testCases := []struct {
+		name        string
+		status      int
+		expectedErr string
+	}{
+		{
+			name:   "success",
+			status: http.StatusAccepted,
+		},
+		{
+			name:        "receiver error",
+			status:      http.StatusBadGateway,
+			expectedErr: "unexpected status",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			var received []*news.OutboxEvent
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&received)
+				w.WriteHeader(tc.status)
+			}))
+			t.Cleanup(receiver.Close)
+			sink := outbox.NewHTTPSink(receiver.URL, receiver.Client())
+			events := newEvents(2)
+
+			// Act
+			err := sink.Publish(context.Background(), events)
+
+			// Assert
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			require.Len(t, received, 2)
+			assert.Equal(t, events[0].EventId, received[0].EventId)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/jackc/pgx/v5"
+)
+
+// Listen calls fn with the payload of every notification sent on channel
+// until ctx is done. It holds a dedicated connection, reconnecting with a
+// backoff when it is lost. Notifications sent while disconnected are lost.
+func Listen(ctx context.Context, c *Config, channel string, fn func(ctx context.Context, payload string)) error {
+	log := logger.FromContext(ctx).With("channel", channel)
+	backoff := time.Second
+	for {
+		err := listen(ctx, c, channel, fn)
+		if ctx.Err() != nil {
+			return nil
+		}
+		log.Error("listen failed", "error", err, "retry_in", backoff)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, 30*time.Second)
+	}
+}
+
+func listen(ctx context.Context, c *Config, channel string, fn func(ctx context.Context, payload string)) error {
+	conn, err := pgx.Connect(ctx, c.ConnString())
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	logger.FromContext(ctx).Info("listening", "channel", channel)
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+		fn(ctx, n.Payload)
+	}
+}
@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/uptrace/bun"
+)
+
+// LockKey derives an advisory lock key from a name, so jobs can pick readable
+// names instead of coordinating numbers.
+func LockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return int64(h.Sum64()) //nolint:gosec // any 64 bits make a valid key
+}
+
+// TryAdvisoryXactLock tries to take the transaction level advisory lock with
+// the given key without waiting. The lock is released when the transaction
+// ends, so db must be a transaction for it to be held across queries.
+func TryAdvisoryXactLock(ctx context.Context, db bun.IDB, key int64) (bool, error) {
+	var locked bool
+	if err := db.NewRaw("SELECT pg_try_advisory_xact_lock(?)", key).Scan(ctx, &locked); err != nil {
+		return false, fmt.Errorf("try advisory lock: %w", err)
+	}
+	return locked, nil
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/stdlib"
@@ -19,23 +20,46 @@ type Config struct {
 	MaxOpenConn int
 	MaxIdleConn int
 	User        string
-	SSLMode     string
+	// SSLMode is the libpq sslmode: disable, allow, prefer, require,
+	// verify-ca or verify-full.
+	SSLMode string
+	// SSLRootCert is the file of the CAs the server certificate is verified
+	// against, with the verify-ca and verify-full modes.
+	SSLRootCert string
+	// SSLCert and SSLKey are the files of the client certificate and its
+	// key, for the server to authenticate the client with.
+	SSLCert string
+	SSLKey  string
 }
 
-func (c *Config) conn() string {
-	return fmt.Sprintf(
-		"dbname=%s host=%s port=%s user=%s password=%s sslmode=%s",
-		c.DBName,
-		c.Host,
-		c.Port,
-		c.User,
-		c.Password,
-		c.SSLMode,
-	)
+// ConnString returns the libpq connection string of the config.
+func (c *Config) ConnString() string {
+	params := []string{
+		param("dbname", c.DBName),
+		param("host", c.Host),
+		param("port", c.Port),
+		param("user", c.User),
+		param("password", c.Password),
+		param("sslmode", c.SSLMode),
+	}
+	if c.SSLRootCert != "" {
+		params = append(params, param("sslrootcert", c.SSLRootCert))
+	}
+	if c.SSLCert != "" {
+		params = append(params, param("sslcert", c.SSLCert), param("sslkey", c.SSLKey))
+	}
+	return strings.Join(params, " ")
+}
+
+// param returns the keyword and value pair of a connection string, the value
+// quoted for it to hold spaces and quotes.
+func param(name, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+	return name + "='" + value + "'"
 }
 
 func NewDB(c *Config) (*bun.DB, error) {
-	config, err := pgx.ParseConfig(c.conn())
+	config, err := pgx.ParseConfig(c.ConnString())
 	if err != nil {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
@@ -0,0 +1,94 @@
+package postgres_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertificate writes a self-signed certificate and its key to dir.
+func writeCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "news"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile, keyFile = filepath.Join(dir, "news.crt"), filepath.Join(dir, "news.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestConfig_ConnString(t *testing.T) {
+	certFile, keyFile := writeCertificate(t, t.TempDir())
+
+	testCases := []struct {
+		name   string
+		config postgres.Config
+		assert func(t *testing.T, c *pgx.ConnConfig)
+	}{
+		{
+			name:   "disable",
+			config: postgres.Config{Host: "localhost", Port: "5432", DBName: "news", User: "news", Password: `it's a "secret"\`, SSLMode: "disable"},
+			assert: func(t *testing.T, c *pgx.ConnConfig) {
+				assert.Equal(t, `it's a "secret"\`, c.Password)
+				assert.Nil(t, c.TLSConfig)
+			},
+		},
+		{
+			name:   "verify-full",
+			config: postgres.Config{Host: "db.example.com", Port: "5432", DBName: "news", User: "news", SSLMode: "verify-full", SSLRootCert: certFile},
+			assert: func(t *testing.T, c *pgx.ConnConfig) {
+				require.NotNil(t, c.TLSConfig)
+				assert.Equal(t, "db.example.com", c.TLSConfig.ServerName)
+				assert.NotNil(t, c.TLSConfig.RootCAs)
+				assert.False(t, c.TLSConfig.InsecureSkipVerify)
+				assert.Empty(t, c.Fallbacks)
+			},
+		},
+		{
+			name:   "client certificate",
+			config: postgres.Config{Host: "db.example.com", Port: "5432", DBName: "news", User: "news", SSLMode: "verify-ca", SSLRootCert: certFile, SSLCert: certFile, SSLKey: keyFile},
+			assert: func(t *testing.T, c *pgx.ConnConfig) {
+				require.NotNil(t, c.TLSConfig)
+				assert.Len(t, c.TLSConfig.Certificates, 1)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			c, err := pgx.ParseConfig(tc.config.ConnString())
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tc.config.Host, c.Host)
+			assert.Equal(t, tc.config.DBName, c.Database)
+			tc.assert(t, c)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+// Package problem writes the RFC 9457 problem details of the error responses
+// that the middlewares answer themselves.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ContentType is the media type of the problem details.
+const ContentType = "application/problem+json"
+
+// Details is an RFC 9457 problem detail.
+type Details struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// Write writes the problem of the status code, explained by detail. Headers
+// have to be set before.
+func Write(w http.ResponseWriter, status int, detail string) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Details{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+}
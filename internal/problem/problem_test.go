@@ -0,0 +1,32 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Write(t *testing.T) {
+	// Arrange
+	w := httptest.NewRecorder()
+
+	// Act
+	problem.Write(w, http.StatusTooManyRequests, "slow down")
+
+	// Assert
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+	var details problem.Details
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&details))
+	assert.Equal(t, problem.Details{
+		Type:   "about:blank",
+		Title:  "Too Many Requests",
+		Status: http.StatusTooManyRequests,
+		Detail: "slow down",
+	}, details)
+}
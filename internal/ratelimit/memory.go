@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is the number of takes between the sweeps of the full buckets.
+const sweepEvery = 1024
+
+type bucket struct {
+	tokens    float64
+	at        time.Time
+	perSecond float64
+	burst     int
+}
+
+// refill returns the tokens of the bucket at now.
+func (b *bucket) refill(now time.Time) float64 {
+	return min(float64(b.burst), b.tokens+now.Sub(b.at).Seconds()*b.perSecond)
+}
+
+// MemoryStore keeps the buckets in memory, so that each replica enforces
+// its own limits.
+type MemoryStore struct {
+	l       sync.Mutex
+	now     func() time.Time
+	buckets map[string]*bucket
+	takes   int
+}
+
+// NewMemoryStore returns a store of the buckets at the times of now,
+// time.Now when nil.
+func NewMemoryStore(now func() time.Time) *MemoryStore {
+	if now == nil {
+		now = time.Now
+	}
+	return &MemoryStore{now: now, buckets: map[string]*bucket{}}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, perSecond float64, burst int) (float64, bool, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	now := s.now()
+	s.takes++
+	if s.takes%sweepEvery == 0 {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), at: now}
+		s.buckets[key] = b
+	}
+	b.perSecond, b.burst = perSecond, burst
+	b.tokens, b.at = b.refill(now), now
+	if b.tokens < 1 {
+		return b.tokens, false, nil
+	}
+	b.tokens--
+	return b.tokens, true, nil
+}
+
+// sweep drops the buckets that are full again.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if b.refill(now) >= float64(b.burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// Len returns the number of buckets kept.
+func (s *MemoryStore) Len() int {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return len(s.buckets)
+}
@@ -0,0 +1,207 @@
+// Package ratelimit limits the rate of the requests of each client with token
+// buckets, per route pattern.
+//
+// Clients are told their quota with the RateLimit-* headers, and requests
+// over it are rejected with a 429 Too Many Requests problem and a
+// Retry-After header. The buckets are kept in a Store, in memory or shared
+// by the replicas.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/problem"
+)
+
+// Rate is the number of requests allowed per period, in bursts of up to
+// Burst requests. The zero Rate is unlimited.
+type Rate struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// ParseRate parses rates like "100/m", "10/s:20" or "500/15m": a limit per
+// period, a second, a minute, an hour or a duration, and an optional burst
+// after a colon, the limit by default. "0" is unlimited.
+func ParseRate(s string) (Rate, error) {
+	if s == "0" {
+		return Rate{}, nil
+	}
+	limit, rest, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid rate: %q", s)
+	}
+	period, burst, hasBurst := strings.Cut(rest, ":")
+	r := Rate{}
+	var err error
+	if r.Limit, err = strconv.Atoi(limit); err != nil || r.Limit <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate limit: %q", s)
+	}
+	switch period {
+	case "s":
+		r.Period = time.Second
+	case "m":
+		r.Period = time.Minute
+	case "h":
+		r.Period = time.Hour
+	default:
+		if r.Period, err = time.ParseDuration(period); err != nil || r.Period <= 0 {
+			return Rate{}, fmt.Errorf("invalid rate period: %q", s)
+		}
+	}
+	r.Burst = r.Limit
+	if hasBurst {
+		if r.Burst, err = strconv.Atoi(burst); err != nil || r.Burst <= 0 {
+			return Rate{}, fmt.Errorf("invalid rate burst: %q", s)
+		}
+	}
+	return r, nil
+}
+
+// ParseRoutes parses a comma separated list of pattern=rate entries, e.g.
+// "GET /news=100/m, POST /news=10/m".
+func ParseRoutes(s string) (map[string]Rate, error) {
+	routes := map[string]Rate{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		i := strings.LastIndex(entry, "=")
+		if i == -1 {
+			return nil, fmt.Errorf("invalid route rate entry: %q", entry)
+		}
+		rate, err := ParseRate(strings.TrimSpace(entry[i+1:]))
+		if err != nil {
+			return nil, err
+		}
+		routes[strings.TrimSpace(entry[:i])] = rate
+	}
+	return routes, nil
+}
+
+func (r Rate) unlimited() bool {
+	return r.Limit == 0
+}
+
+// perSecond returns the number of tokens added to the buckets per second.
+func (r Rate) perSecond() float64 {
+	return float64(r.Limit) / r.Period.Seconds()
+}
+
+func (r Rate) String() string {
+	if r.unlimited() {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d requests per %s", r.Limit, r.Period)
+}
+
+// Store keeps the token buckets.
+type Store interface {
+	// Take takes a token from the bucket of the key, which holds up to
+	// burst tokens and gets perSecond tokens every second, and returns the
+	// tokens left. Full buckets are not stored. Nothing is taken from a
+	// bucket with less than a token.
+	Take(ctx context.Context, key string, perSecond float64, burst int) (tokens float64, ok bool, err error)
+}
+
+// Config configures a Limiter.
+type Config struct {
+	// Default is the rate of the routes without their own.
+	Default Rate
+	// Routes are the rates of route patterns, e.g. "GET /news". Each route
+	// has its own buckets, the routes of the default rate share theirs.
+	Routes map[string]Rate
+	// TrustedProxies are the proxies whose X-Forwarded-For header tells the
+	// client IP.
+	TrustedProxies []netip.Prefix
+	// Keys are the API keys of the principals, for the requests to be
+	// limited before their key is checked.
+	Keys auth.Keys
+}
+
+// Limiter limits the rate of the requests of each client.
+type Limiter struct {
+	store Store
+	c     Config
+}
+
+func New(store Store, c Config) *Limiter {
+	return &Limiter{store: store, c: c}
+}
+
+// Mid limits the rate of the requests to next, matched to their route
+// pattern with mux. Requests go through when the store fails. It runs before
+// auth.Mid, which rejects the unknown API keys, and after auth.CertMid.
+func Mid(l *Limiter, mux *http.ServeMux, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		_, pattern := mux.Handler(r)
+		rate, ok := l.c.Routes[pattern]
+		if !ok {
+			rate, pattern = l.c.Default, ""
+		}
+		if rate.unlimited() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tokens, ok, err := l.store.Take(ctx, pattern+"|"+l.client(r), rate.perSecond(), rate.Burst)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to take rate limit token", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", rate.Limit, seconds(rate.Period), rate.Burst))
+		h.Set("RateLimit-Limit", strconv.Itoa(rate.Burst))
+		h.Set("RateLimit-Remaining", strconv.Itoa(int(tokens)))
+		h.Set("RateLimit-Reset", strconv.Itoa(secondsUntil(float64(rate.Burst)-tokens, rate)))
+		if !ok {
+			h.Set("Retry-After", strconv.Itoa(max(secondsUntil(1-tokens, rate), 1)))
+			tooManyRequests(w, rate)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// client returns the key of the client of the request: the principal of its
+// API key, or else of its client certificate, or else its IP. Requests with
+// an unknown API key are limited by IP, so that guessing keys is limited too.
+func (l *Limiter) client(r *http.Request) string {
+	if key := auth.APIKey(r); key != "" {
+		if p, ok := l.c.Keys[key]; ok {
+			return "principal:" + p.Key()
+		}
+	} else if p, ok := auth.FromContext(r.Context()); ok {
+		return "principal:" + p.Key()
+	}
+	return "ip:" + ClientIP(r, l.c.TrustedProxies).String()
+}
+
+// secondsUntil returns the seconds it takes to get the tokens back.
+func secondsUntil(tokens float64, rate Rate) int {
+	if tokens <= 0 {
+		return 0
+	}
+	return int(math.Ceil(tokens / rate.perSecond()))
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func tooManyRequests(w http.ResponseWriter, rate Rate) {
+	problem.Write(w, http.StatusTooManyRequests, fmt.Sprintf("rate limit of %s exceeded", rate))
+}
@@ -0,0 +1,386 @@
+package ratelimit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseRate(t *testing.T) {
+	testCases := []struct {
+		input       string
+		expected    ratelimit.Rate
+		expectedErr string
+	}{
+		{input: "0", expected: ratelimit.Rate{}},
+		{input: "100/m", expected: ratelimit.Rate{Limit: 100, Period: time.Minute, Burst: 100}},
+		{input: "10/s:20", expected: ratelimit.Rate{Limit: 10, Period: time.Second, Burst: 20}},
+		{input: "500/15m", expected: ratelimit.Rate{Limit: 500, Period: 15 * time.Minute, Burst: 500}},
+		{input: "1/h", expected: ratelimit.Rate{Limit: 1, Period: time.Hour, Burst: 1}},
+		{input: "100", expectedErr: "invalid rate"},
+		{input: "-1/m", expectedErr: "invalid rate limit"},
+		{input: "10/d", expectedErr: "invalid rate period"},
+		{input: "10/-1s", expectedErr: "invalid rate period"},
+		{input: "10/s:0", expectedErr: "invalid rate burst"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			// Act
+			rate, err := ratelimit.ParseRate(tc.input)
+
+			// Assert
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, rate)
+		})
+	}
+}
+
+func Test_ParseRoutes(t *testing.T) {
+	// Act
+	routes, err := ratelimit.ParseRoutes("GET /news=100/m, POST /news=10/m:5,GET /news/stream=0,")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, map[string]ratelimit.Rate{
+		"GET /news":        {Limit: 100, Period: time.Minute, Burst: 100},
+		"POST /news":       {Limit: 10, Period: time.Minute, Burst: 5},
+		"GET /news/stream": {},
+	}, routes)
+
+	_, err = ratelimit.ParseRoutes("GET /news")
+	assert.ErrorContains(t, err, "invalid route rate entry")
+}
+
+func Test_ClientIP(t *testing.T) {
+	trusted, err := ratelimit.ParseProxies("10.0.0.0/8, 127.0.0.1, ::1")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		trusted      []netip.Prefix
+		expectedIP   string
+	}{
+		{
+			name:       "direct client",
+			remoteAddr: "203.0.113.7:5000",
+			expectedIP: "203.0.113.7",
+		},
+		{
+			name:         "untrusted proxy",
+			remoteAddr:   "203.0.113.7:5000",
+			forwardedFor: []string{"198.51.100.1"},
+			trusted:      trusted,
+			expectedIP:   "203.0.113.7",
+		},
+		{
+			name:         "no trusted proxies",
+			remoteAddr:   "10.0.0.1:5000",
+			forwardedFor: []string{"198.51.100.1"},
+			expectedIP:   "10.0.0.1",
+		},
+		{
+			name:         "trusted proxy",
+			remoteAddr:   "10.0.0.1:5000",
+			forwardedFor: []string{"198.51.100.1"},
+			trusted:      trusted,
+			expectedIP:   "198.51.100.1",
+		},
+		{
+			name:         "spoofed hops",
+			remoteAddr:   "10.0.0.1:5000",
+			forwardedFor: []string{"1.2.3.4, 198.51.100.1", "10.0.0.2"},
+			trusted:      trusted,
+			expectedIP:   "198.51.100.1",
+		},
+		{
+			name:         "invalid hop",
+			remoteAddr:   "127.0.0.1:5000",
+			forwardedFor: []string{"198.51.100.1, garbage, 10.0.0.2"},
+			trusted:      trusted,
+			expectedIP:   "10.0.0.2",
+		},
+		{
+			name:         "ipv6 proxy",
+			remoteAddr:   "[::1]:5000",
+			forwardedFor: []string{"2001:db8::1"},
+			trusted:      trusted,
+			expectedIP:   "2001:db8::1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			r.RemoteAddr = tc.remoteAddr
+			for _, v := range tc.forwardedFor {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+
+			// Act
+			ip := ratelimit.ClientIP(r, tc.trusted)
+
+			// Assert
+			assert.Equal(t, tc.expectedIP, ip.String())
+		})
+	}
+
+	_, err = ratelimit.ParseProxies("10.0.0.0/33")
+	assert.ErrorContains(t, err, "invalid trusted proxy")
+}
+
+// failingStore fails to take tokens.
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, float64, int) (float64, bool, error) {
+	return 0, false, errors.New("db error")
+}
+
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, pattern := range []string{"GET /news", "GET /news/{news_id}", "POST /news", "GET /news/stream"} {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {})
+	}
+	return mux
+}
+
+func Test_Mid(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	type request struct {
+		method     string
+		target     string
+		remoteAddr string
+		principal  *auth.Principal
+		apiKey     string
+	}
+	anonymous := request{method: http.MethodGet, target: "/news", remoteAddr: "203.0.113.7:5000"}
+
+	testCases := []struct {
+		name             string
+		store            ratelimit.Store
+		requests         []request
+		expectedStatuses []int
+	}{
+		{
+			name:             "over the default rate",
+			requests:         []request{anonymous, anonymous, anonymous},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name: "routes of the default rate share their buckets",
+			requests: []request{
+				anonymous,
+				{method: http.MethodGet, target: "/news/1", remoteAddr: "203.0.113.7:5000"},
+				{method: http.MethodGet, target: "/unknown", remoteAddr: "203.0.113.7:5000"},
+			},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name: "route rate",
+			requests: []request{
+				{method: http.MethodPost, target: "/news", remoteAddr: "203.0.113.7:5000"},
+				{method: http.MethodPost, target: "/news", remoteAddr: "203.0.113.7:5000"},
+				anonymous,
+			},
+			expectedStatuses: []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+		},
+		{
+			name: "unlimited route",
+			requests: []request{
+				{method: http.MethodGet, target: "/news/stream", remoteAddr: "203.0.113.7:5000"},
+				{method: http.MethodGet, target: "/news/stream", remoteAddr: "203.0.113.7:5000"},
+				{method: http.MethodGet, target: "/news/stream", remoteAddr: "203.0.113.7:5000"},
+			},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+		{
+			name: "clients have their own buckets",
+			requests: []request{
+				anonymous,
+				anonymous,
+				{method: http.MethodGet, target: "/news", remoteAddr: "198.51.100.1:5000"},
+				{method: http.MethodGet, target: "/news", remoteAddr: "203.0.113.7:5000", principal: &auth.Principal{Name: "alice"}},
+				{method: http.MethodGet, target: "/news", remoteAddr: "203.0.113.7:5000", apiKey: "key"},
+			},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+		{
+			name: "principals are limited wherever they come from",
+			requests: []request{
+				{method: http.MethodGet, target: "/news", remoteAddr: "203.0.113.7:5000", principal: &auth.Principal{Name: "alice"}},
+				{method: http.MethodGet, target: "/news", remoteAddr: "198.51.100.1:5000", principal: &auth.Principal{Name: "alice"}},
+				{method: http.MethodGet, target: "/news", remoteAddr: "198.51.100.2:5000", principal: &auth.Principal{Name: "alice"}},
+			},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name: "keys are limited as their principal",
+			requests: []request{
+				{method: http.MethodGet, target: "/news", remoteAddr: "203.0.113.7:5000", apiKey: "key"},
+				{method: http.MethodGet, target: "/news", remoteAddr: "198.51.100.1:5000", principal: &auth.Principal{Name: "bob"}},
+				{method: http.MethodGet, target: "/news", remoteAddr: "198.51.100.2:5000", apiKey: "key", principal: &auth.Principal{Name: "alice"}},
+			},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name: "unknown keys are limited by ip",
+			requests: []request{
+				{method: http.MethodGet, target: "/news", remoteAddr: "203.0.113.7:5000", apiKey: "guess-1"},
+				{method: http.MethodGet, target: "/news", remoteAddr: "203.0.113.7:5000", apiKey: "guess-2", principal: &auth.Principal{Name: "alice"}},
+				anonymous,
+			},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:             "store error",
+			store:            failingStore{},
+			requests:         []request{anonymous, anonymous, anonymous},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			store := tc.store
+			if store == nil {
+				store = ratelimit.NewMemoryStore(clock)
+			}
+			l := ratelimit.New(store, ratelimit.Config{
+				Default: ratelimit.Rate{Limit: 2, Period: time.Minute, Burst: 2},
+				Routes: map[string]ratelimit.Rate{
+					"POST /news":       {Limit: 1, Period: time.Minute, Burst: 1},
+					"GET /news/stream": {},
+				},
+				Keys: auth.Keys{"key": {Name: "bob"}},
+			})
+			mux := newMux()
+			h := ratelimit.Mid(l, mux, mux)
+
+			for i, req := range tc.requests {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(req.method, req.target, http.NoBody)
+				r.RemoteAddr = req.remoteAddr
+				if req.apiKey != "" {
+					r.Header.Set("X-API-Key", req.apiKey)
+				}
+				r = r.WithContext(auth.CtxWithPrincipal(r.Context(), req.principal))
+
+				// Act
+				h(w, r)
+
+				// Assert
+				expectedStatus := tc.expectedStatuses[i]
+				if req.target == "/unknown" && expectedStatus == http.StatusOK {
+					expectedStatus = http.StatusNotFound
+				}
+				assert.Equal(t, expectedStatus, w.Code, "request %d", i)
+			}
+		})
+	}
+}
+
+func Test_Mid_Headers(t *testing.T) {
+	// Arrange
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	l := ratelimit.New(ratelimit.NewMemoryStore(func() time.Time { return now }), ratelimit.Config{
+		Default: ratelimit.Rate{Limit: 60, Period: time.Minute, Burst: 2},
+	})
+	mux := newMux()
+	h := ratelimit.Mid(l, mux, mux)
+	do := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest(http.MethodGet, "/news", http.NoBody))
+		return w
+	}
+
+	// Act & Assert
+	w := do()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "60;w=60;burst=2", w.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Reset"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	do()
+	w = do()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	var problem map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, map[string]any{
+		"type":   "about:blank",
+		"title":  "Too Many Requests",
+		"status": float64(http.StatusTooManyRequests),
+		"detail": "rate limit of 60 requests per 1m0s exceeded",
+	}, problem)
+
+	// The bucket gets a token a second.
+	now = now.Add(time.Second)
+	w = do()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+}
+
+func Test_MemoryStore(t *testing.T) {
+	// Arrange
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	s := ratelimit.NewMemoryStore(func() time.Time { return now })
+	ctx := context.Background()
+
+	// Act & Assert
+	for i, expectedOk := range []bool{true, true, false} {
+		tokens, ok, err := s.Take(ctx, "a", 0.5, 2)
+		require.NoError(t, err)
+		assert.Equal(t, expectedOk, ok, "take %d", i)
+		assert.Equal(t, float64(max(1-i, 0)), tokens)
+	}
+
+	// Half a token a second.
+	now = now.Add(time.Second)
+	tokens, ok, err := s.Take(ctx, "a", 0.5, 2)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 0.5, tokens)
+	now = now.Add(time.Second)
+	_, ok, err = s.Take(ctx, "a", 0.5, 2)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Full buckets are swept.
+	for i := range 2000 {
+		_, _, err := s.Take(ctx, fmt.Sprint(i), 1, 10)
+		require.NoError(t, err)
+	}
+	now = now.Add(time.Minute)
+	for range 48 {
+		_, _, err := s.Take(ctx, "b", 1, 10)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, s.Len())
+}
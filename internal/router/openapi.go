@@ -0,0 +1,418 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/cache"
+	"github.com/TommyLearning/go-rest-api-project/internal/gql"
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/openapi"
+)
+
+var (
+	newsIdParam         = &openapi.Parameter{Name: "news_id", In: "path", Required: true, Schema: &openapi.Schema{Type: "string", Format: "uuid"}}
+	webhookIdParam      = &openapi.Parameter{Name: "webhook_id", In: "path", Required: true, Schema: &openapi.Schema{Type: "string", Format: "uuid"}}
+	tagParam            = &openapi.Parameter{Name: "tag", In: "query", Description: "Only the news with the tag.", Schema: &openapi.Schema{Type: "string"}}
+	authorParam         = &openapi.Parameter{Name: "author", In: "query", Description: "Only the news of the author, by slug.", Schema: &openapi.Schema{Type: "string"}}
+	limitParam          = &openapi.Parameter{Name: "limit", In: "query", Description: "Pages the news, newest first, with at most limit news per page.", Schema: &openapi.Schema{Type: "integer"}}
+	afterParam          = &openapi.Parameter{Name: "after", In: "query", Description: "The next_cursor of the previous page.", Schema: &openapi.Schema{Type: "string"}}
+	idempotencyKeyParam = &openapi.Parameter{Name: "Idempotency-Key", In: "header", Description: "Replays the response of the first request with the key, of at most 255 characters, to its retries.", Schema: &openapi.Schema{Type: "string"}}
+	queryParam          = &openapi.Parameter{Name: "q", In: "query", Description: "Only the news with the text in their title, summary or content, case-insensitively.", Schema: &openapi.Schema{Type: "string"}}
+)
+
+// auditParams returns the parameters filtering the audit events.
+func auditParams() []*openapi.Parameter {
+	return []*openapi.Parameter{
+		{Name: "actor", In: "query", Description: "Only the events of the principal.", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "news_id", In: "query", Description: "Only the events of the news.", Schema: &openapi.Schema{Type: "string", Format: "uuid"}},
+		{Name: "from", In: "query", Description: "Only the events at or after the time.", Schema: &openapi.Schema{Type: "string", Format: "date-time"}},
+		{Name: "to", In: "query", Description: "Only the events before the time.", Schema: &openapi.Schema{Type: "string", Format: "date-time"}},
+		{Name: "after", In: "query", Description: "Only the events after the ID, such as the next_cursor of the previous page.", Schema: &openapi.Schema{Type: "integer", Format: "int64"}},
+	}
+}
+
+// routes documents the routes registered by New and its options. Changes to
+// the routes must be reflected here: the tests fail when they drift.
+var routes = []openapi.Route{
+	{
+		Pattern: "POST /news",
+		Id:      "createNews",
+		Summary: "Create a news",
+		Tag:     "news",
+		Role:    string(auth.RoleEditor),
+		Params:  []*openapi.Parameter{idempotencyKeyParam},
+		Body:    handler.NewsPostReqBody{},
+		Responses: map[int]any{
+			http.StatusCreated:             nil,
+			http.StatusBadRequest:          openapi.Raw("text/plain"),
+			http.StatusConflict:            openapi.Raw("text/plain"),
+			http.StatusUnprocessableEntity: openapi.Raw("text/plain"),
+		},
+	},
+	{
+		Pattern:   "GET /news",
+		Id:        "listNews",
+		Summary:   "List the public news",
+		Tag:       "news",
+		Params:    []*openapi.Parameter{tagParam, queryParam, limitParam, afterParam},
+		Responses: map[int]any{http.StatusOK: handler.AllNewsResponse{}, http.StatusNotModified: nil, http.StatusBadRequest: openapi.Raw("text/plain")},
+	},
+	{
+		Pattern:   "GET /news/{news_id}",
+		Id:        "getNews",
+		Summary:   "Get a public news",
+		Tag:       "news",
+		Params:    []*openapi.Parameter{newsIdParam},
+		Responses: map[int]any{http.StatusOK: news.Record{}, http.StatusNotModified: nil, http.StatusBadRequest: nil, http.StatusNotFound: nil},
+	},
+	{
+		Pattern:   "GET /news/{news_id}/similar",
+		Id:        "listSimilarNews",
+		Summary:   "List the public news similar to a news",
+		Tag:       "news",
+		Params:    []*openapi.Parameter{newsIdParam},
+		Responses: map[int]any{http.StatusOK: handler.AllNewsResponse{}, http.StatusNotModified: nil, http.StatusBadRequest: nil, http.StatusNotFound: nil},
+	},
+	{
+		Pattern:     "PUT /news/{news_id}",
+		Id:          "updateNews",
+		Summary:     "Update a news",
+		Description: "The id of the body, if any, has to be the one of the path.",
+		Tag:         "news",
+		Role:        string(auth.RoleEditor),
+		Params:      []*openapi.Parameter{newsIdParam},
+		Body:        handler.NewsPostReqBody{},
+		Responses:   map[int]any{http.StatusOK: nil, http.StatusBadRequest: openapi.Raw("text/plain"), http.StatusNotFound: nil},
+	},
+	{
+		Pattern:   "DELETE /news/{news_id}",
+		Id:        "deleteNews",
+		Summary:   "Delete a news",
+		Tag:       "news",
+		Role:      string(auth.RoleEditor),
+		Params:    []*openapi.Parameter{newsIdParam},
+		Responses: map[int]any{http.StatusNoContent: nil, http.StatusBadRequest: nil, http.StatusNotFound: nil},
+	},
+	{
+		Pattern:   "GET /tags/{slug}/news",
+		Id:        "listTagNews",
+		Summary:   "List the public news with a tag",
+		Tag:       "tags",
+		Params:    []*openapi.Parameter{limitParam, afterParam},
+		Responses: map[int]any{http.StatusOK: handler.AllNewsResponse{}, http.StatusNotModified: nil, http.StatusBadRequest: openapi.Raw("text/plain")},
+	},
+	{
+		Pattern:   "GET /authors/{slug}/news",
+		Id:        "listAuthorNews",
+		Summary:   "List the public news of an author",
+		Tag:       "authors",
+		Params:    []*openapi.Parameter{limitParam, afterParam},
+		Responses: map[int]any{http.StatusOK: handler.AllNewsResponse{}, http.StatusNotModified: nil, http.StatusBadRequest: openapi.Raw("text/plain")},
+	},
+	{
+		Pattern: "POST /news/{news_id}/transitions",
+		Id:      "transitionNews",
+		Summary: "Move a news to another editorial state",
+		Tag:     "editor",
+		Role:    string(auth.RoleEditor),
+		Params:  []*openapi.Parameter{newsIdParam},
+		Body:    handler.TransitionReqBody{},
+		Responses: map[int]any{
+			http.StatusOK:         news.Record{},
+			http.StatusBadRequest: openapi.Raw("text/plain"),
+			http.StatusNotFound:   nil,
+			http.StatusConflict:   openapi.Raw("text/plain"),
+		},
+	},
+	{
+		Pattern:     "POST /news/{news_id}/restore",
+		Id:          "restoreNews",
+		Summary:     "Restore a deleted news",
+		Description: "Restored news are announced as created again.",
+		Tag:         "editor",
+		Role:        string(auth.RoleEditor),
+		Params:      []*openapi.Parameter{newsIdParam},
+		Responses:   map[int]any{http.StatusOK: news.Record{}, http.StatusBadRequest: nil, http.StatusNotFound: nil},
+	},
+	{
+		Pattern: "GET /editor/news",
+		Id:      "listEditorNews",
+		Summary: "List the news in any editorial state",
+		Tag:     "editor",
+		Role:    string(auth.RoleEditor),
+		Params: []*openapi.Parameter{tagParam, queryParam, limitParam, afterParam, {
+			Name:        "status",
+			In:          "query",
+			Description: "Only the news in one of the comma separated states.",
+			Explode:     new(bool),
+			Schema:      &openapi.Schema{Type: "array", Items: &openapi.Schema{Ref: "#/components/schemas/Status"}},
+		}},
+		Responses: map[int]any{http.StatusOK: handler.AllNewsResponse{}, http.StatusNotModified: nil, http.StatusBadRequest: openapi.Raw("text/plain")},
+	},
+	{
+		Pattern:   "GET /editor/news/{news_id}",
+		Id:        "getEditorNews",
+		Summary:   "Get a news in any editorial state",
+		Tag:       "editor",
+		Role:      string(auth.RoleEditor),
+		Params:    []*openapi.Parameter{newsIdParam},
+		Responses: map[int]any{http.StatusOK: news.Record{}, http.StatusNotModified: nil, http.StatusBadRequest: nil, http.StatusNotFound: nil},
+	},
+
+	{
+		Pattern:   "GET /tags",
+		Id:        "listTags",
+		Summary:   "List the tags",
+		Tag:       "tags",
+		Responses: map[int]any{http.StatusOK: handler.AllTagsResponse{}},
+	},
+	{
+		Pattern:   "PUT /tags/{slug}",
+		Id:        "renameTag",
+		Summary:   "Rename a tag",
+		Tag:       "tags",
+		Role:      string(auth.RoleAdmin),
+		Body:      handler.TagPutReqBody{},
+		Responses: map[int]any{http.StatusOK: news.Tag{}, http.StatusBadRequest: nil, http.StatusNotFound: nil, http.StatusConflict: openapi.Raw("text/plain")},
+	},
+	{
+		Pattern:   "POST /tags/{slug}/merge",
+		Id:        "mergeTag",
+		Summary:   "Merge a tag into another one",
+		Tag:       "tags",
+		Role:      string(auth.RoleAdmin),
+		Body:      handler.TagMergeReqBody{},
+		Responses: map[int]any{http.StatusOK: news.Tag{}, http.StatusBadRequest: nil, http.StatusNotFound: nil},
+	},
+
+	{
+		Pattern:   "GET /authors",
+		Id:        "listAuthors",
+		Summary:   "List the authors",
+		Tag:       "authors",
+		Responses: map[int]any{http.StatusOK: handler.AllAuthorsResponse{}},
+	},
+	{
+		Pattern:   "POST /authors",
+		Id:        "createAuthor",
+		Summary:   "Create an author profile",
+		Tag:       "authors",
+		Role:      string(auth.RoleEditor),
+		Body:      handler.AuthorReqBody{},
+		Responses: map[int]any{http.StatusCreated: news.Author{}, http.StatusBadRequest: openapi.Raw("text/plain"), http.StatusConflict: nil},
+	},
+	{
+		Pattern:   "GET /authors/{slug}",
+		Id:        "getAuthor",
+		Summary:   "Get an author profile",
+		Tag:       "authors",
+		Responses: map[int]any{http.StatusOK: news.Author{}, http.StatusNotFound: nil},
+	},
+	{
+		Pattern:   "PUT /authors/{slug}",
+		Id:        "updateAuthor",
+		Summary:   "Update an author profile",
+		Tag:       "authors",
+		Role:      string(auth.RoleEditor),
+		Body:      handler.AuthorReqBody{},
+		Responses: map[int]any{http.StatusOK: news.Author{}, http.StatusBadRequest: openapi.Raw("text/plain"), http.StatusNotFound: nil},
+	},
+
+	{
+		Pattern:   "GET /admin/sweeper",
+		Id:        "getSweepStats",
+		Summary:   "Get the last run and the counts of the expiry sweeper",
+		Tag:       "admin",
+		Role:      string(auth.RoleAdmin),
+		Responses: map[int]any{http.StatusOK: news.SweepStats{}},
+	},
+	{
+		Pattern:   "GET /admin/cache",
+		Id:        "getCacheStats",
+		Summary:   "Get the counts of the cache of the news",
+		Tag:       "admin",
+		Role:      string(auth.RoleAdmin),
+		Responses: map[int]any{http.StatusOK: cache.Stats{}},
+	},
+	{
+		Pattern:     "GET /admin/audit",
+		Id:          "listAuditEvents",
+		Summary:     "List the audit events of the write requests",
+		Description: "Oldest first, with at most limit events per page, 100 by default and 1000 at most.",
+		Tag:         "admin",
+		Role:        string(auth.RoleAdmin),
+		Params: append(auditParams(), &openapi.Parameter{
+			Name:   "limit",
+			In:     "query",
+			Schema: &openapi.Schema{Type: "integer"},
+		}),
+		Responses: map[int]any{http.StatusOK: handler.AuditEventsResponse{}, http.StatusBadRequest: nil},
+	},
+	{
+		Pattern:     "GET /admin/audit/export",
+		Id:          "exportAuditEvents",
+		Summary:     "Export the audit events as newline delimited JSON",
+		Description: "Oldest first, one audit event per line. Exports are resumed after the ID of the last event exported.",
+		Tag:         "admin",
+		Role:        string(auth.RoleAdmin),
+		Params:      auditParams(),
+		Responses:   map[int]any{http.StatusOK: openapi.Raw("application/x-ndjson"), http.StatusBadRequest: nil},
+	},
+
+	{
+		Pattern:     "POST /webhooks",
+		Id:          "createWebhook",
+		Summary:     "Subscribe a webhook to news events",
+		Description: "The secret is only returned by this operation.",
+		Tag:         "webhooks",
+		Role:        string(auth.RoleAdmin),
+		Body:        handler.WebhookReqBody{},
+		Responses:   map[int]any{http.StatusCreated: news.Webhook{}, http.StatusBadRequest: openapi.Raw("text/plain")},
+	},
+	{
+		Pattern:   "GET /webhooks",
+		Id:        "listWebhooks",
+		Summary:   "List the webhooks",
+		Tag:       "webhooks",
+		Role:      string(auth.RoleAdmin),
+		Responses: map[int]any{http.StatusOK: handler.AllWebhooksResponse{}},
+	},
+	{
+		Pattern:   "GET /webhooks/{webhook_id}",
+		Id:        "getWebhook",
+		Summary:   "Get a webhook",
+		Tag:       "webhooks",
+		Role:      string(auth.RoleAdmin),
+		Params:    []*openapi.Parameter{webhookIdParam},
+		Responses: map[int]any{http.StatusOK: news.Webhook{}, http.StatusBadRequest: nil, http.StatusNotFound: nil},
+	},
+	{
+		Pattern:   "DELETE /webhooks/{webhook_id}",
+		Id:        "deleteWebhook",
+		Summary:   "Delete a webhook",
+		Tag:       "webhooks",
+		Role:      string(auth.RoleAdmin),
+		Params:    []*openapi.Parameter{webhookIdParam},
+		Responses: map[int]any{http.StatusNoContent: nil, http.StatusBadRequest: nil, http.StatusNotFound: nil},
+	},
+	{
+		Pattern: "GET /webhooks/{webhook_id}/deliveries",
+		Id:      "listWebhookDeliveries",
+		Summary: "List the latest deliveries to a webhook",
+		Tag:     "webhooks",
+		Role:    string(auth.RoleAdmin),
+		Params: []*openapi.Parameter{webhookIdParam, {
+			Name:        "limit",
+			In:          "query",
+			Description: "The maximum number of deliveries.",
+			Schema:      &openapi.Schema{Type: "integer"},
+		}},
+		Responses: map[int]any{http.StatusOK: handler.AllDeliveriesResponse{}, http.StatusBadRequest: nil},
+	},
+	{
+		Pattern: "POST /webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver",
+		Id:      "redeliverWebhookDelivery",
+		Summary: "Schedule a delivery for a new series of attempts",
+		Tag:     "webhooks",
+		Role:    string(auth.RoleAdmin),
+		Params: []*openapi.Parameter{webhookIdParam, {
+			Name:     "delivery_id",
+			In:       "path",
+			Required: true,
+			Schema:   &openapi.Schema{Type: "integer", Format: "int64"},
+		}},
+		Responses: map[int]any{http.StatusAccepted: news.Delivery{}, http.StatusBadRequest: nil, http.StatusNotFound: nil},
+	},
+
+	{
+		Pattern:     "GET /news/stream",
+		Id:          "streamNews",
+		Summary:     "Stream the news events",
+		Description: "Server-sent events, resumed after the Last-Event-ID header.",
+		Tag:         "events",
+		Role:        string(auth.RoleEditor),
+		Params: []*openapi.Parameter{tagParam, authorParam, {
+			Name:   "Last-Event-ID",
+			In:     "header",
+			Schema: &openapi.Schema{Type: "integer", Format: "int64"},
+		}},
+		Responses: map[int]any{http.StatusOK: openapi.Raw("text/event-stream"), http.StatusBadRequest: nil},
+	},
+	{
+		Pattern:     "GET /news/ws",
+		Id:          "subscribeNews",
+		Summary:     "Subscribe to the news events",
+		Description: "WebSocket of JSON messages. Only editors receive the events of the news that are not public.",
+		Tag:         "events",
+		Responses:   map[int]any{http.StatusSwitchingProtocols: nil},
+	},
+
+	{
+		Pattern: "GET /graphql",
+		Id:      "queryGraphQL",
+		Summary: "Execute a GraphQL query",
+		Tag:     "graphql",
+		Params: []*openapi.Parameter{
+			{Name: "query", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}},
+			{Name: "operationName", In: "query", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "variables", In: "query", Description: "The variables, as a JSON object.", Schema: &openapi.Schema{Type: "string"}},
+		},
+		Responses: map[int]any{http.StatusOK: map[string]any{}, http.StatusBadRequest: nil, http.StatusMethodNotAllowed: nil},
+	},
+	{
+		Pattern:   "POST /graphql",
+		Id:        "executeGraphQL",
+		Summary:   "Execute a GraphQL query or mutation",
+		Tag:       "graphql",
+		Body:      gql.Request{},
+		Responses: map[int]any{http.StatusOK: map[string]any{}, http.StatusBadRequest: nil},
+	},
+
+	{
+		Pattern:   "GET /openapi.json",
+		Id:        "getOpenAPI",
+		Summary:   "Get this document",
+		Tag:       "docs",
+		Responses: map[int]any{http.StatusOK: map[string]any{}},
+	},
+	{
+		Pattern:   "GET /docs",
+		Id:        "getSwaggerUI",
+		Summary:   "Browse this document with Swagger UI",
+		Tag:       "docs",
+		Responses: map[int]any{http.StatusOK: openapi.Raw("text/html")},
+	},
+	{
+		Pattern:   "GET /docs/redoc",
+		Id:        "getRedoc",
+		Summary:   "Browse this document with Redoc",
+		Tag:       "docs",
+		Responses: map[int]any{http.StatusOK: openapi.Raw("text/html")},
+	},
+}
+
+// OpenAPI returns the OpenAPI document of the routes.
+func OpenAPI() (*openapi.Document, error) {
+	return openapi.New(openapi.Info{
+		Title:       "News API",
+		Description: "Authenticate with an API key in the X-API-Key header or as a bearer token. Select the publication with the X-Tenant-ID header or its host name.",
+		Version:     "1.0.0",
+	}, routes,
+		openapi.EnumOf(news.StatusDraft, news.StatusInReview, news.StatusApproved, news.StatusPublished, news.StatusArchived),
+		openapi.EnumOf(news.EventCreated, news.EventUpdated, news.EventDeleted, news.EventPublished),
+		openapi.EnumOf(news.DeliveryPending, news.DeliveryDelivered, news.DeliveryDead),
+		openapi.EnumOf(news.SweepArchive, news.SweepDelete),
+	)
+}
+
+// WithOpenAPI registers the routes serving the OpenAPI document, along with
+// its Swagger UI and Redoc pages.
+func WithOpenAPI(doc *openapi.Document) Option {
+	return func(r *http.ServeMux, _ bool) {
+		r.HandleFunc("GET /openapi.json", openapi.Handler(doc))
+		r.HandleFunc("GET /docs", openapi.SwaggerUI(doc, "/openapi.json"))
+		r.HandleFunc("GET /docs/redoc", openapi.Redoc(doc, "/openapi.json"))
+	}
+}
@@ -0,0 +1,163 @@
+package router_test
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	"github.com/TommyLearning/go-rest-api-project/internal/openapi"
+	"github.com/TommyLearning/go-rest-api-project/internal/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registeredPatterns returns the patterns of the routes registered in the
+// sources of the router.
+func registeredPatterns(t *testing.T) []string {
+	t.Helper()
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+	fset := token.NewFileSet()
+	var patterns []string
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		require.NoError(t, err)
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "HandleFunc" && sel.Sel.Name != "Handle" {
+				return true
+			}
+			lit, ok := call.Args[0].(*ast.BasicLit)
+			require.True(t, ok, "route registered with a non-literal pattern at %s", fset.Position(call.Pos()))
+			pattern, err := strconv.Unquote(lit.Value)
+			require.NoError(t, err)
+			patterns = append(patterns, pattern)
+			return true
+		})
+	}
+	return patterns
+}
+
+func TestOpenAPI_Drift(t *testing.T) {
+	// Arrange
+	doc, err := router.OpenAPI()
+	require.NoError(t, err)
+
+	// Act
+	patterns := registeredPatterns(t)
+
+	// Assert
+	assert.ElementsMatch(t, patterns, doc.Patterns(), "the routes and their documentation in openapi.go drifted")
+}
+
+func TestOpenAPI_Routes(t *testing.T) {
+	// Arrange
+	doc, err := router.OpenAPI()
+	require.NoError(t, err)
+	r := router.New(nil, handler.DefaultStrict,
+		router.WithTags(nil),
+		router.WithAuthors(nil),
+		router.WithSweeper(nil),
+		router.WithCache(nil),
+		router.WithAudit(nil),
+		router.WithWebhooks(nil),
+		router.WithStream(nil),
+		router.WithGraphQL(nil),
+		router.WithOpenAPI(doc),
+	)
+	wildcard := regexp.MustCompile(`\{[^}]+\}`)
+
+	for _, pattern := range doc.Patterns() {
+		t.Run(pattern, func(t *testing.T) {
+			method, path, _ := strings.Cut(pattern, " ")
+			req := httptest.NewRequest(method, wildcard.ReplaceAllString(path, "1"), nil)
+
+			// Act
+			_, matched := r.Handler(req)
+
+			// Assert
+			assert.Equal(t, pattern, matched)
+		})
+	}
+}
+
+func TestOpenAPI_Refs(t *testing.T) {
+	// Arrange
+	doc, err := router.OpenAPI()
+	require.NoError(t, err)
+	b, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	// Act
+	refs := regexp.MustCompile(`"\$ref":"#/components/schemas/([^"]+)"`).FindAllStringSubmatch(string(b), -1)
+
+	// Assert
+	require.NotEmpty(t, refs)
+	for _, ref := range refs {
+		assert.Contains(t, doc.Components.Schemas, ref[1])
+	}
+}
+
+func TestWithOpenAPI(t *testing.T) {
+	doc, err := router.OpenAPI()
+	require.NoError(t, err)
+	r := router.New(nil, handler.DefaultStrict, router.WithOpenAPI(doc))
+
+	testCases := []struct {
+		name                string
+		target              string
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "document",
+			target:              "/openapi.json",
+			expectedContentType: "application/json",
+			expectedBody:        `"openapi":"` + openapi.Version + `"`,
+		},
+		{
+			name:                "swagger ui",
+			target:              "/docs",
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        `url: "/openapi.json"`,
+		},
+		{
+			name:                "redoc",
+			target:              "/docs/redoc",
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        `spec-url="/openapi.json"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedContentType, w.Result().Header.Get("Content-Type"))
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
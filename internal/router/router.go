@@ -3,17 +3,121 @@ package router
 import (
 	"net/http"
 
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/cache"
+	"github.com/TommyLearning/go-rest-api-project/internal/gql"
 	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
 )
 
-func New(ns handler.NewsStorer) *http.ServeMux {
+// Option registers additional routes on the router. The request bodies of the
+// routes are strict as the router.
+type Option func(r *http.ServeMux, strict bool)
+
+// New returns the router of the news routes and the additional routes of the
+// options. Writing news is reserved to the editors of the tenant of the
+// request, or of no tenant. Request bodies with fields unknown to their type
+// are invalid if strict, rather than ignored.
+func New(ns handler.NewsStorer, strict bool, opts ...Option) *http.ServeMux {
 	r := http.NewServeMux()
 
-	r.HandleFunc("POST /news", handler.PostNews(ns))
+	r.HandleFunc("POST /news", tenant.Require(auth.RoleEditor, handler.PostNews(ns, strict)))
 	r.HandleFunc("GET /news", handler.GetAllNews(ns))
 	r.HandleFunc("GET /news/{news_id}", handler.GetNewsById(ns))
-	r.HandleFunc("PUT /news/{news_id}", handler.UpdateNewsById(ns))
-	r.HandleFunc("DELETE /news/{news_id}", handler.DeleteNewsById(ns))
+	r.HandleFunc("GET /news/{news_id}/similar", handler.GetSimilarNews(ns))
+	r.HandleFunc("PUT /news/{news_id}", tenant.Require(auth.RoleEditor, handler.UpdateNewsById(ns, strict)))
+	r.HandleFunc("DELETE /news/{news_id}", tenant.Require(auth.RoleEditor, handler.DeleteNewsById(ns)))
+	r.HandleFunc("GET /tags/{slug}/news", handler.GetTagNews(ns))
+	r.HandleFunc("GET /authors/{slug}/news", handler.GetAuthorNews(ns))
+
+	r.HandleFunc("POST /news/{news_id}/transitions", tenant.Require(auth.RoleEditor, handler.TransitionNewsById(ns, strict)))
+	r.HandleFunc("POST /news/{news_id}/restore", tenant.Require(auth.RoleEditor, handler.RestoreNewsById(ns)))
+	r.HandleFunc("GET /editor/news", auth.RequireRole(auth.RoleEditor, handler.GetEditorNews(ns)))
+	r.HandleFunc("GET /editor/news/{news_id}", auth.RequireRole(auth.RoleEditor, handler.GetEditorNewsById(ns)))
+
+	for _, opt := range opts {
+		opt(r, strict)
+	}
 
 	return r
 }
+
+// WithTags registers the tag routes. Renaming and merging the tags of a
+// tenant, which rewrites its news, is reserved to the admins of the tenant or
+// of no tenant.
+func WithTags(ts handler.TagStorer) Option {
+	return func(r *http.ServeMux, strict bool) {
+		r.HandleFunc("GET /tags", handler.GetAllTags(ts))
+		r.HandleFunc("PUT /tags/{slug}", tenant.Require(auth.RoleAdmin, handler.RenameTag(ts, strict)))
+		r.HandleFunc("POST /tags/{slug}/merge", tenant.Require(auth.RoleAdmin, handler.MergeTag(ts, strict)))
+	}
+}
+
+// WithAuthors registers the author profile routes. Writing the profiles of a
+// tenant is reserved to the editors of the tenant or of no tenant.
+func WithAuthors(as handler.AuthorStorer) Option {
+	return func(r *http.ServeMux, strict bool) {
+		r.HandleFunc("GET /authors", handler.GetAllAuthors(as))
+		r.HandleFunc("POST /authors", tenant.Require(auth.RoleEditor, handler.PostAuthor(as, strict)))
+		r.HandleFunc("GET /authors/{slug}", handler.GetAuthorBySlug(as))
+		r.HandleFunc("PUT /authors/{slug}", tenant.Require(auth.RoleEditor, handler.UpdateAuthorBySlug(as, strict)))
+	}
+}
+
+// WithSweeper registers the admin route reporting on the expiry sweeper of
+// every tenant, reserved to the admins of no tenant.
+func WithSweeper(ss handler.SweeperStorer) Option {
+	return func(r *http.ServeMux, _ bool) {
+		r.HandleFunc("GET /admin/sweeper", auth.RequireRole(auth.RoleAdmin, auth.RequirePlatform(handler.GetSweepStats(ss))))
+	}
+}
+
+// WithCache registers the admin route reporting on the cache of the news of
+// every tenant, reserved to the admins of no tenant.
+func WithCache(cs *cache.NewsStore) Option {
+	return func(r *http.ServeMux, _ bool) {
+		r.HandleFunc("GET /admin/cache", auth.RequireRole(auth.RoleAdmin, auth.RequirePlatform(cache.Handler(cs))))
+	}
+}
+
+// WithAudit registers the admin routes querying and exporting the audit log
+// of the tenant.
+func WithAudit(as handler.AuditStorer) Option {
+	return func(r *http.ServeMux, _ bool) {
+		r.HandleFunc("GET /admin/audit", auth.RequireRole(auth.RoleAdmin, handler.GetAuditEvents(as)))
+		r.HandleFunc("GET /admin/audit/export", auth.RequireRole(auth.RoleAdmin, handler.ExportAuditEvents(as)))
+	}
+}
+
+// WithWebhooks registers the webhook subscription routes. Webhooks receive
+// the events of their tenant, so they are managed by the admins of the tenant
+// or of no tenant.
+func WithWebhooks(ws handler.WebhookStorer) Option {
+	return func(r *http.ServeMux, strict bool) {
+		r.HandleFunc("POST /webhooks", tenant.Require(auth.RoleAdmin, handler.PostWebhook(ws, strict)))
+		r.HandleFunc("GET /webhooks", tenant.Require(auth.RoleAdmin, handler.GetAllWebhooks(ws)))
+		r.HandleFunc("GET /webhooks/{webhook_id}", tenant.Require(auth.RoleAdmin, handler.GetWebhookById(ws)))
+		r.HandleFunc("DELETE /webhooks/{webhook_id}", tenant.Require(auth.RoleAdmin, handler.DeleteWebhookById(ws)))
+		r.HandleFunc("GET /webhooks/{webhook_id}/deliveries", tenant.Require(auth.RoleAdmin, handler.GetWebhookDeliveries(ws)))
+		r.HandleFunc("POST /webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver", tenant.Require(auth.RoleAdmin, handler.RedeliverWebhookDelivery(ws)))
+	}
+}
+
+// WithStream registers the live news events routes. The event stream exposes
+// the drafts, so it is reserved to editors, while the WebSocket filters the
+// events by the role of its client.
+func WithStream(es handler.EventSubscriber) Option {
+	return func(r *http.ServeMux, _ bool) {
+		r.HandleFunc("GET /news/stream", auth.RequireRole(auth.RoleEditor, handler.StreamNews(es, handler.DefaultHeartbeat)))
+		r.HandleFunc("GET /news/ws", handler.SubscribeNews(es, handler.DefaultPingInterval))
+	}
+}
+
+// WithGraphQL registers the GraphQL endpoint. Queries can be sent in GET or
+// POST requests, mutations in POST requests only.
+func WithGraphQL(s *gql.Schema) Option {
+	return func(r *http.ServeMux, _ bool) {
+		r.HandleFunc("GET /graphql", gql.Handler(s))
+		r.HandleFunc("POST /graphql", gql.Handler(s))
+	}
+}
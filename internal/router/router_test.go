@@ -0,0 +1,369 @@
+package router_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	mockshandler "github.com/TommyLearning/go-rest-api-project/internal/handler/mocks"
+	"github.com/TommyLearning/go-rest-api-project/internal/httpcache"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/router"
+	"github.com/TommyLearning/go-rest-api-project/internal/stream"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// store has no past events: the hub only relays the published ones.
+type store struct{}
+
+func (store) FindEvents(context.Context, ...int64) ([]*news.OutboxEvent, error) {
+	return nil, nil
+}
+
+func (store) FindEventsAfter(context.Context, int64, int) ([]*news.OutboxEvent, error) {
+	return nil, nil
+}
+
+func newServer(t *testing.T) (*httptest.Server, *stream.Hub) {
+	t.Helper()
+	keys, err := auth.ParseKeys("editor-key=alice:editor")
+	require.NoError(t, err)
+	hub := stream.NewHub(store{})
+	ns := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+	srv := httptest.NewServer(auth.Mid(keys, router.New(ns, handler.DefaultStrict, router.WithStream(hub))))
+	t.Cleanup(srv.Close)
+	return srv, hub
+}
+
+func dial(t *testing.T, srv *httptest.Server, key string) *websocket.Conn {
+	t.Helper()
+	opts := &websocket.DialOptions{HTTPHeader: http.Header{}}
+	if key != "" {
+		opts.HTTPHeader.Set("X-API-Key", key)
+	}
+	conn, _, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http")+"/news/ws", opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.CloseNow() })
+	return conn
+}
+
+func send(t *testing.T, conn *websocket.Conn, msg handler.WSMessage) {
+	t.Helper()
+	require.NoError(t, wsjson.Write(context.Background(), conn, msg))
+}
+
+func receive(t *testing.T, conn *websocket.Conn) handler.WSMessage {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var msg handler.WSMessage
+	require.NoError(t, wsjson.Read(ctx, conn, &msg))
+	return msg
+}
+
+func event(t *testing.T, id int64, event news.Event, record *news.Record) *news.OutboxEvent {
+	t.Helper()
+	var data any = record
+	if event == news.EventDeleted {
+		data = map[string]uuid.UUID{"Id": record.Id}
+	}
+	payload, err := json.Marshal(news.EventPayload{Id: uuid.New(), Event: event, Data: data})
+	require.NoError(t, err)
+	return &news.OutboxEvent{Id: id, Position: id, EventId: uuid.New(), Event: event, TenantId: tenant.Default, NewsId: record.Id, Payload: payload}
+}
+
+func TestWithStream_WebSocket(t *testing.T) {
+	draft := &news.Record{Id: uuid.New(), Status: news.StatusDraft, Tags: []string{"go"}, Authors: []*news.Author{{Slug: "alice"}}}
+	published := &news.Record{Id: uuid.New(), Status: news.StatusPublished, Tags: []string{"rust"}, Authors: []*news.Author{{Slug: "bob"}}}
+	otherTenant := event(t, 10, news.EventPublished, published)
+	otherTenant.TenantId = "acme"
+	events := []*news.OutboxEvent{
+		event(t, 1, news.EventCreated, draft),
+		otherTenant,
+		event(t, 2, news.EventPublished, published),
+		event(t, 3, news.EventDeleted, draft),
+	}
+
+	testCases := []struct {
+		name        string
+		key         string
+		topics      []string
+		expectedIds []int64
+	}{
+		{
+			name:        "editor all news",
+			key:         "editor-key",
+			topics:      []string{"all"},
+			expectedIds: []int64{1, 2, 3},
+		},
+		{
+			name:        "anonymous all news",
+			topics:      []string{"all"},
+			expectedIds: []int64{2, 3},
+		},
+		{
+			name:        "by tag",
+			key:         "editor-key",
+			topics:      []string{"tag:Go"},
+			expectedIds: []int64{1, 3},
+		},
+		{
+			name:        "by author",
+			key:         "editor-key",
+			topics:      []string{"author:bob"},
+			expectedIds: []int64{2, 3},
+		},
+		{
+			name:        "by id",
+			key:         "editor-key",
+			topics:      []string{"news:" + published.Id.String()},
+			expectedIds: []int64{2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			srv, hub := newServer(t)
+			conn := dial(t, srv, tc.key)
+			for _, topic := range tc.topics {
+				send(t, conn, handler.WSMessage{Type: handler.WSSubscribe, Topic: topic})
+				assert.Equal(t, handler.WSSubscribed, receive(t, conn).Type)
+			}
+
+			// Act
+			hub.Publish(events...)
+			// The event marks the end of the published ones.
+			hub.Publish(event(t, 4, news.EventDeleted, published))
+
+			// Assert
+			var ids []int64
+			for {
+				msg := receive(t, conn)
+				require.Equal(t, handler.WSEvent, msg.Type)
+				if msg.Id == 4 {
+					break
+				}
+				ids = append(ids, msg.Id)
+			}
+			assert.Equal(t, tc.expectedIds, ids)
+		})
+	}
+}
+
+func TestWithStream_WebSocket_Messages(t *testing.T) {
+	// Arrange
+	srv, hub := newServer(t)
+	conn := dial(t, srv, "")
+
+	// Act & Assert
+	send(t, conn, handler.WSMessage{Type: handler.WSSubscribe, Topic: "invalid"})
+	msg := receive(t, conn)
+	assert.Equal(t, handler.WSError, msg.Type)
+	assert.Equal(t, `invalid topic: "invalid"`, msg.Error)
+
+	send(t, conn, handler.WSMessage{Type: "invalid", Topic: "all"})
+	assert.Equal(t, handler.WSError, receive(t, conn).Type)
+
+	send(t, conn, handler.WSMessage{Type: handler.WSSubscribe, Topic: "tag:go"})
+	assert.Equal(t, handler.WSMessage{Type: handler.WSSubscribed, Topic: "tag:go"}, receive(t, conn))
+	send(t, conn, handler.WSMessage{Type: handler.WSSubscribe, Topic: "tag:rust"})
+	assert.Equal(t, handler.WSSubscribed, receive(t, conn).Type)
+	send(t, conn, handler.WSMessage{Type: handler.WSUnsubscribe, Topic: "tag:go"})
+	assert.Equal(t, handler.WSMessage{Type: handler.WSUnsubscribed, Topic: "tag:go"}, receive(t, conn))
+
+	hub.Publish(
+		event(t, 1, news.EventPublished, &news.Record{Id: uuid.New(), Status: news.StatusPublished, Tags: []string{"go"}}),
+		event(t, 2, news.EventPublished, &news.Record{Id: uuid.New(), Status: news.StatusPublished, Tags: []string{"go", "rust"}}),
+	)
+	msg = receive(t, conn)
+	assert.Equal(t, int64(2), msg.Id)
+	assert.Equal(t, news.EventPublished, msg.Event)
+	assert.Equal(t, []string{"tag:rust"}, msg.Topics)
+	var record news.Record
+	require.NoError(t, json.Unmarshal(msg.Data, &record))
+	assert.Equal(t, []string{"go", "rust"}, record.Tags)
+
+	// Subscribers are disconnected when the hub closes, e.g. on shutdown.
+	hub.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, _, err := conn.Read(ctx)
+	assert.Equal(t, websocket.StatusTryAgainLater, websocket.CloseStatus(err))
+}
+
+func TestWithStream_WebSocket_Auth(t *testing.T) {
+	// Arrange
+	srv, _ := newServer(t)
+	opts := &websocket.DialOptions{HTTPHeader: http.Header{"X-Api-Key": []string{"invalid"}}}
+
+	// Act
+	_, resp, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http")+"/news/ws", opts)
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestWithWebhooks_Tenant(t *testing.T) {
+	keys, err := auth.ParseKeys("ops-key=ops:admin,acme-key=carol:admin@acme,editor-key=dave:editor@acme")
+	require.NoError(t, err)
+	tenants, err := tenant.New(&tenant.Tenant{Id: "acme"}, &tenant.Tenant{Id: "globex"}, &tenant.Tenant{Id: tenant.Default})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		key            string
+		tenant         string
+		expectedStatus int
+	}{
+		{name: "admin of no tenant", key: "ops-key", tenant: "globex", expectedStatus: http.StatusOK},
+		{name: "admin of the tenant", key: "acme-key", tenant: "acme", expectedStatus: http.StatusOK},
+		{name: "admin of another tenant", key: "acme-key", tenant: "globex", expectedStatus: http.StatusForbidden},
+		{name: "editor of the tenant", key: "editor-key", tenant: "acme", expectedStatus: http.StatusForbidden},
+		{name: "anonymous", tenant: "acme", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ctrl := gomock.NewController(t)
+			ws := mockshandler.NewMockWebhookStorer(ctrl)
+			if tc.expectedStatus == http.StatusOK {
+				ws.EXPECT().FindAll(gomock.Any()).Return([]*news.Webhook{}, nil)
+			}
+			h := auth.Mid(keys, tenant.Mid(tenants, router.New(mockshandler.NewMockNewsStorer(ctrl), handler.DefaultStrict, router.WithWebhooks(ws))))
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/webhooks", http.NoBody)
+			r.Header.Set("X-API-Key", tc.key)
+			r.Header.Set(tenant.Header, tc.tenant)
+
+			// Act
+			h.ServeHTTP(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestWithTags_Tenant(t *testing.T) {
+	keys, err := auth.ParseKeys("acme-key=carol:admin@acme")
+	require.NoError(t, err)
+	tenants, err := tenant.New(&tenant.Tenant{Id: "acme"}, &tenant.Tenant{Id: "globex"}, &tenant.Tenant{Id: tenant.Default})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		tenant         string
+		expectedStatus int
+	}{
+		{name: "admin of the tenant", tenant: "acme", expectedStatus: http.StatusOK},
+		{name: "admin of another tenant", tenant: "globex", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ctrl := gomock.NewController(t)
+			ts := mockshandler.NewMockTagStorer(ctrl)
+			if tc.expectedStatus == http.StatusOK {
+				ts.EXPECT().Rename(gomock.Any(), "golang", gomock.Any()).DoAndReturn(func(ctx context.Context, _ string, tag *news.Tag) (*news.Tag, error) {
+					assert.Equal(t, tc.tenant, tenant.FromContext(ctx).Id)
+					return tag, nil
+				})
+			}
+			h := auth.Mid(keys, tenant.Mid(tenants, router.New(mockshandler.NewMockNewsStorer(ctrl), handler.DefaultStrict, router.WithTags(ts))))
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPut, "/tags/golang", strings.NewReader(`{"slug":"go"}`))
+			r.Header.Set("X-API-Key", "acme-key")
+			r.Header.Set(tenant.Header, tc.tenant)
+
+			// Act
+			h.ServeHTTP(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestNew_WriteTenant(t *testing.T) {
+	keys, err := auth.ParseKeys("ops-key=ops:editor,acme-key=alice:editor@acme")
+	require.NoError(t, err)
+	tenants, err := tenant.New(&tenant.Tenant{Id: "acme"}, &tenant.Tenant{Id: "globex"}, &tenant.Tenant{Id: tenant.Default})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		key            string
+		tenant         string
+		expectedStatus int
+	}{
+		{name: "anonymous", tenant: "acme", expectedStatus: http.StatusUnauthorized},
+		{name: "editor of the tenant", key: "acme-key", tenant: "acme", expectedStatus: http.StatusCreated},
+		{name: "editor of another tenant", key: "acme-key", tenant: "globex", expectedStatus: http.StatusForbidden},
+		{name: "editor of no tenant", key: "ops-key", tenant: "globex", expectedStatus: http.StatusCreated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ns := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			if tc.expectedStatus == http.StatusCreated {
+				ns.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, n *news.Record) (*news.Record, error) {
+					assert.Equal(t, tc.tenant, tenant.FromContext(ctx).Id)
+					return n, nil
+				})
+			}
+			h := auth.Mid(keys, tenant.Mid(tenants, router.New(ns, handler.DefaultStrict)))
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/news", strings.NewReader(`{"author": "code learn", "content": "news content", "title": "first news", "summary": "first news post", "created_at": "2024-04-07T05:13:27+00:00", "source": "https://example.com", "tags": ["politics"]}`))
+			r.Header.Set(tenant.Header, tc.tenant)
+			if tc.key != "" {
+				r.Header.Set("X-API-Key", tc.key)
+			}
+
+			// Act
+			h.ServeHTTP(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestHTTPCache_VaryTenant(t *testing.T) {
+	// Arrange
+	tenants, err := tenant.New(&tenant.Tenant{Id: "acme"}, &tenant.Tenant{Id: tenant.Default})
+	require.NoError(t, err)
+	policies, err := httpcache.ParsePolicies("GET /news=public, max-age=60")
+	require.NoError(t, err)
+	ns := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+	ns.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return([]*news.Record{}, nil)
+	r := router.New(ns, handler.DefaultStrict)
+	h := tenant.Mid(tenants, httpcache.Mid(policies, r, r))
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/news", http.NoBody)
+	req.Header.Set(tenant.Header, "acme")
+
+	// Act
+	h.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+	assert.Contains(t, strings.Split(w.Header().Get("Vary"), ", "), tenant.Header)
+}
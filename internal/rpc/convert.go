@@ -0,0 +1,99 @@
+package rpc
+
+import (
+	"time"
+
+	newsv1 "github.com/TommyLearning/go-rest-api-project/api/news/v1"
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var statuses = map[news.Status]newsv1.Status{
+	news.StatusDraft:     newsv1.Status_STATUS_DRAFT,
+	news.StatusInReview:  newsv1.Status_STATUS_IN_REVIEW,
+	news.StatusApproved:  newsv1.Status_STATUS_APPROVED,
+	news.StatusPublished: newsv1.Status_STATUS_PUBLISHED,
+	news.StatusArchived:  newsv1.Status_STATUS_ARCHIVED,
+}
+
+var events = map[news.Event]newsv1.Event{
+	news.EventCreated:   newsv1.Event_EVENT_CREATED,
+	news.EventUpdated:   newsv1.Event_EVENT_UPDATED,
+	news.EventDeleted:   newsv1.Event_EVENT_DELETED,
+	news.EventPublished: newsv1.Event_EVENT_PUBLISHED,
+}
+
+func fromStatus(s newsv1.Status) (news.Status, bool) {
+	for status, pb := range statuses {
+		if pb == s {
+			return status, true
+		}
+	}
+	return "", false
+}
+
+// timestamp returns nil for the zero time.
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// rfc3339 returns the empty string for a nil timestamp.
+func rfc3339(t *timestamppb.Timestamp) string {
+	if t == nil {
+		return ""
+	}
+	return t.AsTime().Format(time.RFC3339)
+}
+
+func toNews(n *news.Record) *newsv1.News {
+	authors := make([]*newsv1.Author, 0, len(n.Authors))
+	for _, a := range n.Authors {
+		authors = append(authors, &newsv1.Author{
+			Id:        a.Id.String(),
+			Slug:      a.Slug,
+			Name:      a.Name,
+			Bio:       a.Bio,
+			AvatarUrl: a.AvatarURL,
+			Contact:   a.Contact,
+		})
+	}
+	return &newsv1.News{
+		Id:           n.Id.String(),
+		Author:       n.Author,
+		Authors:      authors,
+		Title:        n.Title,
+		Summary:      n.Summary,
+		Content:      n.Content,
+		Source:       n.Source,
+		Tags:         n.Tags,
+		Status:       statuses[n.Status],
+		PublishedAt:  timestamp(n.PublishedAt),
+		PublishAt:    timestamp(n.PublishAt),
+		EmbargoUntil: timestamp(n.EmbargoUntil),
+		ExpiresAt:    timestamp(n.ExpiresAt),
+		CreatedAt:    timestamp(n.CreatedAt),
+		UpdatedAt:    timestamp(n.UpdatedAt),
+	}
+}
+
+// fromInput validates the input like the body of POST /news.
+func fromInput(in *newsv1.NewsInput) (*news.Record, error) {
+	body := handler.NewsPostReqBody{
+		Author:       in.GetAuthor(),
+		Authors:      news.AuthorsNamed(in.GetAuthors()),
+		Title:        in.GetTitle(),
+		Summary:      in.GetSummary(),
+		Content:      in.GetContent(),
+		Source:       in.GetSource(),
+		CreatedAt:    rfc3339(in.GetCreatedAt()),
+		Tags:         in.GetTags(),
+		PublishAt:    rfc3339(in.GetPublishAt()),
+		EmbargoUntil: rfc3339(in.GetEmbargoUntil()),
+		ExpiresAt:    rfc3339(in.GetExpiresAt()),
+	}
+	return body.Validate()
+}
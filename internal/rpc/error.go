@@ -0,0 +1,55 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// codeOf maps the HTTP status of a news.CustomError to a gRPC code.
+func codeOf(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+// toStatus maps the store errors to gRPC status errors, without leaking the
+// internal ones.
+func toStatus(ctx context.Context, err error) error {
+	httpStatus := http.StatusInternalServerError
+	var dbErr *news.CustomError
+	if errors.As(err, &dbErr) {
+		httpStatus = dbErr.HttpStatusCode()
+	}
+	code := codeOf(httpStatus)
+	if code == codes.Internal {
+		logger.FromContext(ctx).Error("call failed", "error", err)
+	}
+	return status.Error(code, http.StatusText(httpStatus))
+}
@@ -0,0 +1,213 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	newsv1 "github.com/TommyLearning/go-rest-api-project/api/news/v1"
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultPageSize and MaxPageSize bound the page size of List.
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// NewsService implements news.v1.NewsService on the news store.
+type NewsService struct {
+	newsv1.UnimplementedNewsServiceServer
+	ns handler.NewsStorer
+	es handler.EventSubscriber
+}
+
+func NewNewsService(ns handler.NewsStorer, es handler.EventSubscriber) *NewsService {
+	return &NewsService{
+		ns: ns,
+		es: es,
+	}
+}
+
+func parseId(id string) (uuid.UUID, error) {
+	newsUUID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+	return newsUUID, nil
+}
+
+// authorize rejects the calls whose principal cannot write the news of the
+// tenant of the call, like tenant.Require the requests.
+func authorize(ctx context.Context) error {
+	switch err := tenant.Authorize(ctx, auth.RoleEditor); {
+	case errors.Is(err, tenant.ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, "Unauthorized")
+	case err != nil:
+		return status.Error(codes.PermissionDenied, "Forbidden")
+	}
+	return nil
+}
+
+func (s *NewsService) Create(ctx context.Context, req *newsv1.CreateRequest) (*newsv1.CreateResponse, error) {
+	if err := authorize(ctx); err != nil {
+		return nil, err
+	}
+	n, err := fromInput(req.GetNews())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	created, err := s.ns.Create(ctx, n)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &newsv1.CreateResponse{News: toNews(created)}, nil
+}
+
+func (s *NewsService) Get(ctx context.Context, req *newsv1.GetRequest) (*newsv1.GetResponse, error) {
+	id, err := parseId(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	n, err := s.ns.FindById(ctx, id)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	if !n.Public() && !auth.Editor(ctx) {
+		logger.FromContext(ctx).Info("news is not public", "id", id)
+		return nil, status.Error(codes.NotFound, "Not Found")
+	}
+	return &newsv1.GetResponse{News: toNews(n)}, nil
+}
+
+func (s *NewsService) List(ctx context.Context, req *newsv1.ListRequest) (*newsv1.ListResponse, error) {
+	size := int(req.GetPageSize())
+	if size == 0 {
+		size = DefaultPageSize
+	}
+	if size < 0 || size > MaxPageSize {
+		return nil, status.Error(codes.InvalidArgument, "page size is not between 1 and 100")
+	}
+	f := news.Filter{
+		Tag:    news.Slugify(req.GetTag()),
+		Author: news.Slugify(req.GetAuthor()),
+		Public: !auth.Editor(ctx),
+		Limit:  size + 1,
+	}
+	for _, pb := range req.GetStatuses() {
+		st, ok := fromStatus(pb)
+		if !ok {
+			return nil, status.Error(codes.InvalidArgument, "invalid status")
+		}
+		f.Statuses = append(f.Statuses, st)
+	}
+	if token := req.GetPageToken(); token != "" {
+		cursor, err := news.ParseCursor(token)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+		f.After = cursor
+	}
+
+	records, err := s.ns.FindAll(ctx, f)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	resp := &newsv1.ListResponse{}
+	if len(records) > size {
+		records = records[:size]
+		resp.NextPageToken = news.CursorOf(records[size-1]).String()
+	}
+	for _, n := range records {
+		resp.News = append(resp.News, toNews(n))
+	}
+	return resp, nil
+}
+
+func (s *NewsService) Update(ctx context.Context, req *newsv1.UpdateRequest) (*newsv1.UpdateResponse, error) {
+	if err := authorize(ctx); err != nil {
+		return nil, err
+	}
+	id, err := parseId(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	n, err := fromInput(req.GetNews())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.ns.UpdateById(ctx, id, n); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &newsv1.UpdateResponse{News: toNews(n)}, nil
+}
+
+func (s *NewsService) Delete(ctx context.Context, req *newsv1.DeleteRequest) (*newsv1.DeleteResponse, error) {
+	if err := authorize(ctx); err != nil {
+		return nil, err
+	}
+	id, err := parseId(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ns.DeleteById(ctx, id); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &newsv1.DeleteResponse{}, nil
+}
+
+// Watch streams the news events of the tenant of the call, like GET
+// /news/stream. The stream ends with
+// Unavailable when the caller lags too far behind or the server shuts down,
+// and callers are expected to resume after the last event they received.
+func (s *NewsService) Watch(req *newsv1.WatchRequest, stream grpc.ServerStreamingServer[newsv1.WatchResponse]) error {
+	ctx := stream.Context()
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "Unauthorized")
+	}
+	if !p.Has(auth.RoleEditor) {
+		return status.Error(codes.PermissionDenied, "Forbidden")
+	}
+	tag := news.Slugify(req.GetTag())
+	author := news.Slugify(req.GetAuthor())
+	tenantId := tenant.FromContext(ctx).Id
+
+	sub, err := s.es.Subscribe(ctx, req.GetAfterEventId())
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+	for e := range sub {
+		if e.TenantId != tenantId {
+			continue
+		}
+		resp := &newsv1.WatchResponse{EventId: e.Position, Event: events[e.Event], NewsId: e.NewsId.String()}
+		if e.Event != news.EventDeleted {
+			n, err := e.Record()
+			if err != nil {
+				logger.FromContext(ctx).Error("failed to decode event", "error", err, "id", e.Id)
+				continue
+			}
+			if tag != "" && !slices.Contains(n.Tags, tag) ||
+				author != "" && !slices.ContainsFunc(n.Authors, func(a *news.Author) bool { return a.Slug == author }) {
+				continue
+			}
+			resp.News = toNews(n)
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Unavailable, "subscription closed")
+}
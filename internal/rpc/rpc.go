@@ -0,0 +1,127 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	newsv1 "github.com/TommyLearning/go-rest-api-project/api/news/v1"
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// NewServer returns a gRPC server of the news service, along with the health
+// and reflection services. Callers authenticate with the API keys of the REST
+// API, sent in the x-api-key or authorization metadata, or with the client
+// certificates of the subjects over TLS, as set by the options. Calls act on
+// the tenant of their x-tenant-id metadata or of their authority, like the
+// requests of the REST API.
+func NewServer(log *slog.Logger, keys auth.Keys, subjects auth.Subjects, tenants tenant.Tenants, ns handler.NewsStorer, es handler.EventSubscriber, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryInterceptor(log, keys, subjects, tenants)),
+		grpc.ChainStreamInterceptor(streamInterceptor(log, keys, subjects, tenants)),
+	}, opts...)...)
+	newsv1.RegisterNewsServiceServer(s, NewNewsService(ns, es))
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(newsv1.NewsService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+	reflection.Register(s)
+	return s
+}
+
+// authenticate returns the context of a call with the logger, the principal
+// of its API key or client certificate, if any, and its tenant, like
+// logger.AddLoggerMid, auth.CertMid, auth.Mid and tenant.Mid.
+func authenticate(ctx context.Context, log *slog.Logger, keys auth.Keys, subjects auth.Subjects, tenants tenant.Tenants, method string) (context.Context, error) {
+	ctx = logger.CtxWithLogger(ctx, log)
+	logger.FromContext(ctx).Info("call", "method", method)
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			if p, ok := subjects.Principal(&info.State); ok {
+				ctx = auth.CtxWithPrincipal(ctx, p)
+			}
+		}
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	var key string
+	if v := md.Get("x-api-key"); len(v) > 0 {
+		key = v[0]
+	}
+	if v := md.Get("authorization"); len(v) > 0 {
+		if bearer, ok := strings.CutPrefix(v[0], "Bearer "); ok {
+			key = bearer
+		}
+	}
+	if key != "" {
+		p, ok := keys[key]
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "invalid api key")
+		}
+		ctx = auth.CtxWithPrincipal(ctx, p)
+	}
+
+	var name, host string
+	if v := md.Get(tenantMetadata); len(v) > 0 {
+		name = v[0]
+	}
+	if v := md.Get(":authority"); len(v) > 0 {
+		host = v[0]
+	}
+	t, err := tenants.Resolve(ctx, name, host)
+	switch {
+	case errors.Is(err, tenant.ErrForbidden):
+		return nil, status.Error(codes.PermissionDenied, "Forbidden")
+	case err != nil:
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	ctx = logger.CtxWithLogger(ctx, logger.FromContext(ctx).With("tenant", t.Id))
+	return tenant.CtxWithTenant(ctx, t), nil
+}
+
+// tenantMetadata is the metadata naming the tenant of a call, like the
+// tenant.Header of the requests.
+const tenantMetadata = "x-tenant-id"
+
+func unaryInterceptor(log *slog.Logger, keys auth.Keys, subjects auth.Subjects, tenants tenant.Tenants) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, log, keys, subjects, tenants, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamInterceptor(log *slog.Logger, keys auth.Keys, subjects auth.Subjects, tenants tenant.Tenants) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), log, keys, subjects, tenants, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// serverStream overrides the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,449 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	newsv1 "github.com/TommyLearning/go-rest-api-project/api/news/v1"
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	mockshandler "github.com/TommyLearning/go-rest-api-project/internal/handler/mocks"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/rpc"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// subscriber replays its events, then closes the subscription.
+type subscriber []*news.OutboxEvent
+
+func (s subscriber) Subscribe(context.Context, int64) (<-chan *news.OutboxEvent, error) {
+	ch := make(chan *news.OutboxEvent, len(s))
+	for _, e := range s {
+		ch <- e
+	}
+	close(ch)
+	return ch, nil
+}
+
+func newClient(t *testing.T, ns *mockshandler.MockNewsStorer, es subscriber) *grpc.ClientConn {
+	t.Helper()
+	keys := auth.Keys{
+		"editor-key": {Name: "alice", Role: auth.RoleEditor},
+		// A principal without a role, e.g. authenticated by a JWT.
+		"reader-key": {Name: "bob"},
+		"acme-key":   {Name: "carol", Role: auth.RoleEditor, Tenant: "acme"},
+	}
+	tenants, err := tenant.New(&tenant.Tenant{Id: "acme"}, &tenant.Tenant{Id: "globex"}, &tenant.Tenant{Id: tenant.Default})
+	require.NoError(t, err)
+	lis := bufconn.Listen(1 << 20)
+	srv := rpc.NewServer(slog.New(slog.NewTextHandler(io.Discard, nil)), keys, nil, tenants, ns, es)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func withKey(key string) context.Context {
+	ctx := context.Background()
+	if key == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "x-api-key", key)
+}
+
+func TestNewsService_Get(t *testing.T) {
+	id := uuid.New()
+
+	testCases := []struct {
+		name         string
+		key          string
+		id           string
+		record       *news.Record
+		err          error
+		expectedCode codes.Code
+	}{
+		{
+			name:         "public news",
+			id:           id.String(),
+			record:       &news.Record{Id: id, Title: "title", Status: news.StatusPublished},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "draft news anonymous",
+			id:           id.String(),
+			record:       &news.Record{Id: id, Title: "title", Status: news.StatusDraft},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:         "draft news editor",
+			key:          "editor-key",
+			id:           id.String(),
+			record:       &news.Record{Id: id, Title: "title", Status: news.StatusDraft},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "not found",
+			id:           id.String(),
+			err:          news.NewCustomError(errors.New("not found"), http.StatusNotFound),
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:         "db error",
+			id:           id.String(),
+			err:          news.NewCustomError(errors.New("db error"), http.StatusInternalServerError),
+			expectedCode: codes.Internal,
+		},
+		{
+			name:         "invalid id",
+			id:           "invalid",
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ns := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			if tc.record != nil || tc.err != nil {
+				ns.EXPECT().FindById(gomock.Any(), id).Return(tc.record, tc.err)
+			}
+			client := newsv1.NewNewsServiceClient(newClient(t, ns, nil))
+
+			// Act
+			resp, err := client.Get(withKey(tc.key), &newsv1.GetRequest{Id: tc.id})
+
+			// Assert
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedCode == codes.OK {
+				assert.Equal(t, id.String(), resp.GetNews().GetId())
+				assert.Equal(t, "title", resp.GetNews().GetTitle())
+			}
+		})
+	}
+}
+
+func TestNewsService_List(t *testing.T) {
+	now := time.Now().UTC()
+	records := []*news.Record{
+		{Id: uuid.New(), Status: news.StatusPublished, CreatedAt: now},
+		{Id: uuid.New(), Status: news.StatusPublished, CreatedAt: now.Add(-time.Minute)},
+		{Id: uuid.New(), Status: news.StatusPublished, CreatedAt: now.Add(-2 * time.Minute)},
+	}
+	after := news.CursorOf(records[1])
+
+	testCases := []struct {
+		name              string
+		req               *newsv1.ListRequest
+		expectedFilter    news.Filter
+		records           []*news.Record
+		expectedIds       []string
+		expectedNextToken string
+		expectedCode      codes.Code
+	}{
+		{
+			name:              "first page",
+			req:               &newsv1.ListRequest{PageSize: 2, Tag: "Go"},
+			expectedFilter:    news.Filter{Tag: "go", Public: true, Limit: 3},
+			records:           records,
+			expectedIds:       []string{records[0].Id.String(), records[1].Id.String()},
+			expectedNextToken: after.String(),
+			expectedCode:      codes.OK,
+		},
+		{
+			name: "last page",
+			req: &newsv1.ListRequest{
+				PageSize:  2,
+				PageToken: after.String(),
+				Statuses:  []newsv1.Status{newsv1.Status_STATUS_PUBLISHED},
+			},
+			expectedFilter: news.Filter{
+				Public:   true,
+				Limit:    3,
+				After:    after,
+				Statuses: []news.Status{news.StatusPublished},
+			},
+			records:      records[2:],
+			expectedIds:  []string{records[2].Id.String()},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "invalid page token",
+			req:          &newsv1.ListRequest{PageToken: "invalid"},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "invalid page size",
+			req:          &newsv1.ListRequest{PageSize: 101},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ns := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			if tc.records != nil {
+				ns.EXPECT().FindAll(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f news.Filter) ([]*news.Record, error) {
+					if tc.expectedFilter.After != nil {
+						require.NotNil(t, f.After)
+						assert.Equal(t, tc.expectedFilter.After.String(), f.After.String())
+						f.After, tc.expectedFilter.After = nil, nil
+					}
+					assert.Equal(t, tc.expectedFilter, f)
+					return tc.records, nil
+				})
+			}
+			client := newsv1.NewNewsServiceClient(newClient(t, ns, nil))
+
+			// Act
+			resp, err := client.List(context.Background(), tc.req)
+
+			// Assert
+			require.Equal(t, tc.expectedCode, status.Code(err))
+			var ids []string
+			for _, n := range resp.GetNews() {
+				ids = append(ids, n.GetId())
+			}
+			assert.Equal(t, tc.expectedIds, ids)
+			assert.Equal(t, tc.expectedNextToken, resp.GetNextPageToken())
+		})
+	}
+}
+
+func TestNewsService_Create(t *testing.T) {
+	testCases := []struct {
+		name         string
+		key          string
+		input        *newsv1.NewsInput
+		expectedCode codes.Code
+	}{
+		{
+			name: "valid news",
+			key:  "editor-key",
+			input: &newsv1.NewsInput{
+				Author:    "author",
+				Title:     "title",
+				Summary:   "summary",
+				Content:   "content",
+				CreatedAt: timestamppb.Now(),
+				Source:    "https://example.com",
+				Tags:      []string{"go"},
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "invalid news",
+			key:          "editor-key",
+			input:        &newsv1.NewsInput{Title: "title"},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "anonymous",
+			input:        &newsv1.NewsInput{Title: "title"},
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "not an editor",
+			key:          "reader-key",
+			input:        &newsv1.NewsInput{Title: "title"},
+			expectedCode: codes.PermissionDenied,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ns := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			if tc.expectedCode == codes.OK {
+				ns.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, n *news.Record) (*news.Record, error) {
+					n.Id = uuid.New()
+					return n, nil
+				})
+			}
+			client := newsv1.NewNewsServiceClient(newClient(t, ns, nil))
+
+			// Act
+			resp, err := client.Create(withKey(tc.key), &newsv1.CreateRequest{News: tc.input})
+
+			// Assert
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedCode == codes.OK {
+				assert.Equal(t, "title", resp.GetNews().GetTitle())
+				assert.NotEmpty(t, resp.GetNews().GetId())
+			}
+		})
+	}
+}
+
+func TestNewsService_Delete(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	ns := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+	ns.EXPECT().DeleteById(gomock.Any(), id).Return(news.NewCustomError(errors.New("not found"), http.StatusNotFound))
+	client := newsv1.NewNewsServiceClient(newClient(t, ns, nil))
+
+	// Act
+	_, err := client.Delete(withKey("editor-key"), &newsv1.DeleteRequest{Id: id.String()})
+
+	// Assert
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "Not Found", status.Convert(err).Message())
+}
+
+func TestNewsService_Watch(t *testing.T) {
+	goNews := &news.Record{Id: uuid.New(), Status: news.StatusPublished, Tags: []string{"go"}}
+	rustNews := &news.Record{Id: uuid.New(), Status: news.StatusPublished, Tags: []string{"rust"}}
+	event := func(id int64, event news.Event, record *news.Record) *news.OutboxEvent {
+		payload, err := json.Marshal(news.EventPayload{Id: uuid.New(), Event: event, Data: record})
+		require.NoError(t, err)
+		return &news.OutboxEvent{Id: id, Position: id, EventId: uuid.New(), Event: event, TenantId: tenant.Default, NewsId: record.Id, Payload: payload}
+	}
+	otherTenant := event(4, news.EventCreated, goNews)
+	otherTenant.TenantId = "acme"
+	es := subscriber{
+		event(1, news.EventCreated, goNews),
+		event(2, news.EventCreated, rustNews),
+		event(3, news.EventDeleted, rustNews),
+		otherTenant,
+	}
+
+	testCases := []struct {
+		name         string
+		key          string
+		tag          string
+		expectedIds  []int64
+		expectedCode codes.Code
+	}{
+		{
+			name:         "editor",
+			key:          "editor-key",
+			expectedIds:  []int64{1, 2, 3},
+			expectedCode: codes.Unavailable,
+		},
+		{
+			name:         "by tag",
+			key:          "editor-key",
+			tag:          "go",
+			expectedIds:  []int64{1, 3},
+			expectedCode: codes.Unavailable,
+		},
+		{
+			name:         "reader",
+			key:          "reader-key",
+			expectedCode: codes.PermissionDenied,
+		},
+		{
+			name:         "anonymous",
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "invalid key",
+			key:          "invalid",
+			expectedCode: codes.Unauthenticated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ns := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			client := newsv1.NewNewsServiceClient(newClient(t, ns, es))
+
+			// Act
+			stream, err := client.Watch(withKey(tc.key), &newsv1.WatchRequest{Tag: tc.tag})
+			require.NoError(t, err)
+			var ids []int64
+			for {
+				resp, err := stream.Recv()
+				if err != nil {
+					// Assert
+					assert.Equal(t, tc.expectedCode, status.Code(err))
+					break
+				}
+				ids = append(ids, resp.GetEventId())
+			}
+			assert.Equal(t, tc.expectedIds, ids)
+		})
+	}
+}
+
+func TestNewServer_Tenant(t *testing.T) {
+	id := uuid.New()
+
+	testCases := []struct {
+		name           string
+		key            string
+		tenant         string
+		expectedTenant string
+		expectedCode   codes.Code
+	}{
+		{name: "default", expectedTenant: tenant.Default, expectedCode: codes.OK},
+		{name: "metadata", tenant: "globex", expectedTenant: "globex", expectedCode: codes.OK},
+		{name: "principal", key: "acme-key", expectedTenant: "acme", expectedCode: codes.OK},
+		{name: "principal of another tenant", key: "acme-key", tenant: "globex", expectedCode: codes.PermissionDenied},
+		{name: "unknown tenant", tenant: "initech", expectedCode: codes.NotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ns := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+			var got string
+			if tc.expectedCode == codes.OK {
+				ns.EXPECT().FindById(gomock.Any(), id).DoAndReturn(func(ctx context.Context, id uuid.UUID) (*news.Record, error) {
+					got = tenant.FromContext(ctx).Id
+					return &news.Record{Id: id, Status: news.StatusPublished}, nil
+				})
+			}
+			client := newsv1.NewNewsServiceClient(newClient(t, ns, nil))
+			ctx := withKey(tc.key)
+			if tc.tenant != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "x-tenant-id", tc.tenant)
+			}
+
+			// Act
+			_, err := client.Get(ctx, &newsv1.GetRequest{Id: id.String()})
+
+			// Assert
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			assert.Equal(t, tc.expectedTenant, got)
+		})
+	}
+}
+
+func TestNewServer_Health(t *testing.T) {
+	// Arrange
+	ns := mockshandler.NewMockNewsStorer(gomock.NewController(t))
+	client := healthpb.NewHealthClient(newClient(t, ns, nil))
+
+	// Act
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: newsv1.NewsService_ServiceDesc.ServiceName})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+}
@@ -0,0 +1,227 @@
+// Package server hardens the HTTP server: it bounds the time the connections
+// and the handlers take and the size of the request bodies, recovers the
+// handlers that panic, and serves HTTPS with certificates reloaded as they are
+// renewed.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/problem"
+)
+
+// Config configures the server. Zero durations and sizes mean no limit.
+type Config struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// HandlerTimeout bounds the handlers through the context of their
+	// request, which their queries run with.
+	HandlerTimeout time.Duration
+	// MaxBodyBytes bounds the size of the request bodies.
+	MaxBodyBytes int64
+	// RouteTimeouts and RouteMaxBodyBytes override HandlerTimeout and
+	// MaxBodyBytes per route pattern.
+	RouteTimeouts     map[string]time.Duration
+	RouteMaxBodyBytes map[string]int64
+	// TLS makes the server serve HTTPS, and HTTP/2, if set.
+	TLS *tls.Config
+}
+
+// DefaultConfig is the configuration of the server when not overridden. The
+// event streams and the WebSockets last as long as their clients, and the
+// exports of the audit log as long as each of their batches is written
+// within the write deadline the export sets for it.
+var DefaultConfig = Config{
+	ReadHeaderTimeout: 3 * time.Second,
+	ReadTimeout:       30 * time.Second,
+	WriteTimeout:      60 * time.Second,
+	IdleTimeout:       2 * time.Minute,
+	HandlerTimeout:    30 * time.Second,
+	MaxBodyBytes:      1 << 20,
+	RouteTimeouts: map[string]time.Duration{
+		"GET /news/stream":        0,
+		"GET /news/ws":            0,
+		"GET /admin/audit/export": 0,
+	},
+}
+
+// New returns a server of the handler with the timeouts of the config.
+func New(addr string, c Config, h http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: c.ReadHeaderTimeout,
+		ReadTimeout:       c.ReadTimeout,
+		WriteTimeout:      c.WriteTimeout,
+		IdleTimeout:       c.IdleTimeout,
+		TLSConfig:         c.TLS,
+	}
+}
+
+// ListenAndServe listens and serves HTTPS if the server has a TLS
+// configuration, HTTP otherwise.
+func ListenAndServe(srv *http.Server) error {
+	if srv.TLSConfig != nil {
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
+
+// Limits bounds the time of the handler and the size of the request body of
+// the route of the request, as matched by mux. Bodies over the limit fail to
+// be read with an *http.MaxBytesError.
+func Limits(c Config, mux *http.ServeMux, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		maxBytes, ok := c.RouteMaxBodyBytes[pattern]
+		if !ok {
+			maxBytes = c.MaxBodyBytes
+		}
+		if maxBytes > 0 && r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		}
+		timeout, ok := c.RouteTimeouts[pattern]
+		if !ok {
+			timeout = c.HandlerTimeout
+		}
+		if timeout > 0 {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// Recover recovers the handlers that panic: the panic is logged with its
+// stack, and the request gets a 500 Internal Server Error problem unless its
+// response was already started, in which case it is aborted.
+func Recover(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rw := &writer{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			logger.FromContext(r.Context()).Error("handler panicked", "panic", fmt.Sprint(v), "stack", string(debug.Stack()))
+			if rw.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+			internalServerError(w)
+		}()
+		next.ServeHTTP(rw, r)
+	}
+}
+
+func internalServerError(w http.ResponseWriter) {
+	h := w.Header()
+	for name := range h {
+		delete(h, name)
+	}
+	h.Set("Cache-Control", "no-store")
+	problem.Write(w, http.StatusInternalServerError, "the server failed to handle the request")
+}
+
+// writer records whether the response was started.
+type writer struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *writer) WriteHeader(status int) {
+	if status >= 200 {
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ParseRouteTimeouts parses a comma separated list of pattern=duration
+// entries, e.g. "GET /news/stream=0, POST /graphql=1m".
+func ParseRouteTimeouts(s string) (map[string]time.Duration, error) {
+	return parseRoutes(s, func(v string) (time.Duration, error) {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("invalid timeout: %q", v)
+		}
+		return d, nil
+	})
+}
+
+// ParseRouteSizes parses a comma separated list of pattern=size entries,
+// e.g. "POST /news=2MiB, POST /graphql=64KiB".
+func ParseRouteSizes(s string) (map[string]int64, error) {
+	return parseRoutes(s, ParseSize)
+}
+
+func parseRoutes[T any](s string, parse func(string) (T, error)) (map[string]T, error) {
+	routes := map[string]T{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		i := strings.LastIndex(entry, "=")
+		if i == -1 {
+			return nil, fmt.Errorf("invalid route entry: %q", entry)
+		}
+		v, err := parse(strings.TrimSpace(entry[i+1:]))
+		if err != nil {
+			return nil, err
+		}
+		routes[strings.TrimSpace(entry[:i])] = v
+	}
+	return routes, nil
+}
+
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize parses a size in bytes, optionally in KiB, MiB or GiB, e.g.
+// "512KiB". Sizes over math.MaxInt64 bytes are invalid.
+func ParseSize(s string) (int64, error) {
+	errInvalid := errors.New("invalid size: " + strconv.Quote(s))
+	unit := int64(1)
+	for _, u := range sizeUnits {
+		if n, ok := strings.CutSuffix(s, u.suffix); ok {
+			s, unit = strings.TrimSpace(n), u.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64/unit {
+		return 0, errInvalid
+	}
+	return n * unit, nil
+}
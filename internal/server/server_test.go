@@ -0,0 +1,155 @@
+package server_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Limits(t *testing.T) {
+	mux := http.NewServeMux()
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			w.Header().Set("X-Deadline", "true")
+		}
+		b, err := io.ReadAll(r.Body)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.Write(b)
+	}
+	mux.HandleFunc("POST /news", echo)
+	mux.HandleFunc("POST /graphql", echo)
+	mux.HandleFunc("GET /news/stream", echo)
+	h := server.Limits(server.Config{
+		HandlerTimeout:    time.Second,
+		MaxBodyBytes:      8,
+		RouteTimeouts:     map[string]time.Duration{"GET /news/stream": 0},
+		RouteMaxBodyBytes: map[string]int64{"POST /graphql": 16},
+	}, mux, mux)
+
+	testCases := []struct {
+		name             string
+		method           string
+		target           string
+		body             string
+		expectedStatus   int
+		expectedDeadline bool
+	}{
+		{name: "within limit", method: http.MethodPost, target: "/news", body: "12345678", expectedStatus: http.StatusOK, expectedDeadline: true},
+		{name: "over limit", method: http.MethodPost, target: "/news", body: "123456789", expectedStatus: http.StatusRequestEntityTooLarge, expectedDeadline: true},
+		{name: "route limit", method: http.MethodPost, target: "/graphql", body: "123456789", expectedStatus: http.StatusOK, expectedDeadline: true},
+		{name: "no route timeout", method: http.MethodGet, target: "/news/stream", expectedStatus: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+
+			// Act
+			h(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedDeadline, w.Header().Get("X-Deadline") == "true")
+		})
+	}
+}
+
+func Test_Recover(t *testing.T) {
+	// Arrange
+	h := server.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		panic("boom")
+	}))
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/news", http.NoBody)
+
+	// Act
+	require.NotPanics(t, func() { h(w, r) })
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	var problem struct {
+		Status int `json:"status"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal(t, http.StatusInternalServerError, problem.Status)
+}
+
+func Test_Recover_Started(t *testing.T) {
+	// Arrange
+	h := server.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("news"))
+		panic("boom")
+	}))
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/news", http.NoBody)
+
+	// Act, Assert
+	// The response cannot be replaced anymore: it is aborted.
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { h(w, r) })
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func Test_ParseRouteTimeouts(t *testing.T) {
+	timeouts, err := server.ParseRouteTimeouts("GET /news/stream=0, POST /graphql=1m,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{"GET /news/stream": 0, "POST /graphql": time.Minute}, timeouts)
+
+	_, err = server.ParseRouteTimeouts("GET /news/stream")
+	assert.ErrorContains(t, err, "invalid route entry")
+
+	_, err = server.ParseRouteTimeouts("GET /news/stream=-1s")
+	assert.ErrorContains(t, err, "invalid timeout")
+}
+
+func Test_ParseSize(t *testing.T) {
+	testCases := []struct {
+		input       string
+		expected    int64
+		expectedErr bool
+	}{
+		{input: "1024", expected: 1024},
+		{input: "512B", expected: 512},
+		{input: "64KiB", expected: 64 << 10},
+		{input: "2 MiB", expected: 2 << 20},
+		{input: "1GiB", expected: 1 << 30},
+		{input: "1MB", expectedErr: true},
+		{input: "-1", expectedErr: true},
+		{input: "8589934591GiB", expected: 8589934591 << 30},
+		{input: "8589934592GiB", expectedErr: true},
+		{input: "9223372036854775807", expected: math.MaxInt64},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			n, err := server.ParseSize(tc.input)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, n)
+		})
+	}
+
+	sizes, err := server.ParseRouteSizes("POST /news=2MiB")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"POST /news": 2 << 20}, sizes)
+}
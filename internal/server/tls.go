@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+)
+
+// Certificates keeps the certificate of the server, and the CAs of the client
+// certificates if any, as loaded from their files. Reload loads them again
+// when the files change, so that they are renewed without a restart.
+type Certificates struct {
+	certFile, keyFile, clientCAFile string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  []time.Time
+}
+
+// LoadCertificates loads the certificate and key of the server, and the CAs
+// of the client certificates unless clientCAFile is empty.
+func LoadCertificates(certFile, keyFile, clientCAFile string) (*Certificates, error) {
+	c := &Certificates{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+	modTimes, err := c.stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.load(modTimes); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload loads the files again if any of them changed since they were loaded.
+// The certificates loaded before are kept when the new ones fail to load.
+func (c *Certificates) Reload(ctx context.Context) error {
+	modTimes, err := c.stat()
+	if err != nil {
+		return err
+	}
+	c.mu.RLock()
+	changed := false
+	for i, t := range modTimes {
+		changed = changed || !t.Equal(c.modTimes[i])
+	}
+	c.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	if err := c.load(modTimes); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("certificates reloaded", "cert_file", c.certFile)
+	return nil
+}
+
+func (c *Certificates) files() []string {
+	files := []string{c.certFile, c.keyFile}
+	if c.clientCAFile != "" {
+		files = append(files, c.clientCAFile)
+	}
+	return files
+}
+
+// stat returns the modification times of the files.
+func (c *Certificates) stat() ([]time.Time, error) {
+	var modTimes []time.Time
+	for _, name := range c.files() {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		modTimes = append(modTimes, fi.ModTime())
+	}
+	return modTimes, nil
+}
+
+func (c *Certificates) load(modTimes []time.Time) error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if c.clientCAFile != "" {
+		pem, err := os.ReadFile(c.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("load client CAs: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return errors.New("load client CAs: no certificate found in " + c.clientCAFile)
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cert, c.clientCAs, c.modTimes = &cert, clientCAs, modTimes
+	return nil
+}
+
+// GetCertificate returns the certificate of the server, for
+// tls.Config.GetCertificate.
+func (c *Certificates) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+// ClientCAs returns the CAs of the client certificates, nil if none were
+// loaded.
+func (c *Certificates) ClientCAs() *x509.CertPool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.clientCAs
+}
+
+// clientAuthTypes are the client certificate policies, by name.
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+// ParseClientAuth parses a client certificate policy: none, request,
+// require, verify_if_given or require_and_verify.
+func ParseClientAuth(s string) (tls.ClientAuthType, error) {
+	t, ok := clientAuthTypes[strings.ToLower(strings.TrimSpace(s))]
+	if !ok {
+		return 0, fmt.Errorf("invalid client auth: %q", s)
+	}
+	return t, nil
+}
+
+// NewTLSConfig returns the TLS configuration of a server with the
+// certificates, negotiating HTTP/2. Client certificates are requested and
+// verified against the client CAs of the certificates as set by clientAuth.
+func NewTLSConfig(c *Certificates, clientAuth tls.ClientAuthType) (*tls.Config, error) {
+	if clientAuth >= tls.VerifyClientCertIfGiven && c.ClientCAs() == nil {
+		return nil, errors.New("client certificates cannot be verified without client CAs")
+	}
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: c.GetCertificate,
+		ClientAuth:     clientAuth,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	if c.ClientCAs() == nil {
+		return config, nil
+	}
+	base := config.Clone()
+	// The client CAs are taken at each handshake, since they can be
+	// reloaded.
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		config := base.Clone()
+		config.ClientCAs = c.ClientCAs()
+		return config, nil
+	}
+	return config, nil
+}
@@ -0,0 +1,218 @@
+package server_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// certificate is a certificate generated for the tests, and its files.
+type certificate struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	tls      tls.Certificate
+	certFile string
+	keyFile  string
+}
+
+// newCertificate generates a certificate with the common name, signed by
+// parent, or self-signed CA if parent is nil, and writes it to dir.
+func newCertificate(t *testing.T, dir, cn string, parent *certificate) *certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	c := &certificate{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(dir, cn+".crt"),
+		keyFile:  filepath.Join(dir, cn+".key"),
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	require.NoError(t, os.WriteFile(c.certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(c.keyFile, keyPEM, 0o600))
+	c.tls, err = tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	return c
+}
+
+func Test_TLS(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	ca := newCertificate(t, dir, "ca", nil)
+	serverCert := newCertificate(t, dir, "server", ca)
+	clientCert := newCertificate(t, dir, "alice-laptop", ca)
+	unknownCert := newCertificate(t, dir, "mallory-laptop", ca)
+
+	certs, err := server.LoadCertificates(serverCert.certFile, serverCert.keyFile, ca.certFile)
+	require.NoError(t, err)
+	tlsConfig, err := server.NewTLSConfig(certs, tls.VerifyClientCertIfGiven)
+	require.NoError(t, err)
+	subjects := auth.Subjects{"alice-laptop": {Name: "alice", Role: auth.RoleEditor, Method: auth.MethodClientCert}}
+	h := auth.CertMid(subjects, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+		if p, ok := auth.FromContext(r.Context()); ok {
+			io.WriteString(w, p.Name)
+		}
+	}))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := server.New("", server.Config{TLS: tlsConfig}, h)
+	go srv.ServeTLS(ln, "", "")
+	t.Cleanup(func() { srv.Close() })
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	testCases := []struct {
+		name              string
+		cert              *certificate
+		expectedPrincipal string
+	}{
+		{name: "client certificate", cert: clientCert, expectedPrincipal: "alice"},
+		{name: "unknown subject", cert: unknownCert},
+		{name: "no client certificate"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tlsClientConfig := &tls.Config{RootCAs: roots}
+			if tc.cert != nil {
+				tlsClientConfig.Certificates = []tls.Certificate{tc.cert.tls}
+			}
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsClientConfig, ForceAttemptHTTP2: true}}
+
+			// Act
+			resp, err := client.Get("https://" + ln.Addr().String() + "/news")
+
+			// Assert
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "HTTP/2.0", resp.Header.Get("X-Proto"))
+			assert.Equal(t, tc.expectedPrincipal, string(body))
+		})
+	}
+
+	t.Run("untrusted client certificate", func(t *testing.T) {
+		other := newCertificate(t, t.TempDir(), "other-ca", nil)
+		cert := newCertificate(t, t.TempDir(), "alice-laptop", other).tls
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs: roots,
+			// Sent even though it is not signed by the client CAs of the
+			// server.
+			GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) { return &cert, nil },
+		}}}
+
+		// Act
+		_, err := client.Get("https://" + ln.Addr().String() + "/news")
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
+
+func Test_Certificates_Reload(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	dir := t.TempDir()
+	ca := newCertificate(t, dir, "ca", nil)
+	first := newCertificate(t, dir, "server", ca)
+	certs, err := server.LoadCertificates(first.certFile, first.keyFile, "")
+	require.NoError(t, err)
+	leaf := func() *x509.Certificate {
+		cert, err := certs.GetCertificate(nil)
+		require.NoError(t, err)
+		parsed, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		return parsed
+	}
+	touch := func(names ...string) {
+		later := time.Now().Add(time.Minute)
+		for _, name := range names {
+			require.NoError(t, os.Chtimes(name, later, later))
+		}
+	}
+
+	// Act, Assert
+	require.NoError(t, certs.Reload(ctx))
+	assert.Equal(t, first.cert.SerialNumber, leaf().SerialNumber, "unchanged files are not reloaded")
+
+	renewed := newCertificate(t, dir, "server", ca)
+	touch(renewed.certFile, renewed.keyFile)
+	require.NoError(t, certs.Reload(ctx))
+	assert.Equal(t, renewed.cert.SerialNumber, leaf().SerialNumber)
+
+	require.NoError(t, os.WriteFile(renewed.certFile, []byte("invalid"), 0o600))
+	touch(renewed.certFile)
+	assert.Error(t, certs.Reload(ctx))
+	assert.Equal(t, renewed.cert.SerialNumber, leaf().SerialNumber, "the certificate is kept when the new one is invalid")
+}
+
+func Test_NewTLSConfig_NoClientCAs(t *testing.T) {
+	dir := t.TempDir()
+	cert := newCertificate(t, dir, "server", nil)
+	certs, err := server.LoadCertificates(cert.certFile, cert.keyFile, "")
+	require.NoError(t, err)
+
+	_, err = server.NewTLSConfig(certs, tls.RequireAndVerifyClientCert)
+	assert.ErrorContains(t, err, "without client CAs")
+
+	config, err := server.NewTLSConfig(certs, tls.NoClientCert)
+	require.NoError(t, err)
+	assert.Contains(t, config.NextProtos, "h2")
+}
+
+func Test_ParseClientAuth(t *testing.T) {
+	clientAuth, err := server.ParseClientAuth("require_and_verify")
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, clientAuth)
+
+	_, err = server.ParseClientAuth("always")
+	assert.ErrorContains(t, err, "invalid client auth")
+}
@@ -0,0 +1,275 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/google/uuid"
+)
+
+// NewsStore keeps the news in memory, with the semantics of news.Store for the
+// handlers: it backs the tests that run the whole handler stack without a
+// database. Tags have no aliases, news are similar when their SimHash is close
+// enough, and deleted news are kept until they are restored. Like news.Store,
+// it only reads and writes the news of the tenant of the context.
+type NewsStore struct {
+	l    sync.Mutex
+	news []*news.Record
+}
+
+func NewNewsStore() *NewsStore {
+	return &NewsStore{}
+}
+
+// clone returns a copy of the news that the callers can modify.
+func clone(n *news.Record) *news.Record {
+	c := *n
+	c.Tags = slices.Clone(n.Tags)
+	c.Authors = make([]*news.Author, 0, len(n.Authors))
+	for _, a := range n.Authors {
+		author := *a
+		c.Authors = append(c.Authors, &author)
+	}
+	return &c
+}
+
+// ofTenant reports whether the news belongs to the tenant of the context.
+func ofTenant(ctx context.Context, n *news.Record) bool {
+	id := tenant.FromContext(ctx).Id
+	return id == tenant.All.Id || n.TenantId == id
+}
+
+// find returns the index of the news of the tenant of the context, deleted or
+// not as asked for.
+func (s *NewsStore) find(ctx context.Context, id uuid.UUID, deleted bool) (int, error) {
+	i := slices.IndexFunc(s.news, func(n *news.Record) bool {
+		return n.Id == id && ofTenant(ctx, n) && !n.DeletedAt.IsZero() == deleted
+	})
+	if i == -1 {
+		return -1, news.NewCustomError(sql.ErrNoRows, http.StatusNotFound)
+	}
+	return i, nil
+}
+
+// resolve completes the authors and the fingerprint of the news, like
+// news.Store does on writes. There are no author profiles here, the authors
+// given by slug only are named after it.
+func resolve(n *news.Record) error {
+	if len(n.Authors) == 0 {
+		n.Authors = news.AuthorsOf(n.Author)
+	}
+	for _, a := range n.Authors {
+		slug := news.Slugify(cmp.Or(a.Slug, a.Name))
+		if slug == "" {
+			return news.NewCustomError(fmt.Errorf("invalid author name: %q", a.Name), http.StatusBadRequest)
+		}
+		a.Slug = slug
+		a.Name = cmp.Or(strings.TrimSpace(a.Name), slug)
+	}
+	n.Author = news.Byline(n.Authors)
+	n.Tags = news.NormalizeTags(n.Tags)
+	n.Fingerprint = int64(news.SimHash(n.Title, n.Content)) //nolint:gosec // compared bit for bit
+	return nil
+}
+
+func (s *NewsStore) Create(ctx context.Context, n *news.Record) (*news.Record, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	t := tenant.FromContext(ctx)
+	if t.Id == tenant.All.Id {
+		return nil, news.NewCustomError(errors.New("news cannot be created in all the tenants"), http.StatusInternalServerError)
+	}
+	if err := s.checkQuota(t); err != nil {
+		return nil, err
+	}
+	n = clone(n)
+	if err := resolve(n); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	n.Id = uuid.New()
+	n.TenantId = t.Id
+	n.Status = news.StatusDraft
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = now
+	}
+	n.UpdatedAt = now
+	s.news = append(s.news, n)
+	return clone(n), nil
+}
+
+func (s *NewsStore) FindById(ctx context.Context, id uuid.UUID) (*news.Record, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	i, err := s.find(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	return clone(s.news[i]), nil
+}
+
+func (s *NewsStore) FindAll(ctx context.Context, f news.Filter) ([]*news.Record, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	found := []*news.Record{}
+	query := strings.ToLower(f.Query)
+	for _, n := range s.news {
+		switch {
+		case !ofTenant(ctx, n),
+			!n.DeletedAt.IsZero(),
+			len(f.Ids) > 0 && !slices.Contains(f.Ids, n.Id),
+			f.Tag != "" && !slices.Contains(n.Tags, f.Tag),
+			f.Author != "" && !slices.ContainsFunc(n.Authors, func(a *news.Author) bool { return a.Slug == f.Author }),
+			len(f.Statuses) > 0 && !slices.Contains(f.Statuses, n.Status),
+			f.Public && !n.Public(),
+			query != "" && !matches(n, query),
+			f.After != nil && !before(n, f.After):
+			continue
+		}
+		found = append(found, clone(n))
+	}
+	if f.After != nil || f.Limit > 0 {
+		slices.SortFunc(found, func(a, b *news.Record) int {
+			return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.Id.String(), a.Id.String()))
+		})
+	}
+	if f.Limit > 0 && len(found) > f.Limit {
+		found = found[:f.Limit]
+	}
+	return found, nil
+}
+
+// matches reports whether the news contains the lowercase query.
+func matches(n *news.Record, query string) bool {
+	return slices.ContainsFunc([]string{n.Title, n.Summary, n.Content}, func(s string) bool {
+		return strings.Contains(strings.ToLower(s), query)
+	})
+}
+
+// before reports whether the news comes after the cursor, newest first.
+func before(n *news.Record, c *news.Cursor) bool {
+	if !n.CreatedAt.Equal(c.CreatedAt) {
+		return n.CreatedAt.Before(c.CreatedAt)
+	}
+	return n.Id.String() < c.Id.String()
+}
+
+func (s *NewsStore) DeleteById(ctx context.Context, id uuid.UUID) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+	i, err := s.find(ctx, id, false)
+	if err != nil {
+		return err
+	}
+	s.news[i].DeletedAt = time.Now().UTC()
+	return nil
+}
+
+// checkQuota fails if the tenant already has its maximum number of news that
+// are not deleted.
+func (s *NewsStore) checkQuota(t *tenant.Tenant) error {
+	if t.MaxNews == 0 {
+		return nil
+	}
+	count := 0
+	for _, n := range s.news {
+		if n.TenantId == t.Id && n.DeletedAt.IsZero() {
+			count++
+		}
+	}
+	if count >= t.MaxNews {
+		return news.NewCustomError(news.ErrQuotaExceeded, http.StatusForbidden)
+	}
+	return nil
+}
+
+func (s *NewsStore) Restore(ctx context.Context, id uuid.UUID) (*news.Record, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	i, err := s.find(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkQuota(tenant.FromContext(ctx)); err != nil {
+		return nil, err
+	}
+	n := s.news[i]
+	n.DeletedAt = time.Time{}
+	n.UpdatedAt = time.Now().UTC()
+	return clone(n), nil
+}
+
+func (s *NewsStore) UpdateById(ctx context.Context, id uuid.UUID, n *news.Record) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+	i, err := s.find(ctx, id, false)
+	if err != nil {
+		return err
+	}
+	updated := clone(n)
+	if err := resolve(updated); err != nil {
+		return err
+	}
+	old := s.news[i]
+	updated.Id = id
+	updated.TenantId = old.TenantId
+	updated.Status = old.Status
+	updated.StatusChangedAt = old.StatusChangedAt
+	updated.StatusChangedBy = old.StatusChangedBy
+	updated.PublishedAt = old.PublishedAt
+	if updated.CreatedAt.IsZero() {
+		updated.CreatedAt = old.CreatedAt
+	}
+	updated.UpdatedAt = time.Now().UTC()
+	s.news[i] = updated
+	return nil
+}
+
+func (s *NewsStore) FindSimilar(ctx context.Context, id uuid.UUID) ([]*news.Record, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	i, err := s.find(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := uint64(s.news[i].Fingerprint) //nolint:gosec // compared bit for bit
+	similar := []*news.Record{}
+	for _, n := range s.news {
+		if n.Id != id && ofTenant(ctx, n) && n.DeletedAt.IsZero() && news.HammingDistance(fingerprint, uint64(n.Fingerprint)) <= news.DefaultSimilarityThreshold { //nolint:gosec // compared bit for bit
+			similar = append(similar, clone(n))
+		}
+	}
+	return similar, nil
+}
+
+func (s *NewsStore) Transition(ctx context.Context, id uuid.UUID, to news.Status, actor string) (*news.Record, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	i, err := s.find(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	n := s.news[i]
+	if !n.Status.CanTransitionTo(to) {
+		return nil, news.NewCustomError(fmt.Errorf("cannot move news from %s to %s", n.Status, to), http.StatusConflict)
+	}
+	now := time.Now().UTC()
+	n.Status = to
+	n.StatusChangedAt = now
+	n.StatusChangedBy = actor
+	n.UpdatedAt = now
+	if to == news.StatusPublished {
+		n.PublishedAt = now
+	}
+	return clone(n), nil
+}
@@ -0,0 +1,197 @@
+package stream
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+)
+
+const (
+	// DefaultBufferSize is the number of events a subscriber can lag behind
+	// before being dropped.
+	DefaultBufferSize = 64
+	// DefaultPageSize is the number of past events loaded at once when
+	// replaying them to a subscriber resuming after an event.
+	DefaultPageSize = 1000
+)
+
+type Store interface {
+	FindEvents(ctx context.Context, ids ...int64) ([]*news.OutboxEvent, error)
+	FindEventsAfter(ctx context.Context, position int64, limit int) ([]*news.OutboxEvent, error)
+}
+
+// Hub fans out the news events notified by Postgres to its subscribers.
+type Hub struct {
+	store      Store
+	mu         sync.Mutex
+	subs       map[chan *news.OutboxEvent]struct{}
+	closed     bool
+	BufferSize int
+	PageSize   int
+}
+
+func NewHub(store Store) *Hub {
+	return &Hub{
+		store:      store,
+		subs:       map[chan *news.OutboxEvent]struct{}{},
+		BufferSize: DefaultBufferSize,
+		PageSize:   DefaultPageSize,
+	}
+}
+
+// Notify publishes the outbox event whose ID is the notification payload.
+func (h *Hub) Notify(ctx context.Context, payload string) {
+	log := logger.FromContext(ctx)
+	id, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		log.Error("failed to parse event id", "error", err, "payload", payload)
+		return
+	}
+	events, err := h.store.FindEvents(ctx, id)
+	if err != nil {
+		log.Error("failed to find event", "error", err, "id", id)
+		return
+	}
+	h.Publish(events...)
+}
+
+// Publish passes the events to the subscribers. Subscribers whose buffer is
+// full are dropped rather than holding up the others.
+func (h *Hub) Publish(events ...*news.OutboxEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, e := range events {
+		for ch := range h.subs {
+			select {
+			case ch <- e:
+			default:
+				delete(h.subs, ch)
+				close(ch)
+			}
+		}
+	}
+}
+
+// Subscribe returns the events committed after the one at the given position,
+// if any, followed by the live events. The channel is closed when ctx is done,
+// when the subscriber lags too far behind, when the hub is closed or when the
+// past events fail to load, so that subscribers resume after the last event
+// they received.
+func (h *Hub) Subscribe(ctx context.Context, after int64) (<-chan *news.OutboxEvent, error) {
+	// The past events are replayed page after page before subscribing to the
+	// live ones, which would pile up meanwhile.
+	var live chan *news.OutboxEvent
+	var page []*news.OutboxEvent
+	if after > 0 {
+		var err error
+		if page, err = h.store.FindEventsAfter(ctx, after, h.PageSize); err != nil {
+			return nil, err
+		}
+	} else {
+		live = h.subscribe()
+	}
+
+	out := make(chan *news.OutboxEvent)
+	go func() {
+		defer close(out)
+		defer func() {
+			if live != nil {
+				h.unsubscribe(live)
+			}
+		}()
+
+		// sent holds the events of the current page, and of all the pages
+		// once subscribed, which may be notified again.
+		last, sent := after, map[int64]bool{}
+		for {
+			if live == nil {
+				clear(sent)
+			}
+			for _, e := range page {
+				select {
+				case out <- e:
+					last, sent[e.Id] = e.Position, true
+				case <-ctx.Done():
+					return
+				}
+			}
+			if len(page) < h.PageSize {
+				if live != nil {
+					h.forward(ctx, live, out, sent)
+					return
+				}
+				// Caught up: subscribe, then load the events recorded in
+				// between. Events in both are sent once.
+				live = h.subscribe()
+			}
+			var err error
+			if page, err = h.store.FindEventsAfter(ctx, last, h.PageSize); err != nil {
+				logger.FromContext(ctx).Error("failed to find events", "error", err, "after", last)
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// subscribe registers a channel for the live events, which is closed right
+// away if the hub is closed.
+func (h *Hub) subscribe() chan *news.OutboxEvent {
+	live := make(chan *news.OutboxEvent, h.BufferSize)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(live)
+	} else {
+		h.subs[live] = struct{}{}
+	}
+	return live
+}
+
+// forward sends the live events to out, but for the ones already sent, until
+// live is closed or ctx is done.
+func (h *Hub) forward(ctx context.Context, live <-chan *news.OutboxEvent, out chan<- *news.OutboxEvent, sent map[int64]bool) {
+	for {
+		select {
+		case e, ok := <-live:
+			if !ok {
+				return
+			}
+			if sent[e.Id] {
+				continue
+			}
+			select {
+			case out <- e:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *Hub) unsubscribe(ch chan *news.OutboxEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[ch]; ok {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+// Close ends all the subscriptions and refuses new ones. It is meant to be
+// registered with http.Server.RegisterOnShutdown, which does not wait for
+// streaming handlers.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for ch := range h.subs {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,217 @@
+package stream_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store finds its events by ID and position like news.OutboxStore.
+type store struct {
+	mu     sync.Mutex
+	events []*news.OutboxEvent
+}
+
+func (s *store) add(e *news.OutboxEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, e)
+}
+
+func (s *store) FindEvents(_ context.Context, ids ...int64) ([]*news.OutboxEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []*news.OutboxEvent
+	for _, e := range s.events {
+		for _, id := range ids {
+			if e.Id == id {
+				events = append(events, e)
+			}
+		}
+	}
+	return events, nil
+}
+
+func (s *store) FindEventsAfter(_ context.Context, position int64, limit int) ([]*news.OutboxEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []*news.OutboxEvent
+	for _, e := range s.events {
+		if e.Position > position && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func newEvents(n int) []*news.OutboxEvent {
+	events := make([]*news.OutboxEvent, 0, n)
+	for i := range n {
+		events = append(events, &news.OutboxEvent{Id: int64(i + 1), Event: news.EventCreated, Position: int64(i + 1)})
+	}
+	return events
+}
+
+func receive(t *testing.T, events <-chan *news.OutboxEvent, n int) []int64 {
+	t.Helper()
+	var ids []int64
+	for range n {
+		select {
+		case e, ok := <-events:
+			require.True(t, ok, "stream closed")
+			ids = append(ids, e.Id)
+		case <-time.After(time.Second):
+			require.FailNow(t, "no event received")
+		}
+	}
+	return ids
+}
+
+func closed(t *testing.T, events <-chan *news.OutboxEvent) bool {
+	t.Helper()
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return true
+			}
+		case <-time.After(time.Second):
+			return false
+		}
+	}
+}
+
+func TestHub_Subscribe(t *testing.T) {
+	testCases := []struct {
+		name        string
+		after       int64
+		notified    []string
+		expectedIds []int64
+	}{
+		{
+			name:        "live events",
+			notified:    []string{"4", "invalid", "5"},
+			expectedIds: []int64{4, 5},
+		},
+		{
+			name:        "backlog then live events",
+			after:       2,
+			notified:    []string{"4", "5"},
+			expectedIds: []int64{3, 4, 5},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			s := &store{events: newEvents(5)}
+			hub := stream.NewHub(s)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Act
+			events, err := hub.Subscribe(ctx, tc.after)
+			require.NoError(t, err)
+			for _, payload := range tc.notified {
+				hub.Notify(ctx, payload)
+			}
+
+			// Assert
+			assert.Equal(t, tc.expectedIds, receive(t, events, len(tc.expectedIds)))
+			cancel()
+			assert.True(t, closed(t, events))
+		})
+	}
+}
+
+func TestHub_Subscribe_Pages(t *testing.T) {
+	// Arrange
+	s := &store{events: newEvents(7)}
+	hub := stream.NewHub(s)
+	hub.PageSize = 2
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Act
+	events, err := hub.Subscribe(ctx, 1)
+	require.NoError(t, err)
+	backlog := receive(t, events, 6)
+	// Recorded while catching up, whether notified before subscribing or
+	// after, and notified twice.
+	s.add(&news.OutboxEvent{Id: 8, Event: news.EventCreated, Position: 8})
+	hub.Notify(ctx, "8")
+	caughtUp := receive(t, events, 1)
+	hub.Notify(ctx, "8")
+	s.add(&news.OutboxEvent{Id: 9, Event: news.EventCreated, Position: 9})
+	hub.Notify(ctx, "9")
+
+	// Assert
+	assert.Equal(t, []int64{2, 3, 4, 5, 6, 7}, backlog)
+	assert.Equal(t, []int64{8}, caughtUp)
+	assert.Equal(t, []int64{9}, receive(t, events, 1))
+}
+
+func TestHub_Subscribe_CommitOrder(t *testing.T) {
+	// Arrange
+	// The event 2 committed after the event 3, which was streamed first.
+	s := &store{events: []*news.OutboxEvent{
+		{Id: 1, Event: news.EventCreated, Position: 1},
+		{Id: 3, Event: news.EventCreated, Position: 2},
+		{Id: 2, Event: news.EventCreated, Position: 3},
+	}}
+	hub := stream.NewHub(s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Act
+	events, err := hub.Subscribe(ctx, 2)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, []int64{2}, receive(t, events, 1))
+}
+
+func TestHub_Publish_SlowSubscriber(t *testing.T) {
+	// Arrange
+	hub := stream.NewHub(&store{})
+	hub.BufferSize = 2
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	slow, err := hub.Subscribe(ctx, 0)
+	require.NoError(t, err)
+	fast, err := hub.Subscribe(ctx, 0)
+	require.NoError(t, err)
+
+	// Act
+	var fastIds []int64
+	for _, e := range newEvents(10) {
+		hub.Publish(e)
+		fastIds = append(fastIds, receive(t, fast, 1)...)
+	}
+
+	// Assert
+	assert.Equal(t, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, fastIds)
+	assert.True(t, closed(t, slow))
+}
+
+func TestHub_Close(t *testing.T) {
+	// Arrange
+	hub := stream.NewHub(&store{})
+	events, err := hub.Subscribe(context.Background(), 0)
+	require.NoError(t, err)
+
+	// Act
+	hub.Close()
+
+	// Assert
+	assert.True(t, closed(t, events))
+	events, err = hub.Subscribe(context.Background(), 0)
+	require.NoError(t, err)
+	assert.True(t, closed(t, events))
+}
@@ -0,0 +1,221 @@
+// Package tenant hosts several publications on one deployment. Each request
+// is resolved to the tenant it acts on, whose news are the only ones its
+// stores read and write.
+//
+// The tenant of a request is the one of its X-Tenant-ID header, or else of
+// its host name, or else of its principal, or else the default tenant, if
+// configured. Principals bound to a tenant cannot act on another one.
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+)
+
+// Header is the header naming the tenant of a request.
+const Header = "X-Tenant-ID"
+
+// Default is the ID of the tenant of the deployments hosting one
+// publication, and of the news written before the tenants.
+const Default = "default"
+
+// All is the tenant of the workers acting on the news of every tenant. It is
+// never resolved from a request.
+var All = &Tenant{Id: "*"}
+
+// Tenant is a publication hosted on the deployment, and its configuration.
+type Tenant struct {
+	Id string `json:"id"`
+	// Hosts are the host names of the publication, without port.
+	Hosts []string `json:"hosts"`
+	// MaxNews bounds the number of news of the publication, deleted ones
+	// excluded. Zero means no limit.
+	MaxNews int `json:"max_news"`
+}
+
+// Tenants maps the IDs of the tenants to their configuration.
+type Tenants map[string]*Tenant
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// Load reads the tenants from a JSON file holding an array of tenants. With
+// no file, the default tenant is the only one.
+func Load(path string) (Tenants, error) {
+	if path == "" {
+		return Tenants{Default: {Id: Default}}, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var list []*Tenant
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, fmt.Errorf("invalid tenants file: %w", err)
+	}
+	return New(list...)
+}
+
+// New validates the tenants: their IDs have to be lowercase slugs, and their
+// host names cannot be shared.
+func New(list ...*Tenant) (Tenants, error) {
+	ts := Tenants{}
+	hosts := map[string]string{}
+	for _, t := range list {
+		if !idPattern.MatchString(t.Id) {
+			return nil, fmt.Errorf("invalid tenant id: %q", t.Id)
+		}
+		if _, ok := ts[t.Id]; ok {
+			return nil, fmt.Errorf("duplicate tenant id: %q", t.Id)
+		}
+		if t.MaxNews < 0 {
+			return nil, fmt.Errorf("invalid max news of tenant %q: %d", t.Id, t.MaxNews)
+		}
+		for i, host := range t.Hosts {
+			host = strings.ToLower(host)
+			if other, ok := hosts[host]; ok {
+				return nil, fmt.Errorf("host %q of tenant %q is already the host of tenant %q", host, t.Id, other)
+			}
+			hosts[host] = t.Id
+			t.Hosts[i] = host
+		}
+		ts[t.Id] = t
+	}
+	return ts, nil
+}
+
+// ByHost returns the tenant of the host name, with or without port.
+func (ts Tenants) ByHost(host string) (*Tenant, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	for _, t := range ts {
+		for _, h := range t.Hosts {
+			if h == host {
+				return t, true
+			}
+		}
+	}
+	return nil, false
+}
+
+var (
+	ErrUnknown         = errors.New("unknown tenant")
+	ErrForbidden       = errors.New("principal cannot act on the tenant")
+	ErrUnauthenticated = errors.New("no principal")
+)
+
+// Resolve returns the tenant of the request, as described in the package
+// documentation, given the tenant name of the header or of the call, if any.
+func (ts Tenants) Resolve(ctx context.Context, name, host string) (*Tenant, error) {
+	p, authenticated := auth.FromContext(ctx)
+	var t *Tenant
+	switch {
+	case name != "":
+		var ok bool
+		if t, ok = ts[name]; !ok {
+			return nil, ErrUnknown
+		}
+	case host != "":
+		t, _ = ts.ByHost(host)
+	}
+	if t == nil && authenticated && p.Tenant != "" {
+		t = ts[p.Tenant]
+	}
+	if t == nil {
+		t = ts[Default]
+	}
+	if t == nil {
+		return nil, ErrUnknown
+	}
+	if authenticated && p.Tenant != "" && p.Tenant != t.Id {
+		return nil, ErrForbidden
+	}
+	return t, nil
+}
+
+type ctxKey struct{}
+
+func CtxWithTenant(ctx context.Context, t *Tenant) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKey{}, t)
+}
+
+// FromContext returns the tenant of the context, or the default tenant if
+// there is none.
+func FromContext(ctx context.Context) *Tenant {
+	if t, ok := Lookup(ctx); ok {
+		return t
+	}
+	return &Tenant{Id: Default}
+}
+
+// Lookup returns the tenant of the context, if it was resolved.
+func Lookup(ctx context.Context) (*Tenant, bool) {
+	t, ok := ctx.Value(ctxKey{}).(*Tenant)
+	return t, ok
+}
+
+// Mid resolves the tenant of the requests, once authenticated. Requests for
+// an unknown tenant are not found, and requests of principals bound to
+// another tenant are forbidden.
+func Mid(ts Tenants, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		t, err := ts.Resolve(ctx, r.Header.Get(Header), r.Host)
+		if err != nil {
+			logger.FromContext(ctx).Info("tenant not resolved", "error", err, "tenant", r.Header.Get(Header), "host", r.Host)
+			if errors.Is(err, ErrForbidden) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		ctx = logger.CtxWithLogger(ctx, logger.FromContext(ctx).With("tenant", t.Id))
+		next.ServeHTTP(w, r.WithContext(CtxWithTenant(ctx, t)))
+	}
+}
+
+// Authorize returns nil if the principal of the context can act with the role
+// on the tenant of the context: it has the role, and is bound to the tenant
+// or to none.
+func Authorize(ctx context.Context, role auth.Role) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !p.Has(role) || p.Tenant != "" && p.Tenant != FromContext(ctx).Id {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// Require rejects the requests whose principal cannot act with the role on
+// the tenant of the request, for the routes writing the news of the tenant.
+// It runs after Mid.
+func Require(role auth.Role, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch err := Authorize(r.Context(), role); {
+		case errors.Is(err, ErrUnauthenticated):
+			w.WriteHeader(http.StatusUnauthorized)
+		case err != nil:
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	}
+}
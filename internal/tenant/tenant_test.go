@@ -0,0 +1,178 @@
+package tenant_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Load(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		expected    tenant.Tenants
+		expectedErr string
+	}{
+		{
+			name:    "tenants",
+			content: `[{"id": "acme", "hosts": ["News.Acme.example"], "max_news": 1000}, {"id": "default"}]`,
+			expected: tenant.Tenants{
+				"acme":    {Id: "acme", Hosts: []string{"news.acme.example"}, MaxNews: 1000},
+				"default": {Id: "default"},
+			},
+		},
+		{name: "invalid json", content: `{`, expectedErr: "invalid tenants file"},
+		{name: "invalid id", content: `[{"id": "Acme Corp"}]`, expectedErr: "invalid tenant id"},
+		{name: "duplicate id", content: `[{"id": "acme"}, {"id": "acme"}]`, expectedErr: "duplicate tenant id"},
+		{name: "shared host", content: `[{"id": "acme", "hosts": ["news.example"]}, {"id": "globex", "hosts": ["news.example"]}]`, expectedErr: "already the host"},
+		{name: "negative quota", content: `[{"id": "acme", "max_news": -1}]`, expectedErr: "invalid max news"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tenants.json")
+			require.NoError(t, os.WriteFile(path, []byte(tc.content), 0o600))
+
+			ts, err := tenant.Load(path)
+
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ts)
+		})
+	}
+}
+
+func Test_Load_NoFile(t *testing.T) {
+	ts, err := tenant.Load("")
+
+	require.NoError(t, err)
+	assert.Equal(t, tenant.Tenants{tenant.Default: {Id: tenant.Default}}, ts)
+}
+
+func Test_Mid(t *testing.T) {
+	ts, err := tenant.New(
+		&tenant.Tenant{Id: "acme", Hosts: []string{"news.acme.example"}},
+		&tenant.Tenant{Id: "globex", Hosts: []string{"news.globex.example"}},
+		&tenant.Tenant{Id: tenant.Default},
+	)
+	require.NoError(t, err)
+	acmeEditor := &auth.Principal{Name: "alice", Role: auth.RoleEditor, Tenant: "acme"}
+	operator := &auth.Principal{Name: "ops", Role: auth.RoleAdmin}
+
+	testCases := []struct {
+		name           string
+		host           string
+		header         string
+		principal      *auth.Principal
+		expectedStatus int
+		expectedTenant string
+	}{
+		{name: "host", host: "news.acme.example", expectedStatus: http.StatusOK, expectedTenant: "acme"},
+		{name: "host with port", host: "NEWS.GLOBEX.EXAMPLE:8080", expectedStatus: http.StatusOK, expectedTenant: "globex"},
+		{name: "header over host", host: "news.acme.example", header: "globex", expectedStatus: http.StatusOK, expectedTenant: "globex"},
+		{name: "unknown header", header: "initech", expectedStatus: http.StatusNotFound},
+		{name: "principal", host: "api.example", principal: acmeEditor, expectedStatus: http.StatusOK, expectedTenant: "acme"},
+		{name: "default", host: "api.example", expectedStatus: http.StatusOK, expectedTenant: tenant.Default},
+		{name: "principal of the host", host: "news.acme.example", principal: acmeEditor, expectedStatus: http.StatusOK, expectedTenant: "acme"},
+		{name: "principal of another host", host: "news.globex.example", principal: acmeEditor, expectedStatus: http.StatusForbidden},
+		{name: "principal of another header", header: "globex", principal: acmeEditor, expectedStatus: http.StatusForbidden},
+		{name: "platform principal", header: "globex", principal: operator, expectedStatus: http.StatusOK, expectedTenant: "globex"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			var got string
+			h := tenant.Mid(ts, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = tenant.FromContext(r.Context()).Id
+			}))
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/news", http.NoBody)
+			r.Host = tc.host
+			if tc.header != "" {
+				r.Header.Set(tenant.Header, tc.header)
+			}
+			r = r.WithContext(auth.CtxWithPrincipal(r.Context(), tc.principal))
+
+			// Act
+			h(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedTenant, got)
+		})
+	}
+}
+
+func Test_Mid_NoDefault(t *testing.T) {
+	// Arrange
+	ts, err := tenant.New(&tenant.Tenant{Id: "acme", Hosts: []string{"news.acme.example"}})
+	require.NoError(t, err)
+	h := tenant.Mid(ts, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/news", http.NoBody)
+	r.Host = "news.globex.example"
+
+	// Act
+	h(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func Test_Require(t *testing.T) {
+	acme := &tenant.Tenant{Id: "acme"}
+	globex := &tenant.Tenant{Id: "globex"}
+
+	testCases := []struct {
+		name           string
+		principal      *auth.Principal
+		tenant         *tenant.Tenant
+		expectedStatus int
+	}{
+		{name: "anonymous", tenant: acme, expectedStatus: http.StatusUnauthorized},
+		{name: "editor of the tenant", principal: &auth.Principal{Name: "alice", Role: auth.RoleEditor, Tenant: "acme"}, tenant: acme, expectedStatus: http.StatusOK},
+		{name: "admin of the tenant", principal: &auth.Principal{Name: "carol", Role: auth.RoleAdmin, Tenant: "acme"}, tenant: acme, expectedStatus: http.StatusOK},
+		{name: "editor of another tenant", principal: &auth.Principal{Name: "alice", Role: auth.RoleEditor, Tenant: "acme"}, tenant: globex, expectedStatus: http.StatusForbidden},
+		{name: "platform editor", principal: &auth.Principal{Name: "ops", Role: auth.RoleEditor}, tenant: globex, expectedStatus: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			var called bool
+			h := tenant.Require(auth.RoleEditor, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/news", http.NoBody)
+			ctx := auth.CtxWithPrincipal(r.Context(), tc.principal)
+			r = r.WithContext(tenant.CtxWithTenant(ctx, tc.tenant))
+
+			// Act
+			h(w, r)
+
+			// Assert
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedStatus == http.StatusOK, called)
+		})
+	}
+}
+
+func Test_FromContext(t *testing.T) {
+	assert.Equal(t, tenant.Default, tenant.FromContext(context.Background()).Id)
+
+	ctx := tenant.CtxWithTenant(context.Background(), tenant.All)
+	assert.Same(t, tenant.All, tenant.FromContext(ctx))
+}
@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+)
+
+const (
+	// Headers sent along with the payloads.
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderEventId   = "X-Webhook-Id"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	DefaultMaxAttempts = 8
+	DefaultBatchSize   = 50
+	// DefaultLease is how long a claimed delivery is left alone by the other
+	// replicas. It must be longer than the timeout of the HTTP client.
+	DefaultLease = time.Minute
+)
+
+// Sign computes the signature of a payload sent at the given Unix time:
+// "sha256=" followed by the hex encoded HMAC-SHA256 of "timestamp.body".
+// Receivers recompute it with their secret to authenticate the payload.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "%d.", timestamp)
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of the payload.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Backoff returns the delay before retrying a delivery that failed attempts
+// times: 10s doubling on each attempt, up to an hour.
+func Backoff(attempts int) time.Duration {
+	const (
+		first = 10 * time.Second
+		limit = time.Hour
+	)
+	if attempts < 1 {
+		return first
+	}
+	if attempts > 10 {
+		return limit
+	}
+	return min(first<<(attempts-1), limit)
+}
+
+type Store interface {
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*news.Delivery, error)
+	RecordAttempt(ctx context.Context, delivery *news.Delivery) error
+}
+
+// Dispatcher delivers the pending events to the webhooks.
+type Dispatcher struct {
+	store       Store
+	client      *http.Client
+	MaxAttempts int
+	BatchSize   int
+	Lease       time.Duration
+}
+
+func NewDispatcher(store Store, client *http.Client) *Dispatcher {
+	return &Dispatcher{
+		store:       store,
+		client:      client,
+		MaxAttempts: DefaultMaxAttempts,
+		BatchSize:   DefaultBatchSize,
+		Lease:       DefaultLease,
+	}
+}
+
+// Dispatch delivers the deliveries that are due, batch after batch, until
+// none is left.
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	for {
+		deliveries, err := d.store.ClaimDue(ctx, d.BatchSize, d.Lease)
+		if err != nil {
+			return err
+		}
+		for _, delivery := range deliveries {
+			d.attempt(ctx, delivery)
+			if err := d.store.RecordAttempt(context.WithoutCancel(ctx), delivery); err != nil {
+				return err
+			}
+		}
+		if len(deliveries) < d.BatchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// attempt sends the delivery and updates it with the outcome: delivered,
+// retried later with a backoff, or dead after MaxAttempts failures.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *news.Delivery) {
+	log := logger.FromContext(ctx).With("delivery", delivery.Id, "webhook", delivery.WebhookId, "event", delivery.Event)
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = now
+
+	status, err := d.send(ctx, delivery, now)
+	delivery.ResponseStatus = status
+	switch {
+	case err == nil:
+		log.Info("webhook delivered", "attempts", delivery.Attempts)
+		delivery.Status = news.DeliveryDelivered
+		delivery.DeliveredAt = now
+		delivery.LastError = ""
+	case delivery.Attempts >= d.MaxAttempts:
+		log.Error("webhook delivery dead", "attempts", delivery.Attempts, "error", err)
+		delivery.Status = news.DeliveryDead
+		delivery.LastError = err.Error()
+	default:
+		delivery.NextAttemptAt = now.Add(Backoff(delivery.Attempts))
+		log.Warn("webhook delivery failed", "attempts", delivery.Attempts, "next_attempt_at", delivery.NextAttemptAt, "error", err)
+		delivery.LastError = err.Error()
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *news.Delivery, now time.Time) (int, error) {
+	if delivery.Webhook == nil {
+		return 0, fmt.Errorf("webhook %s not found", delivery.WebhookId)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderEventId, delivery.EventId.String())
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
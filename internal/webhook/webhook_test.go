@@ -0,0 +1,173 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store hands out its pending deliveries that are due, like
+// news.WebhookStore.
+type store struct {
+	mu         sync.Mutex
+	deliveries []*news.Delivery
+	attempts   int
+}
+
+func (s *store) ClaimDue(_ context.Context, limit int, lease time.Duration) ([]*news.Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []*news.Delivery
+	for _, d := range s.deliveries {
+		if len(due) < limit && d.Status == news.DeliveryPending && !d.NextAttemptAt.After(time.Now()) {
+			d.NextAttemptAt = time.Now().Add(lease)
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+func (s *store) RecordAttempt(context.Context, *news.Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts++
+	return nil
+}
+
+func newDelivery(t *testing.T, url string) *news.Delivery {
+	t.Helper()
+	payload, err := json.Marshal(news.EventPayload{Id: uuid.New(), Event: news.EventCreated, Data: &news.Record{Title: "test-title"}})
+	require.NoError(t, err)
+	return &news.Delivery{
+		Id:      1,
+		Webhook: &news.Webhook{Id: uuid.New(), URL: url, Secret: "s3cr3t"},
+		EventId: uuid.New(),
+		Event:   news.EventCreated,
+		Payload: payload,
+		Status:  news.DeliveryPending,
+	}
+}
+
+func Test_Dispatcher_Dispatch(t *testing.T) {
+	testCases := []struct {
+		name             string
+		status           int
+		maxAttempts      int
+		expectedStatus   news.DeliveryStatus
+		expectedAttempts int
+	}{
+		{
+			name:             "delivered",
+			status:           http.StatusNoContent,
+			maxAttempts:      3,
+			expectedStatus:   news.DeliveryDelivered,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "retried later",
+			status:           http.StatusServiceUnavailable,
+			maxAttempts:      3,
+			expectedStatus:   news.DeliveryPending,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "dead",
+			status:           http.StatusInternalServerError,
+			maxAttempts:      1,
+			expectedStatus:   news.DeliveryDead,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			var received http.Header
+			var body []byte
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header.Clone()
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tc.status)
+			}))
+			t.Cleanup(receiver.Close)
+
+			delivery := newDelivery(t, receiver.URL)
+			s := &store{deliveries: []*news.Delivery{delivery}}
+			d := webhook.NewDispatcher(s, receiver.Client())
+			d.MaxAttempts = tc.maxAttempts
+
+			// Act
+			err := d.Dispatch(context.Background())
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, delivery.Status)
+			assert.Equal(t, tc.expectedAttempts, delivery.Attempts)
+			assert.Equal(t, tc.status, delivery.ResponseStatus)
+			assert.Equal(t, 1, s.attempts)
+
+			assert.JSONEq(t, string(delivery.Payload), string(body))
+			assert.Equal(t, string(news.EventCreated), received.Get(webhook.HeaderEvent))
+			assert.Equal(t, delivery.EventId.String(), received.Get(webhook.HeaderEventId))
+			timestamp, err := strconv.ParseInt(received.Get(webhook.HeaderTimestamp), 10, 64)
+			require.NoError(t, err)
+			assert.True(t, webhook.Verify("s3cr3t", timestamp, body, received.Get(webhook.HeaderSignature)))
+			assert.False(t, webhook.Verify("wrong", timestamp, body, received.Get(webhook.HeaderSignature)))
+
+			if tc.expectedStatus == news.DeliveryPending {
+				assert.WithinDuration(t, time.Now().Add(webhook.Backoff(1)), delivery.NextAttemptAt, time.Second)
+				assert.NotEmpty(t, delivery.LastError)
+			}
+		})
+	}
+}
+
+func Test_Dispatcher_Dispatch_unreachable(t *testing.T) {
+	// Arrange
+	receiver := httptest.NewServer(http.NotFoundHandler())
+	receiver.Close()
+	delivery := newDelivery(t, receiver.URL)
+	d := webhook.NewDispatcher(&store{deliveries: []*news.Delivery{delivery}}, http.DefaultClient)
+
+	// Act
+	err := d.Dispatch(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, news.DeliveryPending, delivery.Status)
+	assert.Equal(t, 0, delivery.ResponseStatus)
+	assert.Contains(t, delivery.LastError, "connection refused")
+}
+
+func Test_Backoff(t *testing.T) {
+	testCases := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 0, expected: 10 * time.Second},
+		{attempts: 1, expected: 10 * time.Second},
+		{attempts: 2, expected: 20 * time.Second},
+		{attempts: 5, expected: 160 * time.Second},
+		{attempts: 9, expected: 2560 * time.Second},
+		{attempts: 10, expected: time.Hour},
+		{attempts: 100, expected: time.Hour},
+	}
+
+	for _, tc := range testCases {
+		t.Run(strconv.Itoa(tc.attempts), func(t *testing.T) {
+			assert.Equal(t, tc.expected, webhook.Backoff(tc.attempts))
+		})
+	}
+}
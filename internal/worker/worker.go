@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/logger"
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+)
+
+// Job is a unit of background work run periodically by Run.
+type Job func(ctx context.Context) error
+
+// Run runs the job right away and then every interval until ctx is done.
+// Failures are logged and do not stop the following runs.
+func Run(ctx context.Context, name string, interval time.Duration, job Job) error {
+	log := logger.FromContext(ctx).With("job", name)
+	log.Info("job started", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := job(ctx); err != nil && ctx.Err() == nil {
+			log.Error("job failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			log.Info("job stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+type Publisher interface {
+	PublishDue(ctx context.Context) ([]*news.Record, error)
+}
+
+// PublishDue publishes the scheduled news that are due.
+func PublishDue(p Publisher) Job {
+	return func(ctx context.Context) error {
+		published, err := p.PublishDue(ctx)
+		if err != nil {
+			return err
+		}
+		log := logger.FromContext(ctx)
+		for _, n := range published {
+			log.Info("scheduled news published", "id", n.Id, "publish_at", n.PublishAt)
+		}
+		return nil
+	}
+}
+
+type Sweeper interface {
+	SweepExpired(ctx context.Context, action news.SweepAction, batchSize int, swept func(*news.Record)) (int, error)
+	RecordSweep(ctx context.Context, run news.SweepRun) error
+}
+
+// SweepExpired archives or soft-deletes the expired news, logging each of
+// them, and records the run in the sweeper stats.
+func SweepExpired(s Sweeper, action news.SweepAction, batchSize int) Job {
+	return func(ctx context.Context) error {
+		log := logger.FromContext(ctx)
+		run := news.SweepRun{Action: action, StartedAt: time.Now()}
+		run.Count, run.Err = s.SweepExpired(ctx, action, batchSize, func(n *news.Record) {
+			log.Info("expired news swept", "id", n.Id, "action", action, "expires_at", n.ExpiresAt)
+		})
+		// Record interrupted runs too, they may have swept some batches.
+		if err := s.RecordSweep(context.WithoutCancel(ctx), run); err != nil {
+			return errors.Join(run.Err, err)
+		}
+		return run.Err
+	}
+}
+
+type Backfiller interface {
+	BackfillFingerprints(ctx context.Context, batchSize int) (int, error)
+}
+
+// BackfillFingerprints fingerprints the news stored before fingerprints were
+// computed, so that they are clustered with their near-duplicates.
+func BackfillFingerprints(b Backfiller, batchSize int) Job {
+	return func(ctx context.Context) error {
+		count, err := b.BackfillFingerprints(ctx, batchSize)
+		if count > 0 {
+			logger.FromContext(ctx).Info("news fingerprints backfilled", "count", count)
+		}
+		return err
+	}
+}
@@ -0,0 +1,170 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/news"
+	"github.com/TommyLearning/go-rest-api-project/internal/worker"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Run(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs atomic.Int32
+	job := func(context.Context) error {
+		if runs.Add(1) == 3 {
+			cancel()
+		}
+		return errors.New("job error")
+	}
+
+	// Act
+	err := worker.Run(ctx, "test", time.Millisecond, job)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), runs.Load())
+}
+
+type publisherFunc func(ctx context.Context) ([]*news.Record, error)
+
+func (f publisherFunc) PublishDue(ctx context.Context) ([]*news.Record, error) {
+	return f(ctx)
+}
+
+func Test_PublishDue(t *testing.T) {
+	testCases := []struct {
+		name        string
+		publisher   publisherFunc
+		expectedErr string
+	}{
+		{
+			name: "db error",
+			publisher: func(context.Context) ([]*news.Record, error) {
+				return nil, errors.New("db error")
+			},
+			expectedErr: "db error",
+		},
+		{
+			name: "success",
+			publisher: func(context.Context) ([]*news.Record, error) {
+				return []*news.Record{{Id: uuid.New(), Status: news.StatusPublished}}, nil
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := worker.PublishDue(tc.publisher)(context.Background())
+
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+type sweeper struct {
+	swept []*news.Record
+	err   error
+	runs  []news.SweepRun
+}
+
+func (s *sweeper) SweepExpired(_ context.Context, _ news.SweepAction, _ int, swept func(*news.Record)) (int, error) {
+	for _, n := range s.swept {
+		swept(n)
+	}
+	return len(s.swept), s.err
+}
+
+func (s *sweeper) RecordSweep(_ context.Context, run news.SweepRun) error {
+	s.runs = append(s.runs, run)
+	return nil
+}
+
+func Test_SweepExpired(t *testing.T) {
+	testCases := []struct {
+		name        string
+		sweeper     *sweeper
+		expectedErr string
+	}{
+		{
+			name:        "db error",
+			sweeper:     &sweeper{swept: []*news.Record{{Id: uuid.New()}}, err: errors.New("db error")},
+			expectedErr: "db error",
+		},
+		{
+			name:    "success",
+			sweeper: &sweeper{swept: []*news.Record{{Id: uuid.New()}, {Id: uuid.New()}}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			err := worker.SweepExpired(tc.sweeper, news.SweepArchive, 10)(context.Background())
+
+			// Assert
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			require.Len(t, tc.sweeper.runs, 1)
+			assert.Equal(t, news.SweepArchive, tc.sweeper.runs[0].Action)
+			assert.Equal(t, len(tc.sweeper.swept), tc.sweeper.runs[0].Count)
+			assert.Equal(t, tc.sweeper.err, tc.sweeper.runs[0].Err)
+		})
+	}
+}
+
+type backfillerFunc func(ctx context.Context, batchSize int) (int, error)
+
+func (f backfillerFunc) BackfillFingerprints(ctx context.Context, batchSize int) (int, error) {
+	return f(ctx, batchSize)
+}
+
+func Test_BackfillFingerprints(t *testing.T) {
+	testCases := []struct {
+		name        string
+		backfiller  backfillerFunc
+		expectedErr string
+	}{
+		{
+			name: "db error",
+			backfiller: func(context.Context, int) (int, error) {
+				return 10, errors.New("db error")
+			},
+			expectedErr: "db error",
+		},
+		{
+			name: "success",
+			backfiller: func(_ context.Context, batchSize int) (int, error) {
+				return batchSize + 1, nil
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			err := worker.BackfillFingerprints(tc.backfiller, 10)(context.Background())
+
+			// Assert
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package newsclient
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Error is an error response of the API, the client side of the server's
+// news.CustomError. Message is the body of the response, if any, such as the
+// validation errors of a 400 Bad Request.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+// The errors of the API, matched by status with errors.Is, e.g.
+// errors.Is(err, newsclient.ErrNotFound).
+var (
+	ErrBadRequest   = &Error{StatusCode: http.StatusBadRequest}
+	ErrUnauthorized = &Error{StatusCode: http.StatusUnauthorized}
+	ErrForbidden    = &Error{StatusCode: http.StatusForbidden}
+	ErrNotFound     = &Error{StatusCode: http.StatusNotFound}
+	ErrConflict     = &Error{StatusCode: http.StatusConflict}
+)
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("news api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("news api: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func (e *Error) HttpStatusCode() int {
+	return e.StatusCode
+}
+
+// Is reports whether the target is an error of the same status.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.StatusCode == e.StatusCode
+}
@@ -0,0 +1,364 @@
+package newsclient
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status is the editorial state of a news. Only published news are visible
+// to the public.
+type Status string
+
+const (
+	StatusDraft     Status = "draft"
+	StatusInReview  Status = "in_review"
+	StatusApproved  Status = "approved"
+	StatusPublished Status = "published"
+	StatusArchived  Status = "archived"
+)
+
+// DefaultPageLimit is the size of the pages fetched by the iterators.
+const DefaultPageLimit = 50
+
+// Author is a profile of the responses. Only Slug or Name are needed in the
+// news inputs, the fields left zero are omitted.
+type Author struct {
+	Id        uuid.UUID `json:"id,omitzero"`
+	Slug      string    `json:"slug,omitzero"`
+	Name      string    `json:"name,omitzero"`
+	Bio       string    `json:"bio,omitzero"`
+	AvatarURL string    `json:"avatar_url,omitzero"`
+	Contact   string    `json:"contact,omitzero"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
+}
+
+type News struct {
+	Id              uuid.UUID `json:"Id"`
+	Author          string    `json:"Author"`
+	Authors         []*Author `json:"authors"`
+	Title           string    `json:"Title"`
+	Summary         string    `json:"Summary"`
+	Content         string    `json:"Content"`
+	Source          string    `json:"Source"`
+	Tags            []string  `json:"Tags"`
+	ClusterId       uuid.UUID `json:"cluster_id"`
+	Status          Status    `json:"status"`
+	StatusChangedAt time.Time `json:"status_changed_at"`
+	StatusChangedBy string    `json:"status_changed_by"`
+	PublishedAt     time.Time `json:"published_at"`
+	PublishAt       time.Time `json:"publish_at"`
+	EmbargoUntil    time.Time `json:"embargo_until"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	CreatedAt       time.Time `json:"CreatedAt"`
+	UpdatedAt       time.Time `json:"UpdatedAt"`
+}
+
+// NewsInput is the body of the news created or updated. Authors are found by
+// slug, or by name for the new ones, and Author is used when there are none.
+// The zero times are left unset.
+type NewsInput struct {
+	Author       string    `json:"author"`
+	Authors      []*Author `json:"authors"`
+	Title        string    `json:"title"`
+	Summary      string    `json:"summary"`
+	Content      string    `json:"content"`
+	Source       string    `json:"source"`
+	Tags         []string  `json:"tags"`
+	CreatedAt    time.Time `json:"created_at"`
+	PublishAt    time.Time `json:"publish_at"`
+	EmbargoUntil time.Time `json:"embargo_until"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// newsBody is the request body of NewsInput, with its times as RFC 3339
+// strings, empty when unset.
+type newsBody struct {
+	Id           uuid.UUID `json:"id,omitzero"`
+	Author       string    `json:"author"`
+	Authors      []*Author `json:"authors"`
+	Title        string    `json:"title"`
+	Summary      string    `json:"summary"`
+	Content      string    `json:"content"`
+	Source       string    `json:"source"`
+	Tags         []string  `json:"tags"`
+	CreatedAt    string    `json:"created_at"`
+	PublishAt    string    `json:"publish_at"`
+	EmbargoUntil string    `json:"embargo_until"`
+	ExpiresAt    string    `json:"expires_at"`
+}
+
+func rfc3339(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (in NewsInput) body(id uuid.UUID) newsBody {
+	return newsBody{
+		Id:           id,
+		Author:       in.Author,
+		Authors:      in.Authors,
+		Title:        in.Title,
+		Summary:      in.Summary,
+		Content:      in.Content,
+		Source:       in.Source,
+		Tags:         in.Tags,
+		CreatedAt:    rfc3339(in.CreatedAt),
+		PublishAt:    rfc3339(in.PublishAt),
+		EmbargoUntil: rfc3339(in.EmbargoUntil),
+		ExpiresAt:    rfc3339(in.ExpiresAt),
+	}
+}
+
+func (in NewsInput) MarshalJSON() ([]byte, error) {
+	return json.Marshal(in.body(uuid.Nil))
+}
+
+// UnmarshalJSON decodes the news input as encoded by MarshalJSON, so that it
+// can be edited as JSON.
+func (in *NewsInput) UnmarshalJSON(data []byte) error {
+	var b newsBody
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	times := make([]time.Time, 4)
+	for i, s := range []string{b.CreatedAt, b.PublishAt, b.EmbargoUntil, b.ExpiresAt} {
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+		times[i] = t
+	}
+	*in = NewsInput{
+		Author:       b.Author,
+		Authors:      b.Authors,
+		Title:        b.Title,
+		Summary:      b.Summary,
+		Content:      b.Content,
+		Source:       b.Source,
+		Tags:         b.Tags,
+		CreatedAt:    times[0],
+		PublishAt:    times[1],
+		EmbargoUntil: times[2],
+		ExpiresAt:    times[3],
+	}
+	return nil
+}
+
+// Input returns the news input that updates the news with its own content.
+func (n *News) Input() NewsInput {
+	authors := make([]*Author, 0, len(n.Authors))
+	for _, a := range n.Authors {
+		authors = append(authors, &Author{Slug: a.Slug, Name: a.Name})
+	}
+	return NewsInput{
+		Authors:      authors,
+		Title:        n.Title,
+		Summary:      n.Summary,
+		Content:      n.Content,
+		Source:       n.Source,
+		Tags:         n.Tags,
+		CreatedAt:    n.CreatedAt,
+		PublishAt:    n.PublishAt,
+		EmbargoUntil: n.EmbargoUntil,
+		ExpiresAt:    n.ExpiresAt,
+	}
+}
+
+// ListOptions filters and pages the news lists. Query and Statuses only apply
+// to the news and editor lists. Without a limit, the lists return all their
+// news at once.
+type ListOptions struct {
+	Tag string
+	// Query matches the news with the text in their title, summary or
+	// content, case-insensitively.
+	Query    string
+	Statuses []Status
+	Limit    int
+	// After is the NextCursor of the previous page.
+	After string
+}
+
+func (o *ListOptions) query() url.Values {
+	q := url.Values{}
+	if o == nil {
+		return q
+	}
+	if o.Tag != "" {
+		q.Set("tag", o.Tag)
+	}
+	if o.Query != "" {
+		q.Set("q", o.Query)
+	}
+	if len(o.Statuses) > 0 {
+		statuses := make([]string, 0, len(o.Statuses))
+		for _, s := range o.Statuses {
+			statuses = append(statuses, string(s))
+		}
+		q.Set("status", strings.Join(statuses, ","))
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.After != "" {
+		q.Set("after", o.After)
+	}
+	return q
+}
+
+// NewsPage is a page of news. NextCursor is empty on the last page.
+type NewsPage struct {
+	News       []*News `json:"news"`
+	NextCursor string  `json:"next_cursor"`
+}
+
+func (c *Client) CreateNews(ctx context.Context, in NewsInput) error {
+	return c.do(ctx, http.MethodPost, "/news", nil, in, nil)
+}
+
+// ListNews lists the public news.
+func (c *Client) ListNews(ctx context.Context, opts *ListOptions) (*NewsPage, error) {
+	return c.listNews(ctx, "/news", opts)
+}
+
+// AllNews iterates over the public news, fetching them page by page.
+func (c *Client) AllNews(ctx context.Context, opts ListOptions) iter.Seq2[*News, error] {
+	return c.allNews(ctx, "/news", opts)
+}
+
+// GetNews gets a public news.
+func (c *Client) GetNews(ctx context.Context, id uuid.UUID) (*News, error) {
+	var n News
+	if err := c.do(ctx, http.MethodGet, "/news/"+id.String(), nil, nil, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// SimilarNews lists the public news similar to the news.
+func (c *Client) SimilarNews(ctx context.Context, id uuid.UUID) ([]*News, error) {
+	page, err := c.listNews(ctx, "/news/"+id.String()+"/similar", nil)
+	if err != nil {
+		return nil, err
+	}
+	return page.News, nil
+}
+
+func (c *Client) UpdateNews(ctx context.Context, id uuid.UUID, in NewsInput) error {
+	return c.do(ctx, http.MethodPut, "/news/"+id.String(), nil, in.body(id), nil)
+}
+
+func (c *Client) DeleteNews(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, http.MethodDelete, "/news/"+id.String(), nil, nil, nil)
+}
+
+// TagNews lists the public news with the tag.
+func (c *Client) TagNews(ctx context.Context, slug string, opts *ListOptions) (*NewsPage, error) {
+	return c.listNews(ctx, "/tags/"+url.PathEscape(slug)+"/news", opts)
+}
+
+// AllTagNews iterates over the public news with the tag.
+func (c *Client) AllTagNews(ctx context.Context, slug string, opts ListOptions) iter.Seq2[*News, error] {
+	return c.allNews(ctx, "/tags/"+url.PathEscape(slug)+"/news", opts)
+}
+
+// AuthorNews lists the public news of the author.
+func (c *Client) AuthorNews(ctx context.Context, slug string, opts *ListOptions) (*NewsPage, error) {
+	return c.listNews(ctx, "/authors/"+url.PathEscape(slug)+"/news", opts)
+}
+
+// AllAuthorNews iterates over the public news of the author.
+func (c *Client) AllAuthorNews(ctx context.Context, slug string, opts ListOptions) iter.Seq2[*News, error] {
+	return c.allNews(ctx, "/authors/"+url.PathEscape(slug)+"/news", opts)
+}
+
+// TransitionNews moves the news to another editorial state. It requires the
+// editor role.
+func (c *Client) TransitionNews(ctx context.Context, id uuid.UUID, to Status) (*News, error) {
+	var n News
+	body := struct {
+		Status Status `json:"status"`
+	}{Status: to}
+	if err := c.do(ctx, http.MethodPost, "/news/"+id.String()+"/transitions", nil, body, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// RestoreNews undoes the deletion of the news. It requires the editor role.
+func (c *Client) RestoreNews(ctx context.Context, id uuid.UUID) (*News, error) {
+	var n News
+	if err := c.do(ctx, http.MethodPost, "/news/"+id.String()+"/restore", nil, nil, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// EditorNews lists the news in any editorial state. It requires the editor
+// role.
+func (c *Client) EditorNews(ctx context.Context, opts *ListOptions) (*NewsPage, error) {
+	return c.listNews(ctx, "/editor/news", opts)
+}
+
+// AllEditorNews iterates over the news in any editorial state. It requires
+// the editor role.
+func (c *Client) AllEditorNews(ctx context.Context, opts ListOptions) iter.Seq2[*News, error] {
+	return c.allNews(ctx, "/editor/news", opts)
+}
+
+// GetEditorNews gets a news in any editorial state. It requires the editor
+// role.
+func (c *Client) GetEditorNews(ctx context.Context, id uuid.UUID) (*News, error) {
+	var n News
+	if err := c.do(ctx, http.MethodGet, "/editor/news/"+id.String(), nil, nil, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (c *Client) listNews(ctx context.Context, path string, opts *ListOptions) (*NewsPage, error) {
+	var page NewsPage
+	if err := c.do(ctx, http.MethodGet, path, opts.query(), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// allNews iterates over the pages of the list, from the After cursor of the
+// options. Iteration stops at the first error.
+func (c *Client) allNews(ctx context.Context, path string, opts ListOptions) iter.Seq2[*News, error] {
+	if opts.Limit == 0 {
+		opts.Limit = DefaultPageLimit
+	}
+	return func(yield func(*News, error) bool) {
+		for {
+			page, err := c.listNews(ctx, path, &opts)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, n := range page.News {
+				if !yield(n, nil) {
+					return
+				}
+			}
+			if page.NextCursor == "" {
+				return
+			}
+			opts.After = page.NextCursor
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package newsclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TommyLearning/go-rest-api-project/internal/auth"
+	"github.com/TommyLearning/go-rest-api-project/internal/handler"
+	"github.com/TommyLearning/go-rest-api-project/internal/router"
+	"github.com/TommyLearning/go-rest-api-project/internal/store"
+	"github.com/TommyLearning/go-rest-api-project/pkg/newsclient"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTransport counts the requests sent by the client.
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// newClient returns a client of the whole handler stack, backed by the
+// in-memory store.
+func newClient(t *testing.T, key string) (*newsclient.Client, *countingTransport) {
+	t.Helper()
+	keys, err := auth.ParseKeys("editor-key=alice:editor")
+	require.NoError(t, err)
+	srv := httptest.NewServer(auth.Mid(keys, router.New(store.NewNewsStore(), handler.DefaultStrict)))
+	t.Cleanup(srv.Close)
+
+	transport := &countingTransport{}
+	c, err := newsclient.New(srv.URL, newsclient.WithAPIKey(key), newsclient.WithHTTPClient(&http.Client{Transport: transport}))
+	require.NoError(t, err)
+	return c, transport
+}
+
+func input(title string, createdAt time.Time) newsclient.NewsInput {
+	return newsclient.NewsInput{
+		Authors:   []*newsclient.Author{{Name: "Alice Smith"}},
+		Title:     title,
+		Summary:   "summary",
+		Content:   "content of " + title,
+		Source:    "https://example.com",
+		Tags:      []string{"Go"},
+		CreatedAt: createdAt,
+	}
+}
+
+func TestClient_News(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	c, _ := newClient(t, "editor-key")
+	require.NoError(t, c.CreateNews(ctx, input("title", time.Now())))
+	page, err := c.EditorNews(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, page.News, 1)
+	id := page.News[0].Id
+
+	// Act & Assert
+	n, err := c.GetEditorNews(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "title", n.Title)
+	assert.Equal(t, newsclient.StatusDraft, n.Status)
+	assert.Equal(t, []string{"go"}, n.Tags)
+	assert.Equal(t, "alice-smith", n.Authors[0].Slug)
+
+	_, err = c.GetNews(ctx, id)
+	assert.ErrorIs(t, err, newsclient.ErrNotFound, "drafts are not public")
+
+	for _, to := range []newsclient.Status{newsclient.StatusInReview, newsclient.StatusApproved, newsclient.StatusPublished} {
+		n, err = c.TransitionNews(ctx, id, to)
+		require.NoError(t, err)
+		assert.Equal(t, to, n.Status)
+		assert.Equal(t, "alice", n.StatusChangedBy)
+	}
+
+	require.NoError(t, c.UpdateNews(ctx, id, input("new title", time.Now())))
+	n, err = c.GetNews(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "new title", n.Title)
+	assert.Equal(t, newsclient.StatusPublished, n.Status)
+
+	page, err = c.TagNews(ctx, "go", nil)
+	require.NoError(t, err)
+	assert.Len(t, page.News, 1)
+	page, err = c.AuthorNews(ctx, "alice-smith", nil)
+	require.NoError(t, err)
+	assert.Len(t, page.News, 1)
+	similar, err := c.SimilarNews(ctx, id)
+	require.NoError(t, err)
+	assert.Empty(t, similar)
+
+	page, err = c.ListNews(ctx, &newsclient.ListOptions{Query: "NEW TITLE"})
+	require.NoError(t, err)
+	assert.Len(t, page.News, 1)
+	page, err = c.ListNews(ctx, &newsclient.ListOptions{Query: "other"})
+	require.NoError(t, err)
+	assert.Empty(t, page.News)
+
+	require.NoError(t, c.DeleteNews(ctx, id))
+	_, err = c.GetEditorNews(ctx, id)
+	assert.ErrorIs(t, err, newsclient.ErrNotFound)
+
+	n, err = c.RestoreNews(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "new title", n.Title)
+	_, err = c.RestoreNews(ctx, id)
+	assert.ErrorIs(t, err, newsclient.ErrNotFound, "news is not deleted")
+}
+
+func TestClient_AllEditorNews(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	c, transport := newClient(t, "editor-key")
+	now := time.Now().Truncate(time.Second)
+	for i := range 5 {
+		require.NoError(t, c.CreateNews(ctx, input(string(rune('a'+i)), now.Add(time.Duration(i)*time.Minute))))
+	}
+	transport.requests.Store(0)
+
+	// Act
+	var titles []string
+	for n, err := range c.AllEditorNews(ctx, newsclient.ListOptions{Limit: 2, Statuses: []newsclient.Status{newsclient.StatusDraft}}) {
+		require.NoError(t, err)
+		titles = append(titles, n.Title)
+	}
+
+	// Assert
+	assert.Equal(t, []string{"e", "d", "c", "b", "a"}, titles)
+	assert.Equal(t, int32(3), transport.requests.Load())
+}
+
+func TestClient_AllNews_Break(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	c, transport := newClient(t, "editor-key")
+	for i := range 3 {
+		require.NoError(t, c.CreateNews(ctx, input(string(rune('a'+i)), time.Now())))
+	}
+	transport.requests.Store(0)
+
+	// Act
+	count := 0
+	for _, err := range c.AllEditorNews(ctx, newsclient.ListOptions{Limit: 1}) {
+		require.NoError(t, err)
+		count++
+		break
+	}
+
+	// Assert
+	assert.Equal(t, 1, count)
+	assert.Equal(t, int32(1), transport.requests.Load())
+}
+
+func TestClient_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name            string
+		key             string
+		call            func(c *newsclient.Client) error
+		expectedErr     error
+		expectedMessage string
+	}{
+		{
+			name: "invalid news",
+			key:  "editor-key",
+			call: func(c *newsclient.Client) error {
+				return c.CreateNews(ctx, newsclient.NewsInput{Title: "title"})
+			},
+			expectedErr:     newsclient.ErrBadRequest,
+			expectedMessage: "author is empty",
+		},
+		{
+			name: "anonymous editor",
+			call: func(c *newsclient.Client) error {
+				_, err := c.EditorNews(ctx, nil)
+				return err
+			},
+			expectedErr: newsclient.ErrUnauthorized,
+		},
+		{
+			name: "invalid api key",
+			key:  "invalid",
+			call: func(c *newsclient.Client) error {
+				_, err := c.ListNews(ctx, nil)
+				return err
+			},
+			expectedErr: newsclient.ErrUnauthorized,
+		},
+		{
+			name: "not found",
+			call: func(c *newsclient.Client) error {
+				_, err := c.GetNews(ctx, uuid.New())
+				return err
+			},
+			expectedErr: newsclient.ErrNotFound,
+		},
+		{
+			name: "invalid cursor",
+			call: func(c *newsclient.Client) error {
+				_, err := c.ListNews(ctx, &newsclient.ListOptions{After: "invalid"})
+				return err
+			},
+			expectedErr:     newsclient.ErrBadRequest,
+			expectedMessage: "invalid cursor",
+		},
+		{
+			name: "invalid transition",
+			key:  "editor-key",
+			call: func(c *newsclient.Client) error {
+				if err := c.CreateNews(ctx, input("title", time.Now())); err != nil {
+					return err
+				}
+				page, err := c.EditorNews(ctx, nil)
+				if err != nil {
+					return err
+				}
+				_, err = c.TransitionNews(ctx, page.News[0].Id, newsclient.StatusPublished)
+				return err
+			},
+			expectedErr:     newsclient.ErrConflict,
+			expectedMessage: "cannot move news from draft to published",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			c, _ := newClient(t, tc.key)
+
+			// Act
+			err := tc.call(c)
+
+			// Assert
+			require.ErrorIs(t, err, tc.expectedErr)
+			var apiErr *newsclient.Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tc.expectedErr.(*newsclient.Error).StatusCode, apiErr.HttpStatusCode())
+			assert.Contains(t, apiErr.Message, tc.expectedMessage)
+		})
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	testCases := []struct {
+		name             string
+		failures         int32
+		call             func(ctx context.Context, c *newsclient.Client) error
+		expectedErr      error
+		expectedRequests int32
+	}{
+		{
+			name:     "idempotent call recovers",
+			failures: 2,
+			call: func(ctx context.Context, c *newsclient.Client) error {
+				_, err := c.ListNews(ctx, nil)
+				return err
+			},
+			expectedRequests: 3,
+		},
+		{
+			name:     "idempotent call gives up",
+			failures: 10,
+			call: func(ctx context.Context, c *newsclient.Client) error {
+				return c.DeleteNews(ctx, uuid.New())
+			},
+			expectedErr:      &newsclient.Error{StatusCode: http.StatusServiceUnavailable},
+			expectedRequests: 4,
+		},
+		{
+			name:     "non idempotent call",
+			failures: 1,
+			call: func(ctx context.Context, c *newsclient.Client) error {
+				return c.CreateNews(ctx, input("title", time.Now()))
+			},
+			expectedErr:      &newsclient.Error{StatusCode: http.StatusServiceUnavailable},
+			expectedRequests: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) <= tc.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Write([]byte(`{"news": []}`))
+			}))
+			t.Cleanup(srv.Close)
+			c, err := newsclient.New(srv.URL, newsclient.WithRetries(3, time.Millisecond))
+			require.NoError(t, err)
+
+			// Act
+			err = tc.call(context.Background(), c)
+
+			// Assert
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedRequests, requests.Load())
+		})
+	}
+}
+
+func TestClient_Retries_Canceled(t *testing.T) {
+	// Arrange
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(srv.Close)
+	c, err := newsclient.New(srv.URL)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Act
+	_, err = c.ListNews(ctx, nil)
+
+	// Assert
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestNew(t *testing.T) {
+	for _, baseURL := range []string{"", "localhost:8080", "ftp://example.com", "http://"} {
+		_, err := newsclient.New(baseURL)
+		assert.Error(t, err, baseURL)
+	}
+}
+
+func TestNewsInput_JSON(t *testing.T) {
+	// Arrange
+	in := input("title", time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC))
+	in.ExpiresAt = time.Date(2026, 11, 19, 12, 0, 0, 0, time.UTC)
+
+	// Act
+	b, err := json.Marshal(in)
+	require.NoError(t, err)
+	var got newsclient.NewsInput
+	err = json.Unmarshal(b, &got)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, in, got)
+	assert.Contains(t, string(b), `"publish_at":""`)
+	assert.NotContains(t, string(b), `"id"`)
+}